@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/guilherme096/data-sync/internal/api"
 	"github.com/guilherme096/data-sync/internal/trino"
@@ -16,6 +20,13 @@ import (
 )
 
 func main() {
+	if handled, err := runMetadataCommand(os.Args[1:]); handled {
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8081"
@@ -36,6 +47,42 @@ func main() {
 		trinoUser = "trino"
 	}
 
+	requestTimeout := 60 * time.Second
+	if value := os.Getenv("REQUEST_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("Invalid REQUEST_TIMEOUT '%s': expected a duration such as 60s, or 0 to disable", value)
+		}
+		requestTimeout = parsed
+	}
+
+	streamTimeout := time.Hour
+	if value := os.Getenv("STREAM_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("Invalid STREAM_TIMEOUT '%s': expected a duration such as 1h, or 0 to disable", value)
+		}
+		streamTimeout = parsed
+	}
+
+	discoveryParallelism := discovery.DefaultParallelism
+	if value := os.Getenv("DISCOVERY_PARALLELISM"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			log.Fatalf("Invalid DISCOVERY_PARALLELISM '%s': expected a positive number", value)
+		}
+		discoveryParallelism = parsed
+	}
+
+	chatContextChars := chatbot.DefaultContextChars
+	if value := os.Getenv("CHAT_CONTEXT_CHARS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			log.Fatalf("Invalid CHAT_CONTEXT_CHARS '%s': expected a positive number", value)
+		}
+		chatContextChars = parsed
+	}
+
 	trinoCatalog := os.Getenv("TRINO_CATALOG")
 	trinoSchema := os.Getenv("TRINO_SCHEMA")
 
@@ -53,36 +100,143 @@ func main() {
 	}
 	defer engine.Close()
 
-	metadataDiscovery := discovery.NewTrinoMetadataDiscovery(engine)
-
-	// in-memory
-	metadataStorage := storage.NewMemoryMetadataStorage()
-
-	syncService := sync.NewMetadataSync(metadataDiscovery, metadataStorage)
-
-	chatbotClient, err := chatbot.NewGeminiClient()
+	discoveryFilter, err := discoveryFilterFromEnv()
 	if err != nil {
-		log.Fatalf("Failed to create Gemini client: %v", err)
+		log.Fatal(err)
+	}
+	metadataDiscovery := discovery.NewFilteredDiscovery(discovery.NewTrinoMetadataDiscovery(engine), discoveryFilter)
+
+	var metadataStorage storage.MetadataStorage
+	switch backend := os.Getenv("METADATA_STORAGE"); backend {
+	case "", "memory":
+		metadataStorage = storage.NewMemoryMetadataStorage()
+		log.Println("Using in-memory metadata storage")
+	case "sqlite":
+		sqlitePath := os.Getenv("METADATA_SQLITE_PATH")
+		if sqlitePath == "" {
+			sqlitePath = "data-sync.db"
+		}
+		sqliteStorage, err := storage.NewSQLiteMetadataStorage(sqlitePath)
+		if err != nil {
+			log.Fatalf("Failed to open SQLite metadata storage: %v", err)
+		}
+		defer sqliteStorage.Close()
+		metadataStorage = sqliteStorage
+		log.Printf("Using SQLite metadata storage at %s", sqlitePath)
+	default:
+		log.Fatalf("Unknown METADATA_STORAGE '%s' (expected memory or sqlite)", backend)
 	}
 
-	log.Println("Performing initial metadata sync...")
-	if err := syncService.SyncAll(); err != nil {
-		log.Printf("Warning: initial sync failed: %v", err)
-	} else {
-		log.Println("Initial metadata sync completed successfully")
+	syncService := sync.NewMetadataSync(metadataDiscovery, metadataStorage, discoveryParallelism)
+
+	// Syncs run as background jobs, one at a time, so the server starts while the first one runs
+	syncJobs := sync.NewJobManager(syncService)
+	if _, err := syncJobs.Start(sync.TriggerStartup); err != nil {
+		log.Printf("Warning: initial sync failed to start: %v", err)
+	}
+	if spec := os.Getenv("SYNC_SCHEDULE"); spec != "" {
+		schedule, err := sync.ParseSchedule(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go syncJobs.RunSchedule(context.Background(), schedule)
+		log.Printf("Metadata sync scheduled: %s", spec)
 	}
 
 	// Initialize query translator
 	queryTranslator := query.NewTranslator(metadataStorage, engine)
 	log.Println("Query translator initialized")
 
-	// Initialize matching service with Gemini strategy
-	geminiStrategy := matching.NewGeminiMatchingStrategy(chatbotClient)
-	matcher := matching.NewMatcher(geminiStrategy)
-	log.Println("Table relation matcher initialized with Gemini strategy")
+	// AI features are optional: without a provider the chatbot and auto-matching are disabled
+	var agent chatbot.AgentActions
+	var matcher *matching.Matcher
+	providerConfig, err := llmProviderConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider, err := chatbot.NewProvider(context.Background(), providerConfig)
+	switch {
+	case errors.Is(err, chatbot.ErrNoProvider):
+		log.Println("No LLM provider configured: chatbot and relation auto-matching are disabled")
+	case err != nil:
+		log.Fatalf("Failed to create %s LLM provider: %v", providerConfig.Provider, err)
+	default:
+		agent = chatbot.NewAgent(provider, chatContextChars)
+		matcher = matching.NewMatcher(matching.NewLLMMatchingStrategy(provider))
+		log.Printf("Using %s LLM provider with model %s", provider.Name(), provider.Model())
+	}
 
-	srv := api.NewServer(":"+port, engine, metadataStorage, syncService, metadataDiscovery, chatbotClient, queryTranslator, matcher)
+	srv := api.NewServer(":"+port, engine, metadataStorage, syncService, syncJobs, metadataDiscovery, agent, queryTranslator, matcher, requestTimeout, streamTimeout, discoveryParallelism)
 	if err := srv.Run(); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// discoveryFilterFromEnv reads the comma-separated DISCOVERY_INCLUDE_* and DISCOVERY_EXCLUDE_*
+// patterns. Unless they are set, the system catalog and information_schema schemas are excluded.
+func discoveryFilterFromEnv() (discovery.Filter, error) {
+	rule := func(level, defaultExclude string) (discovery.Rule, error) {
+		include, err := discovery.ParsePatterns(os.Getenv("DISCOVERY_INCLUDE_" + level))
+		if err != nil {
+			return discovery.Rule{}, fmt.Errorf("invalid DISCOVERY_INCLUDE_%s: %w", level, err)
+		}
+		exclude, ok := os.LookupEnv("DISCOVERY_EXCLUDE_" + level)
+		if !ok {
+			exclude = defaultExclude
+		}
+		excludePatterns, err := discovery.ParsePatterns(exclude)
+		if err != nil {
+			return discovery.Rule{}, fmt.Errorf("invalid DISCOVERY_EXCLUDE_%s: %w", level, err)
+		}
+		return discovery.Rule{Include: include, Exclude: excludePatterns}, nil
+	}
+
+	var filter discovery.Filter
+	var err error
+	if filter.Catalogs, err = rule("CATALOGS", "system"); err != nil {
+		return filter, err
+	}
+	if filter.Schemas, err = rule("SCHEMAS", "information_schema"); err != nil {
+		return filter, err
+	}
+	if filter.Tables, err = rule("TABLES", ""); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// llmProviderConfigFromEnv reads the LLM provider settings. LLM_PROVIDER picks gemini, openai or
+// none; when it is unset, the provider is chosen from whichever API settings are present.
+func llmProviderConfigFromEnv() (chatbot.ProviderConfig, error) {
+	config := chatbot.ProviderConfig{
+		Provider: os.Getenv("LLM_PROVIDER"),
+		Model:    os.Getenv("LLM_MODEL"),
+	}
+	if config.Provider == "" {
+		switch {
+		case os.Getenv("GEMINI_API_KEY") != "":
+			config.Provider = chatbot.ProviderGemini
+		case os.Getenv("OPENAI_BASE_URL") != "" || os.Getenv("OPENAI_API_KEY") != "":
+			config.Provider = chatbot.ProviderOpenAI
+		default:
+			config.Provider = chatbot.ProviderNone
+		}
+	}
+
+	switch config.Provider {
+	case chatbot.ProviderGemini:
+		config.APIKey = os.Getenv("GEMINI_API_KEY")
+	case chatbot.ProviderOpenAI:
+		config.APIKey = os.Getenv("OPENAI_API_KEY")
+		config.BaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+
+	if value := os.Getenv("LLM_TEMPERATURE"); value != "" {
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			return config, fmt.Errorf("invalid LLM_TEMPERATURE '%s': expected a number between 0 and 2", value)
+		}
+		config.Temperature = &temperature
+	}
+	return config, nil
+}
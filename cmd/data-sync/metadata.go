@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// runMetadataCommand handles the "export" and "import" subcommands, which talk to a running server.
+// It reports whether args named a subcommand.
+func runMetadataCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "export":
+		return true, runExport(args[1:])
+	case "import":
+		return true, runImport(args[1:])
+	default:
+		return false, nil
+	}
+}
+
+// runExport downloads the metadata bundle to a file or stdout
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	server := fs.String("server", "http://localhost:8081", "data-sync server URL")
+	format := fs.String("format", "yaml", "bundle format: yaml or json")
+	output := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	resp, err := http.Get(strings.TrimRight(*server, "/") + "/metadata/export?format=" + url.QueryEscape(*format))
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("export failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(body)
+		return err
+	}
+	return os.WriteFile(*output, body, 0o644)
+}
+
+// runImport uploads a bundle file and prints the changes it made, or would make with -dry-run
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	server := fs.String("server", "http://localhost:8081", "data-sync server URL")
+	dryRun := fs.Bool("dry-run", false, "only show the changes the import would make")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: data-sync import [-server URL] [-dry-run] <bundle.yaml|bundle.json>")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	endpoint := strings.TrimRight(*server, "/") + "/metadata/import"
+	if *dryRun {
+		endpoint += "?dryRun=true"
+	}
+
+	resp, err := http.Post(endpoint, "application/yaml", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("import failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Changes []struct {
+			Action string `json:"action"`
+			Kind   string `json:"kind"`
+			Name   string `json:"name"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Println("No changes")
+		return nil
+	}
+	for _, change := range result.Changes {
+		fmt.Printf("%-6s %-13s %s\n", change.Action, change.Kind, change.Name)
+	}
+	if *dryRun {
+		fmt.Printf("%d change(s) would be made (dry run)\n", len(result.Changes))
+	} else {
+		fmt.Printf("%d change(s) applied\n", len(result.Changes))
+	}
+	return nil
+}
@@ -28,6 +28,9 @@ export type Column = {
 export type GlobalTable = {
   Name: string;
   Description: string;
+  UnionType?: 'ALL' | 'DISTINCT';
+  SourceColumn?: string;
+  Degraded?: boolean;
 };
 
 export type GlobalColumn = {
@@ -75,13 +78,18 @@ export type JoinColumn = {
   right: string;
 };
 
+export type JoinType = 'INNER' | 'LEFT' | 'RIGHT' | 'FULL';
+
 export type TableRelation = {
   id: string;
   name: string;
   leftTable: TableSource;
   rightTable: TableSource;
   relationType: 'JOIN' | 'UNION';
+  unionType?: 'ALL' | 'DISTINCT';
+  joinType?: JoinType;
   joinColumn?: JoinColumn;
+  joinColumns?: JoinColumn[];
   description?: string;
 };
 
@@ -111,23 +119,93 @@ export type QueryResult = {
 };
 
 export type ToolResult = {
+  id?: string;
   toolName: string;
   data: any;
 };
 
+export type ToolCallEvent = {
+  id?: string;
+  toolName: string;
+  arguments: Record<string, unknown>;
+};
+
+export type ChatStreamHandlers = {
+  onDelta?: (text: string) => void;
+  onToolCallStarted?: (call: ToolCallEvent) => void;
+  onToolCallFinished?: (result: ToolResult) => void;
+};
+
 export type ChatResponse = {
   message: string;
   toolResults?: ToolResult[];
 };
 
+export type QueryValidation = {
+  status: 'valid' | 'invalid' | 'skipped';
+  error?: string;
+  repairRounds: number;
+};
+
 export type QueryGenerationResponse = {
   message: string;
   generatedSQL: string;
+  translatedSQL?: string;
+  validation: QueryValidation;
+  warnings?: string[];
+};
+
+export type SyncChange = {
+  type: 'added' | 'removed' | 'changed';
+  kind: 'catalog' | 'schema' | 'table' | 'column';
+  path: string;
+  before?: string;
+  after?: string;
 };
 
-export type SyncResponse = {
-  status: string;
+export type SyncChangeSummary = {
+  added: number;
+  removed: number;
+  changed: number;
+};
+
+export type ImpactIssue = {
+  problem: 'missing_table' | 'missing_column' | 'missing_relation' | 'type_mismatch';
+  kind: 'tableMapping' | 'columnMapping' | 'relation';
+  reference: string;
   message: string;
+  globalTables: string[];
+};
+
+export type ImpactReport = {
+  issues: ImpactIssue[];
+  degradedTables: string[];
+};
+
+export type SyncReport = {
+  changes: SyncChange[];
+  summary: SyncChangeSummary;
+  errors?: string[];
+  impact?: ImpactReport;
+};
+
+export type SyncJob = {
+  id: string;
+  trigger: 'manual' | 'schedule' | 'startup';
+  status: 'running' | 'succeeded' | 'failed' | 'cancelled';
+  progress: {
+    catalog: string;
+    catalogsDone: number;
+    catalogsTotal: number;
+    tables: number;
+    columns: number;
+    errors: number;
+  };
+  startedAt: string;
+  finishedAt?: string;
+  error?: string;
+  errors: string[];
+  report?: SyncReport;
 };
 
 export type SyncStatus = {
@@ -135,6 +213,20 @@ export type SyncStatus = {
   discoveredCount: number;
   storedCount: number;
   message: string;
+  changes: SyncChange[];
+  summary: SyncChangeSummary;
+  errors?: string[];
+};
+
+export type MetadataChange = {
+  action: 'create' | 'update' | 'delete';
+  kind: 'globalTable' | 'globalColumn' | 'tableMapping' | 'columnMapping' | 'relationship' | 'relation';
+  name: string;
+};
+
+export type MetadataImportResult = {
+  dryRun: boolean;
+  changes: MetadataChange[];
 };
 
 const API_BASE = '/api';
@@ -186,12 +278,40 @@ export const api = {
     return res.json();
   },
 
-  syncMetadata: async (): Promise<SyncResponse> => {
+  // Starts a background sync and resolves once the job has finished
+  syncMetadata: async (): Promise<SyncJob> => {
     const res = await fetch(`${API_BASE}/sync`, { method: 'POST' });
     if (!res.ok) {
         const errText = await res.text();
         throw new Error(errText || 'Sync failed');
     }
+
+    let job: SyncJob = await res.json();
+    while (job.status === 'running') {
+      await new Promise((resolve) => setTimeout(resolve, 1000));
+      job = await api.getSyncJob(job.id);
+    }
+    if (job.status !== 'succeeded') {
+      throw new Error(job.error || `Sync ${job.status}`);
+    }
+    return job;
+  },
+
+  getSyncJob: async (id: string): Promise<SyncJob> => {
+    const res = await fetch(`${API_BASE}/sync/jobs/${id}`);
+    if (!res.ok) {
+        const errText = await res.text();
+        throw new Error(errText || `Failed to get sync job ${id}`);
+    }
+    return res.json();
+  },
+
+  cancelSyncJob: async (id: string): Promise<SyncJob> => {
+    const res = await fetch(`${API_BASE}/sync/jobs/${id}/cancel`, { method: 'POST' });
+    if (!res.ok) {
+        const errText = await res.text();
+        throw new Error(errText || `Failed to cancel sync job ${id}`);
+    }
     return res.json();
   },
 
@@ -204,6 +324,15 @@ export const api = {
     return res.json();
   },
 
+  getSyncImpact: async (): Promise<ImpactReport> => {
+    const res = await fetch(`${API_BASE}/sync/impact`);
+    if (!res.ok) {
+        const errText = await res.text();
+        throw new Error(errText || 'Failed to get sync impact');
+    }
+    return res.json();
+  },
+
   discoverTables: async (catalogName: string, schemaName: string): Promise<Table[]> => {
     const res = await fetch(`${API_BASE}/discover/catalogs/${catalogName}/schemas/${schemaName}/tables`);
     if (!res.ok) {
@@ -430,6 +559,77 @@ export const api = {
     return data; // Return full ChatResponse with message and toolResults
   },
 
+  // Streams a chat answer over Server-Sent Events and resolves with the final ChatResponse
+  streamChatMessage: async (
+    message: string,
+    conversationHistory: unknown[],
+    handlers: ChatStreamHandlers,
+    signal?: AbortSignal
+  ): Promise<ChatResponse> => {
+    const history = (conversationHistory as Array<{ role: string; content: string }>).map(m => ({
+      role: m.role,
+      content: m.content,
+    }));
+
+    const res = await fetch(`${API_BASE}/chatbot/message/stream`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        Accept: 'text/event-stream',
+      },
+      body: JSON.stringify({ message, history }),
+      signal,
+    });
+
+    if (!res.ok || !res.body) {
+      const errText = await res.text();
+      throw new Error(errText || 'Failed to send chat message');
+    }
+
+    const reader = res.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) break;
+      buffer += decoder.decode(value, { stream: true });
+
+      // Events are separated by a blank line
+      let boundary = buffer.indexOf('\n\n');
+      while (boundary !== -1) {
+        const raw = buffer.slice(0, boundary);
+        buffer = buffer.slice(boundary + 2);
+        boundary = buffer.indexOf('\n\n');
+
+        let event = 'message';
+        let data = '';
+        for (const line of raw.split('\n')) {
+          if (line.startsWith('event:')) event = line.slice(6).trim();
+          else if (line.startsWith('data:')) data += line.slice(5).trim();
+        }
+        if (!data) continue;
+        const payload = JSON.parse(data);
+
+        switch (event) {
+          case 'delta':
+            handlers.onDelta?.(payload.text);
+            break;
+          case 'tool_call_started':
+            handlers.onToolCallStarted?.(payload);
+            break;
+          case 'tool_call_finished':
+            handlers.onToolCallFinished?.(payload);
+            break;
+          case 'message':
+            return payload as ChatResponse;
+          case 'error':
+            throw new Error(payload.error || 'Failed to send chat message');
+        }
+      }
+    }
+    throw new Error('Chat stream ended without a response');
+  },
+
   // Query Generation API
   generateQuery: async (message: string, conversationHistory: unknown[]): Promise<QueryGenerationResponse> => {
     // Map conversation history to backend format (role and content only)
@@ -506,4 +706,26 @@ export const api = {
     }
     return res.json();
   },
+
+  exportMetadata: async (format: 'yaml' | 'json' = 'yaml'): Promise<string> => {
+    const res = await fetch(`${API_BASE}/metadata/export?format=${format}`);
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new Error(errText || 'Failed to export metadata');
+    }
+    return res.text();
+  },
+
+  importMetadata: async (bundle: string, dryRun = false): Promise<MetadataImportResult> => {
+    const res = await fetch(`${API_BASE}/metadata/import${dryRun ? '?dryRun=true' : ''}`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/yaml' },
+      body: bundle,
+    });
+    if (!res.ok) {
+      const errText = await res.text();
+      throw new Error(errText || 'Failed to import metadata');
+    }
+    return res.json();
+  },
 };
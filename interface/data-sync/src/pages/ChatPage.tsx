@@ -21,6 +21,7 @@ export function ChatPage() {
   const [messages, setMessages] = useState<Message[]>([])
   const [input, setInput] = useState('')
   const [isLoading, setIsLoading] = useState(false)
+  const [activeTool, setActiveTool] = useState<string | null>(null)
   const scrollRef = useRef<HTMLDivElement>(null)
   const inputRef = useRef<HTMLInputElement>(null)
 
@@ -45,27 +46,30 @@ export function ChatPage() {
     setInput('')
     setIsLoading(true)
 
+    const assistantId = (Date.now() + 1).toString()
+    const updateAssistant = (update: (message: Message) => Message) =>
+      setMessages((prev) => prev.map((m) => (m.id === assistantId ? update(m) : m)))
+
     try {
-      const response = await api.sendChatMessage(input.trim(), messages)
-      const assistantMessage: Message = {
-        id: (Date.now() + 1).toString(),
-        role: 'assistant',
-        content: response.message,
-        timestamp: new Date(),
-        toolResults: response.toolResults,
-      }
-      setMessages((prev) => [...prev, assistantMessage])
+      setMessages((prev) => [
+        ...prev,
+        { id: assistantId, role: 'assistant', content: '', timestamp: new Date(), toolResults: [] },
+      ])
+      const response = await api.streamChatMessage(input.trim(), messages, {
+        onDelta: (text) => updateAssistant((m) => ({ ...m, content: m.content + text })),
+        onToolCallStarted: (call) => setActiveTool(call.toolName),
+        onToolCallFinished: (result) => {
+          setActiveTool(null)
+          updateAssistant((m) => ({ ...m, toolResults: [...(m.toolResults ?? []), result] }))
+        },
+      })
+      updateAssistant((m) => ({ ...m, content: response.message, toolResults: response.toolResults }))
     } catch (error) {
       console.error('Chat error:', error)
-      const errorMessage: Message = {
-        id: (Date.now() + 1).toString(),
-        role: 'assistant',
-        content: 'Sorry, something went wrong. Please try again.',
-        timestamp: new Date(),
-      }
-      setMessages((prev) => [...prev, errorMessage])
+      updateAssistant((m) => ({ ...m, content: 'Sorry, something went wrong. Please try again.' }))
     } finally {
       setIsLoading(false)
+      setActiveTool(null)
       // Focus input after response
       inputRef.current?.focus()
     }
@@ -247,7 +251,7 @@ export function ChatPage() {
                       <div className="space-y-3">
                         {message.toolResults.map((toolResult, idx) => (
                           <div key={idx}>
-                            {toolResult.toolName === 'executeGlobalQuery' && (
+                            {(toolResult.toolName === 'executeGlobalQuery' || toolResult.toolName === 'sampleRows') && (
                               <QueryResultTable data={toolResult.data} />
                             )}
                             {toolResult.toolName === 'listGlobalTables' && toolResult.data.tables && (
@@ -304,6 +308,9 @@ export function ChatPage() {
                     <div className="h-2 w-2 animate-pulse rounded-full bg-primary" />
                     <div className="h-2 w-2 animate-pulse rounded-full bg-primary delay-75" />
                     <div className="h-2 w-2 animate-pulse rounded-full bg-primary delay-150" />
+                    {activeTool && (
+                      <span className="text-sm text-muted-foreground">Running {activeTool}...</span>
+                    )}
                   </div>
                 </div>
               </div>
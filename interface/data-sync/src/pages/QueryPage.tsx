@@ -5,8 +5,8 @@ import { Card } from '@/components/ui/card'
 import { Separator } from '@/components/ui/separator'
 import { Avatar, AvatarFallback, AvatarImage } from '@/components/ui/avatar'
 import { Badge } from '@/components/ui/badge'
-import { Play, Eraser, Send, Bot, User, Sparkles, Database, Globe, Copy, Loader2 } from 'lucide-react'
-import { api } from '@/lib/api'
+import { Play, Eraser, Send, Bot, User, Sparkles, Database, Globe, Copy, Loader2, Download } from 'lucide-react'
+import { api, type QueryValidation } from '@/lib/api'
 import {
   ResizableHandle,
   ResizablePanel,
@@ -34,11 +34,22 @@ import { useTheme } from '@/components/theme-provider'
 
 type QueryMode = 'direct' | 'global'
 
+interface ResultColumn {
+  name: string
+  type: string
+}
+
 interface QueryResult {
   generatedSQL?: string
+  // Columns in SELECT order with their Trino types
+  columns: ResultColumn[]
   rows: Array<Record<string, any>>
   rowCount: number
   executionTime?: string
+  // Set when more rows are available; passed back to fetch the next page
+  nextPageToken?: string
+  query: string
+  mode: QueryMode
 }
 
 type AssistantMessage = {
@@ -46,12 +57,15 @@ type AssistantMessage = {
   role: 'user' | 'assistant'
   content: string
   generatedSQL?: string
+  validation?: QueryValidation
+  warnings?: string[]
   timestamp: Date
 }
 
 const STORAGE_KEY = 'data-sync-sql-query'
 const CHAT_STORAGE_KEY = 'data-sync-chat-history'
 const DEFAULT_QUERY = '-- Write your SQL query here\nSELECT * FROM global_users LIMIT 10;'
+const PAGE_SIZE = 200
 
 export function QueryPage() {
   const [sqlCode, setSqlCode] = useState(() => {
@@ -133,18 +147,22 @@ export function QueryPage() {
     }
   }, [messages])
 
-  const executeQuery = async () => {
+  // executeQuery runs the editor's query, or fetches the next page of the current result
+  const executeQuery = async (nextPage?: QueryResult) => {
     setIsLoading(true)
     setError(null)
 
+    const query = nextPage ? nextPage.query : sqlCode
+    const mode = nextPage ? nextPage.mode : queryMode
+
     try {
-      const endpoint = queryMode === 'global' ? '/query/global' : '/query'
+      const endpoint = mode === 'global' ? '/query/global' : '/query'
       const response = await fetch(`http://localhost:8081${endpoint}`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ query: sqlCode }),
+        body: JSON.stringify({ query, pageSize: PAGE_SIZE, pageToken: nextPage?.nextPageToken }),
       })
 
       if (!response.ok) {
@@ -153,20 +171,18 @@ export function QueryPage() {
       }
 
       const data = await response.json()
-
-      if (queryMode === 'global') {
-        setQueryResult({
-          generatedSQL: data.generatedSQL,
-          rows: data.rows || [],
-          rowCount: data.rowCount || 0,
-          executionTime: data.executionTime,
-        })
-      } else {
-        setQueryResult({
-          rows: data.rows || [],
-          rowCount: data.rows?.length || 0,
-        })
-      }
+      const rows = [...(nextPage?.rows ?? []), ...(data.rows || [])]
+
+      setQueryResult({
+        generatedSQL: mode === 'global' ? data.generatedSQL : undefined,
+        columns: data.columns || nextPage?.columns || [],
+        rows,
+        rowCount: rows.length,
+        executionTime: mode === 'global' ? data.executionTime : undefined,
+        nextPageToken: data.nextPageToken,
+        query,
+        mode,
+      })
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Unknown error occurred')
     } finally {
@@ -174,6 +190,40 @@ export function QueryPage() {
     }
   }
 
+  // exportResult downloads every row of the current result as a CSV, Parquet or Arrow file
+  const exportResult = async (format: 'csv' | 'parquet' | 'arrow') => {
+    if (!queryResult) return
+    setError(null)
+
+    try {
+      const endpoint = queryResult.mode === 'global' ? '/query/global' : '/query'
+      const response = await fetch(`http://localhost:8081${endpoint}?format=${format}`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ query: queryResult.query }),
+      })
+
+      if (!response.ok) {
+        const errorText = await response.text()
+        throw new Error(errorText || 'Export failed')
+      }
+
+      const disposition = response.headers.get('Content-Disposition') || ''
+      const fileName = disposition.match(/filename="([^"]+)"/)?.[1] || `query-result.${format}`
+
+      const url = URL.createObjectURL(await response.blob())
+      const link = document.createElement('a')
+      link.href = url
+      link.download = fileName
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Unknown error occurred')
+    }
+  }
+
   const handleSendChatMessage = async () => {
     if (!chatInput.trim() || isChatLoading) return
 
@@ -203,6 +253,8 @@ export function QueryPage() {
         role: 'assistant',
         content: response.message,
         generatedSQL: response.generatedSQL || '', // Ensure it's never undefined
+        validation: response.validation,
+        warnings: response.warnings,
         timestamp: new Date(),
       }
       setMessages((prev) => [...prev, assistantMessage])
@@ -284,7 +336,7 @@ export function QueryPage() {
                   <Button
                     size="sm"
                     className="h-7"
-                    onClick={executeQuery}
+                    onClick={() => executeQuery()}
                     disabled={isLoading || !sqlCode.trim()}
                   >
                      <Play className="w-3.5 h-3.5 mr-2" />
@@ -327,8 +379,24 @@ export function QueryPage() {
                           <span className="text-xs text-muted-foreground">{queryResult.executionTime}</span>
                         )}
                         <span className="text-xs text-muted-foreground">
-                          {queryResult ? `${queryResult.rowCount} rows` : '0 rows'}
+                          {queryResult ? `${queryResult.rowCount}${queryResult.nextPageToken ? '+' : ''} rows` : '0 rows'}
                         </span>
+                        {queryResult && (
+                          <div className="flex items-center gap-1">
+                            <Download className="w-3.5 h-3.5 text-muted-foreground" />
+                            {(['csv', 'parquet', 'arrow'] as const).map((format) => (
+                              <Button
+                                key={format}
+                                variant="ghost"
+                                size="sm"
+                                className="h-6 px-2 text-xs uppercase"
+                                onClick={() => exportResult(format)}
+                              >
+                                {format}
+                              </Button>
+                            ))}
+                          </div>
+                        )}
                       </div>
                   </div>
 
@@ -367,9 +435,9 @@ export function QueryPage() {
                         <Table>
                           <TableHeader>
                             <TableRow>
-                              {Object.keys(queryResult.rows[0]).map((column) => (
-                                <TableHead key={column} className="font-mono text-xs">
-                                  {column}
+                              {queryResult.columns.map((column) => (
+                                <TableHead key={column.name} className="font-mono text-xs" title={column.type}>
+                                  {column.name}
                                 </TableHead>
                               ))}
                             </TableRow>
@@ -377,7 +445,7 @@ export function QueryPage() {
                           <TableBody>
                             {queryResult.rows.map((row, idx) => (
                               <TableRow key={idx}>
-                                {Object.values(row).map((value, colIdx) => (
+                                {queryResult.columns.map(({ name }) => row[name]).map((value, colIdx) => (
                                   <TableCell key={colIdx} className="font-mono text-xs">
                                     {value === null ? (
                                       <span className="text-muted-foreground italic">null</span>
@@ -393,6 +461,14 @@ export function QueryPage() {
                           </TableBody>
                         </Table>
                       )}
+                      {queryResult?.nextPageToken && !error && (
+                        <div className="flex justify-center pt-4">
+                          <Button variant="outline" size="sm" disabled={isLoading} onClick={() => executeQuery(queryResult)}>
+                            {isLoading && <Loader2 className="w-3.5 h-3.5 mr-2 animate-spin" />}
+                            Load more rows
+                          </Button>
+                        </div>
+                      )}
                   </div>
                </div>
             </Card>
@@ -470,6 +546,16 @@ export function QueryPage() {
                                        {message.generatedSQL}
                                      </pre>
                                   </div>
+                                  {message.validation?.status === 'invalid' && (
+                                    <p className="text-xs text-destructive">
+                                      This query failed validation: {message.validation.error}
+                                    </p>
+                                  )}
+                                  {message.warnings?.map((warning, idx) => (
+                                    <p key={idx} className="text-xs text-amber-600 dark:text-amber-400">
+                                      {warning}
+                                    </p>
+                                  ))}
                                   <Button
                                     variant="secondary"
                                     size="sm"
@@ -6,7 +6,7 @@ import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '@
 import { Tabs, TabsContent, TabsList, TabsTrigger } from '@/components/ui/tabs'
 import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle, DialogTrigger } from '@/components/ui/dialog'
 import { useQuery, useMutation, useQueryClient, QueryClient, QueryClientProvider } from '@tanstack/react-query'
-import { api, type Catalog, type Schema, type Table as TableModel, type Column as ColumnModel, type TableRelation, type TableSource, type GlobalTable, type GlobalColumn, type AutoMatchResponse, type SyncStatus } from '@/lib/api'
+import { api, type Catalog, type Schema, type Table as TableModel, type Column as ColumnModel, type TableRelation, type JoinType, type TableSource, type GlobalTable, type GlobalColumn, type AutoMatchResponse, type SyncStatus } from '@/lib/api'
 import { Plus, Database, GitMerge, Search, Zap, X, Globe, Sparkles, Loader2 } from 'lucide-react'
 import { useMemo } from 'react'
 
@@ -110,7 +110,7 @@ function CatalogSection({ catalogName, tableSearch }: { catalogName: string; tab
 // GLOBAL TABLES TAB COMPONENTS
 // ============================================
 
-function GlobalTableItem({ tableName }: { tableName: string }) {
+function GlobalTableItem({ tableName, degraded }: { tableName: string; degraded?: boolean }) {
   const { data: columns, isLoading } = useQuery<GlobalColumn[], Error>({
     queryKey: ['globalColumns', tableName],
     queryFn: () => api.listGlobalColumns(tableName),
@@ -121,6 +121,14 @@ function GlobalTableItem({ tableName }: { tableName: string }) {
       <div className="py-2 px-3 font-medium flex items-center gap-2">
         <Globe className="w-4 h-4 text-primary" />
         {tableName}
+        {degraded && (
+          <span
+            className="text-xs font-normal px-1.5 py-0.5 rounded bg-amber-100 text-amber-800"
+            title="A mapping or relation of this table points at a dropped or changed source column. See GET /sync/impact."
+          >
+            degraded
+          </span>
+        )}
       </div>
       <div className="pb-2 px-3 pl-9 bg-muted/10">
         {isLoading ? (
@@ -246,6 +254,7 @@ function AddRelationDialog({
   const [open, setOpen] = useState(false)
   const [relationName, setRelationName] = useState('')
   const [relationType, setRelationType] = useState<RelationType>('JOIN')
+  const [joinType, setJoinType] = useState<JoinType>('INNER')
   const [description, setDescription] = useState('')
 
   // Source type selection
@@ -322,6 +331,7 @@ function AddRelationDialog({
     }
 
     if (relationType === 'JOIN' && leftColumn && rightColumn) {
+      relation.joinType = joinType
       relation.joinColumn = {
         left: leftColumn,
         right: rightColumn,
@@ -332,6 +342,7 @@ function AddRelationDialog({
     setOpen(false)
     // Reset form
     setRelationName('')
+    setJoinType('INNER')
     setLeftSourceType('physical')
     setRightSourceType('physical')
     setLeftCatalog('')
@@ -555,6 +566,21 @@ function AddRelationDialog({
         {/* Join Columns - appears only when JOIN is selected */}
         {relationType === 'JOIN' && (
           <div className="space-y-3 mt-4">
+            <div className="space-y-2">
+              <label className="text-sm font-semibold">Join Type</label>
+              <Select value={joinType} onValueChange={(v) => setJoinType(v as JoinType)}>
+                <SelectTrigger>
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="INNER">INNER</SelectItem>
+                  <SelectItem value="LEFT">LEFT</SelectItem>
+                  <SelectItem value="RIGHT">RIGHT</SelectItem>
+                  <SelectItem value="FULL">FULL OUTER</SelectItem>
+                </SelectContent>
+              </Select>
+            </div>
+
             <div className="grid grid-cols-2 gap-6 p-4 border rounded-lg bg-muted/30">
               <div className="space-y-2">
                 <label className="text-sm font-semibold">Left Join Column</label>
@@ -775,7 +801,7 @@ function SchemaStudioPageContent() {
   const { data: syncStatus } = useQuery<SyncStatus, Error>({
     queryKey: ['syncStatus'],
     queryFn: api.getSyncStatus,
-    refetchInterval: 10000, // Refetch every 10 seconds
+    refetchInterval: 60000, // Checking walks every table's columns, so refetch every minute
   })
 
   const syncMutation = useMutation({
@@ -1056,6 +1082,7 @@ function SchemaStudioPageContent() {
                   <GlobalTableItem
                     key={table.Name}
                     tableName={table.Name}
+                    degraded={table.Degraded}
                   />
                 ))}
               </div>
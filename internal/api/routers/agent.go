@@ -2,7 +2,9 @@ package routers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	gosync "sync"
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/chatbot"
 	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
@@ -26,20 +28,51 @@ type ChatResponse struct {
 }
 
 type ToolResult struct {
+	// ID pairs a streamed result with its tool_call_started event
+	ID       string      `json:"id,omitempty"`
 	ToolName string      `json:"toolName"`
 	Data     interface{} `json:"data"`
 }
 
+// ToolCallEvent is the payload of a streamed tool_call_started event
+type ToolCallEvent struct {
+	ID        string                 `json:"id,omitempty"`
+	ToolName  string                 `json:"toolName"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// DeltaEvent is the payload of a streamed delta event
+type DeltaEvent struct {
+	Text string `json:"text"`
+}
+
 type QueryGenerationResponse struct {
 	Message      string `json:"message"`
 	GeneratedSQL string `json:"generatedSQL"`
+	// TranslatedSQL is the Trino SQL the generated query runs as, set when it translates
+	TranslatedSQL string          `json:"translatedSQL,omitempty"`
+	Validation    QueryValidation `json:"validation"`
+	Warnings      []string        `json:"warnings,omitempty"`
+}
+
+// QueryValidation reports whether a generated query validated: "valid", "invalid" or "skipped"
+type QueryValidation struct {
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	RepairRounds int    `json:"repairRounds"`
 }
 
+// ChatbotRouter serves the chatbot. Its agent is nil when no LLM provider is configured, and
+// every route then answers 503.
 type ChatbotRouter struct {
 	agent      chatbot.AgentActions
 	translator query.QueryTranslator
 	discovery  discovery.MetadataDiscovery
 	storage    storage.MetadataStorage
+
+	// busy holds the sessions answering a message, so two turns never interleave in one session
+	mu   gosync.Mutex
+	busy map[string]bool
 }
 
 func NewChatbotRouter(agent chatbot.AgentActions, translator query.QueryTranslator, discovery discovery.MetadataDiscovery, storage storage.MetadataStorage) *ChatbotRouter {
@@ -48,12 +81,31 @@ func NewChatbotRouter(agent chatbot.AgentActions, translator query.QueryTranslat
 		translator: translator,
 		discovery:  discovery,
 		storage:    storage,
+		busy:       make(map[string]bool),
 	}
 }
 
 func (r *ChatbotRouter) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/chatbot/message", r.handleSendMessage)
 	mux.HandleFunc("/chatbot/generate-query", r.handleGenerateQuery)
+	mux.HandleFunc("POST /chatbot/message/stream", r.handleStreamMessage)
+
+	mux.HandleFunc("GET /chatbot/sessions", r.handleListSessions)
+	mux.HandleFunc("POST /chatbot/sessions", r.handleCreateSession)
+	mux.HandleFunc("GET /chatbot/sessions/{id}", r.handleGetSession)
+	mux.HandleFunc("PUT /chatbot/sessions/{id}", r.handleRenameSession)
+	mux.HandleFunc("DELETE /chatbot/sessions/{id}", r.handleDeleteSession)
+	mux.HandleFunc("POST /chatbot/sessions/{id}/messages", r.handleSendSessionMessage)
+	mux.HandleFunc("POST /chatbot/sessions/{id}/messages/stream", r.handleStreamSessionMessage)
+}
+
+// aiDisabled writes a 503 when AI features are disabled and reports whether it did
+func aiDisabled(w http.ResponseWriter, enabled bool) bool {
+	if enabled {
+		return false
+	}
+	http.Error(w, "AI features are disabled: "+chatbot.ErrNoProvider.Error(), http.StatusServiceUnavailable)
+	return true
 }
 
 func (r *ChatbotRouter) handleSendMessage(w http.ResponseWriter, req *http.Request) {
@@ -61,6 +113,9 @@ func (r *ChatbotRouter) handleSendMessage(w http.ResponseWriter, req *http.Reque
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if aiDisabled(w, r.agent != nil) {
+		return
+	}
 
 	// Parse JSON request
 	var chatReq ChatRequest
@@ -74,41 +129,22 @@ func (r *ChatbotRouter) handleSendMessage(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	// Convert history to chatbot format
-	var history []chatbot.ChatMessage
-	for _, msg := range chatReq.History {
-		history = append(history, chatbot.ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
+	history := chatHistory(chatReq.History)
 
 	// Create tool executor with translator, discovery, and storage
 	toolExecutor := chatbot.NewToolExecutor(r.translator, r.discovery, r.storage)
 
 	// Get response from chatbot with tools
-	agentResponse, err := r.agent.SendMessageWithTools(chatReq.Message, history, toolExecutor)
+	agentResponse, err := r.agent.SendMessageWithTools(req.Context(), chatReq.Message, history, toolExecutor)
 	if err != nil {
 		http.Error(w, "Failed to send message: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Convert tool results to API format
-	var toolResults []ToolResult
-	for _, tr := range agentResponse.ToolResults {
-		toolResults = append(toolResults, ToolResult{
-			ToolName: tr.ToolName,
-			Data:     tr.Data,
-		})
-	}
-
 	// Return JSON response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(ChatResponse{
-		Message:     agentResponse.Message,
-		ToolResults: toolResults,
-	})
+	json.NewEncoder(w).Encode(newChatResponse(agentResponse))
 }
 
 func (r *ChatbotRouter) handleGenerateQuery(w http.ResponseWriter, req *http.Request) {
@@ -116,6 +152,9 @@ func (r *ChatbotRouter) handleGenerateQuery(w http.ResponseWriter, req *http.Req
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if aiDisabled(w, r.agent != nil) {
+		return
+	}
 
 	// Parse JSON request
 	var chatReq ChatRequest
@@ -129,20 +168,13 @@ func (r *ChatbotRouter) handleGenerateQuery(w http.ResponseWriter, req *http.Req
 		return
 	}
 
-	// Convert history to chatbot format
-	var history []chatbot.ChatMessage
-	for _, msg := range chatReq.History {
-		history = append(history, chatbot.ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
-	}
+	history := chatHistory(chatReq.History)
 
 	// Create query generator tool executor (no query execution)
 	toolExecutor := chatbot.NewQueryGeneratorToolExecutor(r.discovery, r.storage)
 
 	// Get query generation response from chatbot
-	queryGenResponse, err := r.agent.SendMessageForQueryGeneration(chatReq.Message, history, toolExecutor)
+	queryGenResponse, err := r.agent.SendMessageForQueryGeneration(req.Context(), chatReq.Message, history, toolExecutor, r.translator)
 	if err != nil {
 		http.Error(w, "Failed to generate query: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -152,7 +184,121 @@ func (r *ChatbotRouter) handleGenerateQuery(w http.ResponseWriter, req *http.Req
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(QueryGenerationResponse{
-		Message:      queryGenResponse.Message,
-		GeneratedSQL: queryGenResponse.GeneratedSQL,
+		Message:       queryGenResponse.Message,
+		GeneratedSQL:  queryGenResponse.GeneratedSQL,
+		TranslatedSQL: queryGenResponse.TranslatedSQL,
+		Validation: QueryValidation{
+			Status:       queryGenResponse.Validation.Status,
+			Error:        queryGenResponse.Validation.Error,
+			RepairRounds: queryGenResponse.Validation.RepairRounds,
+		},
+		Warnings: queryGenResponse.Warnings,
 	})
 }
+
+// handleStreamMessage answers a chat message as Server-Sent Events: "delta" events carry text as
+// it is generated, "tool_call_started" and "tool_call_finished" events bracket each tool call,
+// and a final "message" event carries the whole ChatResponse. Failures end the stream with an
+// "error" event.
+func (r *ChatbotRouter) handleStreamMessage(w http.ResponseWriter, req *http.Request) {
+	if aiDisabled(w, r.agent != nil) {
+		return
+	}
+
+	var chatReq ChatRequest
+	if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if chatReq.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	history := chatHistory(chatReq.History)
+
+	stream := newEventStream(w, req)
+	toolExecutor := chatbot.NewToolExecutor(r.translator, r.discovery, r.storage)
+	_, err := r.agent.StreamMessageWithTools(req.Context(), chatReq.Message, history, toolExecutor, stream.relay)
+	if err != nil {
+		stream.send("error", map[string]string{"error": err.Error()})
+	}
+}
+
+// eventStream writes Server-Sent Events, flushing each one to the client as it is sent
+type eventStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+// newEventStream starts an event stream. The server's write timeout is lifted, since a stream
+// lasts as long as the answer; the stream timeout still bounds it.
+func newEventStream(w http.ResponseWriter, req *http.Request) *eventStream {
+	controller := http.NewResponseController(w)
+	liftWriteDeadline(controller, req.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	return &eventStream{w: w, controller: controller}
+}
+
+func (s *eventStream) send(event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		payload, _ = json.Marshal(map[string]string{"error": err.Error()})
+		event = "error"
+	}
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+	s.controller.Flush()
+}
+
+// relay sends an agent event to the client
+func (s *eventStream) relay(event chatbot.AgentEvent) {
+	switch event.Type {
+	case chatbot.EventDelta:
+		s.send(event.Type, DeltaEvent{Text: event.Delta})
+	case chatbot.EventToolCallStarted:
+		s.send(event.Type, ToolCallEvent{
+			ID:        event.ToolCall.ID,
+			ToolName:  event.ToolCall.Name,
+			Arguments: event.ToolCall.Arguments,
+		})
+	case chatbot.EventToolCallFinished:
+		s.send(event.Type, ToolResult{
+			ID:       event.ToolCall.ID,
+			ToolName: event.ToolResult.ToolName,
+			Data:     event.ToolResult.Data,
+		})
+	case chatbot.EventMessage:
+		s.send(event.Type, newChatResponse(event.Response))
+	}
+}
+
+// newChatResponse converts an agent response to its API format
+func newChatResponse(agentResponse *chatbot.AgentResponse) ChatResponse {
+	response := ChatResponse{Message: agentResponse.Message}
+	for _, tr := range agentResponse.ToolResults {
+		response.ToolResults = append(response.ToolResults, ToolResult{
+			ToolName: tr.ToolName,
+			Data:     tr.Data,
+		})
+	}
+	return response
+}
+
+// chatHistory converts the history of a chat request to the agent's format
+func chatHistory(messages []ChatMessage) []chatbot.ChatMessage {
+	var history []chatbot.ChatMessage
+	for _, msg := range messages {
+		history = append(history, chatbot.ChatMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	return history
+}
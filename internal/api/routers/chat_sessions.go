@@ -0,0 +1,249 @@
+package routers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/chatbot"
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// errSessionBusy is returned when a message is sent to a session still answering the previous one
+var errSessionBusy = errors.New("chat session is answering another message")
+
+// ChatSessionSummary describes a session without its messages, for listing
+type ChatSessionSummary struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	MessageCount int       `json:"messageCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ChatSessionRequest creates or renames a session
+type ChatSessionRequest struct {
+	Title string `json:"title"`
+}
+
+// SessionMessageRequest is a message sent to a session; the session supplies the history
+type SessionMessageRequest struct {
+	Message string `json:"message"`
+}
+
+func (r *ChatbotRouter) handleListSessions(w http.ResponseWriter, req *http.Request) {
+	sessions, err := r.storage.ListChatSessions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summaries := make([]ChatSessionSummary, len(sessions))
+	for i, session := range sessions {
+		summaries[i] = ChatSessionSummary{
+			ID:           session.ID,
+			Title:        session.Title,
+			MessageCount: len(session.Messages),
+			CreatedAt:    session.CreatedAt,
+			UpdatedAt:    session.UpdatedAt,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// handleCreateSession starts a session; without a title, the first message names it
+func (r *ChatbotRouter) handleCreateSession(w http.ResponseWriter, req *http.Request) {
+	var sessionReq ChatSessionRequest
+	if req.ContentLength != 0 {
+		if err := json.NewDecoder(req.Body).Decode(&sessionReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	session := chatbot.NewSession(strings.TrimSpace(sessionReq.Title))
+	if err := r.storage.SaveChatSession(session); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/chatbot/sessions/"+session.ID)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+func (r *ChatbotRouter) handleGetSession(w http.ResponseWriter, req *http.Request) {
+	session, err := r.storage.GetChatSession(req.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+func (r *ChatbotRouter) handleRenameSession(w http.ResponseWriter, req *http.Request) {
+	var sessionReq ChatSessionRequest
+	if err := json.NewDecoder(req.Body).Decode(&sessionReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	title := strings.TrimSpace(sessionReq.Title)
+	if title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	}
+
+	id := req.PathValue("id")
+	if err := r.lockSession(id); err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+	defer r.unlockSession(id)
+
+	session, err := r.storage.GetChatSession(id)
+	if err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+	session.Title = title
+	if err := r.storage.SaveChatSession(session); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+func (r *ChatbotRouter) handleDeleteSession(w http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+	if err := r.lockSession(id); err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+	defer r.unlockSession(id)
+
+	if err := r.storage.DeleteChatSession(id); err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSendSessionMessage answers a message in a session and saves the turn
+func (r *ChatbotRouter) handleSendSessionMessage(w http.ResponseWriter, req *http.Request) {
+	session, message, ok := r.beginSessionTurn(w, req)
+	if !ok {
+		return
+	}
+	defer r.unlockSession(session.ID)
+
+	toolExecutor := chatbot.NewToolExecutor(r.translator, r.discovery, r.storage)
+	agentResponse, err := r.agent.SendSessionMessage(req.Context(), session, message, toolExecutor, nil)
+	if err != nil {
+		http.Error(w, "Failed to send message: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := r.storage.SaveChatSession(session); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save chat session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newChatResponse(agentResponse))
+}
+
+// handleStreamSessionMessage answers a message in a session with the events of
+// /chatbot/message/stream, saving the turn before the final message event
+func (r *ChatbotRouter) handleStreamSessionMessage(w http.ResponseWriter, req *http.Request) {
+	session, message, ok := r.beginSessionTurn(w, req)
+	if !ok {
+		return
+	}
+	defer r.unlockSession(session.ID)
+
+	stream := newEventStream(w, req)
+	toolExecutor := chatbot.NewToolExecutor(r.translator, r.discovery, r.storage)
+	_, err := r.agent.SendSessionMessage(req.Context(), session, message, toolExecutor, func(event chatbot.AgentEvent) {
+		if event.Type == chatbot.EventMessage {
+			if err := r.storage.SaveChatSession(session); err != nil {
+				stream.send("error", map[string]string{"error": fmt.Sprintf("failed to save chat session: %v", err)})
+				return
+			}
+		}
+		stream.relay(event)
+	})
+	if err != nil {
+		stream.send("error", map[string]string{"error": err.Error()})
+	}
+}
+
+// beginSessionTurn validates a session message request, claims the session and loads it. When
+// it returns ok the caller must unlock the session.
+func (r *ChatbotRouter) beginSessionTurn(w http.ResponseWriter, req *http.Request) (*models.ChatSession, string, bool) {
+	if aiDisabled(w, r.agent != nil) {
+		return nil, "", false
+	}
+
+	var messageReq SessionMessageRequest
+	if err := json.NewDecoder(req.Body).Decode(&messageReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, "", false
+	}
+	if messageReq.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return nil, "", false
+	}
+
+	id := req.PathValue("id")
+	if err := r.lockSession(id); err != nil {
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return nil, "", false
+	}
+	session, err := r.storage.GetChatSession(id)
+	if err != nil {
+		r.unlockSession(id)
+		http.Error(w, err.Error(), sessionErrorStatus(err))
+		return nil, "", false
+	}
+	return session, messageReq.Message, true
+}
+
+// lockSession claims a session for one request, failing with errSessionBusy if it is taken
+func (r *ChatbotRouter) lockSession(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.busy[id] {
+		return fmt.Errorf("%w: '%s'", errSessionBusy, id)
+	}
+	r.busy[id] = true
+	return nil
+}
+
+func (r *ChatbotRouter) unlockSession(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.busy, id)
+}
+
+// sessionErrorStatus maps chat session errors to HTTP statuses
+func sessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrChatSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errSessionBusy):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -35,9 +35,9 @@ func (r *DiscoveryRouter) handleDiscoverTables(w http.ResponseWriter, req *http.
 		return
 	}
 
-	tables, err := r.discovery.DiscoverTables(catalogName, schemaName)
+	tables, err := r.discovery.DiscoverTables(req.Context(), catalogName, schemaName)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), executionErrorStatus(err))
 		return
 	}
 
@@ -63,9 +63,9 @@ func (r *DiscoveryRouter) handleDiscoverColumns(w http.ResponseWriter, req *http
 		return
 	}
 
-	columns, err := r.discovery.DiscoverColumns(catalogName, schemaName, tableName)
+	columns, err := r.discovery.DiscoverColumns(req.Context(), catalogName, schemaName, tableName)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), executionErrorStatus(err))
 		return
 	}
 
@@ -1,19 +1,25 @@
 package routers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/query"
 )
 
 type GlobalQueryRouter struct {
 	translator query.QueryTranslator
+	cursors    *datasync.Cursors
 }
 
 func NewGlobalQueryRouter(translator query.QueryTranslator) *GlobalQueryRouter {
 	return &GlobalQueryRouter{
 		translator: translator,
+		cursors:    datasync.NewCursors(cursorIdleTimeout, maxOpenCursors),
 	}
 }
 
@@ -22,7 +28,12 @@ func (r *GlobalQueryRouter) RegisterRoutes(mux *http.ServeMux) {
 }
 
 type GlobalQueryRequest struct {
-	Query string `json:"query"`
+	Query  string           `json:"query"`
+	Params *datasync.Params `json:"params"`
+
+	// PageSize and PageToken select a page of a JSON response; NDJSON responses stream every row
+	PageSize  int    `json:"pageSize,omitempty"`
+	PageToken string `json:"pageToken,omitempty"`
 }
 
 func (r *GlobalQueryRouter) handleGlobalQuery(w http.ResponseWriter, req *http.Request) {
@@ -37,12 +48,75 @@ func (r *GlobalQueryRouter) handleGlobalQuery(w http.ResponseWriter, req *http.R
 		return
 	}
 
-	result, err := r.translator.TranslateAndExecute(queryReq.Query)
+	if wantsPages(req) {
+		r.handleGlobalQueryPage(w, req, queryReq)
+		return
+	}
+
+	stream, err := r.translator.TranslateAndStream(req.Context(), queryReq.Query, queryReq.Params)
+	if err != nil {
+		http.Error(w, err.Error(), executionErrorStatus(err))
+		return
+	}
+	defer stream.Rows.Close()
+
+	// The generated SQL travels in a header since the body is only rows
+	setGeneratedSQLHeader(w, stream.GeneratedSQL)
+	if format := exportFormat(req); format != "" {
+		writeExport(w, req, format, stream.TableName, stream.Rows)
+		return
+	}
+	writeNDJSON(w, req, stream.Rows)
+}
+
+// streamRows keeps a translated query with its rows while they are paged through
+type streamRows struct {
+	datasync.Rows
+	stream *query.QueryStream
+}
+
+// handleGlobalQueryPage serves a page of a global query's rows. The first page runs the query;
+// later pages continue reading it from the cursor their page token refers to.
+func (r *GlobalQueryRouter) handleGlobalQueryPage(w http.ResponseWriter, req *http.Request, queryReq GlobalQueryRequest) {
+	pageSize, err := pageSizeParam(queryReq.PageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := pageKey(queryReq.Query, queryReq.Params)
+
+	var cursor *datasync.Cursor
+	if queryReq.PageToken != "" {
+		cursor, err = r.cursors.Take(key, queryReq.PageToken)
+	} else {
+		cursor, err = openCursor(req, func(ctx context.Context) (datasync.Rows, error) {
+			stream, err := r.translator.TranslateAndStream(ctx, queryReq.Query, queryReq.Params)
+			if err != nil {
+				return nil, err
+			}
+			return &streamRows{Rows: stream.Rows, stream: stream}, nil
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), executionErrorStatus(err))
+		return
+	}
+
+	stream := cursor.Rows().(*streamRows).stream
+	page, nextPageToken, err := readCursorPage(req, r.cursors, key, cursor, pageSize)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), executionErrorStatus(err))
 		return
 	}
 
+	result := stream.Result(page)
+	result.NextPageToken = nextPageToken
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
+
+// setGeneratedSQLHeader returns the generated SQL in a header, for responses whose body is only rows
+func setGeneratedSQLHeader(w http.ResponseWriter, generatedSQL string) {
+	w.Header().Set("X-Generated-SQL", strings.NewReplacer("\r", " ", "\n", " ").Replace(generatedSQL))
+}
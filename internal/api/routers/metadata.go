@@ -0,0 +1,97 @@
+package routers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/bundle"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// maxBundleSize bounds the size of an imported bundle document
+const maxBundleSize = 10 << 20
+
+type MetadataRouter struct {
+	storage storage.MetadataStorage
+}
+
+func NewMetadataRouter(storage storage.MetadataStorage) *MetadataRouter {
+	return &MetadataRouter{
+		storage: storage,
+	}
+}
+
+func (r *MetadataRouter) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /metadata/export", r.handleExport)
+	mux.HandleFunc("POST /metadata/import", r.handleImport)
+}
+
+// handleExport writes the global model as a bundle; ?format=yaml|json (default yaml)
+func (r *MetadataRouter) handleExport(w http.ResponseWriter, req *http.Request) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = "yaml"
+	}
+
+	exported, err := bundle.Export(r.storage)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := bundle.Marshal(exported, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"metadata.%s\"", format))
+	w.Write(data)
+}
+
+// handleImport applies a YAML or JSON bundle and responds with the changes made.
+// With ?dryRun=true the changes are only computed, not applied.
+func (r *MetadataRouter) handleImport(w http.ResponseWriter, req *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxBundleSize))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := bundle.Unmarshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := req.URL.Query().Get("dryRun") == "true"
+
+	var plan *bundle.Plan
+	if dryRun {
+		plan, err = bundle.Diff(r.storage, doc)
+	} else {
+		plan, err = bundle.Import(r.storage, doc)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if plan == nil {
+			// Nothing was written, so the bundle itself was rejected
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dryRun":  dryRun,
+		"changes": plan.Changes,
+	})
+}
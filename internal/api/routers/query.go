@@ -1,20 +1,25 @@
 package routers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
 )
 
 type QueryRouter struct {
-	engine datasync.QueryEngine
+	engine  datasync.QueryEngine
+	cursors *datasync.Cursors
 }
 
 func NewQueryRouter(engine datasync.QueryEngine) *QueryRouter {
 	return &QueryRouter{
-		engine: engine,
+		engine:  engine,
+		cursors: datasync.NewCursors(cursorIdleTimeout, maxOpenCursors),
 	}
 }
 
@@ -23,13 +28,21 @@ func (r *QueryRouter) RegisterRoutes(mux *http.ServeMux) {
 }
 
 type QueryRequest struct {
-	Query  string                 `json:"query"`
-	Params map[string]interface{} `json:"params"`
+	Query  string           `json:"query"`
+	Params *datasync.Params `json:"params"`
+
+	// PageSize and PageToken select a page of a JSON response; NDJSON responses stream every row
+	PageSize  int    `json:"pageSize,omitempty"`
+	PageToken string `json:"pageToken,omitempty"`
 }
 
 type QueryResponse struct {
-	Rows     []map[string]interface{} `json:"rows"`
-	RowCount int                      `json:"rowCount"`
+	// Columns lists the result's columns in SELECT order with their Trino types; rows are
+	// JSON objects, which do not keep that order
+	Columns       []datasync.ResultColumn  `json:"columns"`
+	Rows          []map[string]interface{} `json:"rows"`
+	RowCount      int                      `json:"rowCount"`
+	NextPageToken string                   `json:"nextPageToken,omitempty"`
 }
 
 func (r *QueryRouter) handleQuery(w http.ResponseWriter, req *http.Request) {
@@ -43,18 +56,78 @@ func (r *QueryRouter) handleQuery(w http.ResponseWriter, req *http.Request) {
 	query := strings.TrimSpace(queryReq.Query)
 	query = strings.TrimSuffix(query, ";")
 
-	result, err := r.engine.ExecuteQuery(query, queryReq.Params)
+	if wantsPages(req) {
+		r.handleQueryPage(w, req, query, queryReq)
+		return
+	}
+
+	rows, err := r.engine.QueryRowsContext(req.Context(), query, queryReq.Params)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), executionErrorStatus(err))
+		return
+	}
+	defer rows.Close()
+
+	if format := exportFormat(req); format != "" {
+		writeExport(w, req, format, "query-result", rows)
+		return
+	}
+	writeNDJSON(w, req, rows)
+}
+
+// handleQueryPage serves a page of a query's rows. The first page runs the query; later pages
+// continue reading it from the cursor their page token refers to.
+func (r *QueryRouter) handleQueryPage(w http.ResponseWriter, req *http.Request, query string, queryReq QueryRequest) {
+	pageSize, err := pageSizeParam(queryReq.PageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := pageKey(query, queryReq.Params)
+
+	var cursor *datasync.Cursor
+	if queryReq.PageToken != "" {
+		cursor, err = r.cursors.Take(key, queryReq.PageToken)
+	} else {
+		cursor, err = openCursor(req, func(ctx context.Context) (datasync.Rows, error) {
+			return r.engine.QueryRowsContext(ctx, query, queryReq.Params)
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), executionErrorStatus(err))
+		return
+	}
+
+	columns := cursor.Rows().Columns()
+	page, nextPageToken, err := readCursorPage(req, r.cursors, key, cursor, pageSize)
+	if err != nil {
+		http.Error(w, err.Error(), executionErrorStatus(err))
 		return
 	}
 
 	// Format response to match global query endpoint format
 	response := QueryResponse{
-		Rows:     result.Rows,
-		RowCount: len(result.Rows),
+		Columns:       columns,
+		Rows:          page,
+		RowCount:      len(page),
+		NextPageToken: nextPageToken,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// executionErrorStatus maps an error from running a query to an HTTP status,
+// reporting bad parameters as 400 and queries stopped by the request timeout as 504 Gateway Timeout
+func executionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, datasync.ErrInvalidParams), errors.Is(err, datasync.ErrInvalidIdentifier),
+		errors.Is(err, datasync.ErrInvalidPageToken):
+		return http.StatusBadRequest
+	case errors.Is(err, discovery.ErrExcluded):
+		return http.StatusNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
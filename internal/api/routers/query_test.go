@@ -0,0 +1,108 @@
+package routers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
+
+// countingEngine serves n numbered rows for every query, counting the queries it runs
+type countingEngine struct {
+	n       int
+	queries int
+}
+
+func (e *countingEngine) ExecuteQuery(query string, params *datasync.Params) (datasync.QueryResult, error) {
+	return e.ExecuteQueryContext(context.Background(), query, params)
+}
+
+func (e *countingEngine) ExecuteQueryContext(ctx context.Context, query string, params *datasync.Params) (datasync.QueryResult, error) {
+	rows, err := e.QueryRowsContext(ctx, query, params)
+	if err != nil {
+		return datasync.QueryResult{}, err
+	}
+	return datasync.CollectRows(rows)
+}
+
+func (e *countingEngine) QueryRowsContext(ctx context.Context, query string, params *datasync.Params) (datasync.Rows, error) {
+	e.queries++
+	rows := make([]map[string]interface{}, e.n)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"n": i}
+	}
+	return &contextRows{Rows: datasync.NewSliceRows([]datasync.ResultColumn{{Name: "n", Type: "integer"}}, rows), ctx: ctx}, nil
+}
+
+// contextRows stops with the context's error once it is done, as the Trino driver does
+type contextRows struct {
+	datasync.Rows
+	ctx context.Context
+}
+
+func (r *contextRows) Next() bool {
+	return r.ctx.Err() == nil && r.Rows.Next()
+}
+
+func (r *contextRows) Err() error {
+	return r.ctx.Err()
+}
+
+func TestQuery_PagesReadTheQueryOnce(t *testing.T) {
+	engine := &countingEngine{n: 7}
+	mux := http.NewServeMux()
+	NewQueryRouter(engine).RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var seen []float64
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected 3 pages, got more")
+		}
+
+		body, _ := json.Marshal(QueryRequest{Query: "SELECT n FROM numbers", PageSize: 3, PageToken: token})
+		res, err := http.Post(server.URL+"/query", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var page QueryResponse
+		json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", res.StatusCode)
+		}
+
+		for _, row := range page.Rows {
+			seen = append(seen, row["n"].(float64))
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+
+	if fmt.Sprint(seen) != "[0 1 2 3 4 5 6]" {
+		t.Fatalf("Expected every row once across pages, got %v", seen)
+	}
+	if engine.queries != 1 {
+		t.Fatalf("Expected the query to run once, got %d runs", engine.queries)
+	}
+
+	// A used token does not serve its page again
+	body, _ := json.Marshal(QueryRequest{Query: "SELECT n FROM numbers", PageSize: 3, PageToken: token})
+	res, err := http.Post(server.URL+"/query", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for a used page token, got %d", res.StatusCode)
+	}
+}
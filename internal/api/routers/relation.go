@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,20 +10,26 @@ import (
 	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
 	"github.com/guilherme096/data-sync/pkg/data-sync/matching"
 	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/query"
 	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
 )
 
 type RelationRouter struct {
 	storage   storage.MetadataStorage
 	discovery discovery.MetadataDiscovery
-	matcher   *matching.Matcher
+	// matcher is nil when no LLM provider is configured; auto-matching then answers 503
+	matcher *matching.Matcher
+
+	// parallelism bounds the discovery queries run at once when building the matching context
+	parallelism int
 }
 
-func NewRelationRouter(storage storage.MetadataStorage, discovery discovery.MetadataDiscovery, matcher *matching.Matcher) *RelationRouter {
+func NewRelationRouter(storage storage.MetadataStorage, discovery discovery.MetadataDiscovery, matcher *matching.Matcher, parallelism int) *RelationRouter {
 	return &RelationRouter{
-		storage:   storage,
-		discovery: discovery,
-		matcher:   matcher,
+		storage:     storage,
+		discovery:   discovery,
+		matcher:     matcher,
+		parallelism: parallelism,
 	}
 }
 
@@ -41,6 +48,11 @@ func (r *RelationRouter) handleCreateRelation(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	if err := r.validateRelation(req.Context(), &relation); err != nil {
+		http.Error(w, fmt.Sprintf("invalid relation: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	// Create the table relation
 	if err := r.storage.CreateTableRelation(&relation); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -48,7 +60,7 @@ func (r *RelationRouter) handleCreateRelation(w http.ResponseWriter, req *http.R
 	}
 
 	// Automatically create global table and column mappings
-	if err := r.autoCreateGlobalTableFromRelation(&relation); err != nil {
+	if err := r.autoCreateGlobalTableFromRelation(req.Context(), &relation); err != nil {
 		// Log error but don't fail the relation creation
 		fmt.Printf("Warning: failed to auto-create global table for relation '%s': %v\n", relation.Name, err)
 	}
@@ -103,7 +115,7 @@ func (r *RelationRouter) handleDeleteRelation(w http.ResponseWriter, req *http.R
 
 // autoCreateGlobalTableFromRelation automatically creates a global table and column mappings
 // when a relation is created
-func (r *RelationRouter) autoCreateGlobalTableFromRelation(relation *models.TableRelation) error {
+func (r *RelationRouter) autoCreateGlobalTableFromRelation(ctx context.Context, relation *models.TableRelation) error {
 	// Check if global table already exists
 	existingTable, _ := r.storage.GetGlobalTable(relation.Name)
 	if existingTable != nil {
@@ -121,46 +133,26 @@ func (r *RelationRouter) autoCreateGlobalTableFromRelation(relation *models.Tabl
 	}
 
 	// Discover and create columns from physical tables
-	if err := r.discoverAndCreateColumnsFromRelation(relation); err != nil {
+	if err := r.discoverAndCreateColumnsFromRelation(ctx, relation); err != nil {
 		return fmt.Errorf("failed to discover columns: %w", err)
 	}
 
 	return nil
 }
 
-// discoverAndCreateColumnsFromRelation discovers columns from physical tables in the relation
-func (r *RelationRouter) discoverAndCreateColumnsFromRelation(relation *models.TableRelation) error {
-	// Collect all physical tables from the relation
-	var physicalTables []struct {
-		catalog string
-		schema  string
-		table   string
-	}
-
-	// Add left table if physical
-	if relation.LeftTable.Type == "physical" {
-		physicalTables = append(physicalTables, struct {
-			catalog string
-			schema  string
-			table   string
-		}{
-			catalog: relation.LeftTable.Catalog,
-			schema:  relation.LeftTable.Schema,
-			table:   relation.LeftTable.Table,
-		})
-	}
-
-	// Add right table if physical
-	if relation.RightTable.Type == "physical" {
-		physicalTables = append(physicalTables, struct {
-			catalog string
-			schema  string
-			table   string
-		}{
-			catalog: relation.RightTable.Catalog,
-			schema:  relation.RightTable.Schema,
-			table:   relation.RightTable.Table,
-		})
+// discoverAndCreateColumnsFromRelation discovers columns from the physical tables in the relation,
+// including those inside nested relations
+func (r *RelationRouter) discoverAndCreateColumnsFromRelation(ctx context.Context, relation *models.TableRelation) error {
+	// Collect all physical tables from the relation tree
+	relationResolver := query.NewRelationResolver(r.storage)
+	resolved, err := relationResolver.ResolveRelation(relation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve relation: %w", err)
+	}
+
+	physicalTables, err := relationResolver.GetPhysicalTables(resolved)
+	if err != nil {
+		return fmt.Errorf("failed to collect physical tables: %w", err)
 	}
 
 	if len(physicalTables) == 0 {
@@ -169,10 +161,10 @@ func (r *RelationRouter) discoverAndCreateColumnsFromRelation(relation *models.T
 
 	// Discover columns from the first table and create global columns
 	firstTable := physicalTables[0]
-	columns, err := r.discovery.DiscoverColumns(firstTable.catalog, firstTable.schema, firstTable.table)
+	columns, err := r.discovery.DiscoverColumns(ctx, firstTable.Catalog, firstTable.Schema, firstTable.Table)
 	if err != nil {
 		return fmt.Errorf("failed to discover columns from %s.%s.%s: %w",
-			firstTable.catalog, firstTable.schema, firstTable.table, err)
+			firstTable.Catalog, firstTable.Schema, firstTable.Table, err)
 	}
 
 	// Create global columns
@@ -181,7 +173,7 @@ func (r *RelationRouter) discoverAndCreateColumnsFromRelation(relation *models.T
 			GlobalTableName: relation.Name,
 			Name:            col.Name,
 			DataType:        col.DataType,
-			Description:     fmt.Sprintf("Auto-discovered from %s.%s.%s", firstTable.catalog, firstTable.schema, firstTable.table),
+			Description:     fmt.Sprintf("Auto-discovered from %s.%s.%s", firstTable.Catalog, firstTable.Schema, firstTable.Table),
 		}
 
 		if err := r.storage.CreateGlobalColumn(globalColumn); err != nil {
@@ -195,15 +187,15 @@ func (r *RelationRouter) discoverAndCreateColumnsFromRelation(relation *models.T
 			mapping := &models.ColumnMapping{
 				GlobalTableName:  relation.Name,
 				GlobalColumnName: col.Name,
-				CatalogName:      physTable.catalog,
-				SchemaName:       physTable.schema,
-				TableName:        physTable.table,
+				CatalogName:      physTable.Catalog,
+				SchemaName:       physTable.Schema,
+				TableName:        physTable.Table,
 				ColumnName:       col.Name,
 			}
 
 			if err := r.storage.CreateColumnMapping(mapping); err != nil {
 				fmt.Printf("Warning: failed to create column mapping for '%s' in %s.%s.%s: %v\n",
-					col.Name, physTable.catalog, physTable.schema, physTable.table, err)
+					col.Name, physTable.Catalog, physTable.Schema, physTable.Table, err)
 			}
 		}
 	}
@@ -225,6 +217,10 @@ type AutoMatchResponse struct {
 }
 
 func (r *RelationRouter) handleAutoMatch(w http.ResponseWriter, req *http.Request) {
+	if aiDisabled(w, r.matcher != nil) {
+		return
+	}
+
 	var matchReq AutoMatchRequest
 	if err := json.NewDecoder(req.Body).Decode(&matchReq); err != nil {
 		// Use defaults if no body provided or invalid JSON
@@ -237,14 +233,14 @@ func (r *RelationRouter) handleAutoMatch(w http.ResponseWriter, req *http.Reques
 	}
 
 	// Gather metadata for matching context
-	ctx, err := r.buildMatchingContext(matchReq.MaxSuggestions)
+	matchCtx, err := r.buildMatchingContext(req.Context(), matchReq.MaxSuggestions)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to build matching context: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Get suggestions from matching service
-	suggestions, err := r.matcher.SuggestRelations(ctx)
+	suggestions, err := r.matcher.SuggestRelations(req.Context(), matchCtx)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get suggestions: %v", err), http.StatusInternalServerError)
 		return
@@ -256,7 +252,7 @@ func (r *RelationRouter) handleAutoMatch(w http.ResponseWriter, req *http.Reques
 
 	// Auto-create relations if requested
 	if matchReq.AutoCreate {
-		createdRelations, errors := r.createSuggestedRelations(suggestions)
+		createdRelations, errors := r.createSuggestedRelations(req.Context(), suggestions)
 		response.CreatedRelations = createdRelations
 		response.Errors = errors
 	}
@@ -265,29 +261,30 @@ func (r *RelationRouter) handleAutoMatch(w http.ResponseWriter, req *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-func (r *RelationRouter) buildMatchingContext(maxSuggestions int) (matching.MatchingContext, error) {
-	// Discover all physical tables
-	catalogs, err := r.discovery.DiscoverCatalogs()
+func (r *RelationRouter) buildMatchingContext(ctx context.Context, maxSuggestions int) (matching.MatchingContext, error) {
+	// Discover all physical tables concurrently; parts that fail to be discovered are skipped
+	snapshot, err := discovery.NewWalker(r.discovery, r.parallelism).Walk(ctx, nil)
 	if err != nil {
 		return matching.MatchingContext{}, err
 	}
 
 	var physicalTables []matching.PhysicalTableInfo
 
+	catalogs, _ := snapshot.DiscoverCatalogs(ctx)
 	for _, catalog := range catalogs {
-		schemas, err := r.discovery.DiscoverSchemas(catalog.Name)
+		schemas, err := snapshot.DiscoverSchemas(ctx, catalog.Name)
 		if err != nil {
 			continue // Skip catalogs with errors
 		}
 
 		for _, schema := range schemas {
-			tables, err := r.discovery.DiscoverTables(catalog.Name, schema.Name)
+			tables, err := snapshot.DiscoverTables(ctx, catalog.Name, schema.Name)
 			if err != nil {
 				continue
 			}
 
 			for _, table := range tables {
-				columns, err := r.discovery.DiscoverColumns(catalog.Name, schema.Name, table.Name)
+				columns, err := snapshot.DiscoverColumns(ctx, catalog.Name, schema.Name, table.Name)
 				if err != nil {
 					continue
 				}
@@ -323,7 +320,7 @@ func (r *RelationRouter) buildMatchingContext(maxSuggestions int) (matching.Matc
 	}, nil
 }
 
-func (r *RelationRouter) createSuggestedRelations(suggestions []matching.RelationSuggestion) ([]*models.TableRelation, []string) {
+func (r *RelationRouter) createSuggestedRelations(ctx context.Context, suggestions []matching.RelationSuggestion) ([]*models.TableRelation, []string) {
 	var createdRelations []*models.TableRelation
 	var errors []string
 
@@ -347,7 +344,7 @@ func (r *RelationRouter) createSuggestedRelations(suggestions []matching.Relatio
 		relation.ID = fmt.Sprintf("auto_%d", time.Now().UnixNano())
 
 		// Validate relation before creating
-		if err := r.validateRelation(relation); err != nil {
+		if err := r.validateRelation(ctx, relation); err != nil {
 			errors = append(errors, fmt.Sprintf("Invalid relation '%s': %v", relation.Name, err))
 			continue
 		}
@@ -358,7 +355,7 @@ func (r *RelationRouter) createSuggestedRelations(suggestions []matching.Relatio
 		}
 
 		// Auto-create global table (existing logic)
-		if err := r.autoCreateGlobalTableFromRelation(relation); err != nil {
+		if err := r.autoCreateGlobalTableFromRelation(ctx, relation); err != nil {
 			fmt.Printf("Warning: failed to auto-create global table for '%s': %v\n", relation.Name, err)
 		}
 
@@ -369,28 +366,61 @@ func (r *RelationRouter) createSuggestedRelations(suggestions []matching.Relatio
 	return createdRelations, errors
 }
 
-func (r *RelationRouter) validateRelation(relation *models.TableRelation) error {
+func (r *RelationRouter) validateRelation(ctx context.Context, relation *models.TableRelation) error {
 	// Validate left table exists
-	if relation.LeftTable.Type == "physical" {
-		if _, err := r.discovery.DiscoverColumns(
-			relation.LeftTable.Catalog,
-			relation.LeftTable.Schema,
-			relation.LeftTable.Table,
-		); err != nil {
-			return fmt.Errorf("left table not found: %w", err)
-		}
+	leftColumns, err := r.sourceColumns(ctx, relation.LeftTable)
+	if err != nil {
+		return fmt.Errorf("left table not found: %w", err)
 	}
 
 	// Validate right table exists
-	if relation.RightTable.Type == "physical" {
-		if _, err := r.discovery.DiscoverColumns(
-			relation.RightTable.Catalog,
-			relation.RightTable.Schema,
-			relation.RightTable.Table,
-		); err != nil {
-			return fmt.Errorf("right table not found: %w", err)
+	rightColumns, err := r.sourceColumns(ctx, relation.RightTable)
+	if err != nil {
+		return fmt.Errorf("right table not found: %w", err)
+	}
+
+	if relation.RelationType != "JOIN" {
+		return models.ValidateUnionType(relation.UnionType)
+	}
+
+	// Validate join type and that every join column exists on its side
+	if err := relation.ValidateJoin(); err != nil {
+		return err
+	}
+	for _, key := range relation.JoinKeys() {
+		if leftColumns != nil && !leftColumns[key.Left] {
+			return fmt.Errorf("left join column '%s' not found", key.Left)
+		}
+		if rightColumns != nil && !rightColumns[key.Right] {
+			return fmt.Errorf("right join column '%s' not found", key.Right)
 		}
 	}
 
 	return nil
 }
+
+// sourceColumns returns the column names of a physical table source, discovered from the catalog.
+// Nested relations are only checked to exist; their columns are global columns and are not returned.
+func (r *RelationRouter) sourceColumns(ctx context.Context, source models.TableSource) (map[string]bool, error) {
+	if source.Type == "relation" {
+		if _, err := r.storage.GetTableRelation(source.RelationID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if source.Type != "physical" {
+		return nil, fmt.Errorf("unknown table source type: %s", source.Type)
+	}
+
+	columns, err := r.discovery.DiscoverColumns(ctx, source.Catalog, source.Schema, source.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		names[col.Name] = true
+	}
+	return names, nil
+}
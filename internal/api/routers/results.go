@@ -0,0 +1,189 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+	"github.com/guilherme096/data-sync/pkg/data-sync/export"
+)
+
+const (
+	// defaultPageSize is used when a JSON query request does not set pageSize
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+
+	// ndjsonFlushEvery is how many NDJSON rows are written between flushes to the client
+	ndjsonFlushEvery = 100
+
+	// writeDeadlineGrace leaves room to finish a response after its request context expires
+	writeDeadlineGrace = 10 * time.Second
+
+	// cursorIdleTimeout is how long a paged query is kept open waiting for its next page
+	// request; Trino abandons queries whose client stops fetching for 5 minutes
+	cursorIdleTimeout = 2 * time.Minute
+	// maxOpenCursors bounds how many paged queries each endpoint keeps open at once
+	maxOpenCursors = 100
+)
+
+// IsStreamingRequest reports whether a request asks for a response that lasts as long as its rows
+// or answer: NDJSON rows, a file export or a chatbot event stream. The server bounds these by its
+// stream timeout instead of the request timeout.
+func IsStreamingRequest(req *http.Request) bool {
+	return wantsNDJSON(req) || exportFormat(req) != "" || strings.HasSuffix(req.URL.Path, "/stream")
+}
+
+// liftWriteDeadline replaces the server's write deadline, which is sized for ordinary requests,
+// with one just after the request context expires, or none if the context has no deadline
+func liftWriteDeadline(controller *http.ResponseController, ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		controller.SetWriteDeadline(time.Time{})
+		return
+	}
+	controller.SetWriteDeadline(deadline.Add(writeDeadlineGrace))
+}
+
+// wantsNDJSON reports whether the client asked for rows streamed as newline-delimited JSON,
+// either with "Accept: application/x-ndjson" or "?format=ndjson"
+func wantsNDJSON(req *http.Request) bool {
+	return req.URL.Query().Get("format") == "ndjson" ||
+		strings.Contains(req.Header.Get("Accept"), "application/x-ndjson")
+}
+
+// exportFormat returns the file format requested with ?format=csv|parquet|arrow, or "" for a JSON or NDJSON response
+func exportFormat(req *http.Request) string {
+	if format := req.URL.Query().Get("format"); export.IsFormat(format) {
+		return format
+	}
+	return ""
+}
+
+// pageSizeParam validates the requested page size, applying the default
+func pageSizeParam(pageSize int) (int, error) {
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize < 0 || pageSize > maxPageSize {
+		return 0, fmt.Errorf("pageSize must be between 1 and %d", maxPageSize)
+	}
+	return pageSize, nil
+}
+
+// wantsPages reports whether a query response is paged JSON rather than a stream or file
+func wantsPages(req *http.Request) bool {
+	return exportFormat(req) == "" && !wantsNDJSON(req)
+}
+
+// openCursor runs a paged query under a context that outlives the request, so the rest of its
+// rows can be kept for later pages. It is still stopped if the request ends while it starts.
+func openCursor(req *http.Request, run func(ctx context.Context) (datasync.Rows, error)) (*datasync.Cursor, error) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+	stop := context.AfterFunc(req.Context(), cancel)
+
+	rows, err := run(ctx)
+	if !stop() {
+		if err == nil {
+			rows.Close()
+		}
+		cancel()
+		return nil, req.Context().Err()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return datasync.NewCursor(rows, cancel), nil
+}
+
+// readCursorPage reads the next page from cursor and, if more rows follow, keeps the cursor
+// behind the returned page token. Otherwise, or if the request ends first, the cursor is closed.
+func readCursorPage(req *http.Request, cursors *datasync.Cursors, key string, cursor *datasync.Cursor, pageSize int) ([]map[string]interface{}, string, error) {
+	stop := context.AfterFunc(req.Context(), func() { cursor.Close() })
+	page, hasMore, err := cursor.ReadPage(pageSize)
+	if !stop() {
+		return nil, "", req.Context().Err()
+	}
+	if err != nil || !hasMore {
+		cursor.Close()
+		return page, "", err
+	}
+	return page, cursors.Keep(key, cursor), nil
+}
+
+// pageKey identifies a query and its parameters for page tokens, so a token cannot be
+// replayed against the same query with different parameter values
+func pageKey(query string, params *datasync.Params) string {
+	if params == nil {
+		return query
+	}
+	encoded, _ := json.Marshal(params)
+	return query + "\n" + string(encoded)
+}
+
+// writeNDJSON streams rows to the client, one JSON object per line, flushing as it goes.
+// Once rows have been sent the status can no longer change, so a failure part way is
+// reported as a final {"error": "..."} line.
+func writeNDJSON(w http.ResponseWriter, req *http.Request, rows datasync.Rows) {
+	controller := http.NewResponseController(w)
+	liftWriteDeadline(controller, req.Context())
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+
+	written := 0
+	for rows.Next() {
+		if err := encoder.Encode(rows.Row()); err != nil {
+			// The client has gone away; closing rows stops the query
+			return
+		}
+		written++
+		if written%ndjsonFlushEvery == 0 {
+			controller.Flush()
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		encoder.Encode(map[string]string{"error": err.Error()})
+	}
+	controller.Flush()
+}
+
+// writeExport streams every row as a file download named after name. An error before
+// any bytes are sent is reported with a status; after that the file is left truncated,
+// which Parquet and Arrow readers detect since the footer is missing.
+func writeExport(w http.ResponseWriter, req *http.Request, format, name string, rows datasync.Rows) {
+	liftWriteDeadline(http.NewResponseController(w), req.Context())
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName(name, format)))
+
+	counter := &countingWriter{w: w}
+	if err := export.Write(counter, format, rows); err != nil {
+		if counter.written == 0 {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), executionErrorStatus(err))
+			return
+		}
+		log.Printf("Export to %s failed after %d bytes: %v", format, counter.written, err)
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w       http.ResponseWriter
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
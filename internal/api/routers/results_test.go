@@ -0,0 +1,64 @@
+package routers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
+
+// slowRows serves n rows, waiting delay before each
+type slowRows struct {
+	n     int
+	delay time.Duration
+	pos   int
+}
+
+func (r *slowRows) Columns() []datasync.ResultColumn {
+	return []datasync.ResultColumn{{Name: "id", Type: "integer"}}
+}
+
+func (r *slowRows) Next() bool {
+	if r.pos >= r.n {
+		return false
+	}
+	time.Sleep(r.delay)
+	r.pos++
+	return true
+}
+
+func (r *slowRows) Row() map[string]interface{} { return map[string]interface{}{"id": r.pos} }
+
+func (r *slowRows) Values() []interface{} { return []interface{}{r.pos} }
+
+func (r *slowRows) Err() error { return nil }
+
+func (r *slowRows) Close() error { return nil }
+
+func TestWriteExport_OutlivesTheWriteTimeout(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeExport(w, req, "csv", "slow", &slowRows{n: 5, delay: 50 * time.Millisecond})
+	}))
+	// The export takes longer than the server lets an ordinary response take
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "?format=csv")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Reading the export failed: %v", err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(string(body)), "\n"); len(lines) != 6 {
+		t.Fatalf("Expected a header and 5 rows, got %q", body)
+	}
+}
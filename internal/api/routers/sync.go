@@ -2,6 +2,8 @@ package routers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/sync"
@@ -9,35 +11,73 @@ import (
 
 type SyncRouter struct {
 	sync sync.MetadataSync
+	jobs *sync.JobManager
 }
 
-func NewSyncRouter(sync sync.MetadataSync) *SyncRouter {
+func NewSyncRouter(metadataSync sync.MetadataSync, jobs *sync.JobManager) *SyncRouter {
 	return &SyncRouter{
-		sync: sync,
+		sync: metadataSync,
+		jobs: jobs,
 	}
 }
 
 func (r *SyncRouter) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /sync", r.handleSync)
 	mux.HandleFunc("GET /sync/status", r.handleSyncStatus)
+	mux.HandleFunc("GET /sync/impact", r.handleSyncImpact)
+	mux.HandleFunc("GET /sync/jobs", r.handleListJobs)
+	mux.HandleFunc("GET /sync/jobs/{id}", r.handleGetJob)
+	mux.HandleFunc("POST /sync/jobs/{id}/cancel", r.handleCancelJob)
 }
 
+// handleSync starts a full sync in the background and returns its job; poll GET /sync/jobs/{id} for the result
 func (r *SyncRouter) handleSync(w http.ResponseWriter, req *http.Request) {
-	if err := r.sync.SyncAll(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	job, err := r.jobs.Start(sync.TriggerManual)
+	if errors.Is(err, sync.ErrSyncRunning) {
+		http.Error(w, fmt.Sprintf("%v: job %s", err, job.ID), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/sync/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+func (r *SyncRouter) handleListJobs(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(r.jobs.List())
+}
+
+func (r *SyncRouter) handleGetJob(w http.ResponseWriter, req *http.Request) {
+	job, err := r.jobs.Get(req.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func (r *SyncRouter) handleCancelJob(w http.ResponseWriter, req *http.Request) {
+	job, err := r.jobs.Cancel(req.PathValue("id"))
+	switch {
+	case errors.Is(err, sync.ErrJobNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, sync.ErrJobFinished):
+		http.Error(w, fmt.Sprintf("%v with status %s", err, job.Status), http.StatusConflict)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "success",
-		"message": "Metadata sync completed successfully",
-	})
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
 }
 
 func (r *SyncRouter) handleSyncStatus(w http.ResponseWriter, req *http.Request) {
-	status, err := r.sync.CheckSyncStatus()
+	status, err := r.sync.CheckSyncStatus(req.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -46,3 +86,14 @@ func (r *SyncRouter) handleSyncStatus(w http.ResponseWriter, req *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
+
+func (r *SyncRouter) handleSyncImpact(w http.ResponseWriter, req *http.Request) {
+	impact, err := r.sync.Impact()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(impact)
+}
@@ -1,13 +1,14 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/guilherme096/data-sync/internal/api/routers"
-	"github.com/guilherme096/data-sync/pkg/data-sync/chatbot"
 	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+	"github.com/guilherme096/data-sync/pkg/data-sync/chatbot"
 	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
 	"github.com/guilherme096/data-sync/pkg/data-sync/matching"
 	"github.com/guilherme096/data-sync/pkg/data-sync/query"
@@ -20,22 +21,35 @@ type Server struct {
 	engine     datasync.QueryEngine
 	storage    storage.MetadataStorage
 	sync       sync.MetadataSync
+	syncJobs   *sync.JobManager
 	discovery  discovery.MetadataDiscovery
 	agent      chatbot.AgentActions
 	translator query.QueryTranslator
 	matcher    *matching.Matcher
+
+	// requestTimeout bounds how long a request, and the queries it runs, may take; 0 disables it
+	requestTimeout time.Duration
+	// streamTimeout replaces requestTimeout for streamed responses, see routers.IsStreamingRequest
+	streamTimeout time.Duration
+	// discoveryParallelism bounds the discovery queries a request runs at once
+	discoveryParallelism int
 }
 
-func NewServer(addr string, engine datasync.QueryEngine, storage storage.MetadataStorage, sync sync.MetadataSync, discovery discovery.MetadataDiscovery, agent chatbot.AgentActions, translator query.QueryTranslator, matcher *matching.Matcher) *Server {
+func NewServer(addr string, engine datasync.QueryEngine, storage storage.MetadataStorage, sync sync.MetadataSync, syncJobs *sync.JobManager, discovery discovery.MetadataDiscovery, agent chatbot.AgentActions, translator query.QueryTranslator, matcher *matching.Matcher, requestTimeout, streamTimeout time.Duration, discoveryParallelism int) *Server {
 	return &Server{
 		addr:       addr,
 		engine:     engine,
 		storage:    storage,
 		sync:       sync,
+		syncJobs:   syncJobs,
 		discovery:  discovery,
 		agent:      agent,
 		translator: translator,
 		matcher:    matcher,
+
+		requestTimeout:       requestTimeout,
+		streamTimeout:        streamTimeout,
+		discoveryParallelism: discoveryParallelism,
 	}
 }
 
@@ -45,7 +59,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-Timeout")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Generated-SQL, Content-Disposition")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
 		// Handle preflight OPTIONS request
@@ -59,6 +74,40 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// timeoutMiddleware cancels each request's context after the server timeout, or after the
+// shorter duration a client asks for in the X-Request-Timeout header (e.g. "30s"). Streamed
+// responses take streamTimeout instead, since they last as long as their rows.
+// Queries run with the request context, so a timed out or disconnected request cancels them on Trino.
+func timeoutMiddleware(timeout, streamTimeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := timeout
+		if routers.IsStreamingRequest(r) {
+			limit = streamTimeout
+		}
+
+		requestTimeout := limit
+		if header := r.Header.Get("X-Request-Timeout"); header != "" {
+			requested, err := time.ParseDuration(header)
+			if err != nil || requested <= 0 {
+				http.Error(w, "invalid X-Request-Timeout header: expected a positive duration such as 30s", http.StatusBadRequest)
+				return
+			}
+			if limit <= 0 || requested < limit {
+				requestTimeout = requested
+			}
+		}
+
+		if requestTimeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (s *Server) Run() error {
 	mux := http.NewServeMux()
 
@@ -75,13 +124,16 @@ func (s *Server) Run() error {
 	discoveryRouter := routers.NewDiscoveryRouter(s.discovery)
 	discoveryRouter.RegisterRoutes(mux)
 
-	syncRouter := routers.NewSyncRouter(s.sync)
+	syncRouter := routers.NewSyncRouter(s.sync, s.syncJobs)
 	syncRouter.RegisterRoutes(mux)
 
 	globalRouter := routers.NewGlobalRouter(s.storage)
 	globalRouter.RegisterRoutes(mux)
 
-	relationRouter := routers.NewRelationRouter(s.storage, s.discovery, s.matcher)
+	metadataRouter := routers.NewMetadataRouter(s.storage)
+	metadataRouter.RegisterRoutes(mux)
+
+	relationRouter := routers.NewRelationRouter(s.storage, s.discovery, s.matcher, s.discoveryParallelism)
 	relationRouter.RegisterRoutes(mux)
 
 	chatbotRouter := routers.NewChatbotRouter(s.agent, s.translator, s.discovery, s.storage)
@@ -90,14 +142,21 @@ func (s *Server) Run() error {
 	globalQueryRouter := routers.NewGlobalQueryRouter(s.translator)
 	globalQueryRouter.RegisterRoutes(mux)
 
-	// Wrap with CORS middleware
-	handler := corsMiddleware(mux)
+	// Wrap with timeout and CORS middleware
+	handler := corsMiddleware(timeoutMiddleware(s.requestTimeout, s.streamTimeout, mux))
+
+	// Leave room to write the timeout error after the request context expires. Streamed
+	// responses move their write deadline to match the stream timeout.
+	writeTimeout := time.Duration(0)
+	if s.requestTimeout > 0 {
+		writeTimeout = s.requestTimeout + 10*time.Second
+	}
 
 	server := &http.Server{
 		Addr:         s.addr,
 		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
 	}
 
 	log.Printf("Server starting on %s", s.addr)
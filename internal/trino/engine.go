@@ -1,10 +1,15 @@
 package trino
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
-	_ "github.com/trinodb/trino-go-client/trino"
+	"github.com/trinodb/trino-go-client/trino"
 )
 
 type Engine struct {
@@ -23,47 +28,198 @@ func (e *Engine) Close() error {
 	return e.db.Close()
 }
 
-func (e *Engine) ExecuteQuery(query string, params map[string]interface{}) (datasync.QueryResult, error) {
-	stmt, err := e.db.Prepare(query)
+func (e *Engine) ExecuteQuery(query string, params *datasync.Params) (datasync.QueryResult, error) {
+	return e.ExecuteQueryContext(context.Background(), query, params)
+}
+
+// ExecuteQueryContext runs a query; when ctx is cancelled the Trino client cancels the query on the cluster
+func (e *Engine) ExecuteQueryContext(ctx context.Context, query string, params *datasync.Params) (datasync.QueryResult, error) {
+	rows, err := e.QueryRowsContext(ctx, query, params)
 	if err != nil {
 		return datasync.QueryResult{}, err
 	}
-	defer stmt.Close()
+	return datasync.CollectRows(rows)
+}
+
+// QueryRowsContext runs a query and streams its rows from Trino as they are read
+func (e *Engine) QueryRowsContext(ctx context.Context, query string, params *datasync.Params) (datasync.Rows, error) {
+	boundQuery, bound, err := params.Bind(query)
+	if err != nil {
+		return nil, err
+	}
 
-	args := make([]interface{}, 0, len(params))
-	for _, v := range params {
-		args = append(args, v)
+	args := make([]interface{}, len(bound))
+	for i, param := range bound {
+		args[i], err = toTrinoValue(param)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %d: %v", datasync.ErrInvalidParams, i+1, err)
+		}
 	}
 
-	rows, err := stmt.Query(args...)
+	stmt, err := e.db.PrepareContext(ctx, boundQuery)
 	if err != nil {
-		return datasync.QueryResult{}, err
+		return nil, err
 	}
-	defer rows.Close()
 
-	columns, err := rows.Columns()
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
-		return datasync.QueryResult{}, err
+		stmt.Close()
+		return nil, err
 	}
 
-	var results []map[string]interface{}
-	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		stmt.Close()
+		return nil, err
+	}
+
+	columns := make([]datasync.ResultColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = datasync.ResultColumn{Name: columnType.Name(), Type: trinoTypeName(columnType)}
+	}
+
+	return &engineRows{stmt: stmt, rows: rows, columns: datasync.UniqueColumnNames(columns)}, nil
+}
+
+// trinoTypeName returns a column's Trino type as Trino writes it, e.g. "decimal(10,2)" or "array(varchar)"
+func trinoTypeName(columnType *sql.ColumnType) string {
+	typeName := strings.ToLower(columnType.DatabaseTypeName())
+	if typeName == "decimal" {
+		if precision, scale, ok := columnType.DecimalSize(); ok {
+			return fmt.Sprintf("decimal(%d,%d)", precision, scale)
+		}
+	}
+	return typeName
+}
+
+// localTimestampLayouts are the zoneless formats accepted for "timestamp" parameters, besides RFC 3339
+var localTimestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// toTrinoValue converts a parameter to a value the Trino driver can send, applying its type hint
+func toTrinoValue(param datasync.Param) (interface{}, error) {
+	switch param.Type {
+	case datasync.ParamTypeDate:
+		text, ok := param.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("date values must be strings such as \"2024-01-31\"")
+		}
+		date, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date '%s': expected YYYY-MM-DD", text)
+		}
+		return trino.Date(date.Year(), date.Month(), date.Day()), nil
+
+	case datasync.ParamTypeTimestamp:
+		text, ok := param.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("timestamp values must be strings such as \"2024-01-31 12:00:00\"")
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			// The value carries a zone, so bind it as an instant
+			return ts, nil
+		}
+		for _, layout := range localTimestampLayouts {
+			if ts, err := time.Parse(layout, text); err == nil {
+				return trino.Timestamp(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond()), nil
+			}
 		}
+		return nil, fmt.Errorf("invalid timestamp '%s': expected YYYY-MM-DD HH:MM:SS or RFC 3339", text)
 
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return datasync.QueryResult{}, err
+	case datasync.ParamTypeDecimal:
+		switch value := param.Value.(type) {
+		case json.Number:
+			return trino.Numeric(value.String()), nil
+		case string:
+			if _, err := json.Number(value).Float64(); err != nil {
+				return nil, fmt.Errorf("invalid decimal '%s'", value)
+			}
+			return trino.Numeric(value), nil
+		default:
+			return nil, fmt.Errorf("decimal values must be numbers or numeric strings")
 		}
+	}
 
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			row[col] = values[i]
+	switch value := param.Value.(type) {
+	case json.Number:
+		// Integers bind as BIGINT; anything else keeps its exact digits as a DECIMAL
+		if n, err := value.Int64(); err == nil {
+			return n, nil
 		}
-		results = append(results, row)
+		return trino.Numeric(value.String()), nil
+	case float64:
+		return trino.Numeric(fmt.Sprint(value)), nil
+	case nil, string, bool, int, int64:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// engineRows adapts sql.Rows to datasync.Rows
+type engineRows struct {
+	stmt    *sql.Stmt
+	rows    *sql.Rows
+	columns []datasync.ResultColumn
+	values  []interface{}
+	err     error
+}
+
+func (r *engineRows) Columns() []datasync.ResultColumn {
+	return r.columns
+}
+
+func (r *engineRows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
 	}
 
-	return datasync.QueryResult{Rows: results}, nil
+	values := make([]interface{}, len(r.columns))
+	valuePtrs := make([]interface{}, len(r.columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := r.rows.Scan(valuePtrs...); err != nil {
+		r.err = err
+		return false
+	}
+
+	r.values = values
+	return true
+}
+
+func (r *engineRows) Row() map[string]interface{} {
+	row := make(map[string]interface{}, len(r.columns))
+	for i, value := range r.Values() {
+		row[r.columns[i].Name] = value
+	}
+	return row
+}
+
+func (r *engineRows) Values() []interface{} {
+	values := make([]interface{}, len(r.columns))
+	for i, col := range r.columns {
+		values[i] = datasync.EncodeValue(r.values[i], col.Type)
+	}
+	return values
+}
+
+func (r *engineRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close releases the result; closing before the last row cancels the query on Trino
+func (r *engineRows) Close() error {
+	err := r.rows.Close()
+	if stmtErr := r.stmt.Close(); err == nil {
+		err = stmtErr
+	}
+	return err
 }
@@ -0,0 +1,188 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+	"sigs.k8s.io/yaml"
+)
+
+// CurrentVersion is the bundle format version written by Export
+const CurrentVersion = 1
+
+// Bundle is a declarative description of the federated model: every global table
+// with its columns, mappings and relationships, and every table relation.
+// Importing a bundle makes the metadata storage match it exactly.
+type Bundle struct {
+	Version      int                     `json:"version"`
+	GlobalTables []GlobalTable           `json:"globalTables"`
+	Relations    []*models.TableRelation `json:"relations,omitempty"`
+}
+
+// GlobalTable describes a global table and everything that belongs to it
+type GlobalTable struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	UnionType    string `json:"unionType,omitempty"`
+	SourceColumn string `json:"sourceColumn,omitempty"`
+
+	Columns  []Column       `json:"columns,omitempty"`
+	Mappings []TableMapping `json:"mappings,omitempty"`
+
+	// Relationships from columns of this table to columns of other global tables
+	Relationships []Relationship `json:"relationships,omitempty"`
+}
+
+// Column describes a global column and the physical columns mapped to it
+type Column struct {
+	Name        string          `json:"name"`
+	DataType    string          `json:"dataType,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Mappings    []ColumnMapping `json:"mappings,omitempty"`
+}
+
+// TableMapping is a physical table mapped to a global table
+type TableMapping struct {
+	Catalog string `json:"catalog"`
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+}
+
+// ColumnMapping is a physical column mapped to a global column
+type ColumnMapping struct {
+	Catalog string `json:"catalog"`
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Column  string `json:"column"`
+}
+
+// Relationship links a column of the enclosing global table to a column of a target global table
+type Relationship struct {
+	Column       string `json:"column"`
+	TargetTable  string `json:"targetTable"`
+	TargetColumn string `json:"targetColumn"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Export reads the global model from storage into a bundle.
+// Entries are sorted so exporting the same model always gives the same document.
+func Export(store storage.MetadataStorage) (*Bundle, error) {
+	tables, err := store.ListGlobalTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list global tables: %w", err)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	bundle := &Bundle{Version: CurrentVersion, GlobalTables: make([]GlobalTable, 0, len(tables))}
+	for _, table := range tables {
+		exported, err := exportGlobalTable(store, table)
+		if err != nil {
+			return nil, err
+		}
+		bundle.GlobalTables = append(bundle.GlobalTables, *exported)
+	}
+
+	relations, err := store.ListTableRelations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list table relations: %w", err)
+	}
+	sort.Slice(relations, func(i, j int) bool { return relations[i].ID < relations[j].ID })
+	bundle.Relations = relations
+
+	return bundle, nil
+}
+
+// exportGlobalTable reads a global table with its columns, mappings and outgoing relationships
+func exportGlobalTable(store storage.MetadataStorage, table *models.GlobalTable) (*GlobalTable, error) {
+	exported := &GlobalTable{
+		Name:         table.Name,
+		Description:  table.Description,
+		UnionType:    table.UnionType,
+		SourceColumn: table.SourceColumn,
+	}
+
+	columns, err := store.ListGlobalColumns(table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of global table '%s': %w", table.Name, err)
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Name < columns[j].Name })
+
+	for _, col := range columns {
+		column := Column{Name: col.Name, DataType: col.DataType, Description: col.Description}
+
+		mappings, err := store.ListColumnMappings(table.Name, col.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list mappings of global column '%s.%s': %w", table.Name, col.Name, err)
+		}
+		for _, mapping := range mappings {
+			column.Mappings = append(column.Mappings, ColumnMapping{
+				Catalog: mapping.CatalogName,
+				Schema:  mapping.SchemaName,
+				Table:   mapping.TableName,
+				Column:  mapping.ColumnName,
+			})
+		}
+
+		exported.Columns = append(exported.Columns, column)
+	}
+
+	mappings, err := store.ListTableMappings(table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mappings of global table '%s': %w", table.Name, err)
+	}
+	for _, mapping := range mappings {
+		exported.Mappings = append(exported.Mappings, TableMapping{
+			Catalog: mapping.CatalogName,
+			Schema:  mapping.SchemaName,
+			Table:   mapping.TableName,
+		})
+	}
+
+	// Relationships are listed for both of their tables; keep them under their source table only
+	relationships, err := store.ListColumnRelationships(table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relationships of global table '%s': %w", table.Name, err)
+	}
+	for _, rel := range relationships {
+		if rel.SourceGlobalTableName != table.Name {
+			continue
+		}
+		exported.Relationships = append(exported.Relationships, Relationship{
+			Column:       rel.SourceGlobalColumnName,
+			TargetTable:  rel.TargetGlobalTableName,
+			TargetColumn: rel.TargetGlobalColumnName,
+			Name:         rel.RelationshipName,
+			Description:  rel.Description,
+		})
+	}
+
+	return exported, nil
+}
+
+// Marshal encodes a bundle as "yaml" or "json"
+func Marshal(bundle *Bundle, format string) ([]byte, error) {
+	switch format {
+	case "yaml", "yml":
+		return yaml.Marshal(bundle)
+	case "json", "":
+		return json.MarshalIndent(bundle, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported bundle format '%s' (expected yaml or json)", format)
+	}
+}
+
+// Unmarshal decodes a YAML or JSON bundle. Unknown fields are rejected so typos do not silently drop settings.
+func Unmarshal(data []byte) (*Bundle, error) {
+	var bundle Bundle
+	if err := yaml.UnmarshalStrict(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if bundle.Version != CurrentVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (expected %d)", bundle.Version, CurrentVersion)
+	}
+	return &bundle, nil
+}
@@ -0,0 +1,226 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+const customersBundle = `
+version: 1
+globalTables:
+  - name: customers
+    description: All customers
+    columns:
+      - name: id
+        dataType: integer
+        mappings:
+          - {catalog: postgresql, schema: public, table: customers, column: id}
+          - {catalog: mysql, schema: crm, table: clients, column: client_id}
+      - name: name
+        dataType: varchar
+    mappings:
+      - {catalog: postgresql, schema: public, table: customers}
+      - {catalog: mysql, schema: crm, table: clients}
+  - name: orders
+    columns:
+      - name: id
+      - name: customer_id
+    relationships:
+      - {column: customer_id, targetTable: customers, targetColumn: id, name: placed_by}
+relations:
+  - id: rel-1
+    name: customer_orders
+    relationType: JOIN
+    leftTable: {type: physical, catalog: postgresql, schema: public, table: customers}
+    rightTable: {type: physical, catalog: postgresql, schema: public, table: orders}
+    joinColumns:
+      - {left: id, right: customer_id}
+`
+
+func mustUnmarshal(t *testing.T, doc string) *Bundle {
+	t.Helper()
+	b, err := Unmarshal([]byte(doc))
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return b
+}
+
+func TestImport_RoundTripAndIdempotent(t *testing.T) {
+	store := storage.NewMemoryMetadataStorage()
+
+	plan, err := Import(store, mustUnmarshal(t, customersBundle))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	for _, change := range plan.Changes {
+		if change.Action != ActionCreate {
+			t.Errorf("Expected only creates on an empty storage, got %s %s '%s'", change.Action, change.Kind, change.Name)
+		}
+	}
+
+	// Exporting and importing the export into a fresh storage gives the same model
+	exported, err := Export(store)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	yamlDoc, err := Marshal(exported, "yaml")
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	fresh := storage.NewMemoryMetadataStorage()
+	if _, err := Import(fresh, mustUnmarshal(t, string(yamlDoc))); err != nil {
+		t.Fatalf("Import of export failed: %v", err)
+	}
+	reexported, err := Export(fresh)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	again, err := Marshal(reexported, "yaml")
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(again) != string(yamlDoc) {
+		t.Errorf("Expected round trip to preserve the bundle\n  got:      %s\n  expected: %s", again, yamlDoc)
+	}
+
+	// Importing the same document again changes nothing
+	plan, err = Import(store, mustUnmarshal(t, customersBundle))
+	if err != nil {
+		t.Fatalf("Second import failed: %v", err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes on re-import, got %+v", plan.Changes)
+	}
+}
+
+// failingRelationshipStore fails every column relationship it is asked to create
+type failingRelationshipStore struct {
+	storage.MetadataStorage
+}
+
+func (s failingRelationshipStore) CreateColumnRelationship(relationship *models.ColumnRelationship) error {
+	return fmt.Errorf("disk full")
+}
+
+func (s failingRelationshipStore) WithTx(fn func(tx storage.MetadataStorage) error) error {
+	return s.MetadataStorage.WithTx(func(tx storage.MetadataStorage) error {
+		return fn(failingRelationshipStore{tx})
+	})
+}
+
+func TestImport_AppliesNothingWhenAChangeFails(t *testing.T) {
+	store := storage.NewMemoryMetadataStorage()
+
+	plan, err := Import(failingRelationshipStore{store}, mustUnmarshal(t, customersBundle))
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Expected the failed change to be reported, got %v", err)
+	}
+	if plan == nil {
+		t.Fatal("Expected the plan that failed to be returned")
+	}
+
+	exported, err := Export(store)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(exported.GlobalTables) != 0 || len(exported.Relations) != 0 {
+		t.Errorf("Expected nothing applied, got %d global tables and %d relations", len(exported.GlobalTables), len(exported.Relations))
+	}
+}
+
+func TestDiff_UpdatesAndDeletes(t *testing.T) {
+	store := storage.NewMemoryMetadataStorage()
+	if _, err := Import(store, mustUnmarshal(t, customersBundle)); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	// Drop the orders table and the relation, change a description and remove one mapping
+	updated := mustUnmarshal(t, customersBundle)
+	updated.GlobalTables = updated.GlobalTables[:1]
+	updated.GlobalTables[0].Description = "Every customer"
+	updated.GlobalTables[0].Mappings = updated.GlobalTables[0].Mappings[:1]
+	updated.Relations = nil
+
+	plan, err := Diff(store, updated)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	var got []string
+	for _, change := range plan.Changes {
+		got = append(got, change.Action+" "+change.Kind+" "+change.Name)
+	}
+	expected := []string{
+		"delete relation rel-1",
+		"delete tableMapping customers -> mysql.crm.clients",
+		"delete globalTable orders",
+		"update globalTable customers",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected plan:\n  got:      %v\n  expected: %v", got, expected)
+	}
+
+	// A dry run writes nothing
+	tables, err := store.ListGlobalTables()
+	if err != nil {
+		t.Fatalf("ListGlobalTables failed: %v", err)
+	}
+	if len(tables) != 2 {
+		t.Errorf("Expected dry run to leave 2 global tables, got %d", len(tables))
+	}
+}
+
+func TestValidate_ReportsBrokenReferences(t *testing.T) {
+	b := mustUnmarshal(t, customersBundle)
+	b.GlobalTables[1].Relationships[0].TargetColumn = "missing"
+	b.Relations = append(b.Relations, &models.TableRelation{
+		ID:           "rel-2",
+		Name:         "dangling",
+		RelationType: "UNION",
+		LeftTable:    models.TableSource{Type: "relation", RelationID: "rel-404"},
+		RightTable:   models.TableSource{Type: "physical", Catalog: "postgresql", Schema: "public", Table: "customers"},
+	}, &models.TableRelation{
+		ID:           "rel-3",
+		Name:         "untyped",
+		RelationType: "UNION",
+		LeftTable:    models.TableSource{Type: "view"},
+		RightTable:   models.TableSource{Type: "file"},
+	})
+
+	err := Validate(b)
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	for _, want := range []string{"customers.missing", "rel-404"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got: %v", want, err)
+		}
+	}
+	if !strings.Contains(err.Error(), "unknown left table source type 'view'; relation 'rel-3': unknown right table source type 'file'") {
+		t.Errorf("Expected the left side's problem to be reported before the right's, got: %v", err)
+	}
+
+	store := storage.NewMemoryMetadataStorage()
+	if _, err := Import(store, b); err == nil {
+		t.Fatal("Expected Import to reject an invalid bundle")
+	}
+	tables, _ := store.ListGlobalTables()
+	if len(tables) != 0 {
+		t.Errorf("Expected nothing written for an invalid bundle, got %d global tables", len(tables))
+	}
+}
+
+func TestUnmarshal_RejectsUnknownFieldsAndVersions(t *testing.T) {
+	if _, err := Unmarshal([]byte("version: 1\nglobalTabels: []\n")); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+	if _, err := Unmarshal([]byte(`{"version": 2, "globalTables": []}`)); err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+}
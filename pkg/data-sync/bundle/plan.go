@@ -0,0 +1,540 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// Actions a change can take
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Kinds of entity a change applies to
+const (
+	KindGlobalTable   = "globalTable"
+	KindGlobalColumn  = "globalColumn"
+	KindTableMapping  = "tableMapping"
+	KindColumnMapping = "columnMapping"
+	KindRelationship  = "relationship"
+	KindRelation      = "relation"
+)
+
+// Change is one write an import makes to the metadata storage
+type Change struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+
+	apply func(store storage.MetadataStorage) error
+}
+
+// Plan is the ordered list of changes that makes the metadata storage match a bundle.
+// Deleting a global table or column also deletes everything under it, so those
+// dependent deletions are not listed separately.
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+func (p *Plan) add(action, kind, name string, apply func(store storage.MetadataStorage) error) {
+	p.Changes = append(p.Changes, Change{Action: action, Kind: kind, Name: name, apply: apply})
+}
+
+// Diff validates a bundle and returns the changes importing it would make, without writing anything
+func Diff(store storage.MetadataStorage, bundle *Bundle) (*Plan, error) {
+	if err := Validate(bundle); err != nil {
+		return nil, err
+	}
+
+	current, err := Export(store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current metadata: %w", err)
+	}
+
+	return buildPlan(newIndex(current), newIndex(bundle)), nil
+}
+
+// Import validates a bundle and applies it in a single storage transaction, so that
+// storage holds exactly the model it describes. Importing the same bundle again makes no
+// changes. If a change fails nothing is applied, and the plan is returned with the error.
+func Import(store storage.MetadataStorage, bundle *Bundle) (*Plan, error) {
+	var plan *Plan
+	err := store.WithTx(func(tx storage.MetadataStorage) error {
+		var err error
+		plan, err = Diff(tx, bundle)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range plan.Changes {
+			if err := change.apply(tx); err != nil {
+				return fmt.Errorf("failed to %s %s '%s': %w", change.Action, change.Kind, change.Name, err)
+			}
+		}
+		return nil
+	})
+	return plan, err
+}
+
+// index holds the entities of a bundle by key, in bundle order
+type index struct {
+	tables        []*GlobalTable
+	tableByName   map[string]*GlobalTable
+	columns       []indexedColumn
+	columnByKey   map[string]indexedColumn
+	tableMappings []indexedTableMapping
+	hasMapping    map[string]bool
+	colMappings   []indexedColumnMapping
+	hasColMapping map[string]bool
+	relationships []indexedRelationship
+	relByKey      map[string]indexedRelationship
+	relations     []*models.TableRelation
+	relationByID  map[string]*models.TableRelation
+}
+
+type indexedColumn struct {
+	table  string
+	column Column
+}
+
+type indexedTableMapping struct {
+	table   string
+	mapping TableMapping
+}
+
+type indexedColumnMapping struct {
+	table, column string
+	mapping       ColumnMapping
+}
+
+type indexedRelationship struct {
+	table        string
+	relationship Relationship
+}
+
+func (c indexedColumn) key() string { return c.table + "." + c.column.Name }
+
+func (m indexedTableMapping) key() string {
+	return fmt.Sprintf("%s -> %s.%s.%s", m.table, m.mapping.Catalog, m.mapping.Schema, m.mapping.Table)
+}
+
+func (m indexedColumnMapping) key() string {
+	return fmt.Sprintf("%s.%s -> %s.%s.%s.%s", m.table, m.column, m.mapping.Catalog, m.mapping.Schema, m.mapping.Table, m.mapping.Column)
+}
+
+func (r indexedRelationship) key() string {
+	return fmt.Sprintf("%s.%s -> %s.%s", r.table, r.relationship.Column, r.relationship.TargetTable, r.relationship.TargetColumn)
+}
+
+func newIndex(bundle *Bundle) *index {
+	idx := &index{
+		tableByName:   make(map[string]*GlobalTable),
+		columnByKey:   make(map[string]indexedColumn),
+		hasMapping:    make(map[string]bool),
+		hasColMapping: make(map[string]bool),
+		relByKey:      make(map[string]indexedRelationship),
+		relationByID:  make(map[string]*models.TableRelation),
+	}
+
+	for i := range bundle.GlobalTables {
+		table := &bundle.GlobalTables[i]
+		idx.tables = append(idx.tables, table)
+		idx.tableByName[table.Name] = table
+
+		for _, col := range table.Columns {
+			column := indexedColumn{table: table.Name, column: col}
+			idx.columns = append(idx.columns, column)
+			idx.columnByKey[column.key()] = column
+
+			for _, m := range col.Mappings {
+				mapping := indexedColumnMapping{table: table.Name, column: col.Name, mapping: m}
+				idx.colMappings = append(idx.colMappings, mapping)
+				idx.hasColMapping[mapping.key()] = true
+			}
+		}
+
+		for _, m := range table.Mappings {
+			mapping := indexedTableMapping{table: table.Name, mapping: m}
+			idx.tableMappings = append(idx.tableMappings, mapping)
+			idx.hasMapping[mapping.key()] = true
+		}
+
+		for _, r := range table.Relationships {
+			rel := indexedRelationship{table: table.Name, relationship: r}
+			idx.relationships = append(idx.relationships, rel)
+			idx.relByKey[rel.key()] = rel
+		}
+	}
+
+	for _, relation := range bundle.Relations {
+		idx.relations = append(idx.relations, relation)
+		idx.relationByID[relation.ID] = relation
+	}
+
+	return idx
+}
+
+// buildPlan lists the changes turning current into desired: deletions first, dependents
+// before what they depend on, then creations and updates in the opposite order
+func buildPlan(current, desired *index) *Plan {
+	plan := &Plan{Changes: []Change{}}
+
+	// Entities removed together with a deleted global table or column need no change of their own
+	tableDeleted := func(table string) bool { return desired.tableByName[table] == nil }
+	columnDeleted := func(table, column string) bool {
+		_, exists := desired.columnByKey[table+"."+column]
+		return !exists
+	}
+
+	for _, relation := range current.relations {
+		if desired.relationByID[relation.ID] == nil {
+			id := relation.ID
+			plan.add(ActionDelete, KindRelation, id, func(store storage.MetadataStorage) error {
+				return store.DeleteTableRelation(id)
+			})
+		}
+	}
+
+	for _, rel := range current.relationships {
+		r := rel
+		if _, exists := desired.relByKey[r.key()]; exists ||
+			columnDeleted(r.table, r.relationship.Column) ||
+			columnDeleted(r.relationship.TargetTable, r.relationship.TargetColumn) {
+			continue
+		}
+		plan.add(ActionDelete, KindRelationship, r.key(), func(store storage.MetadataStorage) error {
+			return store.DeleteColumnRelationship(r.table, r.relationship.Column, r.relationship.TargetTable, r.relationship.TargetColumn)
+		})
+	}
+
+	for _, mapping := range current.colMappings {
+		m := mapping
+		if desired.hasColMapping[m.key()] || columnDeleted(m.table, m.column) {
+			continue
+		}
+		plan.add(ActionDelete, KindColumnMapping, m.key(), func(store storage.MetadataStorage) error {
+			return store.DeleteColumnMapping(m.table, m.column, m.mapping.Catalog, m.mapping.Schema, m.mapping.Table, m.mapping.Column)
+		})
+	}
+
+	for _, mapping := range current.tableMappings {
+		m := mapping
+		if desired.hasMapping[m.key()] || tableDeleted(m.table) {
+			continue
+		}
+		plan.add(ActionDelete, KindTableMapping, m.key(), func(store storage.MetadataStorage) error {
+			return store.DeleteTableMapping(m.table, m.mapping.Catalog, m.mapping.Schema, m.mapping.Table)
+		})
+	}
+
+	for _, column := range current.columns {
+		c := column
+		if !columnDeleted(c.table, c.column.Name) || tableDeleted(c.table) {
+			continue
+		}
+		plan.add(ActionDelete, KindGlobalColumn, c.key(), func(store storage.MetadataStorage) error {
+			return store.DeleteGlobalColumn(c.table, c.column.Name)
+		})
+	}
+
+	for _, table := range current.tables {
+		name := table.Name
+		if tableDeleted(name) {
+			plan.add(ActionDelete, KindGlobalTable, name, func(store storage.MetadataStorage) error {
+				return store.DeleteGlobalTable(name)
+			})
+		}
+	}
+
+	for _, table := range desired.tables {
+		model := &models.GlobalTable{
+			Name:         table.Name,
+			Description:  table.Description,
+			UnionType:    table.UnionType,
+			SourceColumn: table.SourceColumn,
+		}
+		existing := current.tableByName[table.Name]
+		switch {
+		case existing == nil:
+			plan.add(ActionCreate, KindGlobalTable, table.Name, func(store storage.MetadataStorage) error {
+				return store.CreateGlobalTable(model)
+			})
+		case existing.Description != table.Description || existing.UnionType != table.UnionType ||
+			existing.SourceColumn != table.SourceColumn:
+			plan.add(ActionUpdate, KindGlobalTable, table.Name, func(store storage.MetadataStorage) error {
+				return store.UpdateGlobalTable(model)
+			})
+		}
+	}
+
+	for _, column := range desired.columns {
+		model := &models.GlobalColumn{
+			GlobalTableName: column.table,
+			Name:            column.column.Name,
+			DataType:        column.column.DataType,
+			Description:     column.column.Description,
+		}
+		existing, exists := current.columnByKey[column.key()]
+		switch {
+		case !exists:
+			plan.add(ActionCreate, KindGlobalColumn, column.key(), func(store storage.MetadataStorage) error {
+				return store.CreateGlobalColumn(model)
+			})
+		case existing.column.DataType != model.DataType || existing.column.Description != model.Description:
+			plan.add(ActionUpdate, KindGlobalColumn, column.key(), func(store storage.MetadataStorage) error {
+				return store.UpdateGlobalColumn(model)
+			})
+		}
+	}
+
+	for _, mapping := range desired.tableMappings {
+		if current.hasMapping[mapping.key()] {
+			continue
+		}
+		model := &models.TableMapping{
+			GlobalTableName: mapping.table,
+			CatalogName:     mapping.mapping.Catalog,
+			SchemaName:      mapping.mapping.Schema,
+			TableName:       mapping.mapping.Table,
+		}
+		plan.add(ActionCreate, KindTableMapping, mapping.key(), func(store storage.MetadataStorage) error {
+			return store.CreateTableMapping(model)
+		})
+	}
+
+	for _, mapping := range desired.colMappings {
+		if current.hasColMapping[mapping.key()] {
+			continue
+		}
+		model := &models.ColumnMapping{
+			GlobalTableName:  mapping.table,
+			GlobalColumnName: mapping.column,
+			CatalogName:      mapping.mapping.Catalog,
+			SchemaName:       mapping.mapping.Schema,
+			TableName:        mapping.mapping.Table,
+			ColumnName:       mapping.mapping.Column,
+		}
+		plan.add(ActionCreate, KindColumnMapping, mapping.key(), func(store storage.MetadataStorage) error {
+			return store.CreateColumnMapping(model)
+		})
+	}
+
+	for _, rel := range desired.relationships {
+		model := &models.ColumnRelationship{
+			SourceGlobalTableName:  rel.table,
+			SourceGlobalColumnName: rel.relationship.Column,
+			TargetGlobalTableName:  rel.relationship.TargetTable,
+			TargetGlobalColumnName: rel.relationship.TargetColumn,
+			RelationshipName:       rel.relationship.Name,
+			Description:            rel.relationship.Description,
+		}
+		existing, exists := current.relByKey[rel.key()]
+		switch {
+		case !exists:
+			plan.add(ActionCreate, KindRelationship, rel.key(), func(store storage.MetadataStorage) error {
+				return store.CreateColumnRelationship(model)
+			})
+		case existing.relationship != rel.relationship:
+			plan.add(ActionUpdate, KindRelationship, rel.key(), func(store storage.MetadataStorage) error {
+				if err := store.DeleteColumnRelationship(model.SourceGlobalTableName, model.SourceGlobalColumnName,
+					model.TargetGlobalTableName, model.TargetGlobalColumnName); err != nil {
+					return err
+				}
+				return store.CreateColumnRelationship(model)
+			})
+		}
+	}
+
+	for _, relation := range desired.relations {
+		model := relation
+		existing := current.relationByID[relation.ID]
+		switch {
+		case existing == nil:
+			plan.add(ActionCreate, KindRelation, relation.ID, func(store storage.MetadataStorage) error {
+				return store.CreateTableRelation(model)
+			})
+		case !sameRelation(existing, relation):
+			plan.add(ActionUpdate, KindRelation, relation.ID, func(store storage.MetadataStorage) error {
+				if err := store.DeleteTableRelation(model.ID); err != nil {
+					return err
+				}
+				return store.CreateTableRelation(model)
+			})
+		}
+	}
+
+	return plan
+}
+
+// sameRelation compares two relations through their JSON form, which covers every field
+func sameRelation(a, b *models.TableRelation) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// Validate checks that a bundle is complete and consistent: names are present and
+// unique, and every relationship and relation source refers to something in the bundle
+func Validate(bundle *Bundle) error {
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	columns := make(map[string]map[string]bool)
+	for _, table := range bundle.GlobalTables {
+		if table.Name == "" {
+			problem("global table name cannot be empty")
+			continue
+		}
+		if columns[table.Name] != nil {
+			problem("global table '%s' is defined more than once", table.Name)
+			continue
+		}
+		if err := models.ValidateUnionType(table.UnionType); err != nil {
+			problem("global table '%s': %v", table.Name, err)
+		}
+
+		columns[table.Name] = make(map[string]bool)
+		for _, col := range table.Columns {
+			if col.Name == "" {
+				problem("global table '%s' has a column without a name", table.Name)
+				continue
+			}
+			if columns[table.Name][col.Name] {
+				problem("column '%s.%s' is defined more than once", table.Name, col.Name)
+			}
+			columns[table.Name][col.Name] = true
+
+			for _, m := range col.Mappings {
+				if m.Catalog == "" || m.Schema == "" || m.Table == "" || m.Column == "" {
+					problem("column '%s.%s' has a mapping with empty fields", table.Name, col.Name)
+				}
+			}
+		}
+
+		for _, m := range table.Mappings {
+			if m.Catalog == "" || m.Schema == "" || m.Table == "" {
+				problem("global table '%s' has a mapping with empty fields", table.Name)
+			}
+		}
+	}
+
+	// Relationships may point at tables defined later in the bundle
+	for _, table := range bundle.GlobalTables {
+		for _, rel := range table.Relationships {
+			if !columns[table.Name][rel.Column] {
+				problem("relationship source column '%s.%s' is not defined", table.Name, rel.Column)
+			}
+			if !columns[rel.TargetTable][rel.TargetColumn] {
+				problem("relationship from '%s.%s' targets undefined column '%s.%s'",
+					table.Name, rel.Column, rel.TargetTable, rel.TargetColumn)
+			}
+		}
+	}
+
+	problems = append(problems, validateRelations(bundle.Relations)...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid bundle: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateRelations checks relation definitions, their references to other relations and reference cycles
+func validateRelations(relations []*models.TableRelation) []string {
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	byID := make(map[string]*models.TableRelation, len(relations))
+	names := make(map[string]bool, len(relations))
+	for _, relation := range relations {
+		if relation.ID == "" || relation.Name == "" {
+			problem("relation ID and name cannot be empty")
+			continue
+		}
+		if byID[relation.ID] != nil {
+			problem("relation ID '%s' is used more than once", relation.ID)
+		}
+		if names[relation.Name] {
+			problem("relation name '%s' is used more than once", relation.Name)
+		}
+		byID[relation.ID] = relation
+		names[relation.Name] = true
+
+		switch relation.RelationType {
+		case "JOIN":
+			if err := relation.ValidateJoin(); err != nil {
+				problem("relation '%s': %v", relation.ID, err)
+			}
+		case "UNION":
+			if err := models.ValidateUnionType(relation.UnionType); err != nil {
+				problem("relation '%s': %v", relation.ID, err)
+			}
+		default:
+			problem("relation '%s': relation type must be JOIN or UNION", relation.ID)
+		}
+	}
+
+	for _, relation := range relations {
+		for _, side := range []struct {
+			name   string
+			source models.TableSource
+		}{{"left", relation.LeftTable}, {"right", relation.RightTable}} {
+			source := side.source
+			switch source.Type {
+			case "physical":
+				if source.Catalog == "" || source.Schema == "" || source.Table == "" {
+					problem("relation '%s': %s physical table needs catalog, schema and table", relation.ID, side.name)
+				}
+			case "relation":
+				if byID[source.RelationID] == nil {
+					problem("relation '%s': %s source refers to missing relation '%s'", relation.ID, side.name, source.RelationID)
+				}
+			default:
+				problem("relation '%s': unknown %s table source type '%s'", relation.ID, side.name, source.Type)
+			}
+		}
+	}
+
+	// Walk each relation's references to find cycles
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(byID))
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		relation := byID[id]
+		if relation == nil || state[id] == done {
+			return false
+		}
+		if state[id] == visiting {
+			return true
+		}
+		state[id] = visiting
+		for _, source := range []models.TableSource{relation.LeftTable, relation.RightTable} {
+			if source.Type == "relation" && visit(source.RelationID) {
+				return true
+			}
+		}
+		state[id] = done
+		return false
+	}
+	for _, relation := range relations {
+		if visit(relation.ID) {
+			problem("relation '%s' is part of a reference cycle", relation.ID)
+			break
+		}
+	}
+
+	return problems
+}
@@ -1,5 +1,15 @@
 package chatbot
 
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/query"
+)
+
 type ChatMessage struct {
 	Role    string
 	Content string
@@ -21,11 +31,385 @@ type ToolResult struct {
 type QueryGenerationResponse struct {
 	Message      string
 	GeneratedSQL string
+	// TranslatedSQL is the Trino SQL the generated query runs as, once it translates
+	TranslatedSQL string
+	Validation    QueryValidation
+	Warnings      []string
+}
+
+// Validation statuses of a generated query
+const (
+	ValidationValid   = "valid"
+	ValidationInvalid = "invalid"
+	// ValidationSkipped is set when there was no query to validate, nothing to validate it with,
+	// or the query translated but could not be planned, as with parameter placeholders
+	ValidationSkipped = "skipped"
+)
+
+// QueryValidation reports whether a generated query validated and how many repairs it took
+type QueryValidation struct {
+	Status string
+	// Error is why the query is invalid
+	Error        string
+	RepairRounds int
+}
+
+// QueryValidator checks a query on global tables without running it. query.Translator implements it.
+type QueryValidator interface {
+	Validate(ctx context.Context, globalQuery string) (*query.Validation, error)
+}
+
+// maxRepairRounds bounds how many times the model is asked to fix a query that fails validation
+const maxRepairRounds = 2
+
+// Agent event types, in the order a streamed answer produces them
+const (
+	EventDelta            = "delta"
+	EventToolCallStarted  = "tool_call_started"
+	EventToolCallFinished = "tool_call_finished"
+	EventMessage          = "message"
+)
+
+// AgentEvent reports the progress of a streamed answer
+type AgentEvent struct {
+	Type string
+	// Delta is the next piece of the model's text, for delta events
+	Delta string
+	// ToolCall is the call being run, for tool call events
+	ToolCall *ToolCall
+	// ToolResult is the call's result, for tool_call_finished events
+	ToolResult *ToolResult
+	// Response is the complete answer, for the final message event
+	Response *AgentResponse
 }
 
 type AgentActions interface {
-	SendMessage(message string) (string, error)
-	SendMessageWithHistory(message string, history []ChatMessage) (string, error)
-	SendMessageWithTools(message string, history []ChatMessage, tools ToolExecutor) (*AgentResponse, error)
-	SendMessageForQueryGeneration(message string, history []ChatMessage, tools ToolExecutor) (*QueryGenerationResponse, error)
+	SendMessage(ctx context.Context, message string) (string, error)
+	SendMessageWithHistory(ctx context.Context, message string, history []ChatMessage) (string, error)
+	SendMessageWithTools(ctx context.Context, message string, history []ChatMessage, tools ToolExecutor) (*AgentResponse, error)
+	// StreamMessageWithTools answers like SendMessageWithTools, reporting text deltas and tool
+	// calls to onEvent as they happen and ending with a message event
+	StreamMessageWithTools(ctx context.Context, message string, history []ChatMessage, tools ToolExecutor, onEvent func(AgentEvent)) (*AgentResponse, error)
+	// SendMessageForQueryGeneration generates a query, validating it with validator and asking the
+	// model to fix it when it fails. A nil validator skips validation.
+	SendMessageForQueryGeneration(ctx context.Context, message string, history []ChatMessage, tools ToolExecutor, validator QueryValidator) (*QueryGenerationResponse, error)
+	// SendSessionMessage answers a message in a chat session and appends the turn, with its tool
+	// calls and results, to the session. When onEvent is set the answer is streamed like
+	// StreamMessageWithTools. The caller saves the session.
+	SendSessionMessage(ctx context.Context, session *models.ChatSession, message string, tools ToolExecutor, onEvent func(AgentEvent)) (*AgentResponse, error)
+}
+
+// maxToolRounds bounds how many rounds of tool calls the model may make for one message
+const maxToolRounds = 5
+
+// Agent implements AgentActions on top of any LLM provider
+type Agent struct {
+	provider Provider
+	// contextChars bounds the size of a session's conversation sent to the model
+	contextChars int
+}
+
+// NewAgent creates an agent. Sessions longer than contextChars characters have their oldest
+// turns summarised; 0 uses DefaultContextChars.
+func NewAgent(provider Provider, contextChars int) *Agent {
+	if contextChars <= 0 {
+		contextChars = DefaultContextChars
+	}
+	return &Agent{provider: provider, contextChars: contextChars}
+}
+
+// Provider returns the provider the agent talks to
+func (a *Agent) Provider() Provider {
+	return a.provider
+}
+
+func (a *Agent) SendMessage(ctx context.Context, message string) (string, error) {
+	return a.SendMessageWithHistory(ctx, message, nil)
+}
+
+func (a *Agent) SendMessageWithHistory(ctx context.Context, message string, history []ChatMessage) (string, error) {
+	res, err := a.provider.Chat(ctx, ChatRequest{
+		Messages: conversation(message, history),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content with history: %w", err)
+	}
+	return res.Content, nil
+}
+
+func (a *Agent) SendMessageWithTools(ctx context.Context, message string, history []ChatMessage, toolExecutor ToolExecutor) (*AgentResponse, error) {
+	response, _, err := a.chatWithTools(ctx, chatSystemInstruction, conversation(message, history), toolExecutor, nil)
+	return response, err
+}
+
+func (a *Agent) StreamMessageWithTools(ctx context.Context, message string, history []ChatMessage, toolExecutor ToolExecutor, onEvent func(AgentEvent)) (*AgentResponse, error) {
+	response, _, err := a.chatWithTools(ctx, chatSystemInstruction, conversation(message, history), toolExecutor, onEvent)
+	if err != nil {
+		return nil, err
+	}
+	onEvent(AgentEvent{Type: EventMessage, Response: response})
+	return response, nil
+}
+
+// chatWithTools runs the tool loop, streaming from the provider when onEvent is set. Besides
+// the response it returns the conversation with every tool call, result and the final answer.
+func (a *Agent) chatWithTools(ctx context.Context, system string, messages []Message, toolExecutor ToolExecutor, onEvent func(AgentEvent)) (*AgentResponse, []Message, error) {
+	// Track tool results for response
+	var toolResults []ToolResult
+
+	for i := 0; i < maxToolRounds; i++ {
+		req := ChatRequest{
+			System:   system,
+			Messages: messages,
+			Tools:    BuildToolDeclarations(),
+		}
+		var res *ChatResponse
+		var err error
+		if onEvent != nil {
+			res, err = chatStream(ctx, a.provider, req, func(delta string) {
+				onEvent(AgentEvent{Type: EventDelta, Delta: delta})
+			})
+		} else {
+			res, err = a.provider.Chat(ctx, req)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate content with tools: %w", err)
+		}
+
+		// If no function calls, we have the final response
+		if len(res.ToolCalls) == 0 {
+			messages = append(messages, Message{Role: RoleAssistant, Content: res.Content})
+			return &AgentResponse{
+				Message:     res.Content,
+				ToolResults: toolResults,
+			}, messages, nil
+		}
+
+		var results []ToolResult
+		messages, results = runToolCalls(ctx, toolExecutor, messages, res, onEvent)
+		toolResults = append(toolResults, results...)
+	}
+
+	// Max iterations reached
+	apology := "I apologize, but I needed to use too many tools to answer your question. Please try rephrasing or breaking down your request."
+	messages = append(messages, Message{Role: RoleAssistant, Content: apology})
+	return &AgentResponse{
+		Message:     apology,
+		ToolResults: toolResults,
+	}, messages, nil
+}
+
+func (a *Agent) SendMessageForQueryGeneration(ctx context.Context, message string, history []ChatMessage, toolExecutor ToolExecutor, validator QueryValidator) (*QueryGenerationResponse, error) {
+	messages := conversation(message, history)
+
+	for repairs := 0; ; repairs++ {
+		content, ok, err := a.generateQuery(ctx, &messages, toolExecutor)
+		if err != nil {
+			return nil, err
+		}
+
+		response := &QueryGenerationResponse{
+			Message:      content,
+			GeneratedSQL: extractSQLFromResponse(content),
+			Validation:   QueryValidation{Status: ValidationSkipped, RepairRounds: repairs},
+		}
+		switch {
+		case !ok:
+			response.Message = "I apologize, but I needed too many steps to generate your query. Please try a simpler request."
+			return response, nil
+		case response.GeneratedSQL == "":
+			response.Warnings = []string{"no SQL query was found in the answer"}
+			return response, nil
+		case validator == nil:
+			response.Warnings = []string{"the query was not validated"}
+			return response, nil
+		}
+
+		checked, err := validator.Validate(ctx, response.GeneratedSQL)
+		if err == nil {
+			response.Validation.Status = ValidationValid
+			if !checked.Planned {
+				response.Validation.Status = ValidationSkipped
+			}
+			response.TranslatedSQL = checked.GeneratedSQL
+			response.Warnings = checked.Warnings
+			return response, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if repairs == maxRepairRounds {
+			response.Validation.Status = ValidationInvalid
+			response.Validation.Error = err.Error()
+			response.Warnings = []string{fmt.Sprintf("the query still fails validation after %d attempts to fix it", maxRepairRounds)}
+			return response, nil
+		}
+
+		// Show the model why the query failed and let it try again
+		messages = append(messages,
+			Message{Role: RoleAssistant, Content: content},
+			Message{Role: RoleUser, Content: fmt.Sprintf(queryRepairInstruction, err)},
+		)
+	}
+}
+
+// generateQuery runs the query generation tool loop on messages until the model answers.
+// It returns false if the model was still calling tools after maxToolRounds.
+func (a *Agent) generateQuery(ctx context.Context, messages *[]Message, toolExecutor ToolExecutor) (string, bool, error) {
+	for i := 0; i < maxToolRounds; i++ {
+		res, err := a.provider.Chat(ctx, ChatRequest{
+			System:   queryGenerationSystemInstruction,
+			Messages: *messages,
+			Tools:    BuildQueryGeneratorToolDeclarations(),
+		})
+		if err != nil {
+			return "", false, fmt.Errorf("failed to generate content with tools: %w", err)
+		}
+
+		// If no function calls, we have the final response
+		if len(res.ToolCalls) == 0 {
+			return res.Content, true, nil
+		}
+
+		*messages, _ = runToolCalls(ctx, toolExecutor, *messages, res, nil)
+	}
+	return "", false, nil
+}
+
+// conversation converts the chat history and the new user message to provider messages
+func conversation(message string, history []ChatMessage) []Message {
+	messages := make([]Message, 0, len(history)+1)
+	for _, msg := range history {
+		role := RoleUser
+		if msg.Role == RoleAssistant || msg.Role == "model" {
+			role = RoleAssistant
+		}
+		messages = append(messages, Message{Role: role, Content: msg.Content})
+	}
+	return append(messages, Message{Role: RoleUser, Content: message})
+}
+
+// runToolCalls executes the tool calls in a model response and appends the call and its
+// results to the conversation. A failing tool is reported to the model as an error result.
+// When onEvent is set, each call is reported as it starts and finishes.
+func runToolCalls(ctx context.Context, toolExecutor ToolExecutor, messages []Message, res *ChatResponse, onEvent func(AgentEvent)) ([]Message, []ToolResult) {
+	messages = append(messages, Message{
+		Role:      RoleAssistant,
+		Content:   res.Content,
+		ToolCalls: res.ToolCalls,
+	})
+
+	results := make([]ToolResult, 0, len(res.ToolCalls))
+	for _, call := range res.ToolCalls {
+		if onEvent != nil {
+			onEvent(AgentEvent{Type: EventToolCallStarted, ToolCall: &call})
+		}
+		result, err := toolExecutor.ExecuteTool(ctx, call.Name, call.Arguments)
+		if err != nil {
+			result = map[string]interface{}{
+				"error": err.Error(),
+			}
+		}
+		toolResult := ToolResult{
+			ToolName: call.Name,
+			Data:     result,
+		}
+		results = append(results, toolResult)
+		if onEvent != nil {
+			onEvent(AgentEvent{Type: EventToolCallFinished, ToolCall: &call, ToolResult: &toolResult})
+		}
+
+		// Providers expect an object; wrap anything else
+		resultMap, ok := result.(map[string]interface{})
+		if !ok {
+			resultMap = map[string]interface{}{
+				"result": result,
+			}
+		}
+		messages = append(messages, Message{
+			Role:       RoleTool,
+			ToolCallID: call.ID,
+			ToolName:   call.Name,
+			ToolResult: resultMap,
+		})
+	}
+	return messages, results
+}
+
+// chatSystemInstruction guides the model on how to use the chat tools
+const chatSystemInstruction = `You are a helpful data assistant with access to a federated data system.
+
+IMPORTANT GUIDELINES:
+1. When users ask what tables are available (e.g., "what tables do I have?", "show me available tables"), ALWAYS use listGlobalTables first.
+2. When users ask to see, query, or analyze data (e.g., "show me clients", "how many orders"), use executeGlobalQuery with SQL queries on GLOBAL TABLES.
+3. If you encounter an error saying a table doesn't exist, use listGlobalTables to see what tables are actually available.
+4. Global tables abstract physical data sources - users query logical table names like "clients" or "orders", not physical catalog.schema.table names.
+5. Always use executeGlobalQuery for data retrieval queries - construct proper SQL SELECT statements.
+6. Use describeGlobalTable to learn a table's columns and how it relates to other tables before writing a query that filters on or joins it, and sampleRows to see what its values look like.
+7. Use listTableRelations when users ask how tables are combined, and explainQuery when they ask what SQL a query runs on the data sources.
+8. The discoverMetadata tool is for exploring physical catalogs/schemas/tables/columns - use it when users ask about the underlying data sources.
+9. Provide friendly, conversational responses that explain the data you found.
+
+Example interactions:
+- "Show me all clients" → listGlobalTables (to verify "clients" exists), then executeGlobalQuery with "SELECT * FROM clients"
+- "How many orders are there?" → executeGlobalQuery with "SELECT COUNT(*) FROM orders"
+- "What tables do I have?" → listGlobalTables
+- "Show me clients from USA" → executeGlobalQuery with "SELECT * FROM clients WHERE country = 'USA'"
+- "How are clients linked to orders?" → describeGlobalTable with tableName="clients"
+- "What catalogs exist?" → discoverMetadata with level="catalogs"`
+
+// queryGenerationSystemInstruction focuses the model on writing, not running, queries
+const queryGenerationSystemInstruction = `You are an expert SQL query generator for a federated data system with global tables.
+
+IMPORTANT GUIDELINES:
+1. Your ONLY job is to generate SQL queries - NEVER execute them.
+2. When users ask for queries, ALWAYS use listGlobalTables first to see what tables are available.
+3. Use getTableColumns to understand what columns exist in the tables you want to query, or describeGlobalTable to also see the relationships to join tables on.
+4. Your answer's query is translated and checked by Trino before it is returned; if it fails you will be shown the error to fix.
+5. Generate queries using GLOBAL TABLE names (e.g., "clients", "orders"), not physical catalog.schema.table names.
+6. Your final response MUST include a valid SQL query in a code block (triple backticks with sql).
+7. Provide a brief explanation of what the query does.
+8. Use discoverMetadata only if the user specifically asks about physical database structure.
+
+QUERY FORMAT:
+Always return your SQL query in this format:
+` + "```sql\n" + `SELECT column1, column2 FROM table_name WHERE condition
+` + "```" + `
+
+Example interactions:
+- "Get all clients" → listGlobalTables, getTableColumns("clients"), then respond with:
+  "Here's a query to get all clients:
+  ` + "```sql\nSELECT * FROM clients\n```" + `"
+
+- "Top 5 customers by revenue" → listGlobalTables, getTableColumns("clients"), then respond with:
+  "Here's a query to get the top 5 customers by revenue:
+  ` + "```sql\nSELECT name, revenue FROM clients ORDER BY revenue DESC LIMIT 5\n```" + `"
+
+- "Count orders from USA" → listGlobalTables, getTableColumns("orders"), then respond with:
+  "Here's a query to count orders from the USA:
+  ` + "```sql\nSELECT COUNT(*) as order_count FROM orders WHERE country = 'USA'\n```" + `"`
+
+// queryRepairInstruction asks the model to fix a generated query that failed validation
+const queryRepairInstruction = `The query in your answer failed validation: %v
+
+Fix the query, using the tools to check table and column names if needed, and answer again with the corrected query in a ` + "```sql" + ` code block.`
+
+// extractSQLFromResponse extracts SQL code from markdown code blocks
+func extractSQLFromResponse(response string) string {
+	// Regular expression to find markdown code blocks.
+	// (?i) - case insensitive (for SQL, sql, Sql)
+	// (?s) - dot matches newlines
+	// ```(?:sql)? - matches ``` optionally followed by sql
+	// \s* - matches any leading whitespace/newlines
+	// (.*?) - non-greedy match of the content
+	// \s*``` - matches any trailing whitespace followed by ```
+	re := regexp.MustCompile("(?i)(?s)```(?:sql)?\\s*(.*?)\\s*```")
+
+	matches := re.FindStringSubmatch(response)
+	if len(matches) >= 2 {
+		return strings.TrimSpace(matches[1])
+	}
+
+	return ""
 }
@@ -0,0 +1,280 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/query"
+)
+
+// recordingExecutor returns canned tool results and records the calls it receives
+type recordingExecutor struct {
+	results map[string]interface{}
+	calls   []ToolCall
+}
+
+func (e *recordingExecutor) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (interface{}, error) {
+	e.calls = append(e.calls, ToolCall{Name: toolName, Arguments: arguments})
+	result, ok := e.results[toolName]
+	if !ok {
+		return nil, errors.New("unknown tool: " + toolName)
+	}
+	return result, nil
+}
+
+func TestSendMessageWithTools_RunsToolCalls(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{ToolCalls: []ToolCall{{ID: "call_1", Name: "listGlobalTables", Arguments: map[string]interface{}{}}}},
+		ChatResponse{ToolCalls: []ToolCall{{ID: "call_2", Name: "executeGlobalQuery", Arguments: map[string]interface{}{"query": "SELECT COUNT(*) FROM orders"}}}},
+		ChatResponse{Content: "There are 42 orders."},
+	)
+	executor := &recordingExecutor{results: map[string]interface{}{
+		"listGlobalTables":   map[string]interface{}{"count": 1},
+		"executeGlobalQuery": []int{42},
+	}}
+
+	history := []ChatMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "Hello!"}}
+	res, err := NewAgent(provider, 0).SendMessageWithTools(context.Background(), "How many orders?", history, executor)
+	if err != nil {
+		t.Fatalf("SendMessageWithTools failed: %v", err)
+	}
+
+	if res.Message != "There are 42 orders." {
+		t.Fatalf("Expected the final message, got %q", res.Message)
+	}
+	if len(res.ToolResults) != 2 || res.ToolResults[0].ToolName != "listGlobalTables" || res.ToolResults[1].ToolName != "executeGlobalQuery" {
+		t.Fatalf("Expected results for both tool calls, got %+v", res.ToolResults)
+	}
+	if len(executor.calls) != 2 || executor.calls[1].Arguments["query"] != "SELECT COUNT(*) FROM orders" {
+		t.Fatalf("Expected both tools to run, got %+v", executor.calls)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 provider calls, got %d", len(requests))
+	}
+	if requests[0].System == "" || len(requests[0].Tools) != len(BuildToolDeclarations()) {
+		t.Fatalf("Expected the system instruction and chat tools to be sent, got %+v", requests[0])
+	}
+	first := requests[0].Messages
+	if len(first) != 3 || first[1].Role != RoleAssistant || first[2].Role != RoleUser || first[2].Content != "How many orders?" {
+		t.Fatalf("Unexpected first conversation: %+v", first)
+	}
+
+	// The last round sees every call, each followed by its result
+	last := requests[2].Messages
+	if len(last) != 7 {
+		t.Fatalf("Expected 7 messages in the last round, got %+v", last)
+	}
+	if last[3].Role != RoleAssistant || last[3].ToolCalls[0].ID != "call_1" {
+		t.Fatalf("Expected the first tool call, got %+v", last[3])
+	}
+	if last[4].Role != RoleTool || last[4].ToolCallID != "call_1" || last[4].ToolResult["count"] != 1 {
+		t.Fatalf("Expected the first tool result, got %+v", last[4])
+	}
+	if wrapped, ok := last[6].ToolResult["result"].([]int); !ok || wrapped[0] != 42 {
+		t.Fatalf("Expected a non-object result to be wrapped, got %+v", last[6].ToolResult)
+	}
+}
+
+func TestSendMessageWithTools_ReportsToolErrorsToTheModel(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{ToolCalls: []ToolCall{{Name: "dropEverything"}}},
+		ChatResponse{Content: "I can't do that."},
+	)
+
+	res, err := NewAgent(provider, 0).SendMessageWithTools(context.Background(), "drop it", nil, &recordingExecutor{})
+	if err != nil {
+		t.Fatalf("SendMessageWithTools failed: %v", err)
+	}
+	if res.Message != "I can't do that." {
+		t.Fatalf("Expected the final message, got %q", res.Message)
+	}
+
+	result := provider.Requests()[1].Messages[2].ToolResult
+	if result["error"] != "unknown tool: dropEverything" {
+		t.Fatalf("Expected the tool error to be sent back, got %+v", result)
+	}
+}
+
+func TestSendMessageWithTools_StopsAfterMaxToolRounds(t *testing.T) {
+	responses := make([]ChatResponse, maxToolRounds)
+	for i := range responses {
+		responses[i] = ChatResponse{ToolCalls: []ToolCall{{Name: "listGlobalTables"}}}
+	}
+	provider := NewScriptedProvider(responses...)
+	executor := &recordingExecutor{results: map[string]interface{}{"listGlobalTables": map[string]interface{}{}}}
+
+	res, err := NewAgent(provider, 0).SendMessageWithTools(context.Background(), "loop", nil, executor)
+	if err != nil {
+		t.Fatalf("SendMessageWithTools failed: %v", err)
+	}
+	if len(res.ToolResults) != maxToolRounds || res.Message == "" {
+		t.Fatalf("Expected %d tool results and an apology, got %+v", maxToolRounds, res)
+	}
+}
+
+func TestSendMessageForQueryGeneration_ExtractsSQL(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{ToolCalls: []ToolCall{{Name: "getTableColumns", Arguments: map[string]interface{}{"tableName": "clients"}}}},
+		ChatResponse{Content: "Here you go:\n```sql\nSELECT name FROM clients\n```"},
+	)
+	executor := &recordingExecutor{results: map[string]interface{}{"getTableColumns": map[string]interface{}{"count": 1}}}
+
+	res, err := NewAgent(provider, 0).SendMessageForQueryGeneration(context.Background(), "client names", nil, executor, nil)
+	if err != nil {
+		t.Fatalf("SendMessageForQueryGeneration failed: %v", err)
+	}
+	if res.GeneratedSQL != "SELECT name FROM clients" {
+		t.Fatalf("Expected the SQL to be extracted, got %q", res.GeneratedSQL)
+	}
+	if res.Validation.Status != ValidationSkipped || len(res.Warnings) != 1 {
+		t.Fatalf("Expected validation to be skipped without a validator, got %+v and %q", res.Validation, res.Warnings)
+	}
+	if tools := provider.Requests()[0].Tools; len(tools) != len(BuildQueryGeneratorToolDeclarations()) {
+		t.Fatalf("Expected the query generator tools, got %+v", tools)
+	}
+}
+
+// scriptedValidator fails the queries it validates with errs, in order, and accepts the rest,
+// as planned unless unplanned is set
+type scriptedValidator struct {
+	errs      []error
+	unplanned bool
+	queries   []string
+}
+
+func (v *scriptedValidator) Validate(ctx context.Context, globalQuery string) (*query.Validation, error) {
+	v.queries = append(v.queries, globalQuery)
+	if len(v.queries) <= len(v.errs) {
+		return nil, v.errs[len(v.queries)-1]
+	}
+	return &query.Validation{GeneratedSQL: "SELECT full_name FROM pg.public.customers", Planned: !v.unplanned, Warnings: []string{"degraded"}}, nil
+}
+
+func TestSendMessageForQueryGeneration_RepairsInvalidSQL(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{Content: "```sql\nSELECT fullname FROM clients\n```"},
+		ChatResponse{Content: "Fixed:\n```sql\nSELECT name FROM clients\n```"},
+	)
+	validator := &scriptedValidator{errs: []error{errors.New("column resolution error: column 'fullname' not found")}}
+
+	res, err := NewAgent(provider, 0).SendMessageForQueryGeneration(context.Background(), "client names", nil, &recordingExecutor{}, validator)
+	if err != nil {
+		t.Fatalf("SendMessageForQueryGeneration failed: %v", err)
+	}
+
+	if res.GeneratedSQL != "SELECT name FROM clients" || res.TranslatedSQL != "SELECT full_name FROM pg.public.customers" {
+		t.Fatalf("Expected the repaired query and its translation, got %q and %q", res.GeneratedSQL, res.TranslatedSQL)
+	}
+	if res.Validation.Status != ValidationValid || res.Validation.RepairRounds != 1 || len(res.Warnings) != 1 {
+		t.Fatalf("Expected a valid query after one repair, got %+v and %q", res.Validation, res.Warnings)
+	}
+
+	// The model sees its failed answer and the validation error
+	repair := provider.Requests()[1].Messages
+	if last := repair[len(repair)-1]; last.Role != RoleUser || !strings.Contains(last.Content, "column 'fullname' not found") {
+		t.Fatalf("Expected the validation error to be fed back, got %+v", repair)
+	}
+}
+
+func TestSendMessageForQueryGeneration_SkipsUnplannedQueries(t *testing.T) {
+	provider := NewScriptedProvider(ChatResponse{Content: "```sql\nSELECT name FROM clients WHERE id = ?\n```"})
+
+	res, err := NewAgent(provider, 0).SendMessageForQueryGeneration(context.Background(), "a client", nil, &recordingExecutor{}, &scriptedValidator{unplanned: true})
+	if err != nil {
+		t.Fatalf("SendMessageForQueryGeneration failed: %v", err)
+	}
+	if res.Validation.Status != ValidationSkipped || res.TranslatedSQL == "" || len(res.Warnings) != 1 {
+		t.Fatalf("Expected a translated but skipped query, got %+v, %q and %q", res.Validation, res.TranslatedSQL, res.Warnings)
+	}
+}
+
+func TestSendMessageForQueryGeneration_GivesUpAfterMaxRepairRounds(t *testing.T) {
+	var responses []ChatResponse
+	var errs []error
+	for i := 0; i <= maxRepairRounds; i++ {
+		responses = append(responses, ChatResponse{Content: "```sql\nSELECT x FROM clients\n```"})
+		errs = append(errs, errors.New("planning error: column 'x' cannot be resolved"))
+	}
+	provider := NewScriptedProvider(responses...)
+
+	res, err := NewAgent(provider, 0).SendMessageForQueryGeneration(context.Background(), "x", nil, &recordingExecutor{}, &scriptedValidator{errs: errs})
+	if err != nil {
+		t.Fatalf("SendMessageForQueryGeneration failed: %v", err)
+	}
+
+	if res.Validation.Status != ValidationInvalid || res.Validation.RepairRounds != maxRepairRounds || res.Validation.Error != errs[0].Error() {
+		t.Fatalf("Expected the query to stay invalid, got %+v", res.Validation)
+	}
+	if res.GeneratedSQL != "SELECT x FROM clients" || res.TranslatedSQL != "" {
+		t.Fatalf("Expected the last query without a translation, got %q and %q", res.GeneratedSQL, res.TranslatedSQL)
+	}
+	if len(provider.Requests()) != maxRepairRounds+1 {
+		t.Fatalf("Expected %d model calls, got %d", maxRepairRounds+1, len(provider.Requests()))
+	}
+}
+
+func TestSendMessage_ProviderError(t *testing.T) {
+	_, err := NewAgent(NewScriptedProvider(), 0).SendMessage(context.Background(), "hello")
+	if err == nil {
+		t.Fatal("Expected an error once the script runs out")
+	}
+}
+
+func TestNewProvider_None(t *testing.T) {
+	if _, err := NewProvider(context.Background(), ProviderConfig{Provider: ProviderNone}); !errors.Is(err, ErrNoProvider) {
+		t.Fatalf("Expected ErrNoProvider, got %v", err)
+	}
+	if _, err := NewProvider(context.Background(), ProviderConfig{Provider: "carrier-pigeon"}); err == nil {
+		t.Fatal("Expected an error for an unknown provider")
+	}
+	if _, err := NewProvider(context.Background(), ProviderConfig{Provider: ProviderGemini}); err == nil {
+		t.Fatal("Expected an error for Gemini without an API key")
+	}
+}
+
+func TestStreamMessageWithTools_ReportsEvents(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{Content: "Let me check.", ToolCalls: []ToolCall{{ID: "call_1", Name: "listGlobalTables"}}},
+		ChatResponse{Content: "You have one table."},
+	)
+	executor := &recordingExecutor{results: map[string]interface{}{"listGlobalTables": map[string]interface{}{"count": 1}}}
+
+	var events []AgentEvent
+	res, err := NewAgent(provider, 0).StreamMessageWithTools(context.Background(), "tables?", nil, executor, func(event AgentEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatalf("StreamMessageWithTools failed: %v", err)
+	}
+
+	var types []string
+	var text string
+	for _, event := range events {
+		types = append(types, event.Type)
+		text += event.Delta
+	}
+	expected := []string{EventDelta, EventDelta, EventDelta, EventToolCallStarted, EventToolCallFinished, EventDelta, EventDelta, EventDelta, EventDelta, EventMessage}
+	if len(types) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Fatalf("Expected events %v, got %v", expected, types)
+		}
+	}
+	if text != "Let me check.You have one table." {
+		t.Fatalf("Expected the streamed text of both rounds, got %q", text)
+	}
+
+	finished := events[4]
+	if finished.ToolCall.ID != "call_1" || finished.ToolResult.Data.(map[string]interface{})["count"] != 1 {
+		t.Fatalf("Expected the tool result in the finished event, got %+v", finished)
+	}
+	if last := events[len(events)-1]; last.Response != res || res.Message != "You have one table." || len(res.ToolResults) != 1 {
+		t.Fatalf("Expected the final response in the message event, got %+v", last.Response)
+	}
+}
@@ -3,381 +3,184 @@ package chatbot
 import (
 	"context"
 	"fmt"
-	"os"
-	"regexp"
 	"strings"
 
 	"google.golang.org/genai"
 )
 
-type GeminiClient struct {
-	client *genai.Client
-	ctx    context.Context
+// GeminiProvider talks to Google's Gemini API
+type GeminiProvider struct {
+	client      *genai.Client
+	model       string
+	temperature *float32
 }
 
-func NewGeminiClient() (*GeminiClient, error) {
-	ctx := context.Background()
-
-	apiKey := os.Getenv("GEMINI_API_KEY")
+func NewGeminiProvider(ctx context.Context, apiKey, model string, temperature *float64) (*GeminiProvider, error) {
 	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable is not set")
+		return nil, fmt.Errorf("a Gemini API key is required")
 	}
 
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey: apiKey,
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
-	return &GeminiClient{client: client, ctx: ctx}, nil
-}
 
-func (g *GeminiClient) SendMessage(message string) (string, error) {
-	res, err := g.client.Models.GenerateContent(g.ctx, "gemini-2.5-flash", genai.Text(message), nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate content: %w", err)
+	provider := &GeminiProvider{client: client, model: model}
+	if temperature != nil {
+		t := float32(*temperature)
+		provider.temperature = &t
 	}
-	return res.Text(), nil
+	return provider, nil
 }
 
-func (g *GeminiClient) SendMessageWithHistory(message string, history []ChatMessage) (string, error) {
-	// Convert history to Gemini format
-	var contents []*genai.Content
-
-	for _, msg := range history {
-		// Map frontend roles to Gemini roles
-		role := msg.Role
-		if role == "assistant" {
-			role = "model"
-		}
-
-		// Create content for this message with the correct role
-		msgContents := genai.Text(msg.Content)
-		if len(msgContents) > 0 {
-			msgContents[0].Role = role
-			contents = append(contents, msgContents...)
-		}
-	}
+func (g *GeminiProvider) Name() string {
+	return ProviderGemini
+}
 
-	// Add current user message
-	userContents := genai.Text(message)
-	if len(userContents) > 0 {
-		userContents[0].Role = "user"
-		contents = append(contents, userContents...)
-	}
+func (g *GeminiProvider) Model() string {
+	return g.model
+}
 
-	// Generate response with conversation context
-	res, err := g.client.Models.GenerateContent(g.ctx, "gemini-2.5-flash", contents, nil)
+func (g *GeminiProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	res, err := g.client.Models.GenerateContent(ctx, g.model, geminiContents(req.Messages), g.config(req))
 	if err != nil {
-		return "", fmt.Errorf("failed to generate content with history: %w", err)
+		return nil, fmt.Errorf("failed to generate content: %w", err)
 	}
 
-	return res.Text(), nil
+	response := &ChatResponse{}
+	var text strings.Builder
+	addGeminiParts(response, &text, res)
+	response.Content = text.String()
+	return response, nil
 }
 
-func (g *GeminiClient) SendMessageWithTools(message string, history []ChatMessage, toolExecutor ToolExecutor) (*AgentResponse, error) {
-	// Convert history to Gemini format
-	var contents []*genai.Content
-
-	for _, msg := range history {
-		// Map frontend roles to Gemini roles
-		role := msg.Role
-		if role == "assistant" {
-			role = "model"
-		}
-
-		// Create content for this message with the correct role
-		msgContents := genai.Text(msg.Content)
-		if len(msgContents) > 0 {
-			msgContents[0].Role = role
-			contents = append(contents, msgContents...)
-		}
-	}
-
-	// Add current user message
-	userContents := genai.Text(message)
-	if len(userContents) > 0 {
-		userContents[0].Role = "user"
-		contents = append(contents, userContents...)
-	}
-
-	// Build tool declarations
-	tools := BuildToolDeclarations()
-
-	// Track tool results for response
-	var toolResults []ToolResult
-
-	// System instruction to guide Gemini on how to use the tools
-	systemInstruction := `You are a helpful data assistant with access to a federated data system.
-
-IMPORTANT GUIDELINES:
-1. When users ask what tables are available (e.g., "what tables do I have?", "show me available tables"), ALWAYS use listGlobalTables first.
-2. When users ask to see, query, or analyze data (e.g., "show me clients", "how many orders"), use executeGlobalQuery with SQL queries on GLOBAL TABLES.
-3. If you encounter an error saying a table doesn't exist, use listGlobalTables to see what tables are actually available.
-4. Global tables abstract physical data sources - users query logical table names like "clients" or "orders", not physical catalog.schema.table names.
-5. Always use executeGlobalQuery for data retrieval queries - construct proper SQL SELECT statements.
-6. The discoverMetadata tool is for exploring physical catalogs/schemas/tables/columns - use it when users ask about the underlying data sources.
-7. Provide friendly, conversational responses that explain the data you found.
-
-Example interactions:
-- "Show me all clients" → listGlobalTables (to verify "clients" exists), then executeGlobalQuery with "SELECT * FROM clients"
-- "How many orders are there?" → executeGlobalQuery with "SELECT COUNT(*) FROM orders"
-- "What tables do I have?" → listGlobalTables
-- "Show me clients from USA" → executeGlobalQuery with "SELECT * FROM clients WHERE country = 'USA'"
-- "What catalogs exist?" → discoverMetadata with level="catalogs"`
-
-	// Generate content with tools - may require multiple rounds
-	maxIterations := 5
-	for i := 0; i < maxIterations; i++ {
-		// Generate response with tools
-		res, err := g.client.Models.GenerateContent(g.ctx, "gemini-2.5-flash", contents, &genai.GenerateContentConfig{
-			Tools:             tools,
-			SystemInstruction: genai.NewContentFromText(systemInstruction, "system"),
-		})
+func (g *GeminiProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(string)) (*ChatResponse, error) {
+	response := &ChatResponse{}
+	var text strings.Builder
+	for res, err := range g.client.Models.GenerateContentStream(ctx, g.model, geminiContents(req.Messages), g.config(req)) {
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate content with tools: %w", err)
+			return nil, fmt.Errorf("failed to generate content: %w", err)
 		}
-
-		// Check if Gemini wants to call a function
-		if len(res.Candidates) == 0 || len(res.Candidates[0].Content.Parts) == 0 {
-			return &AgentResponse{
-				Message:     "",
-				ToolResults: toolResults,
-			}, nil
-		}
-
-		// Look for function calls in the response
-		var hasFunctionCalls bool
-		for _, part := range res.Candidates[0].Content.Parts {
-			if part.FunctionCall != nil {
-				hasFunctionCalls = true
-				break
-			}
-		}
-
-		// If no function calls, we have the final response
-		if !hasFunctionCalls {
-			return &AgentResponse{
-				Message:     res.Text(),
-				ToolResults: toolResults,
-			}, nil
+		if delta := addGeminiParts(response, &text, res); delta != "" {
+			onDelta(delta)
 		}
-
-		// Execute each function call and collect responses
-		for _, part := range res.Candidates[0].Content.Parts {
-			if fc := part.FunctionCall; fc != nil {
-				// Execute the tool
-				result, err := toolExecutor.ExecuteTool(fc.Name, fc.Args)
-				if err != nil {
-					// Create error response
-					result = map[string]interface{}{
-						"error": err.Error(),
-					}
-				}
-
-				// Track tool result
-				toolResults = append(toolResults, ToolResult{
-					ToolName: fc.Name,
-					Data:     result,
-				})
-
-				// Convert result to map[string]any for FunctionResponse
-				resultMap, ok := result.(map[string]interface{})
-				if !ok {
-					// If result is not a map, wrap it
-					resultMap = map[string]interface{}{
-						"result": result,
-					}
-				}
-
-				// Add function response to conversation
-				functionContent := genai.NewContentFromFunctionResponse(fc.Name, resultMap, "function")
-				contents = append(contents, functionContent)
-			}
-		}
-
-		// Continue to next iteration to get Gemini's response based on function results
 	}
-
-	// Max iterations reached
-	return &AgentResponse{
-		Message:     "I apologize, but I needed to use too many tools to answer your question. Please try rephrasing or breaking down your request.",
-		ToolResults: toolResults,
-	}, nil
+	response.Content = text.String()
+	return response, nil
 }
 
-func (g *GeminiClient) SendMessageForQueryGeneration(message string, history []ChatMessage, toolExecutor ToolExecutor) (*QueryGenerationResponse, error) {
-	// Convert history to Gemini format
-	var contents []*genai.Content
-
-	for _, msg := range history {
-		// Map frontend roles to Gemini roles
-		role := msg.Role
-		if role == "assistant" {
-			role = "model"
-		}
-
-		// Create content for this message with the correct role
-		msgContents := genai.Text(msg.Content)
-		if len(msgContents) > 0 {
-			msgContents[0].Role = role
-			contents = append(contents, msgContents...)
-		}
+func (g *GeminiProvider) config(req ChatRequest) *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{
+		Temperature: g.temperature,
 	}
-
-	// Add current user message
-	userContents := genai.Text(message)
-	if len(userContents) > 0 {
-		userContents[0].Role = "user"
-		contents = append(contents, userContents...)
+	if req.System != "" {
+		config.SystemInstruction = genai.NewContentFromText(req.System, genai.RoleUser)
 	}
+	if len(req.Tools) > 0 {
+		config.Tools = geminiTools(req.Tools)
+	}
+	if req.JSON {
+		config.ResponseMIMEType = "application/json"
+	}
+	return config
+}
 
-	// Build query generator tool declarations (no execution tools)
-	tools := BuildQueryGeneratorToolDeclarations()
-
-	// System instruction focused on query generation
-	systemInstruction := `You are an expert SQL query generator for a federated data system with global tables.
-
-IMPORTANT GUIDELINES:
-1. Your ONLY job is to generate SQL queries - NEVER execute them.
-2. When users ask for queries, ALWAYS use listGlobalTables first to see what tables are available.
-3. Use getTableColumns to understand what columns exist in the tables you want to query.
-4. Generate queries using GLOBAL TABLE names (e.g., "clients", "orders"), not physical catalog.schema.table names.
-5. Your final response MUST include a valid SQL query in a code block (triple backticks with sql).
-6. Provide a brief explanation of what the query does.
-7. Use discoverMetadata only if the user specifically asks about physical database structure.
-
-QUERY FORMAT:
-Always return your SQL query in this format:
-` + "```sql\n" + `SELECT column1, column2 FROM table_name WHERE condition
-` + "```" + `
-
-Example interactions:
-- "Get all clients" → listGlobalTables, getTableColumns("clients"), then respond with:
-  "Here's a query to get all clients:
-  ` + "```sql\nSELECT * FROM clients\n```" + `"
-
-- "Top 5 customers by revenue" → listGlobalTables, getTableColumns("clients"), then respond with:
-  "Here's a query to get the top 5 customers by revenue:
-  ` + "```sql\nSELECT name, revenue FROM clients ORDER BY revenue DESC LIMIT 5\n```" + `"
-
-- "Count orders from USA" → listGlobalTables, getTableColumns("orders"), then respond with:
-  "Here's a query to count orders from the USA:
-  ` + "```sql\nSELECT COUNT(*) as order_count FROM orders WHERE country = 'USA'\n```" + `"`
-
-	// Generate content with tools - may require multiple rounds
-	maxIterations := 5
-	var lastResponse string
-	for i := 0; i < maxIterations; i++ {
-		// Generate response with tools
-		res, err := g.client.Models.GenerateContent(g.ctx, "gemini-2.5-flash", contents, &genai.GenerateContentConfig{
-			Tools:             tools,
-			SystemInstruction: genai.NewContentFromText(systemInstruction, "system"),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate content with tools: %w", err)
-		}
+// addGeminiParts adds the tool calls and text of a (possibly partial) Gemini response to the
+// response being built and returns the text it added
+func addGeminiParts(response *ChatResponse, text *strings.Builder, res *genai.GenerateContentResponse) string {
+	if len(res.Candidates) == 0 || res.Candidates[0].Content == nil {
+		return ""
+	}
 
-		// Check if Gemini wants to call a function
-		if len(res.Candidates) == 0 || len(res.Candidates[0].Content.Parts) == 0 {
-			return &QueryGenerationResponse{
-				Message:      lastResponse,
-				GeneratedSQL: "",
-			}, nil
+	var delta strings.Builder
+	for _, part := range res.Candidates[0].Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			response.ToolCalls = append(response.ToolCalls, ToolCall{
+				ID:        part.FunctionCall.ID,
+				Name:      part.FunctionCall.Name,
+				Arguments: part.FunctionCall.Args,
+			})
+		case part.Text != "" && !part.Thought:
+			delta.WriteString(part.Text)
 		}
+	}
+	text.WriteString(delta.String())
+	return delta.String()
+}
 
-		// Look for function calls in the response
-		var hasFunctionCalls bool
-		for _, part := range res.Candidates[0].Content.Parts {
-			if part.FunctionCall != nil {
-				hasFunctionCalls = true
-				break
+// geminiContents converts a conversation to Gemini contents. Gemini calls the assistant
+// "model" and expects tool results as function responses from the user.
+func geminiContents(messages []Message) []*genai.Content {
+	var contents []*genai.Content
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleAssistant:
+			var parts []*genai.Part
+			if msg.Content != "" {
+				parts = append(parts, genai.NewPartFromText(msg.Content))
 			}
-		}
-
-		// If no function calls, we have the final response
-		if !hasFunctionCalls {
-			responseText := res.Text()
-			generatedSQL := extractSQLFromResponse(responseText)
-
-			// Debug logging
-			fmt.Printf("[Query Generation] Response text: %s\n", responseText)
-			fmt.Printf("[Query Generation] Extracted SQL: %s\n", generatedSQL)
-
-			return &QueryGenerationResponse{
-				Message:      responseText,
-				GeneratedSQL: generatedSQL,
-			}, nil
-		}
-
-		// Execute each function call and collect responses
-		for _, part := range res.Candidates[0].Content.Parts {
-			if fc := part.FunctionCall; fc != nil {
-				// Execute the tool
-				result, err := toolExecutor.ExecuteTool(fc.Name, fc.Args)
-				if err != nil {
-					// Create error response
-					result = map[string]interface{}{
-						"error": err.Error(),
-					}
-				}
-
-				// Convert result to map[string]any for FunctionResponse
-				resultMap, ok := result.(map[string]interface{})
-				if !ok {
-					// If result is not a map, wrap it
-					resultMap = map[string]interface{}{
-						"result": result,
-					}
-				}
-
-				// Add function response to conversation
-				functionContent := genai.NewContentFromFunctionResponse(fc.Name, resultMap, "function")
-				contents = append(contents, functionContent)
+			for _, call := range msg.ToolCalls {
+				part := genai.NewPartFromFunctionCall(call.Name, call.Arguments)
+				part.FunctionCall.ID = call.ID
+				parts = append(parts, part)
+			}
+			if len(parts) > 0 {
+				contents = append(contents, genai.NewContentFromParts(parts, genai.RoleModel))
 			}
+		case RoleTool:
+			part := genai.NewPartFromFunctionResponse(msg.ToolName, msg.ToolResult)
+			part.FunctionResponse.ID = msg.ToolCallID
+			// Results of the same round of calls go back together in one content
+			if last := len(contents) - 1; last >= 0 && contents[last].Role == genai.RoleUser && contents[last].Parts[0].FunctionResponse != nil {
+				contents[last].Parts = append(contents[last].Parts, part)
+			} else {
+				contents = append(contents, genai.NewContentFromParts([]*genai.Part{part}, genai.RoleUser))
+			}
+		default:
+			contents = append(contents, genai.NewContentFromText(msg.Content, genai.RoleUser))
 		}
-
-		// Store last text response if any
-		lastResponse = res.Text()
-
-		// Continue to next iteration to get Gemini's response based on function results
 	}
-
-	// Max iterations reached
-	return &QueryGenerationResponse{
-		Message:      "I apologize, but I needed too many steps to generate your query. Please try a simpler request.",
-		GeneratedSQL: "",
-	}, nil
+	return contents
 }
 
-// SendMessageWithConfig sends a message to Gemini with custom configuration
-// This is useful for features like JSON mode or other structured outputs
-func (g *GeminiClient) SendMessageWithConfig(ctx context.Context, message string, config *genai.GenerateContentConfig) (string, error) {
-	contents := genai.Text(message)
-
-	res, err := g.client.Models.GenerateContent(ctx, "gemini-2.5-flash", contents, config)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate content: %w", err)
+// geminiTools converts tool declarations to Gemini function declarations
+func geminiTools(tools []ToolDeclaration) []*genai.Tool {
+	declarations := make([]*genai.FunctionDeclaration, len(tools))
+	for i, tool := range tools {
+		properties := make(map[string]*genai.Schema, len(tool.Parameters))
+		for name, param := range tool.Parameters {
+			properties[name] = &genai.Schema{
+				Type:        geminiType(param.Type),
+				Description: param.Description,
+				Enum:        param.Enum,
+			}
+		}
+		declarations[i] = &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters: &genai.Schema{
+				Type:       genai.TypeObject,
+				Properties: properties,
+				Required:   tool.Required,
+			},
+		}
 	}
-
-	return res.Text(), nil
+	return []*genai.Tool{{FunctionDeclarations: declarations}}
 }
 
-// extractSQLFromResponse extracts SQL code from markdown code blocks
-func extractSQLFromResponse(response string) string {
-	// Regular expression to find markdown code blocks.
-	// (?i) - case insensitive (for SQL, sql, Sql)
-	// (?s) - dot matches newlines
-	// ```(?:sql)? - matches ``` optionally followed by sql
-	// \s* - matches any leading whitespace/newlines
-	// (.*?) - non-greedy match of the content
-	// \s*``` - matches any trailing whitespace followed by ```
-	re := regexp.MustCompile("(?i)(?s)```(?:sql)?\\s*(.*?)\\s*```")
-
-	matches := re.FindStringSubmatch(response)
-	if len(matches) >= 2 {
-		return strings.TrimSpace(matches[1])
+func geminiType(paramType string) genai.Type {
+	switch paramType {
+	case "integer":
+		return genai.TypeInteger
+	case "number":
+		return genai.TypeNumber
+	case "boolean":
+		return genai.TypeBoolean
+	default:
+		return genai.TypeString
 	}
-
-	return ""
 }
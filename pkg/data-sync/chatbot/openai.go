@@ -0,0 +1,342 @@
+package chatbot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultOpenAIBaseURL is used when no base URL is configured
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider talks to an OpenAI-compatible chat completions API. Besides OpenAI itself this
+// covers local servers such as llama.cpp and Ollama, which do not need an API key.
+type OpenAIProvider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature *float64
+	client      *http.Client
+}
+
+// NewOpenAIProvider creates a provider for the API at baseURL. A nil client uses http.DefaultClient.
+func NewOpenAIProvider(baseURL, apiKey, model string, temperature *float64, client *http.Client) (*OpenAIProvider, error) {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	if model == "" {
+		return nil, fmt.Errorf("a model is required for the OpenAI-compatible provider")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAIProvider{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		apiKey:      apiKey,
+		model:       model,
+		temperature: temperature,
+		client:      client,
+	}, nil
+}
+
+func (o *OpenAIProvider) Name() string {
+	return ProviderOpenAI
+}
+
+func (o *OpenAIProvider) Model() string {
+	return o.model
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Name       string           `json:"name,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments is a JSON object encoded as a string
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIRequest struct {
+	Model          string            `json:"model"`
+	Messages       []openAIMessage   `json:"messages"`
+	Tools          []openAITool      `json:"tools,omitempty"`
+	Temperature    *float64          `json:"temperature,omitempty"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+	Stream         bool              `json:"stream,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (o *OpenAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	httpRes, err := o.send(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	var res openAIResponse
+	if err := json.NewDecoder(httpRes.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	}
+
+	response := &ChatResponse{}
+	if len(res.Choices) == 0 {
+		return response, nil
+	}
+	message := res.Choices[0].Message
+	if message.Content != nil {
+		response.Content = *message.Content
+	}
+	if response.ToolCalls, err = decodeOpenAIToolCalls(message.ToolCalls); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index int `json:"index"`
+				openAIToolCall
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// ChatStream reads the server-sent chunks of a streamed completion. Tool call names and
+// arguments arrive in pieces and are put back together by their index.
+func (o *OpenAIProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(string)) (*ChatResponse, error) {
+	httpRes, err := o.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	var text strings.Builder
+	var calls []openAIToolCall
+	scanner := bufio.NewScanner(httpRes.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode chat response chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("chat request failed: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			text.WriteString(delta.Content)
+			onDelta(delta.Content)
+		}
+		for _, piece := range delta.ToolCalls {
+			for len(calls) <= piece.Index {
+				calls = append(calls, openAIToolCall{Type: "function"})
+			}
+			call := &calls[piece.Index]
+			if piece.ID != "" {
+				call.ID = piece.ID
+			}
+			call.Function.Name += piece.Function.Name
+			call.Function.Arguments += piece.Function.Arguments
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chat response: %w", err)
+	}
+
+	response := &ChatResponse{Content: text.String()}
+	if response.ToolCalls, err = decodeOpenAIToolCalls(calls); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// send posts a chat completion request and returns the response once it has a 200 status
+func (o *OpenAIProvider) send(ctx context.Context, req ChatRequest, stream bool) (*http.Response, error) {
+	body := openAIRequest{
+		Model:       o.model,
+		Messages:    openAIMessages(req.System, req.Messages),
+		Temperature: o.temperature,
+		Stream:      stream,
+	}
+	if len(req.Tools) > 0 {
+		body.Tools = openAITools(req.Tools)
+	}
+	if req.JSON {
+		body.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chat request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	httpRes, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat request: %w", err)
+	}
+	if httpRes.StatusCode == http.StatusOK {
+		return httpRes, nil
+	}
+	defer httpRes.Body.Close()
+
+	data, _ := io.ReadAll(httpRes.Body)
+	var res openAIResponse
+	if err := json.Unmarshal(data, &res); err == nil && res.Error != nil {
+		return nil, fmt.Errorf("chat request failed with status %d: %s", httpRes.StatusCode, res.Error.Message)
+	}
+	return nil, fmt.Errorf("chat request failed with status %d: %s", httpRes.StatusCode, strings.TrimSpace(string(data)))
+}
+
+// decodeOpenAIToolCalls parses the JSON-encoded arguments of tool calls
+func decodeOpenAIToolCalls(calls []openAIToolCall) ([]ToolCall, error) {
+	var decoded []ToolCall
+	for _, call := range calls {
+		arguments := map[string]interface{}{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &arguments); err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool call '%s': %w", call.Function.Name, err)
+			}
+		}
+		decoded = append(decoded, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		})
+	}
+	return decoded, nil
+}
+
+// openAIMessages converts a conversation to chat completion messages, with the system prompt first
+func openAIMessages(system string, messages []Message) []openAIMessage {
+	var converted []openAIMessage
+	if system != "" {
+		converted = append(converted, openAIMessage{Role: "system", Content: &system})
+	}
+	for _, msg := range messages {
+		content := msg.Content
+		switch msg.Role {
+		case RoleAssistant:
+			converted = append(converted, openAIMessage{
+				Role:      "assistant",
+				Content:   &content,
+				ToolCalls: openAIToolCalls(msg.ToolCalls),
+			})
+		case RoleTool:
+			result, err := json.Marshal(msg.ToolResult)
+			if err != nil {
+				result, _ = json.Marshal(map[string]string{"error": err.Error()})
+			}
+			content = string(result)
+			converted = append(converted, openAIMessage{
+				Role:       "tool",
+				Content:    &content,
+				ToolCallID: msg.ToolCallID,
+				Name:       msg.ToolName,
+			})
+		default:
+			converted = append(converted, openAIMessage{Role: "user", Content: &content})
+		}
+	}
+	return converted
+}
+
+func openAIToolCalls(calls []ToolCall) []openAIToolCall {
+	var converted []openAIToolCall
+	for _, call := range calls {
+		arguments, _ := json.Marshal(call.Arguments)
+		tc := openAIToolCall{ID: call.ID, Type: "function"}
+		tc.Function.Name = call.Name
+		tc.Function.Arguments = string(arguments)
+		converted = append(converted, tc)
+	}
+	return converted
+}
+
+// openAITools converts tool declarations to JSON Schema function tools
+func openAITools(tools []ToolDeclaration) []openAITool {
+	converted := make([]openAITool, len(tools))
+	for i, tool := range tools {
+		properties := make(map[string]interface{}, len(tool.Parameters))
+		for name, param := range tool.Parameters {
+			property := map[string]interface{}{
+				"type":        param.Type,
+				"description": param.Description,
+			}
+			if len(param.Enum) > 0 {
+				property["enum"] = param.Enum
+			}
+			properties[name] = property
+		}
+		parameters := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(tool.Required) > 0 {
+			parameters["required"] = tool.Required
+		}
+
+		converted[i].Type = "function"
+		converted[i].Function.Name = tool.Name
+		converted[i].Function.Description = tool.Description
+		converted[i].Function.Parameters = parameters
+	}
+	return converted
+}
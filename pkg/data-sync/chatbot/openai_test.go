@@ -0,0 +1,136 @@
+package chatbot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIProvider_Chat(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Expected a bearer token, got %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[
+			{"id":"call_9","type":"function","function":{"name":"executeGlobalQuery","arguments":"{\"query\":\"SELECT 1\"}"}}
+		]}}]}`))
+	}))
+	defer server.Close()
+
+	temperature := 0.2
+	provider, err := NewOpenAIProvider(server.URL+"/v1/", "secret", "llama3", &temperature, server.Client())
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	res, err := provider.Chat(context.Background(), ChatRequest{
+		System: "be helpful",
+		Messages: []Message{
+			{Role: RoleUser, Content: "count"},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "listGlobalTables", Arguments: map[string]interface{}{}}}},
+			{Role: RoleTool, ToolCallID: "call_1", ToolName: "listGlobalTables", ToolResult: map[string]interface{}{"count": 0}},
+		},
+		Tools: BuildToolDeclarations(),
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if len(res.ToolCalls) != 1 || res.ToolCalls[0].ID != "call_9" || res.ToolCalls[0].Arguments["query"] != "SELECT 1" {
+		t.Fatalf("Expected the decoded tool call, got %+v", res.ToolCalls)
+	}
+
+	if received["model"] != "llama3" || received["temperature"] != 0.2 {
+		t.Fatalf("Expected the model and temperature to be sent, got %v", received)
+	}
+	messages := received["messages"].([]interface{})
+	if len(messages) != 4 {
+		t.Fatalf("Expected the system prompt and 3 messages, got %v", messages)
+	}
+	if system := messages[0].(map[string]interface{}); system["role"] != "system" || system["content"] != "be helpful" {
+		t.Fatalf("Expected the system prompt first, got %v", system)
+	}
+	call := messages[2].(map[string]interface{})["tool_calls"].([]interface{})[0].(map[string]interface{})
+	if call["id"] != "call_1" || call["function"].(map[string]interface{})["arguments"] != "{}" {
+		t.Fatalf("Unexpected tool call message: %v", call)
+	}
+	tool := messages[3].(map[string]interface{})
+	if tool["role"] != "tool" || tool["tool_call_id"] != "call_1" || tool["content"] != `{"count":0}` {
+		t.Fatalf("Unexpected tool result message: %v", tool)
+	}
+	tools := received["tools"].([]interface{})
+	if len(tools) != len(BuildToolDeclarations()) {
+		t.Fatalf("Expected %d tools, got %d", len(BuildToolDeclarations()), len(tools))
+	}
+	if _, ok := received["response_format"]; ok {
+		t.Fatal("Expected no response_format outside JSON mode")
+	}
+}
+
+func TestOpenAIProvider_ChatError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"message":"model 'llama9' not found"}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(server.URL, "", "llama9", nil, server.Client())
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	_, err = provider.Chat(context.Background(), ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}, JSON: true})
+	if err == nil || !strings.Contains(err.Error(), "model 'llama9' not found") {
+		t.Fatalf("Expected the API error message, got %v", err)
+	}
+}
+
+func TestOpenAIProvider_ChatStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("Expected a streamed request, got %v", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"delta":{"content":"Checking"}}]}`,
+			`{"choices":[{"delta":{"content":" now"}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"executeGlobalQuery","arguments":"{\"qu"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ery\":\"SELECT 1\"}"}}]}}]}`,
+		} {
+			w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(server.URL, "", "llama3", nil, server.Client())
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	var deltas []string
+	res, err := provider.ChatStream(context.Background(), ChatRequest{Messages: []Message{{Role: RoleUser, Content: "hi"}}}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	if len(deltas) != 2 || res.Content != "Checking now" {
+		t.Fatalf("Expected 2 deltas making up the content, got %q and %q", deltas, res.Content)
+	}
+	if len(res.ToolCalls) != 1 || res.ToolCalls[0].ID != "call_1" || res.ToolCalls[0].Arguments["query"] != "SELECT 1" {
+		t.Fatalf("Expected the tool call to be reassembled, got %+v", res.ToolCalls)
+	}
+}
@@ -0,0 +1,133 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// ErrNoProvider is returned when AI features are used without an LLM provider configured
+var ErrNoProvider = errors.New("no LLM provider is configured")
+
+// Message roles in a provider conversation
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// ToolCall is a request from the model to run a tool
+type ToolCall = models.ChatToolCall
+
+// Message is one turn of a provider conversation. Assistant messages may carry tool calls,
+// and each call is answered by a tool message holding its result. Chat sessions store these
+// messages as they are, so a resumed session keeps its tool calls and results.
+type Message = models.ChatSessionMessage
+
+// ToolParameter describes one argument of a tool
+type ToolParameter struct {
+	Type        string // "string", "integer", "number" or "boolean"
+	Description string
+	Enum        []string
+}
+
+// ToolDeclaration describes a tool the model may call
+type ToolDeclaration struct {
+	Name        string
+	Description string
+	Parameters  map[string]ToolParameter
+	Required    []string
+}
+
+// ChatRequest is a conversation sent to a provider
+type ChatRequest struct {
+	System   string
+	Messages []Message
+	Tools    []ToolDeclaration
+	// JSON asks the model to answer with a JSON object
+	JSON bool
+}
+
+// ChatResponse is the model's answer: text, tool calls, or both
+type ChatResponse struct {
+	Content   string
+	ToolCalls []ToolCall
+}
+
+// Provider is a chat model with tool calling
+type Provider interface {
+	// Name identifies the provider, e.g. "gemini" or "openai"
+	Name() string
+	Model() string
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+}
+
+// StreamingProvider is a provider that can stream the text of its answer as it is generated
+type StreamingProvider interface {
+	Provider
+	// ChatStream calls onDelta with each piece of text as it arrives and returns the whole response
+	ChatStream(ctx context.Context, req ChatRequest, onDelta func(string)) (*ChatResponse, error)
+}
+
+// chatStream streams a chat from providers that support it; others answer in a single delta
+func chatStream(ctx context.Context, provider Provider, req ChatRequest, onDelta func(string)) (*ChatResponse, error) {
+	if streaming, ok := provider.(StreamingProvider); ok {
+		return streaming.ChatStream(ctx, req, onDelta)
+	}
+	res, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.Content != "" {
+		onDelta(res.Content)
+	}
+	return res, nil
+}
+
+// Supported provider names
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderNone   = "none"
+)
+
+// Default models, used when ProviderConfig.Model is empty
+const (
+	DefaultGeminiModel = "gemini-2.5-flash"
+	DefaultOpenAIModel = "gpt-4o-mini"
+)
+
+// ProviderConfig selects and configures a provider
+type ProviderConfig struct {
+	Provider string
+	Model    string
+	// Temperature is left to the provider's default when nil
+	Temperature *float64
+	APIKey      string
+	// BaseURL is the OpenAI-compatible API root, e.g. http://localhost:11434/v1 for Ollama
+	BaseURL string
+}
+
+// NewProvider creates the provider named in the config. The "none" provider returns ErrNoProvider.
+func NewProvider(ctx context.Context, config ProviderConfig) (Provider, error) {
+	switch config.Provider {
+	case ProviderGemini:
+		model := config.Model
+		if model == "" {
+			model = DefaultGeminiModel
+		}
+		return NewGeminiProvider(ctx, config.APIKey, model, config.Temperature)
+	case ProviderOpenAI:
+		model := config.Model
+		if model == "" {
+			model = DefaultOpenAIModel
+		}
+		return NewOpenAIProvider(config.BaseURL, config.APIKey, model, config.Temperature, nil)
+	case ProviderNone, "":
+		return nil, ErrNoProvider
+	default:
+		return nil, fmt.Errorf("unknown LLM provider '%s' (expected gemini, openai or none)", config.Provider)
+	}
+}
@@ -0,0 +1,67 @@
+package chatbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	gosync "sync"
+)
+
+// ScriptedProvider is a deterministic provider for tests. It answers each Chat call with the
+// next scripted response, in order, and records the requests it received.
+type ScriptedProvider struct {
+	mu        gosync.Mutex
+	responses []ChatResponse
+	requests  []ChatRequest
+}
+
+func NewScriptedProvider(responses ...ChatResponse) *ScriptedProvider {
+	return &ScriptedProvider{responses: responses}
+}
+
+func (s *ScriptedProvider) Name() string {
+	return "scripted"
+}
+
+func (s *ScriptedProvider) Model() string {
+	return "scripted"
+}
+
+func (s *ScriptedProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep a copy, since callers append to the same message slice between rounds
+	req.Messages = append([]Message(nil), req.Messages...)
+	s.requests = append(s.requests, req)
+	if len(s.requests) > len(s.responses) {
+		return nil, fmt.Errorf("scripted provider has no response for call %d", len(s.requests))
+	}
+	response := s.responses[len(s.requests)-1]
+	return &response, nil
+}
+
+// ChatStream answers like Chat, streaming the scripted content one word at a time
+func (s *ScriptedProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(string)) (*ChatResponse, error) {
+	res, err := s.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.Content != "" {
+		for _, word := range strings.SplitAfter(res.Content, " ") {
+			onDelta(word)
+		}
+	}
+	return res, nil
+}
+
+// Requests returns the requests received so far
+func (s *ScriptedProvider) Requests() []ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ChatRequest(nil), s.requests...)
+}
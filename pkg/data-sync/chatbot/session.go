@@ -0,0 +1,161 @@
+package chatbot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// DefaultContextChars bounds a session's conversation sent to the model, roughly 32k tokens
+const DefaultContextChars = 128000
+
+// maxSessionTitle is how much of the first message becomes the title of an untitled session
+const maxSessionTitle = 60
+
+// maxSummarisedToolResult is how much of each tool result is shown to the model when summarising
+const maxSummarisedToolResult = 500
+
+// NewSession starts an empty chat session with a random, unguessable ID
+func NewSession(title string) *models.ChatSession {
+	// crypto/rand.Read never fails; it crashes the program if the system has no randomness
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	now := time.Now().UTC()
+	return &models.ChatSession{
+		ID:        "chat_" + hex.EncodeToString(id),
+		Title:     title,
+		Messages:  []models.ChatSessionMessage{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// SendSessionMessage answers a message in the context of a session and appends the turn,
+// tool calls included, to it. The caller saves the session.
+func (a *Agent) SendSessionMessage(ctx context.Context, session *models.ChatSession, message string, toolExecutor ToolExecutor, onEvent func(AgentEvent)) (*AgentResponse, error) {
+	a.fitContext(ctx, session, message)
+
+	system := chatSystemInstruction
+	if session.Summary != "" {
+		system += "\n\nSUMMARY OF THE EARLIER CONVERSATION:\n" + session.Summary
+	}
+
+	messages := append(append([]Message{}, session.Messages...), Message{Role: RoleUser, Content: message})
+	response, messages, err := a.chatWithTools(ctx, system, messages, toolExecutor, onEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Messages = messages
+	session.UpdatedAt = time.Now().UTC()
+	if session.Title == "" {
+		session.Title = sessionTitle(message)
+	}
+	if onEvent != nil {
+		onEvent(AgentEvent{Type: EventMessage, Response: response})
+	}
+	return response, nil
+}
+
+// fitContext drops a session's oldest turns until the conversation, with the new message, fits
+// the context budget, and folds them into the session summary. If summarising fails the turns
+// are dropped anyway, so a long session never outgrows the model's context window.
+func (a *Agent) fitContext(ctx context.Context, session *models.ChatSession, message string) {
+	size := len(session.Summary) + len(message)
+	for _, msg := range session.Messages {
+		size += messageSize(msg)
+	}
+
+	// Drop whole turns, each starting at a user message, so tool calls keep their results
+	drop := 0
+	for drop < len(session.Messages) && size > a.contextChars {
+		size -= messageSize(session.Messages[drop])
+		drop++
+		for drop < len(session.Messages) && session.Messages[drop].Role != RoleUser {
+			size -= messageSize(session.Messages[drop])
+			drop++
+		}
+	}
+	if drop == 0 {
+		return
+	}
+
+	dropped := session.Messages[:drop]
+	session.Messages = append([]Message{}, session.Messages[drop:]...)
+
+	summary, err := a.summarise(ctx, session.Summary, dropped)
+	if err != nil {
+		log.Printf("Warning: failed to summarise %d messages of chat session %s, dropping them: %v", len(dropped), session.ID, err)
+		return
+	}
+	session.Summary = summary
+}
+
+// summarise asks the model to fold messages into the running summary of a conversation
+func (a *Agent) summarise(ctx context.Context, summary string, messages []Message) (string, error) {
+	var transcript strings.Builder
+	if summary != "" {
+		fmt.Fprintf(&transcript, "Summary so far:\n%s\n\nLater messages:\n", summary)
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleTool:
+			result, _ := json.Marshal(msg.ToolResult)
+			fmt.Fprintf(&transcript, "tool %s returned: %s\n", msg.ToolName, truncate(string(result), maxSummarisedToolResult))
+		default:
+			if msg.Content != "" {
+				fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, msg.Content)
+			}
+			for _, call := range msg.ToolCalls {
+				arguments, _ := json.Marshal(call.Arguments)
+				fmt.Fprintf(&transcript, "%s called %s with %s\n", msg.Role, call.Name, arguments)
+			}
+		}
+	}
+
+	res, err := a.provider.Chat(ctx, ChatRequest{
+		System: "You summarise conversations between a user and a data assistant. Keep the tables, columns, " +
+			"queries and findings that later questions may refer to. Answer with the summary only.",
+		Messages: []Message{{Role: RoleUser, Content: transcript.String()}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res.Content), nil
+}
+
+// messageSize estimates how much of the context window a message takes
+func messageSize(msg Message) int {
+	size := len(msg.Content)
+	if len(msg.ToolCalls) > 0 {
+		calls, _ := json.Marshal(msg.ToolCalls)
+		size += len(calls)
+	}
+	if msg.ToolResult != nil {
+		result, _ := json.Marshal(msg.ToolResult)
+		size += len(result)
+	}
+	return size
+}
+
+// sessionTitle makes a title from the first message of a session
+func sessionTitle(message string) string {
+	return truncate(strings.Join(strings.Fields(message), " "), maxSessionTitle)
+}
+
+// truncate shortens text to at most n runes, marking the cut with an ellipsis
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
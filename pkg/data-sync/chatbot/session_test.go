@@ -0,0 +1,121 @@
+package chatbot
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewSession_RandomIDs(t *testing.T) {
+	first, second := NewSession(""), NewSession("")
+	if !regexp.MustCompile(`^chat_[0-9a-f]{32}$`).MatchString(first.ID) {
+		t.Fatalf("Expected a chat_ ID with 16 random hex-encoded bytes, got %q", first.ID)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("Expected distinct session IDs, got %q twice", first.ID)
+	}
+}
+
+func TestSendSessionMessage_KeepsToolCallsAcrossTurns(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{ToolCalls: []ToolCall{{ID: "call_1", Name: "listGlobalTables", Arguments: map[string]interface{}{}}}},
+		ChatResponse{Content: "You have a clients table."},
+		ChatResponse{Content: "It is called clients."},
+	)
+	executor := &recordingExecutor{results: map[string]interface{}{"listGlobalTables": map[string]interface{}{"count": 1}}}
+	agent := NewAgent(provider, 0)
+	session := NewSession("")
+
+	if _, err := agent.SendSessionMessage(context.Background(), session, "What   tables do I have?", executor, nil); err != nil {
+		t.Fatalf("SendSessionMessage failed: %v", err)
+	}
+	if session.Title != "What tables do I have?" {
+		t.Fatalf("Expected the first message to title the session, got %q", session.Title)
+	}
+	if len(session.Messages) != 4 {
+		t.Fatalf("Expected the user message, tool call, tool result and answer, got %+v", session.Messages)
+	}
+
+	res, err := agent.SendSessionMessage(context.Background(), session, "What was it called?", executor, nil)
+	if err != nil {
+		t.Fatalf("SendSessionMessage failed: %v", err)
+	}
+	if res.Message != "It is called clients." || len(session.Messages) != 6 {
+		t.Fatalf("Expected a second turn, got %q with %d messages", res.Message, len(session.Messages))
+	}
+
+	// The second turn is answered with the first turn's tool call and result
+	second := provider.Requests()[2].Messages
+	if len(second) != 5 || second[1].ToolCalls[0].ID != "call_1" || second[2].ToolResult["count"] != 1 {
+		t.Fatalf("Expected the earlier tool call and result to be resent, got %+v", second)
+	}
+}
+
+func TestSendSessionMessage_SummarisesOldTurns(t *testing.T) {
+	provider := NewScriptedProvider(
+		ChatResponse{Content: "The user asked about orders; there are 42."},
+		ChatResponse{Content: "Clients live in Portugal."},
+	)
+	session := NewSession("Orders")
+	session.Messages = []Message{
+		{Role: RoleUser, Content: "How many orders are there?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "executeGlobalQuery", Arguments: map[string]interface{}{"query": "SELECT COUNT(*) FROM orders"}}}},
+		{Role: RoleTool, ToolCallID: "call_1", ToolName: "executeGlobalQuery", ToolResult: map[string]interface{}{"count": 42}},
+		{Role: RoleAssistant, Content: "There are 42 orders."},
+		{Role: RoleUser, Content: "Thanks"},
+		{Role: RoleAssistant, Content: "You're welcome!"},
+	}
+
+	// Only the last turn and the new message fit
+	agent := NewAgent(provider, 60)
+	if _, err := agent.SendSessionMessage(context.Background(), session, "Where do clients live?", &recordingExecutor{}, nil); err != nil {
+		t.Fatalf("SendSessionMessage failed: %v", err)
+	}
+
+	if session.Summary != "The user asked about orders; there are 42." {
+		t.Fatalf("Expected the dropped turn to be summarised, got %q", session.Summary)
+	}
+	if len(session.Messages) != 4 || session.Messages[0].Content != "Thanks" {
+		t.Fatalf("Expected the first turn to be dropped, got %+v", session.Messages)
+	}
+
+	requests := provider.Requests()
+	if transcript := requests[0].Messages[0].Content; !strings.Contains(transcript, "executeGlobalQuery") || !strings.Contains(transcript, `{"count":42}`) {
+		t.Fatalf("Expected the dropped tool call and result in the transcript, got %q", transcript)
+	}
+	if !strings.Contains(requests[1].System, session.Summary) {
+		t.Fatalf("Expected the summary in the system instruction, got %q", requests[1].System)
+	}
+	if len(requests[1].Messages) != 3 {
+		t.Fatalf("Expected the kept turn and the new message, got %+v", requests[1].Messages)
+	}
+}
+
+func TestSendSessionMessage_DropsTurnsWhenSummaryFails(t *testing.T) {
+	provider := &failingSummaryProvider{ScriptedProvider: NewScriptedProvider(ChatResponse{Content: "Hi again."})}
+	session := NewSession("Hello")
+	session.Messages = []Message{
+		{Role: RoleUser, Content: strings.Repeat("long question ", 10)},
+		{Role: RoleAssistant, Content: strings.Repeat("long answer ", 10)},
+	}
+
+	if _, err := NewAgent(provider, 50).SendSessionMessage(context.Background(), session, "Hello", &recordingExecutor{}, nil); err != nil {
+		t.Fatalf("SendSessionMessage failed: %v", err)
+	}
+	if session.Summary != "" || len(session.Messages) != 2 || session.Messages[0].Content != "Hello" {
+		t.Fatalf("Expected the old turn to be dropped without a summary, got %q and %+v", session.Summary, session.Messages)
+	}
+}
+
+// failingSummaryProvider fails summary requests and answers the rest from its script
+type failingSummaryProvider struct {
+	*ScriptedProvider
+}
+
+func (p *failingSummaryProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if len(req.Tools) == 0 {
+		return nil, context.DeadlineExceeded
+	}
+	return p.ScriptedProvider.Chat(ctx, req)
+}
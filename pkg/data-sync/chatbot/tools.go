@@ -1,55 +1,253 @@
 package chatbot
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
 	"github.com/guilherme096/data-sync/pkg/data-sync/models"
 	"github.com/guilherme096/data-sync/pkg/data-sync/query"
-	"google.golang.org/genai"
 )
 
-// ToolExecutor executes tools called by the Gemini agent
+// Bounds on the rows sampleRows fetches
+const (
+	defaultSampleRows = 10
+	maxSampleRows     = 50
+)
+
+// ToolExecutor executes tools called by the agent
 type ToolExecutor interface {
-	ExecuteTool(toolName string, arguments map[string]interface{}) (interface{}, error)
+	ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (interface{}, error)
 }
 
-// DefaultToolExecutor implements ToolExecutor with access to query translator and metadata discovery
-type DefaultToolExecutor struct {
+// ToolStorage is the metadata the tools read
+type ToolStorage interface {
+	GetGlobalTable(name string) (*models.GlobalTable, error)
+	ListGlobalTables() ([]*models.GlobalTable, error)
+	ListGlobalColumns(globalTableName string) ([]*models.GlobalColumn, error)
+	ListTableMappings(globalTableName string) ([]*models.TableMapping, error)
+	ListColumnMappings(globalTableName, globalColumnName string) ([]*models.ColumnMapping, error)
+	ListColumnRelationships(globalTableName string) ([]*models.ColumnRelationship, error)
+	ListTableRelations() ([]*models.TableRelation, error)
+}
+
+// toolEnv is what tool handlers run against
+type toolEnv struct {
 	translator query.QueryTranslator
 	discovery  discovery.MetadataDiscovery
-	storage    interface {
-		ListGlobalTables() ([]*models.GlobalTable, error)
-	}
+	storage    ToolStorage
+}
+
+// tool is a tool declared to the model together with the handler that runs it
+type tool struct {
+	declaration ToolDeclaration
+	run         func(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error)
+}
+
+// chatTools are the tools offered when chatting, in the order they are declared
+var chatTools = []string{
+	"listGlobalTables",
+	"describeGlobalTable",
+	"listTableRelations",
+	"sampleRows",
+	"explainQuery",
+	"executeGlobalQuery",
+	"discoverMetadata",
+}
+
+// queryGeneratorTools are the tools offered when generating a query. None of them runs a
+// query on Trino; the generated query is validated after the model answers.
+var queryGeneratorTools = []string{
+	"listGlobalTables",
+	"getTableColumns",
+	"describeGlobalTable",
+	"listTableRelations",
+	"discoverMetadata",
+}
+
+// DefaultToolExecutor implements ToolExecutor with the chat tools
+type DefaultToolExecutor struct {
+	env toolEnv
 }
 
 // NewToolExecutor creates a new tool executor with required dependencies
-func NewToolExecutor(translator query.QueryTranslator, discovery discovery.MetadataDiscovery, storage interface {
-	ListGlobalTables() ([]*models.GlobalTable, error)
-}) ToolExecutor {
+func NewToolExecutor(translator query.QueryTranslator, discovery discovery.MetadataDiscovery, storage ToolStorage) ToolExecutor {
 	return &DefaultToolExecutor{
-		translator: translator,
-		discovery:  discovery,
-		storage:    storage,
+		env: toolEnv{translator: translator, discovery: discovery, storage: storage},
 	}
 }
 
 // ExecuteTool routes tool calls to the appropriate handler
-func (te *DefaultToolExecutor) ExecuteTool(toolName string, arguments map[string]interface{}) (interface{}, error) {
-	switch toolName {
-	case "executeGlobalQuery":
-		return te.executeGlobalQuery(arguments)
-	case "discoverMetadata":
-		return te.discoverMetadata(arguments)
-	case "listGlobalTables":
-		return te.listGlobalTables(arguments)
-	default:
-		return nil, fmt.Errorf("unknown tool: %s", toolName)
+func (te *DefaultToolExecutor) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (interface{}, error) {
+	return runTool(ctx, &te.env, chatTools, toolName, arguments)
+}
+
+// QueryGeneratorToolExecutor implements ToolExecutor for query generation only (no execution)
+type QueryGeneratorToolExecutor struct {
+	env toolEnv
+}
+
+// NewQueryGeneratorToolExecutor creates a tool executor for query generation
+func NewQueryGeneratorToolExecutor(discovery discovery.MetadataDiscovery, storage ToolStorage) ToolExecutor {
+	return &QueryGeneratorToolExecutor{
+		env: toolEnv{discovery: discovery, storage: storage},
 	}
 }
 
+// ExecuteTool routes tool calls for query generation
+func (te *QueryGeneratorToolExecutor) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (interface{}, error) {
+	return runTool(ctx, &te.env, queryGeneratorTools, toolName, arguments)
+}
+
+// BuildToolDeclarations returns the tool declarations for chat function calling
+func BuildToolDeclarations() []ToolDeclaration {
+	return toolDeclarations(chatTools)
+}
+
+// BuildQueryGeneratorToolDeclarations returns tool declarations for query generation
+func BuildQueryGeneratorToolDeclarations() []ToolDeclaration {
+	return toolDeclarations(queryGeneratorTools)
+}
+
+// runTool runs the named tool if it is one of the offered tools
+func runTool(ctx context.Context, env *toolEnv, offered []string, toolName string, arguments map[string]interface{}) (interface{}, error) {
+	for _, name := range offered {
+		if name == toolName {
+			return toolRegistry[name].run(ctx, env, arguments)
+		}
+	}
+	return nil, fmt.Errorf("unknown tool: %s", toolName)
+}
+
+func toolDeclarations(names []string) []ToolDeclaration {
+	declarations := make([]ToolDeclaration, len(names))
+	for i, name := range names {
+		declarations[i] = toolRegistry[name].declaration
+	}
+	return declarations
+}
+
+// toolRegistry declares every tool the agent can be offered, keyed by name
+var toolRegistry = map[string]tool{
+	"listGlobalTables": {
+		declaration: ToolDeclaration{
+			Name:        "listGlobalTables",
+			Description: "Lists all available global tables in the system. Use this tool when the user asks 'what tables do I have?', 'show me available tables', or when you need to know what tables exist before querying. This is the FIRST tool you should use when unsure what data is available.",
+		},
+		run: listGlobalTables,
+	},
+	"getTableColumns": {
+		declaration: ToolDeclaration{
+			Name:        "getTableColumns",
+			Description: "Gets the columns for a specific global table. Use this to understand what columns are available for a table before generating a query. This helps you create accurate SELECT statements and WHERE clauses.",
+			Parameters: map[string]ToolParameter{
+				"tableName": {
+					Type:        "string",
+					Description: "The name of the global table to get columns for. Example: 'clients', 'orders'",
+				},
+			},
+			Required: []string{"tableName"},
+		},
+		run: getTableColumns,
+	},
+	"describeGlobalTable": {
+		declaration: ToolDeclaration{
+			Name:        "describeGlobalTable",
+			Description: "Describes a global table: its columns with their types, the physical tables and columns they are mapped to, and its relationships to columns of other global tables. Use this before writing a query on a table, and to find the columns to join global tables on.",
+			Parameters: map[string]ToolParameter{
+				"tableName": {
+					Type:        "string",
+					Description: "The name of the global table to describe. Example: 'clients', 'orders'",
+				},
+			},
+			Required: []string{"tableName"},
+		},
+		run: describeGlobalTable,
+	},
+	"listTableRelations": {
+		declaration: ToolDeclaration{
+			Name:        "listTableRelations",
+			Description: "Lists the relations that combine physical tables or other relations with a JOIN or UNION, with their join columns. Use this to explain how the data behind a global table is put together.",
+		},
+		run: listTableRelations,
+	},
+	"sampleRows": {
+		declaration: ToolDeclaration{
+			Name:        "sampleRows",
+			Description: fmt.Sprintf("Fetches a few rows of a global table. Use this to see what the values of its columns look like, e.g. their format or casing, before filtering on them. Returns at most %d rows.", maxSampleRows),
+			Parameters: map[string]ToolParameter{
+				"tableName": {
+					Type:        "string",
+					Description: "The name of the global table to sample. Example: 'clients'",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: fmt.Sprintf("How many rows to fetch (default %d, at most %d)", defaultSampleRows, maxSampleRows),
+				},
+			},
+			Required: []string{"tableName"},
+		},
+		run: sampleRows,
+	},
+	"explainQuery": {
+		declaration: ToolDeclaration{
+			Name:        "explainQuery",
+			Description: "Translates a SQL query on global tables to the Trino SQL that would run on the physical tables, without running it. Use this to check that a query is valid, or when the user asks how a query is executed.",
+			Parameters: map[string]ToolParameter{
+				"query": {
+					Type:        "string",
+					Description: "SQL query using global table names. Example: 'SELECT name FROM clients WHERE country = 'USA''",
+				},
+			},
+			Required: []string{"query"},
+		},
+		run: explainQuery,
+	},
+	"executeGlobalQuery": {
+		declaration: ToolDeclaration{
+			Name:        "executeGlobalQuery",
+			Description: "Executes a SQL query on global tables. Use this when the user wants to retrieve, count, filter, or analyze data from global tables. The query should use global table names (e.g., 'SELECT * FROM customers'). This tool will automatically translate the global query to the appropriate physical tables and execute it.",
+			Parameters: map[string]ToolParameter{
+				"query": {
+					Type:        "string",
+					Description: "SQL query using global table names. Example: 'SELECT * FROM customers WHERE country = 'USA' LIMIT 10'. Supports SELECT, WHERE, JOIN, GROUP BY, ORDER BY, and LIMIT clauses.",
+				},
+			},
+			Required: []string{"query"},
+		},
+		run: executeGlobalQuery,
+	},
+	"discoverMetadata": {
+		declaration: ToolDeclaration{
+			Name:        "discoverMetadata",
+			Description: "Discovers metadata about data sources including catalogs, schemas, tables, and columns. Use this when the user asks about available data sources, table structures, or column information, or wants to query physical tables directly instead of global tables.",
+			Parameters: map[string]ToolParameter{
+				"level": {
+					Type:        "string",
+					Description: "Level of metadata to discover. Options: 'catalogs' (lists all data source catalogs), 'schemas' (lists schemas in a catalog), 'tables' (lists tables in a schema), 'columns' (lists columns in a table)",
+					Enum:        []string{"catalogs", "schemas", "tables", "columns"},
+				},
+				"catalog": {
+					Type:        "string",
+					Description: "Catalog name (required for schemas, tables, columns levels). Example: 'postgresql', 'mysql'",
+				},
+				"schema": {
+					Type:        "string",
+					Description: "Schema name (required for tables, columns levels). Example: 'public', 'testdb'",
+				},
+				"table": {
+					Type:        "string",
+					Description: "Table name (required for columns level). Example: 'customers', 'orders'",
+				},
+			},
+			Required: []string{"level"},
+		},
+		run: discoverMetadata,
+	},
+}
+
 // executeGlobalQuery executes a SQL query on global tables
-func (te *DefaultToolExecutor) executeGlobalQuery(args map[string]interface{}) (interface{}, error) {
+func executeGlobalQuery(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
 	query, ok := args["query"].(string)
 	if !ok {
 		return map[string]interface{}{
@@ -58,9 +256,9 @@ func (te *DefaultToolExecutor) executeGlobalQuery(args map[string]interface{}) (
 		}, nil
 	}
 
-	result, err := te.translator.TranslateAndExecute(query)
+	result, err := env.translator.TranslateAndExecute(ctx, query)
 	if err != nil {
-		// Return structured error that Gemini can explain to user
+		// Return structured error that the model can explain to user
 		return map[string]interface{}{
 			"error":      err.Error(),
 			"suggestion": "Check that the table names and column names are correct, and the SQL syntax is valid",
@@ -70,9 +268,69 @@ func (te *DefaultToolExecutor) executeGlobalQuery(args map[string]interface{}) (
 	return result, nil
 }
 
+// explainQuery translates a SQL query on global tables without running it
+func explainQuery(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
+	query, ok := args["query"].(string)
+	if !ok {
+		return map[string]interface{}{
+			"error":      "Invalid query parameter",
+			"suggestion": "Please provide a valid SQL query string",
+		}, nil
+	}
+
+	generatedSQL, err := env.translator.TranslateQuery(query)
+	if err != nil {
+		return map[string]interface{}{
+			"error":      err.Error(),
+			"suggestion": "Check that the table names and column names are correct, and the SQL syntax is valid",
+		}, nil
+	}
+
+	return map[string]interface{}{
+		"query":        query,
+		"generatedSQL": generatedSQL,
+	}, nil
+}
+
+// sampleRows fetches a bounded number of rows of a global table
+func sampleRows(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
+	tableName, ok := args["tableName"].(string)
+	if !ok {
+		return map[string]interface{}{
+			"error":      "Invalid tableName parameter",
+			"suggestion": "Please provide a valid global table name",
+		}, nil
+	}
+
+	// Numbers decode from JSON as float64
+	limit := defaultSampleRows
+	if value, ok := args["limit"].(float64); ok && value >= 1 {
+		limit = int(value)
+	}
+	if limit > maxSampleRows {
+		limit = maxSampleRows
+	}
+
+	result, err := env.translator.TranslateAndExecute(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT %d", query.FormatIdent(tableName), limit))
+	if err != nil {
+		return map[string]interface{}{
+			"error":      err.Error(),
+			"suggestion": "Check that the global table exists with listGlobalTables",
+		}, nil
+	}
+
+	return map[string]interface{}{
+		"tableName":    tableName,
+		"generatedSQL": result.GeneratedSQL,
+		"columns":      result.Columns,
+		"rows":         result.Rows,
+		"rowCount":     result.RowCount,
+	}, nil
+}
+
 // listGlobalTables lists all available global tables in the system
-func (te *DefaultToolExecutor) listGlobalTables(args map[string]interface{}) (interface{}, error) {
-	tables, err := te.storage.ListGlobalTables()
+func listGlobalTables(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
+	tables, err := env.storage.ListGlobalTables()
 	if err != nil {
 		return map[string]interface{}{
 			"error": err.Error(),
@@ -94,236 +352,144 @@ func (te *DefaultToolExecutor) listGlobalTables(args map[string]interface{}) (in
 	}, nil
 }
 
-// discoverMetadata discovers metadata about data sources
-func (te *DefaultToolExecutor) discoverMetadata(args map[string]interface{}) (interface{}, error) {
-	level, ok := args["level"].(string)
+// getTableColumns gets columns for a global table
+func getTableColumns(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
+	tableName, ok := args["tableName"].(string)
 	if !ok {
 		return map[string]interface{}{
-			"error":      "Invalid level parameter",
-			"suggestion": "Please specify one of: catalogs, schemas, tables, columns",
+			"error":      "Invalid tableName parameter",
+			"suggestion": "Please provide a valid global table name",
 		}, nil
 	}
 
-	switch level {
-	case "catalogs":
-		catalogs, err := te.discovery.DiscoverCatalogs()
-		if err != nil {
-			return map[string]interface{}{
-				"error": err.Error(),
-			}, nil
-		}
+	columns, err := env.storage.ListGlobalColumns(tableName)
+	if err != nil {
 		return map[string]interface{}{
-			"level":    "catalogs",
-			"catalogs": catalogs,
+			"error": err.Error(),
 		}, nil
+	}
 
-	case "schemas":
-		catalog, ok := args["catalog"].(string)
-		if !ok {
-			return map[string]interface{}{
-				"error":      "Missing catalog parameter",
-				"suggestion": "Please specify a catalog name",
-			}, nil
-		}
-		schemas, err := te.discovery.DiscoverSchemas(catalog)
-		if err != nil {
-			return map[string]interface{}{
-				"error": err.Error(),
-			}, nil
+	columnList := make([]map[string]string, len(columns))
+	for i, col := range columns {
+		columnList[i] = map[string]string{
+			"name":        col.Name,
+			"type":        col.DataType,
+			"description": col.Description,
 		}
-		return map[string]interface{}{
-			"level":   "schemas",
-			"catalog": catalog,
-			"schemas": schemas,
-		}, nil
+	}
 
-	case "tables":
-		catalog, catalogOk := args["catalog"].(string)
-		schema, schemaOk := args["schema"].(string)
-		if !catalogOk || !schemaOk {
-			return map[string]interface{}{
-				"error":      "Missing catalog or schema parameter",
-				"suggestion": "Please specify both catalog and schema names",
-			}, nil
-		}
-		tables, err := te.discovery.DiscoverTables(catalog, schema)
-		if err != nil {
-			return map[string]interface{}{
-				"error": err.Error(),
-			}, nil
-		}
+	return map[string]interface{}{
+		"tableName": tableName,
+		"columns":   columnList,
+		"count":     len(columns),
+	}, nil
+}
+
+// describeGlobalTable describes a global table with its columns, mappings and column relationships
+func describeGlobalTable(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
+	tableName, ok := args["tableName"].(string)
+	if !ok {
 		return map[string]interface{}{
-			"level":   "tables",
-			"catalog": catalog,
-			"schema":  schema,
-			"tables":  tables,
+			"error":      "Invalid tableName parameter",
+			"suggestion": "Please provide a valid global table name",
 		}, nil
+	}
 
-	case "columns":
-		catalog, catalogOk := args["catalog"].(string)
-		schema, schemaOk := args["schema"].(string)
-		table, tableOk := args["table"].(string)
-		if !catalogOk || !schemaOk || !tableOk {
-			return map[string]interface{}{
-				"error":      "Missing catalog, schema, or table parameter",
-				"suggestion": "Please specify catalog, schema, and table names",
-			}, nil
-		}
-		columns, err := te.discovery.DiscoverColumns(catalog, schema, table)
-		if err != nil {
-			return map[string]interface{}{
-				"error": err.Error(),
-			}, nil
-		}
+	table, err := env.storage.GetGlobalTable(tableName)
+	if err != nil {
 		return map[string]interface{}{
-			"level":   "columns",
-			"catalog": catalog,
-			"schema":  schema,
-			"table":   table,
-			"columns": columns,
+			"error":      err.Error(),
+			"suggestion": "Check that the global table exists with listGlobalTables",
 		}, nil
+	}
 
-	default:
+	description, err := describeTable(env.storage, table)
+	if err != nil {
 		return map[string]interface{}{
-			"error":      fmt.Sprintf("Invalid level: %s", level),
-			"suggestion": "Please use one of: catalogs, schemas, tables, columns",
+			"error": err.Error(),
 		}, nil
 	}
+	return description, nil
 }
 
-// BuildToolDeclarations returns the tool declarations for Gemini function calling
-func BuildToolDeclarations() []*genai.Tool {
-	return []*genai.Tool{
-		{
-			FunctionDeclarations: []*genai.FunctionDeclaration{
-				buildListGlobalTablesTool(),
-				buildExecuteGlobalQueryTool(),
-				buildDiscoverMetadataTool(),
-			},
-		},
+// describeTable gathers the columns, mappings and column relationships of a global table
+func describeTable(storage ToolStorage, table *models.GlobalTable) (map[string]interface{}, error) {
+	columns, err := storage.ListGlobalColumns(table.Name)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// buildListGlobalTablesTool creates the tool declaration for listing global tables
-func buildListGlobalTablesTool() *genai.FunctionDeclaration {
-	return &genai.FunctionDeclaration{
-		Name:        "listGlobalTables",
-		Description: "Lists all available global tables in the system. Use this tool when the user asks 'what tables do I have?', 'show me available tables', or when you need to know what tables exist before querying. This is the FIRST tool you should use when unsure what data is available.",
-		Parameters: &genai.Schema{
-			Type:       genai.TypeObject,
-			Properties: map[string]*genai.Schema{},
-		},
+	columnList := make([]map[string]interface{}, len(columns))
+	for i, col := range columns {
+		columnMappings, err := storage.ListColumnMappings(table.Name, col.Name)
+		if err != nil {
+			return nil, err
+		}
+		mappedTo := make([]string, len(columnMappings))
+		for j, mapping := range columnMappings {
+			mappedTo[j] = fmt.Sprintf("%s.%s.%s.%s", mapping.CatalogName, mapping.SchemaName, mapping.TableName, mapping.ColumnName)
+		}
+		columnList[i] = map[string]interface{}{
+			"name":        col.Name,
+			"type":        col.DataType,
+			"description": col.Description,
+			"mappedTo":    mappedTo,
+		}
 	}
-}
 
-// buildExecuteGlobalQueryTool creates the tool declaration for executing queries
-func buildExecuteGlobalQueryTool() *genai.FunctionDeclaration {
-	return &genai.FunctionDeclaration{
-		Name:        "executeGlobalQuery",
-		Description: "Executes a SQL query on global tables. Use this when the user wants to retrieve, count, filter, or analyze data from global tables. The query should use global table names (e.g., 'SELECT * FROM customers'). This tool will automatically translate the global query to the appropriate physical tables and execute it.",
-		Parameters: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"query": {
-					Type:        genai.TypeString,
-					Description: "SQL query using global table names. Example: 'SELECT * FROM customers WHERE country = 'USA' LIMIT 10'. Supports SELECT, WHERE, JOIN, GROUP BY, ORDER BY, and LIMIT clauses.",
-				},
-			},
-			Required: []string{"query"},
-		},
+	tableMappings, err := storage.ListTableMappings(table.Name)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// buildDiscoverMetadataTool creates the tool declaration for discovering metadata
-func buildDiscoverMetadataTool() *genai.FunctionDeclaration {
-	return &genai.FunctionDeclaration{
-		Name:        "discoverMetadata",
-		Description: "Discovers metadata about data sources including catalogs, schemas, tables, and columns. Use this when the user asks about available data sources, table structures, or column information. This helps users understand what data they can query.",
-		Parameters: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"level": {
-					Type:        genai.TypeString,
-					Description: "Level of metadata to discover. Options: 'catalogs' (lists all data source catalogs), 'schemas' (lists schemas in a catalog), 'tables' (lists tables in a schema), 'columns' (lists columns in a table)",
-					Enum:        []string{"catalogs", "schemas", "tables", "columns"},
-				},
-				"catalog": {
-					Type:        genai.TypeString,
-					Description: "Catalog name (required for schemas, tables, columns levels). Example: 'postgresql', 'mysql'",
-				},
-				"schema": {
-					Type:        genai.TypeString,
-					Description: "Schema name (required for tables, columns levels). Example: 'public', 'testdb'",
-				},
-				"table": {
-					Type:        genai.TypeString,
-					Description: "Table name (required for columns level). Example: 'customers', 'orders'",
-				},
-			},
-			Required: []string{"level"},
-		},
+	mappings := make([]string, len(tableMappings))
+	for i, mapping := range tableMappings {
+		mappings[i] = fmt.Sprintf("%s.%s.%s", mapping.CatalogName, mapping.SchemaName, mapping.TableName)
 	}
-}
 
-// QueryGeneratorToolExecutor implements ToolExecutor for query generation only (no execution)
-type QueryGeneratorToolExecutor struct {
-	discovery discovery.MetadataDiscovery
-	storage   interface {
-		ListGlobalTables() ([]*models.GlobalTable, error)
-		ListGlobalColumns(globalTableName string) ([]*models.GlobalColumn, error)
+	relationships, err := storage.ListColumnRelationships(table.Name)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// NewQueryGeneratorToolExecutor creates a tool executor for query generation
-func NewQueryGeneratorToolExecutor(discovery discovery.MetadataDiscovery, storage interface {
-	ListGlobalTables() ([]*models.GlobalTable, error)
-	ListGlobalColumns(globalTableName string) ([]*models.GlobalColumn, error)
-}) ToolExecutor {
-	return &QueryGeneratorToolExecutor{
-		discovery: discovery,
-		storage:   storage,
+	relationshipList := make([]map[string]string, len(relationships))
+	for i, rel := range relationships {
+		relationshipList[i] = map[string]string{
+			"name":         rel.RelationshipName,
+			"description":  rel.Description,
+			"sourceColumn": rel.SourceGlobalTableName + "." + rel.SourceGlobalColumnName,
+			"targetColumn": rel.TargetGlobalTableName + "." + rel.TargetGlobalColumnName,
+		}
 	}
-}
 
-// ExecuteTool routes tool calls for query generation
-func (te *QueryGeneratorToolExecutor) ExecuteTool(toolName string, arguments map[string]interface{}) (interface{}, error) {
-	switch toolName {
-	case "listGlobalTables":
-		return te.listGlobalTables(arguments)
-	case "discoverMetadata":
-		return te.discoverMetadata(arguments)
-	case "getTableColumns":
-		return te.getTableColumns(arguments)
-	default:
-		return nil, fmt.Errorf("unknown tool: %s", toolName)
+	description := map[string]interface{}{
+		"tableName":     table.Name,
+		"description":   table.Description,
+		"columns":       columnList,
+		"mappings":      mappings,
+		"relationships": relationshipList,
+	}
+	if table.Degraded {
+		description["degraded"] = true
+		description["warning"] = "A mapping or relation of this table points at physical metadata that no longer exists or has changed type, so queries on it may fail"
 	}
+	return description, nil
 }
 
-// listGlobalTables - same as DefaultToolExecutor
-func (te *QueryGeneratorToolExecutor) listGlobalTables(args map[string]interface{}) (interface{}, error) {
-	tables, err := te.storage.ListGlobalTables()
+// listTableRelations lists the JOIN and UNION relations between tables
+func listTableRelations(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
+	relations, err := env.storage.ListTableRelations()
 	if err != nil {
 		return map[string]interface{}{
 			"error": err.Error(),
 		}, nil
 	}
 
-	tableList := make([]map[string]string, len(tables))
-	for i, table := range tables {
-		tableList[i] = map[string]string{
-			"name":        table.Name,
-			"description": table.Description,
-		}
-	}
-
 	return map[string]interface{}{
-		"tables": tableList,
-		"count":  len(tables),
+		"relations": relations,
+		"count":     len(relations),
 	}, nil
 }
 
-// discoverMetadata - same as DefaultToolExecutor
-func (te *QueryGeneratorToolExecutor) discoverMetadata(args map[string]interface{}) (interface{}, error) {
+// discoverMetadata discovers metadata about data sources
+func discoverMetadata(ctx context.Context, env *toolEnv, args map[string]interface{}) (interface{}, error) {
 	level, ok := args["level"].(string)
 	if !ok {
 		return map[string]interface{}{
@@ -334,7 +500,7 @@ func (te *QueryGeneratorToolExecutor) discoverMetadata(args map[string]interface
 
 	switch level {
 	case "catalogs":
-		catalogs, err := te.discovery.DiscoverCatalogs()
+		catalogs, err := env.discovery.DiscoverCatalogs(ctx)
 		if err != nil {
 			return map[string]interface{}{
 				"error": err.Error(),
@@ -353,7 +519,7 @@ func (te *QueryGeneratorToolExecutor) discoverMetadata(args map[string]interface
 				"suggestion": "Please specify a catalog name",
 			}, nil
 		}
-		schemas, err := te.discovery.DiscoverSchemas(catalog)
+		schemas, err := env.discovery.DiscoverSchemas(ctx, catalog)
 		if err != nil {
 			return map[string]interface{}{
 				"error": err.Error(),
@@ -374,7 +540,7 @@ func (te *QueryGeneratorToolExecutor) discoverMetadata(args map[string]interface
 				"suggestion": "Please specify both catalog and schema names",
 			}, nil
 		}
-		tables, err := te.discovery.DiscoverTables(catalog, schema)
+		tables, err := env.discovery.DiscoverTables(ctx, catalog, schema)
 		if err != nil {
 			return map[string]interface{}{
 				"error": err.Error(),
@@ -397,7 +563,7 @@ func (te *QueryGeneratorToolExecutor) discoverMetadata(args map[string]interface
 				"suggestion": "Please specify catalog, schema, and table names",
 			}, nil
 		}
-		columns, err := te.discovery.DiscoverColumns(catalog, schema, table)
+		columns, err := env.discovery.DiscoverColumns(ctx, catalog, schema, table)
 		if err != nil {
 			return map[string]interface{}{
 				"error": err.Error(),
@@ -418,110 +584,3 @@ func (te *QueryGeneratorToolExecutor) discoverMetadata(args map[string]interface
 		}, nil
 	}
 }
-
-// getTableColumns gets columns for a global table
-func (te *QueryGeneratorToolExecutor) getTableColumns(args map[string]interface{}) (interface{}, error) {
-	tableName, ok := args["tableName"].(string)
-	if !ok {
-		return map[string]interface{}{
-			"error":      "Invalid tableName parameter",
-			"suggestion": "Please provide a valid global table name",
-		}, nil
-	}
-
-	columns, err := te.storage.ListGlobalColumns(tableName)
-	if err != nil {
-		return map[string]interface{}{
-			"error": err.Error(),
-		}, nil
-	}
-
-	columnList := make([]map[string]string, len(columns))
-	for i, col := range columns {
-		columnList[i] = map[string]string{
-			"name":        col.Name,
-			"type":        col.DataType,
-			"description": col.Description,
-		}
-	}
-
-	return map[string]interface{}{
-		"tableName": tableName,
-		"columns":   columnList,
-		"count":     len(columns),
-	}, nil
-}
-
-// BuildQueryGeneratorToolDeclarations returns tool declarations for query generation
-func BuildQueryGeneratorToolDeclarations() []*genai.Tool {
-	return []*genai.Tool{
-		{
-			FunctionDeclarations: []*genai.FunctionDeclaration{
-				buildListGlobalTablesToolForGenerator(),
-				buildGetTableColumnsTool(),
-				buildDiscoverMetadataToolForGenerator(),
-			},
-		},
-	}
-}
-
-// buildListGlobalTablesToolForGenerator - optimized for query generation
-func buildListGlobalTablesToolForGenerator() *genai.FunctionDeclaration {
-	return &genai.FunctionDeclaration{
-		Name:        "listGlobalTables",
-		Description: "Lists all available global tables in the system. Use this to understand what tables the user can query. Always call this first to see what data is available.",
-		Parameters: &genai.Schema{
-			Type:       genai.TypeObject,
-			Properties: map[string]*genai.Schema{},
-		},
-	}
-}
-
-// buildGetTableColumnsTool - new tool for getting columns of a global table
-func buildGetTableColumnsTool() *genai.FunctionDeclaration {
-	return &genai.FunctionDeclaration{
-		Name:        "getTableColumns",
-		Description: "Gets the columns for a specific global table. Use this to understand what columns are available for a table before generating a query. This helps you create accurate SELECT statements and WHERE clauses.",
-		Parameters: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"tableName": {
-					Type:        genai.TypeString,
-					Description: "The name of the global table to get columns for. Example: 'clients', 'orders'",
-				},
-			},
-			Required: []string{"tableName"},
-		},
-	}
-}
-
-// buildDiscoverMetadataToolForGenerator - optimized description for query generation
-func buildDiscoverMetadataToolForGenerator() *genai.FunctionDeclaration {
-	return &genai.FunctionDeclaration{
-		Name:        "discoverMetadata",
-		Description: "Discovers metadata about physical data sources. Use this when the user asks about the underlying database structure or wants to query physical tables directly instead of global tables.",
-		Parameters: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"level": {
-					Type:        genai.TypeString,
-					Description: "Level of metadata to discover: 'catalogs', 'schemas', 'tables', or 'columns'",
-					Enum:        []string{"catalogs", "schemas", "tables", "columns"},
-				},
-				"catalog": {
-					Type:        genai.TypeString,
-					Description: "Catalog name (required for schemas, tables, columns levels)",
-				},
-				"schema": {
-					Type:        genai.TypeString,
-					Description: "Schema name (required for tables, columns levels)",
-				},
-				"table": {
-					Type:        genai.TypeString,
-					Description: "Table name (required for columns level)",
-				},
-			},
-			Required: []string{"level"},
-		},
-	}
-}
@@ -0,0 +1,129 @@
+package chatbot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/query"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// recordingTranslator records the queries it is asked to translate or run
+type recordingTranslator struct {
+	queries []string
+}
+
+func (t *recordingTranslator) Translate(globalQuery string) (string, error) {
+	return t.TranslateQuery(globalQuery)
+}
+
+func (t *recordingTranslator) TranslateQuery(globalQuery string) (string, error) {
+	t.queries = append(t.queries, globalQuery)
+	return "SELECT * FROM pg.public.clients", nil
+}
+
+func (t *recordingTranslator) Validate(ctx context.Context, globalQuery string) (*query.Validation, error) {
+	generatedSQL, err := t.TranslateQuery(globalQuery)
+	return &query.Validation{GeneratedSQL: generatedSQL}, err
+}
+
+func (t *recordingTranslator) TranslateAndExecute(ctx context.Context, globalQuery string) (*query.QueryResult, error) {
+	t.queries = append(t.queries, globalQuery)
+	return &query.QueryResult{Rows: []map[string]interface{}{{"id": 1}}, RowCount: 1}, nil
+}
+
+func (t *recordingTranslator) TranslateAndStream(ctx context.Context, globalQuery string, params *datasync.Params) (*query.QueryStream, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func TestToolDeclarations_ComeFromTheRegistry(t *testing.T) {
+	for _, declarations := range [][]ToolDeclaration{BuildToolDeclarations(), BuildQueryGeneratorToolDeclarations()} {
+		for _, declaration := range declarations {
+			if toolRegistry[declaration.Name].declaration.Name != declaration.Name {
+				t.Fatalf("Expected %s to be declared in the registry", declaration.Name)
+			}
+		}
+	}
+
+	executor := NewQueryGeneratorToolExecutor(nil, storage.NewMemoryMetadataStorage())
+	for _, name := range []string{"executeGlobalQuery", "sampleRows", "explainQuery"} {
+		if _, err := executor.ExecuteTool(context.Background(), name, map[string]interface{}{"query": "SELECT 1", "tableName": "clients"}); err == nil {
+			t.Fatalf("Expected the query generator not to offer %s", name)
+		}
+	}
+}
+
+func TestDescribeGlobalTable(t *testing.T) {
+	store := storage.NewMemoryMetadataStorage()
+	store.CreateGlobalTable(&models.GlobalTable{Name: "clients", Description: "Our clients"})
+	store.CreateGlobalTable(&models.GlobalTable{Name: "orders"})
+	store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "clients", Name: "id", DataType: "integer"})
+	store.CreateTableMapping(&models.TableMapping{GlobalTableName: "clients", CatalogName: "pg", SchemaName: "public", TableName: "customers"})
+	store.CreateColumnMapping(&models.ColumnMapping{GlobalTableName: "clients", GlobalColumnName: "id", CatalogName: "pg", SchemaName: "public", TableName: "customers", ColumnName: "customer_id"})
+	store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "orders", Name: "client_id", DataType: "integer"})
+	if err := store.CreateColumnRelationship(&models.ColumnRelationship{
+		SourceGlobalTableName: "orders", SourceGlobalColumnName: "client_id",
+		TargetGlobalTableName: "clients", TargetGlobalColumnName: "id",
+	}); err != nil {
+		t.Fatalf("CreateColumnRelationship failed: %v", err)
+	}
+
+	executor := NewToolExecutor(&recordingTranslator{}, nil, store)
+	result, err := executor.ExecuteTool(context.Background(), "describeGlobalTable", map[string]interface{}{"tableName": "clients"})
+	if err != nil {
+		t.Fatalf("describeGlobalTable failed: %v", err)
+	}
+	description := result.(map[string]interface{})
+
+	columns := description["columns"].([]map[string]interface{})
+	if len(columns) != 1 || columns[0]["mappedTo"].([]string)[0] != "pg.public.customers.customer_id" {
+		t.Fatalf("Expected the column with its mapping, got %v", columns)
+	}
+	if mappings := description["mappings"].([]string); len(mappings) != 1 || mappings[0] != "pg.public.customers" {
+		t.Fatalf("Expected the table mapping, got %v", mappings)
+	}
+	relationships := description["relationships"].([]map[string]string)
+	if len(relationships) != 1 || relationships[0]["sourceColumn"] != "orders.client_id" || relationships[0]["targetColumn"] != "clients.id" {
+		t.Fatalf("Expected the relationship to orders, got %v", relationships)
+	}
+
+	result, _ = executor.ExecuteTool(context.Background(), "describeGlobalTable", map[string]interface{}{"tableName": "invoices"})
+	if _, ok := result.(map[string]interface{})["error"]; !ok {
+		t.Fatalf("Expected an error for an unknown table, got %v", result)
+	}
+}
+
+func TestSampleRows_BoundsTheLimit(t *testing.T) {
+	translator := &recordingTranslator{}
+	executor := NewToolExecutor(translator, nil, storage.NewMemoryMetadataStorage())
+
+	for _, args := range []map[string]interface{}{
+		{"tableName": "clients"},
+		{"tableName": "order", "limit": float64(500)},
+	} {
+		if _, err := executor.ExecuteTool(context.Background(), "sampleRows", args); err != nil {
+			t.Fatalf("sampleRows failed: %v", err)
+		}
+	}
+
+	expected := []string{"SELECT * FROM clients LIMIT 10", `SELECT * FROM "order" LIMIT 50`}
+	if len(translator.queries) != 2 || translator.queries[0] != expected[0] || translator.queries[1] != expected[1] {
+		t.Fatalf("Expected %q, got %q", expected, translator.queries)
+	}
+}
+
+func TestExplainQuery_DoesNotRunTheQuery(t *testing.T) {
+	translator := &recordingTranslator{}
+	executor := NewToolExecutor(translator, nil, storage.NewMemoryMetadataStorage())
+
+	result, err := executor.ExecuteTool(context.Background(), "explainQuery", map[string]interface{}{"query": "SELECT * FROM clients"})
+	if err != nil {
+		t.Fatalf("explainQuery failed: %v", err)
+	}
+	if generated := result.(map[string]interface{})["generatedSQL"]; generated != "SELECT * FROM pg.public.clients" {
+		t.Fatalf("Expected the translated SQL, got %v", generated)
+	}
+}
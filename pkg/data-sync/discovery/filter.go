@@ -0,0 +1,212 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// ErrExcluded is returned when discovering inside a catalog, schema or table the filter excludes
+var ErrExcluded = errors.New("excluded by the discovery filter")
+
+// Pattern matches metadata names. A pattern written as /.../ is a regular expression, anything
+// else is a glob such as "tmp_*". Patterns are matched against the bare name and against the
+// qualified name, so "information_schema" and "pg.information_schema" both match that schema.
+type Pattern struct {
+	text string
+	glob string
+	re   *regexp.Regexp
+}
+
+// ParsePattern parses a glob or /regex/ pattern
+func ParsePattern(text string) (Pattern, error) {
+	if len(text) >= 2 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/") {
+		re, err := regexp.Compile(text[1 : len(text)-1])
+		if err != nil {
+			return Pattern{}, fmt.Errorf("invalid pattern '%s': %w", text, err)
+		}
+		return Pattern{text: text, re: re}, nil
+	}
+	if _, err := path.Match(text, ""); err != nil {
+		return Pattern{}, fmt.Errorf("invalid pattern '%s': %w", text, err)
+	}
+	return Pattern{text: text, glob: text}, nil
+}
+
+// ParsePatterns parses a comma-separated list of patterns, ignoring empty entries
+func ParsePatterns(list string) ([]Pattern, error) {
+	var patterns []Pattern
+	for _, text := range strings.Split(list, ",") {
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		pattern, err := ParsePattern(text)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func (p Pattern) String() string {
+	return p.text
+}
+
+// Match reports whether the pattern matches any of the given names
+func (p Pattern) Match(names ...string) bool {
+	for _, name := range names {
+		if p.re != nil {
+			if p.re.MatchString(name) {
+				return true
+			}
+		} else if matched, _ := path.Match(p.glob, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule includes and excludes names at one level. An empty include list includes everything;
+// an exclude always wins over an include.
+type Rule struct {
+	Include []Pattern
+	Exclude []Pattern
+}
+
+func (r Rule) allows(names ...string) bool {
+	for _, pattern := range r.Exclude {
+		if pattern.Match(names...) {
+			return false
+		}
+	}
+	if len(r.Include) == 0 {
+		return true
+	}
+	for _, pattern := range r.Include {
+		if pattern.Match(names...) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter decides which catalogs, schemas and tables are discovered
+type Filter struct {
+	Catalogs Rule
+	Schemas  Rule
+	Tables   Rule
+}
+
+// AllowsCatalog reports whether a catalog is discovered
+func (f Filter) AllowsCatalog(catalogName string) bool {
+	return f.Catalogs.allows(catalogName)
+}
+
+// AllowsSchema reports whether a schema, and its catalog, are discovered
+func (f Filter) AllowsSchema(catalogName, schemaName string) bool {
+	return f.AllowsCatalog(catalogName) && f.Schemas.allows(schemaName, catalogName+"."+schemaName)
+}
+
+// AllowsTable reports whether a table, its schema and its catalog are discovered
+func (f Filter) AllowsTable(catalogName, schemaName, tableName string) bool {
+	return f.AllowsSchema(catalogName, schemaName) &&
+		f.Tables.allows(tableName, schemaName+"."+tableName, catalogName+"."+schemaName+"."+tableName)
+}
+
+type filteredDiscovery struct {
+	discovery MetadataDiscovery
+	filter    Filter
+}
+
+// NewFilteredDiscovery hides the catalogs, schemas and tables the filter excludes from a
+// discovery. Discovering inside an excluded one returns ErrExcluded.
+func NewFilteredDiscovery(discovery MetadataDiscovery, filter Filter) MetadataDiscovery {
+	filtered := &filteredDiscovery{discovery: discovery, filter: filter}
+	if _, ok := discovery.(CatalogColumnDiscovery); ok {
+		return &filteredCatalogColumnDiscovery{filtered}
+	}
+	return filtered
+}
+
+func (d *filteredDiscovery) DiscoverCatalogs(ctx context.Context) ([]*models.Catalog, error) {
+	catalogs, err := d.discovery.DiscoverCatalogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]*models.Catalog, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		if d.filter.AllowsCatalog(catalog.Name) {
+			allowed = append(allowed, catalog)
+		}
+	}
+	return allowed, nil
+}
+
+func (d *filteredDiscovery) DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error) {
+	if !d.filter.AllowsCatalog(catalogName) {
+		return nil, fmt.Errorf("catalog '%s' is %w", catalogName, ErrExcluded)
+	}
+	schemas, err := d.discovery.DiscoverSchemas(ctx, catalogName)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]*models.Schema, 0, len(schemas))
+	for _, schema := range schemas {
+		if d.filter.AllowsSchema(catalogName, schema.Name) {
+			allowed = append(allowed, schema)
+		}
+	}
+	return allowed, nil
+}
+
+func (d *filteredDiscovery) DiscoverTables(ctx context.Context, catalogName, schemaName string) ([]*models.Table, error) {
+	if !d.filter.AllowsSchema(catalogName, schemaName) {
+		return nil, fmt.Errorf("schema '%s.%s' is %w", catalogName, schemaName, ErrExcluded)
+	}
+	tables, err := d.discovery.DiscoverTables(ctx, catalogName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]*models.Table, 0, len(tables))
+	for _, table := range tables {
+		if d.filter.AllowsTable(catalogName, schemaName, table.Name) {
+			allowed = append(allowed, table)
+		}
+	}
+	return allowed, nil
+}
+
+func (d *filteredDiscovery) DiscoverColumns(ctx context.Context, catalogName, schemaName, tableName string) ([]*models.Column, error) {
+	if !d.filter.AllowsTable(catalogName, schemaName, tableName) {
+		return nil, fmt.Errorf("table '%s.%s.%s' is %w", catalogName, schemaName, tableName, ErrExcluded)
+	}
+	return d.discovery.DiscoverColumns(ctx, catalogName, schemaName, tableName)
+}
+
+// filteredCatalogColumnDiscovery keeps the batched column query of the discovery it filters
+type filteredCatalogColumnDiscovery struct {
+	*filteredDiscovery
+}
+
+func (d *filteredCatalogColumnDiscovery) DiscoverCatalogColumns(ctx context.Context, catalogName string) ([]*models.Column, error) {
+	if !d.filter.AllowsCatalog(catalogName) {
+		return nil, fmt.Errorf("catalog '%s' is %w", catalogName, ErrExcluded)
+	}
+	columns, err := d.discovery.(CatalogColumnDiscovery).DiscoverCatalogColumns(ctx, catalogName)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]*models.Column, 0, len(columns))
+	for _, column := range columns {
+		if d.filter.AllowsTable(catalogName, column.SchemaName, column.TableName) {
+			allowed = append(allowed, column)
+		}
+	}
+	return allowed, nil
+}
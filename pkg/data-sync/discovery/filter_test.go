@@ -0,0 +1,119 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func mustPatterns(t *testing.T, list string) []Pattern {
+	t.Helper()
+	patterns, err := ParsePatterns(list)
+	if err != nil {
+		t.Fatalf("ParsePatterns failed: %v", err)
+	}
+	return patterns
+}
+
+func TestPattern_GlobAndRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"tmp_*", "tmp_orders", true},
+		{"tmp_*", "orders", false},
+		{"pg.information_schema", "pg.information_schema", true},
+		{"/^test_[0-9]+$/", "test_42", true},
+		{"/^test_[0-9]+$/", "test_x", false},
+	}
+
+	for _, tt := range tests {
+		pattern, err := ParsePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParsePattern(%q) failed: %v", tt.pattern, err)
+		}
+		if got := pattern.Match(tt.name); got != tt.match {
+			t.Errorf("Expected %q matching %q to be %v, got %v", tt.pattern, tt.name, tt.match, got)
+		}
+	}
+
+	if _, err := ParsePattern("/[/"); err == nil {
+		t.Error("Expected an error for an invalid regex")
+	}
+	if _, err := ParsePattern("[a-"); err == nil {
+		t.Error("Expected an error for an invalid glob")
+	}
+}
+
+func TestFilteredDiscovery(t *testing.T) {
+	inner := &treeDiscovery{
+		tables: map[string][]string{
+			"pg.public.customers":             {"id"},
+			"pg.public.tmp_import":            {"id"},
+			"pg.information_schema.columns":   {"column_name"},
+			"system.runtime.nodes":            {"node_id"},
+			"mysql.shop.items":                {"sku"},
+			"mysql.information_schema.tables": {"table_name"},
+		},
+		batched: map[string]bool{"pg": true, "mysql": true},
+	}
+	filter := Filter{
+		Catalogs: Rule{Exclude: mustPatterns(t, "system")},
+		Schemas:  Rule{Exclude: mustPatterns(t, "information_schema")},
+		Tables:   Rule{Exclude: mustPatterns(t, "/^tmp_/")},
+	}
+	discovery := NewFilteredDiscovery(inner, filter)
+	ctx := context.Background()
+
+	catalogs, err := discovery.DiscoverCatalogs(ctx)
+	if err != nil {
+		t.Fatalf("DiscoverCatalogs failed: %v", err)
+	}
+	if len(catalogs) != 2 || catalogs[0].Name != "mysql" || catalogs[1].Name != "pg" {
+		t.Fatalf("Expected catalogs mysql and pg, got %v", catalogs)
+	}
+
+	schemas, err := discovery.DiscoverSchemas(ctx, "pg")
+	if err != nil || len(schemas) != 1 || schemas[0].Name != "public" {
+		t.Fatalf("Expected only schema public, got %v, %v", schemas, err)
+	}
+
+	tables, err := discovery.DiscoverTables(ctx, "pg", "public")
+	if err != nil || len(tables) != 1 || tables[0].Name != "customers" {
+		t.Fatalf("Expected only table customers, got %v, %v", tables, err)
+	}
+
+	if _, err := discovery.DiscoverSchemas(ctx, "system"); !errors.Is(err, ErrExcluded) {
+		t.Errorf("Expected ErrExcluded for catalog system, got %v", err)
+	}
+	if _, err := discovery.DiscoverColumns(ctx, "pg", "public", "tmp_import"); !errors.Is(err, ErrExcluded) {
+		t.Errorf("Expected ErrExcluded for table tmp_import, got %v", err)
+	}
+
+	// Batched columns are filtered too, so a walk only sees allowed tables
+	columns, err := discovery.(CatalogColumnDiscovery).DiscoverCatalogColumns(ctx, "pg")
+	if err != nil || len(columns) != 1 || columns[0].TableName != "customers" {
+		t.Fatalf("Expected only the columns of customers, got %v, %v", columns, err)
+	}
+}
+
+func TestFilter_Include(t *testing.T) {
+	filter := Filter{
+		Catalogs: Rule{Include: mustPatterns(t, "pg, mysql")},
+		Schemas:  Rule{Include: mustPatterns(t, "pg.public, shop"), Exclude: mustPatterns(t, "mysql.shop")},
+	}
+
+	if filter.AllowsCatalog("mongo") {
+		t.Error("Expected catalog mongo not to be included")
+	}
+	if !filter.AllowsSchema("pg", "public") || filter.AllowsSchema("pg", "sales") {
+		t.Error("Expected only pg.public to be included in pg")
+	}
+	if filter.AllowsSchema("mysql", "shop") {
+		t.Error("Expected the exclude to win over the include for mysql.shop")
+	}
+	if !filter.AllowsTable("pg", "public", "customers") {
+		t.Error("Expected tables to be included when no table rule is set")
+	}
+}
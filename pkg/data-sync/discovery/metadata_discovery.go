@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
 
 	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
@@ -9,10 +10,16 @@ import (
 
 // MetadataDiscovery discovers catalogs, schemas, tables, and columns from Trino
 type MetadataDiscovery interface {
-	DiscoverCatalogs() ([]*models.Catalog, error)
-	DiscoverSchemas(catalogName string) ([]*models.Schema, error)
-	DiscoverTables(catalogName, schemaName string) ([]*models.Table, error)
-	DiscoverColumns(catalogName, schemaName, tableName string) ([]*models.Column, error)
+	DiscoverCatalogs(ctx context.Context) ([]*models.Catalog, error)
+	DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error)
+	DiscoverTables(ctx context.Context, catalogName, schemaName string) ([]*models.Table, error)
+	DiscoverColumns(ctx context.Context, catalogName, schemaName, tableName string) ([]*models.Column, error)
+}
+
+// CatalogColumnDiscovery is implemented by discoveries that can list the columns of every
+// table in a catalog with a single query
+type CatalogColumnDiscovery interface {
+	DiscoverCatalogColumns(ctx context.Context, catalogName string) ([]*models.Column, error)
 }
 
 type trinoMetadataDiscovery struct {
@@ -25,8 +32,8 @@ func NewTrinoMetadataDiscovery(engine datasync.QueryEngine) MetadataDiscovery {
 	}
 }
 
-func (d *trinoMetadataDiscovery) DiscoverCatalogs() ([]*models.Catalog, error) {
-	result, err := d.engine.ExecuteQuery("SHOW CATALOGS", nil)
+func (d *trinoMetadataDiscovery) DiscoverCatalogs(ctx context.Context) ([]*models.Catalog, error) {
+	result, err := d.engine.ExecuteQueryContext(ctx, "SHOW CATALOGS", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover catalogs: %w", err)
 	}
@@ -45,9 +52,13 @@ func (d *trinoMetadataDiscovery) DiscoverCatalogs() ([]*models.Catalog, error) {
 	return catalogs, nil
 }
 
-func (d *trinoMetadataDiscovery) DiscoverSchemas(catalogName string) ([]*models.Schema, error) {
-	query := fmt.Sprintf("SHOW SCHEMAS FROM %s", catalogName)
-	result, err := d.engine.ExecuteQuery(query, nil)
+func (d *trinoMetadataDiscovery) DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error) {
+	name, err := datasync.QualifiedName(catalogName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover schemas for catalog %s: %w", catalogName, err)
+	}
+	query := "SHOW SCHEMAS FROM " + name
+	result, err := d.engine.ExecuteQueryContext(ctx, query, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover schemas for catalog %s: %w", catalogName, err)
 	}
@@ -67,9 +78,13 @@ func (d *trinoMetadataDiscovery) DiscoverSchemas(catalogName string) ([]*models.
 	return schemas, nil
 }
 
-func (d *trinoMetadataDiscovery) DiscoverTables(catalogName, schemaName string) ([]*models.Table, error) {
-	query := fmt.Sprintf("SHOW TABLES FROM %s.%s", catalogName, schemaName)
-	result, err := d.engine.ExecuteQuery(query, nil)
+func (d *trinoMetadataDiscovery) DiscoverTables(ctx context.Context, catalogName, schemaName string) ([]*models.Table, error) {
+	name, err := datasync.QualifiedName(catalogName, schemaName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover tables for catalog %s and schema %s: %w", catalogName, schemaName, err)
+	}
+	query := "SHOW TABLES FROM " + name
+	result, err := d.engine.ExecuteQueryContext(ctx, query, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover tables for catalog %s and schema %s: %w", catalogName, schemaName, err)
 	}
@@ -89,9 +104,13 @@ func (d *trinoMetadataDiscovery) DiscoverTables(catalogName, schemaName string)
 	return tables, nil
 }
 
-func (d *trinoMetadataDiscovery) DiscoverColumns(catalogName, schemaName, tableName string) ([]*models.Column, error) {
-	query := fmt.Sprintf("DESCRIBE %s.%s.%s", catalogName, schemaName, tableName)
-	result, err := d.engine.ExecuteQuery(query, nil)
+func (d *trinoMetadataDiscovery) DiscoverColumns(ctx context.Context, catalogName, schemaName, tableName string) ([]*models.Column, error) {
+	name, err := datasync.QualifiedName(catalogName, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover columns for table %s.%s.%s: %w", catalogName, schemaName, tableName, err)
+	}
+	query := "DESCRIBE " + name
+	result, err := d.engine.ExecuteQueryContext(ctx, query, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover columns for table %s.%s.%s: %w", catalogName, schemaName, tableName, err)
 	}
@@ -115,3 +134,38 @@ func (d *trinoMetadataDiscovery) DiscoverColumns(catalogName, schemaName, tableN
 
 	return columns, nil
 }
+
+// DiscoverCatalogColumns lists the columns of every table in a catalog from its information_schema,
+// in table and ordinal order
+func (d *trinoMetadataDiscovery) DiscoverCatalogColumns(ctx context.Context, catalogName string) ([]*models.Column, error) {
+	name, err := datasync.QualifiedName(catalogName, "information_schema", "columns")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover columns for catalog %s: %w", catalogName, err)
+	}
+	query := "SELECT table_schema, table_name, column_name, data_type FROM " + name + " ORDER BY table_schema, table_name, ordinal_position"
+	result, err := d.engine.ExecuteQueryContext(ctx, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover columns for catalog %s: %w", catalogName, err)
+	}
+
+	columns := make([]*models.Column, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		schemaName, schemaOk := row["table_schema"].(string)
+		tableName, tableOk := row["table_name"].(string)
+		columnName, nameOk := row["column_name"].(string)
+		dataType, typeOk := row["data_type"].(string)
+
+		if schemaOk && tableOk && nameOk && typeOk {
+			columns = append(columns, &models.Column{
+				Name:        columnName,
+				TableName:   tableName,
+				SchemaName:  schemaName,
+				CatalogName: catalogName,
+				DataType:    dataType,
+				Metadata:    make(map[string]string),
+			})
+		}
+	}
+
+	return columns, nil
+}
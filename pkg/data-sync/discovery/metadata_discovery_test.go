@@ -1,7 +1,10 @@
 package discovery
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
@@ -9,14 +12,17 @@ import (
 
 // Mock QueryEngine for testing
 type mockQueryEngine struct {
-	catalogsResult datasync.QueryResult
-	schemasResult  datasync.QueryResult
-	tablesResult   datasync.QueryResult
-	columnsResult  datasync.QueryResult
-	shouldError    bool
+	catalogsResult       datasync.QueryResult
+	schemasResult        datasync.QueryResult
+	tablesResult         datasync.QueryResult
+	columnsResult        datasync.QueryResult
+	catalogColumnsResult datasync.QueryResult
+	shouldError          bool
+	queries              []string
 }
 
-func (m *mockQueryEngine) ExecuteQuery(query string, params map[string]interface{}) (datasync.QueryResult, error) {
+func (m *mockQueryEngine) ExecuteQuery(query string, params *datasync.Params) (datasync.QueryResult, error) {
+	m.queries = append(m.queries, query)
 	if m.shouldError {
 		return datasync.QueryResult{}, fmt.Errorf("mock error")
 	}
@@ -41,9 +47,29 @@ func (m *mockQueryEngine) ExecuteQuery(query string, params map[string]interface
 		return m.columnsResult, nil
 	}
 
+	// Return every column of a catalog for information_schema queries
+	if strings.Contains(query, "information_schema.columns") {
+		return m.catalogColumnsResult, nil
+	}
+
 	return datasync.QueryResult{}, fmt.Errorf("unexpected query: %s", query)
 }
 
+func (m *mockQueryEngine) ExecuteQueryContext(ctx context.Context, query string, params *datasync.Params) (datasync.QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return datasync.QueryResult{}, err
+	}
+	return m.ExecuteQuery(query, params)
+}
+
+func (m *mockQueryEngine) QueryRowsContext(ctx context.Context, query string, params *datasync.Params) (datasync.Rows, error) {
+	result, err := m.ExecuteQueryContext(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return datasync.NewSliceRows(nil, result.Rows), nil
+}
+
 func TestDiscoverCatalogs_Success(t *testing.T) {
 	mockEngine := &mockQueryEngine{
 		catalogsResult: datasync.QueryResult{
@@ -56,7 +82,7 @@ func TestDiscoverCatalogs_Success(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	catalogs, err := discovery.DiscoverCatalogs()
+	catalogs, err := discovery.DiscoverCatalogs(context.Background())
 
 	if err != nil {
 		t.Fatalf("DiscoverCatalogs failed: %v", err)
@@ -85,7 +111,7 @@ func TestDiscoverCatalogs_Empty(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	catalogs, err := discovery.DiscoverCatalogs()
+	catalogs, err := discovery.DiscoverCatalogs(context.Background())
 
 	if err != nil {
 		t.Fatalf("DiscoverCatalogs failed: %v", err)
@@ -102,7 +128,7 @@ func TestDiscoverCatalogs_Error(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	_, err := discovery.DiscoverCatalogs()
+	_, err := discovery.DiscoverCatalogs(context.Background())
 
 	if err == nil {
 		t.Fatal("Expected error from DiscoverCatalogs, got nil")
@@ -121,7 +147,7 @@ func TestDiscoverCatalogs_InvalidDataType(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	catalogs, err := discovery.DiscoverCatalogs()
+	catalogs, err := discovery.DiscoverCatalogs(context.Background())
 
 	if err != nil {
 		t.Fatalf("DiscoverCatalogs failed: %v", err)
@@ -145,7 +171,7 @@ func TestDiscoverSchemas_Success(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	schemas, err := discovery.DiscoverSchemas("postgresql")
+	schemas, err := discovery.DiscoverSchemas(context.Background(), "postgresql")
 
 	if err != nil {
 		t.Fatalf("DiscoverSchemas failed: %v", err)
@@ -177,7 +203,7 @@ func TestDiscoverSchemas_Empty(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	schemas, err := discovery.DiscoverSchemas("postgresql")
+	schemas, err := discovery.DiscoverSchemas(context.Background(), "postgresql")
 
 	if err != nil {
 		t.Fatalf("DiscoverSchemas failed: %v", err)
@@ -194,7 +220,7 @@ func TestDiscoverSchemas_Error(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	_, err := discovery.DiscoverSchemas("postgresql")
+	_, err := discovery.DiscoverSchemas(context.Background(), "postgresql")
 
 	if err == nil {
 		t.Fatal("Expected error from DiscoverSchemas, got nil")
@@ -213,7 +239,7 @@ func TestDiscoverSchemas_InvalidDataType(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	schemas, err := discovery.DiscoverSchemas("postgresql")
+	schemas, err := discovery.DiscoverSchemas(context.Background(), "postgresql")
 
 	if err != nil {
 		t.Fatalf("DiscoverSchemas failed: %v", err)
@@ -237,7 +263,7 @@ func TestDiscoverTables_Success(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	tables, err := discovery.DiscoverTables("postgresql", "public")
+	tables, err := discovery.DiscoverTables(context.Background(), "postgresql", "public")
 
 	if err != nil {
 		t.Fatalf("DiscoverTables failed: %v", err)
@@ -272,7 +298,7 @@ func TestDiscoverTables_Empty(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	tables, err := discovery.DiscoverTables("postgresql", "public")
+	tables, err := discovery.DiscoverTables(context.Background(), "postgresql", "public")
 
 	if err != nil {
 		t.Fatalf("DiscoverTables failed: %v", err)
@@ -289,7 +315,7 @@ func TestDiscoverTables_Error(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	_, err := discovery.DiscoverTables("postgresql", "public")
+	_, err := discovery.DiscoverTables(context.Background(), "postgresql", "public")
 
 	if err == nil {
 		t.Fatal("Expected error from DiscoverTables, got nil")
@@ -308,7 +334,7 @@ func TestDiscoverTables_InvalidDataType(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	tables, err := discovery.DiscoverTables("postgresql", "public")
+	tables, err := discovery.DiscoverTables(context.Background(), "postgresql", "public")
 
 	if err != nil {
 		t.Fatalf("DiscoverTables failed: %v", err)
@@ -332,7 +358,7 @@ func TestDiscoverColumns_Success(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	columns, err := discovery.DiscoverColumns("postgresql", "public", "users")
+	columns, err := discovery.DiscoverColumns(context.Background(), "postgresql", "public", "users")
 
 	if err != nil {
 		t.Fatalf("DiscoverColumns failed: %v", err)
@@ -374,7 +400,7 @@ func TestDiscoverColumns_Empty(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	columns, err := discovery.DiscoverColumns("postgresql", "public", "users")
+	columns, err := discovery.DiscoverColumns(context.Background(), "postgresql", "public", "users")
 
 	if err != nil {
 		t.Fatalf("DiscoverColumns failed: %v", err)
@@ -391,7 +417,7 @@ func TestDiscoverColumns_Error(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	_, err := discovery.DiscoverColumns("postgresql", "public", "users")
+	_, err := discovery.DiscoverColumns(context.Background(), "postgresql", "public", "users")
 
 	if err == nil {
 		t.Fatal("Expected error from DiscoverColumns, got nil")
@@ -411,7 +437,7 @@ func TestDiscoverColumns_InvalidDataType(t *testing.T) {
 	}
 
 	discovery := NewTrinoMetadataDiscovery(mockEngine)
-	columns, err := discovery.DiscoverColumns("postgresql", "public", "users")
+	columns, err := discovery.DiscoverColumns(context.Background(), "postgresql", "public", "users")
 
 	if err != nil {
 		t.Fatalf("DiscoverColumns failed: %v", err)
@@ -422,3 +448,82 @@ func TestDiscoverColumns_InvalidDataType(t *testing.T) {
 		t.Errorf("Expected 2 columns (skipping invalid entries), got %d", len(columns))
 	}
 }
+
+func TestDiscoverCatalogs_Cancelled(t *testing.T) {
+	discovery := NewTrinoMetadataDiscovery(&mockQueryEngine{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := discovery.DiscoverCatalogs(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDiscoverCatalogColumns_Success(t *testing.T) {
+	mockEngine := &mockQueryEngine{
+		catalogColumnsResult: datasync.QueryResult{
+			Rows: []map[string]interface{}{
+				{"table_schema": "public", "table_name": "users", "column_name": "id", "data_type": "bigint"},
+				{"table_schema": "public", "table_name": "users", "column_name": "email", "data_type": "varchar(255)"},
+				{"table_schema": "sales", "table_name": "orders", "column_name": "id", "data_type": "integer"},
+				{"table_schema": "sales", "table_name": 7, "column_name": "id", "data_type": "integer"}, // Invalid: table_name should be string
+			},
+		},
+	}
+
+	discovery := NewTrinoMetadataDiscovery(mockEngine).(CatalogColumnDiscovery)
+	columns, err := discovery.DiscoverCatalogColumns(context.Background(), "postgresql")
+
+	if err != nil {
+		t.Fatalf("DiscoverCatalogColumns failed: %v", err)
+	}
+
+	if len(columns) != 3 {
+		t.Fatalf("Expected 3 columns (skipping invalid entries), got %d", len(columns))
+	}
+
+	last := columns[2]
+	if last.CatalogName != "postgresql" || last.SchemaName != "sales" || last.TableName != "orders" || last.Name != "id" || last.DataType != "integer" {
+		t.Errorf("Unexpected column: %+v", last)
+	}
+}
+
+func TestDiscover_QuotesHostileNames(t *testing.T) {
+	mockEngine := &mockQueryEngine{}
+	discovery := NewTrinoMetadataDiscovery(mockEngine)
+	ctx := context.Background()
+
+	if _, err := discovery.DiscoverSchemas(ctx, "mongo"); err != nil {
+		t.Fatalf("DiscoverSchemas failed: %v", err)
+	}
+	if _, err := discovery.DiscoverTables(ctx, "mongo", "app-db"); err != nil {
+		t.Fatalf("DiscoverTables failed: %v", err)
+	}
+	if _, err := discovery.DiscoverColumns(ctx, "mongo", "select", `Users"; DROP TABLE users; --`); err != nil {
+		t.Fatalf("DiscoverColumns failed: %v", err)
+	}
+	if _, err := discovery.(CatalogColumnDiscovery).DiscoverCatalogColumns(ctx, "my catalog"); err != nil {
+		t.Fatalf("DiscoverCatalogColumns failed: %v", err)
+	}
+
+	expected := []string{
+		"SHOW SCHEMAS FROM mongo",
+		`SHOW TABLES FROM mongo."app-db"`,
+		`DESCRIBE mongo."select"."Users""; DROP TABLE users; --"`,
+		`SELECT table_schema, table_name, column_name, data_type FROM "my catalog".information_schema.columns ORDER BY table_schema, table_name, ordinal_position`,
+	}
+	if strings.Join(mockEngine.queries, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected queries:\n  got:      %s\n  expected: %s", strings.Join(mockEngine.queries, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// Names that cannot be identifiers are rejected before any query is sent
+	mockEngine.queries = nil
+	if _, err := discovery.DiscoverTables(ctx, "mongo", "app\ndb"); !errors.Is(err, datasync.ErrInvalidIdentifier) {
+		t.Fatalf("Expected ErrInvalidIdentifier, got %v", err)
+	}
+	if len(mockEngine.queries) != 0 {
+		t.Fatalf("Expected no query to be sent, got %v", mockEngine.queries)
+	}
+}
@@ -0,0 +1,273 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// DefaultParallelism is how many discovery queries a walk runs at once when none is configured
+const DefaultParallelism = 8
+
+// WalkProgress reports how far a walk has got. It is sent each time a catalog is finished.
+type WalkProgress struct {
+	Catalog       string `json:"catalog"`
+	CatalogsDone  int    `json:"catalogsDone"`
+	CatalogsTotal int    `json:"catalogsTotal"`
+	Tables        int    `json:"tables"`
+	Columns       int    `json:"columns"`
+	Errors        int    `json:"errors"`
+}
+
+// Walker discovers the whole metadata tree with a bounded number of concurrent queries
+type Walker struct {
+	discovery   MetadataDiscovery
+	parallelism int
+}
+
+// NewWalker creates a walker running at most parallelism queries at once; 0 or less uses DefaultParallelism
+func NewWalker(discovery MetadataDiscovery, parallelism int) *Walker {
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+	return &Walker{
+		discovery:   discovery,
+		parallelism: parallelism,
+	}
+}
+
+// Walk discovers every catalog, schema, table and column. Catalogs are walked concurrently and
+// a failure in one only affects the part of the tree below it: it is kept in the snapshot and
+// returned when that part is read. When the discovery implements CatalogColumnDiscovery, the
+// columns of a catalog are listed with one query and only tables missing from it are described
+// one by one. Only a failure to list catalogs, or ctx being done, is returned as an error.
+// progress may be nil; it is never called concurrently.
+func (w *Walker) Walk(ctx context.Context, progress func(WalkProgress)) (*Snapshot, error) {
+	catalogs, err := w.discovery.DiscoverCatalogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &walk{
+		ctx:       ctx,
+		discovery: w.discovery,
+		slots:     make(chan struct{}, w.parallelism),
+		progress:  progress,
+		snapshot:  newSnapshot(catalogs),
+	}
+	run.state.CatalogsTotal = len(catalogs)
+
+	var wg sync.WaitGroup
+	for _, catalog := range catalogs {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			run.catalog(name)
+			run.catalogDone(name)
+		}(catalog.Name)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return run.snapshot, nil
+}
+
+// walk is the state of one Walk call
+type walk struct {
+	ctx       context.Context
+	discovery MetadataDiscovery
+	// slots bounds the number of queries running at once
+	slots    chan struct{}
+	progress func(WalkProgress)
+
+	mu       sync.Mutex
+	snapshot *Snapshot
+	state    WalkProgress
+}
+
+// query runs a discovery call once a slot is free
+func (w *walk) query(call func() error) error {
+	select {
+	case w.slots <- struct{}{}:
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+	defer func() { <-w.slots }()
+	return call()
+}
+
+// catalog discovers the schemas of a catalog, their tables and the tables' columns
+func (w *walk) catalog(catalogName string) {
+	var schemas []*models.Schema
+	err := w.query(func() (err error) {
+		schemas, err = w.discovery.DiscoverSchemas(w.ctx, catalogName)
+		return err
+	})
+	if err != nil {
+		w.fail(snapshotKey(catalogName), err)
+		return
+	}
+	w.mu.Lock()
+	w.snapshot.schemas[snapshotKey(catalogName)] = schemas
+	w.mu.Unlock()
+
+	// List the catalog's columns while its tables are being listed
+	var batched map[string][]*models.Column
+	var wg sync.WaitGroup
+	if batch, ok := w.discovery.(CatalogColumnDiscovery); ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var columns []*models.Column
+			err := w.query(func() (err error) {
+				columns, err = batch.DiscoverCatalogColumns(w.ctx, catalogName)
+				return err
+			})
+			if err != nil {
+				if w.ctx.Err() == nil {
+					log.Printf("Warning: %v; describing its tables one by one", err)
+				}
+				return
+			}
+			batched = make(map[string][]*models.Column)
+			for _, column := range columns {
+				key := snapshotKey(catalogName, column.SchemaName, column.TableName)
+				batched[key] = append(batched[key], column)
+			}
+		}()
+	}
+
+	tables := make([][]*models.Table, len(schemas))
+	for i, schema := range schemas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := w.query(func() (err error) {
+				tables[i], err = w.discovery.DiscoverTables(w.ctx, catalogName, schema.Name)
+				return err
+			})
+			key := snapshotKey(catalogName, schema.Name)
+			if err != nil {
+				w.fail(key, err)
+				return
+			}
+			w.mu.Lock()
+			w.snapshot.tables[key] = tables[i]
+			w.state.Tables += len(tables[i])
+			w.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for i, schema := range schemas {
+		for _, table := range tables[i] {
+			key := snapshotKey(catalogName, schema.Name, table.Name)
+			if columns, ok := batched[key]; ok {
+				w.setColumns(key, columns)
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var columns []*models.Column
+				err := w.query(func() (err error) {
+					columns, err = w.discovery.DiscoverColumns(w.ctx, catalogName, schema.Name, table.Name)
+					return err
+				})
+				if err != nil {
+					w.fail(key, err)
+					return
+				}
+				w.setColumns(key, columns)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func (w *walk) setColumns(key string, columns []*models.Column) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.snapshot.columns[key] = columns
+	w.state.Columns += len(columns)
+}
+
+// fail records that the children of key could not be discovered
+func (w *walk) fail(key string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.snapshot.errors[key] = err
+	w.state.Errors++
+}
+
+func (w *walk) catalogDone(catalogName string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state.Catalog = catalogName
+	w.state.CatalogsDone++
+	if w.progress != nil {
+		w.progress(w.state)
+	}
+}
+
+// Snapshot is the metadata tree found by a walk. It implements MetadataDiscovery by serving
+// the walked metadata, or the error met while walking that part of the tree.
+type Snapshot struct {
+	catalogs []*models.Catalog
+	schemas  map[string][]*models.Schema
+	tables   map[string][]*models.Table
+	columns  map[string][]*models.Column
+	errors   map[string]error
+}
+
+func newSnapshot(catalogs []*models.Catalog) *Snapshot {
+	return &Snapshot{
+		catalogs: catalogs,
+		schemas:  make(map[string][]*models.Schema),
+		tables:   make(map[string][]*models.Table),
+		columns:  make(map[string][]*models.Column),
+		errors:   make(map[string]error),
+	}
+}
+
+// snapshotKey joins a path with a separator that cannot appear in names, unlike "."
+func snapshotKey(names ...string) string {
+	return strings.Join(names, "\x00")
+}
+
+// lookup returns the walked children of key, the error met walking them, or an error when the
+// walk never reached key
+func lookup[T any](s *Snapshot, children map[string][]T, names ...string) ([]T, error) {
+	key := snapshotKey(names...)
+	if err, failed := s.errors[key]; failed {
+		return nil, err
+	}
+	found, ok := children[key]
+	if !ok {
+		return nil, fmt.Errorf("'%s' was not discovered", strings.Join(names, "."))
+	}
+	return found, nil
+}
+
+func (s *Snapshot) DiscoverCatalogs(ctx context.Context) ([]*models.Catalog, error) {
+	return s.catalogs, nil
+}
+
+func (s *Snapshot) DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error) {
+	return lookup(s, s.schemas, catalogName)
+}
+
+func (s *Snapshot) DiscoverTables(ctx context.Context, catalogName, schemaName string) ([]*models.Table, error) {
+	return lookup(s, s.tables, catalogName, schemaName)
+}
+
+func (s *Snapshot) DiscoverColumns(ctx context.Context, catalogName, schemaName, tableName string) ([]*models.Column, error) {
+	return lookup(s, s.columns, catalogName, schemaName, tableName)
+}
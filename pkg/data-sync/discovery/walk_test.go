@@ -0,0 +1,207 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// treeDiscovery serves a metadata tree of "catalog.schema.table" -> column names, tracking how
+// many calls run at once. Paths in failing return an error when their children are discovered.
+type treeDiscovery struct {
+	tables  map[string][]string
+	failing map[string]bool
+	// batched lists the catalogs whose columns DiscoverCatalogColumns returns; other catalogs fail
+	batched map[string]bool
+
+	mu        sync.Mutex
+	running   int
+	maxActive int
+	describes int
+}
+
+func (d *treeDiscovery) enter() func() {
+	d.mu.Lock()
+	d.running++
+	if d.running > d.maxActive {
+		d.maxActive = d.running
+	}
+	d.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+	return func() {
+		d.mu.Lock()
+		d.running--
+		d.mu.Unlock()
+	}
+}
+
+func (d *treeDiscovery) children(prefix string, depth int) ([]string, error) {
+	defer d.enter()()
+	if d.failing[prefix] {
+		return nil, fmt.Errorf("discovery failed for %s", prefix)
+	}
+	seen := map[string]bool{}
+	var names []string
+	for path := range d.tables {
+		parts := strings.Split(path, ".")
+		if prefix != "" && strings.Join(parts[:depth], ".") != prefix {
+			continue
+		}
+		if name := parts[depth]; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *treeDiscovery) DiscoverCatalogs(ctx context.Context) ([]*models.Catalog, error) {
+	names, err := d.children("", 0)
+	var catalogs []*models.Catalog
+	for _, name := range names {
+		catalogs = append(catalogs, &models.Catalog{Name: name})
+	}
+	return catalogs, err
+}
+
+func (d *treeDiscovery) DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error) {
+	names, err := d.children(catalogName, 1)
+	var schemas []*models.Schema
+	for _, name := range names {
+		schemas = append(schemas, &models.Schema{CatalogName: catalogName, Name: name})
+	}
+	return schemas, err
+}
+
+func (d *treeDiscovery) DiscoverTables(ctx context.Context, catalogName, schemaName string) ([]*models.Table, error) {
+	names, err := d.children(catalogName+"."+schemaName, 2)
+	var tables []*models.Table
+	for _, name := range names {
+		tables = append(tables, &models.Table{CatalogName: catalogName, SchemaName: schemaName, Name: name})
+	}
+	return tables, err
+}
+
+func (d *treeDiscovery) DiscoverColumns(ctx context.Context, catalogName, schemaName, tableName string) ([]*models.Column, error) {
+	defer d.enter()()
+	d.mu.Lock()
+	d.describes++
+	d.mu.Unlock()
+
+	path := catalogName + "." + schemaName + "." + tableName
+	if d.failing[path] {
+		return nil, fmt.Errorf("discovery failed for %s", path)
+	}
+	var columns []*models.Column
+	for _, name := range d.tables[path] {
+		columns = append(columns, &models.Column{CatalogName: catalogName, SchemaName: schemaName, TableName: tableName, Name: name, DataType: "bigint"})
+	}
+	return columns, nil
+}
+
+func (d *treeDiscovery) DiscoverCatalogColumns(ctx context.Context, catalogName string) ([]*models.Column, error) {
+	defer d.enter()()
+	if !d.batched[catalogName] {
+		return nil, fmt.Errorf("failed to discover columns for catalog %s", catalogName)
+	}
+	var columns []*models.Column
+	for path, names := range d.tables {
+		parts := strings.Split(path, ".")
+		if parts[0] != catalogName {
+			continue
+		}
+		for _, name := range names {
+			columns = append(columns, &models.Column{CatalogName: catalogName, SchemaName: parts[1], TableName: parts[2], Name: name, DataType: "bigint"})
+		}
+	}
+	return columns, nil
+}
+
+func TestWalk_BoundsParallelismAndBatchesColumns(t *testing.T) {
+	tables := map[string][]string{}
+	for _, catalog := range []string{"pg", "mysql", "mongo"} {
+		for i := 0; i < 10; i++ {
+			tables[fmt.Sprintf("%s.public.t%d", catalog, i)] = []string{"id", "name"}
+		}
+	}
+	discovery := &treeDiscovery{tables: tables, batched: map[string]bool{"pg": true, "mysql": true}}
+
+	var progress []WalkProgress
+	snapshot, err := NewWalker(discovery, 3).Walk(context.Background(), func(p WalkProgress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	if discovery.maxActive > 3 {
+		t.Errorf("Expected at most 3 concurrent queries, got %d", discovery.maxActive)
+	}
+	// Only the catalog without batched columns is described table by table
+	if discovery.describes != 10 {
+		t.Errorf("Expected 10 DESCRIBE calls, got %d", discovery.describes)
+	}
+
+	for path := range tables {
+		parts := strings.Split(path, ".")
+		columns, err := snapshot.DiscoverColumns(context.Background(), parts[0], parts[1], parts[2])
+		if err != nil || len(columns) != 2 {
+			t.Fatalf("Expected 2 columns for %s, got %v, %v", path, columns, err)
+		}
+	}
+
+	if len(progress) != 3 {
+		t.Fatalf("Expected progress for 3 catalogs, got %d", len(progress))
+	}
+	last := progress[2]
+	if last.CatalogsDone != 3 || last.CatalogsTotal != 3 || last.Tables != 30 || last.Columns != 60 || last.Errors != 0 {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+}
+
+func TestWalk_IsolatesFailures(t *testing.T) {
+	discovery := &treeDiscovery{
+		tables: map[string][]string{
+			"pg.public.customers": {"id"},
+			"pg.public.orders":    {"id"},
+			"mysql.shop.items":    {"sku"},
+		},
+		failing: map[string]bool{"mysql": true, "pg.public.orders": true},
+	}
+
+	snapshot, err := NewWalker(discovery, 2).Walk(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	if _, err := snapshot.DiscoverSchemas(context.Background(), "mysql"); err == nil {
+		t.Error("Expected the schema discovery error for mysql")
+	}
+	if _, err := snapshot.DiscoverColumns(context.Background(), "pg", "public", "orders"); err == nil {
+		t.Error("Expected the column discovery error for pg.public.orders")
+	}
+	columns, err := snapshot.DiscoverColumns(context.Background(), "pg", "public", "customers")
+	if err != nil || len(columns) != 1 {
+		t.Fatalf("Expected pg.public.customers to be discovered, got %v, %v", columns, err)
+	}
+}
+
+func TestWalk_Cancelled(t *testing.T) {
+	discovery := &treeDiscovery{tables: map[string][]string{"pg.public.customers": {"id"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := NewWalker(discovery, 1).Walk(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
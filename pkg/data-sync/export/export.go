@@ -0,0 +1,188 @@
+// Package export writes query results as CSV, Apache Parquet or Arrow IPC files,
+// keeping the column types Trino reports.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
+
+// Export formats
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+	FormatArrow   = "arrow" // Arrow IPC file format
+)
+
+// batchSize is how many rows are buffered into each Parquet row group or Arrow record batch
+const batchSize = 8192
+
+// IsFormat reports whether format is a supported export format
+func IsFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatParquet, FormatArrow:
+		return true
+	}
+	return false
+}
+
+// ContentType returns the MIME type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/vnd.apache.arrow.file"
+	}
+}
+
+// FileName returns the file name for an export of the given base name
+func FileName(base, format string) string {
+	if base == "" {
+		base = "query-result"
+	}
+	return base + "." + format
+}
+
+// Write streams every remaining row to w in the given format and closes rows
+func Write(w io.Writer, format string, rows datasync.Rows) error {
+	defer rows.Close()
+
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatParquet, FormatArrow:
+		return writeArrow(w, format, rows)
+	default:
+		return fmt.Errorf("unsupported export format '%s' (expected csv, parquet or arrow)", format)
+	}
+}
+
+// writeCSV writes a header row and one line per row, with nested values as JSON
+func writeCSV(w io.Writer, rows datasync.Rows) error {
+	columns := rows.Columns()
+	writer := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.Name
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values := rows.Values()
+		for i, col := range columns {
+			field, err := csvField(values[i])
+			if err != nil {
+				return fmt.Errorf("column %s: %w", col.Name, err)
+			}
+			record[i] = field
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvField(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}, map[string]interface{}:
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// recordWriter is implemented by the Parquet and Arrow IPC file writers
+type recordWriter interface {
+	Write(rec arrow.RecordBatch) error
+	Close() error
+}
+
+// writeArrow converts rows to Arrow record batches and writes them as Parquet or Arrow IPC
+func writeArrow(w io.Writer, format string, rows datasync.Rows) error {
+	columns := rows.Columns()
+	schema := arrowSchema(columns)
+
+	var writer recordWriter
+	var err error
+	if format == FormatParquet {
+		props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+		writer, err = pqarrow.NewFileWriter(schema, w, props, pqarrow.DefaultWriterProps())
+	} else {
+		writer, err = ipc.NewFileWriter(w, ipc.WithSchema(schema))
+	}
+	if err != nil {
+		return err
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	flushed := false
+	flush := func() error {
+		flushed = true
+		batch := builder.NewRecordBatch()
+		defer batch.Release()
+		return writer.Write(batch)
+	}
+
+	buffered := 0
+	for rows.Next() {
+		values := rows.Values()
+		for i, col := range columns {
+			if err := appendValue(builder.Field(i), values[i]); err != nil {
+				return fmt.Errorf("column %s: %w", col.Name, err)
+			}
+		}
+
+		buffered++
+		if buffered == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+			buffered = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// An empty result still gets one (empty) batch so readers see its columns
+	if buffered > 0 || !flushed {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
@@ -0,0 +1,206 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
+
+// sampleRows returns rows as the engine encodes them, covering scalar and nested types
+func sampleRows() datasync.Rows {
+	columns := []datasync.ResultColumn{
+		{Name: "id", Type: "bigint"},
+		{Name: "total", Type: "decimal(10,2)"},
+		{Name: "created", Type: "date"},
+		{Name: "seen_at", Type: "timestamp with time zone"},
+		{Name: "tags", Type: "array(varchar)"},
+		{Name: "address", Type: "row(city varchar, zip integer)"},
+	}
+	rows := []map[string]interface{}{
+		{
+			"id": int64(1), "total": "12.50", "created": "2024-01-31", "seen_at": "2024-01-31T12:00:00+01:00",
+			"tags":    []interface{}{"a", "b, c"},
+			"address": map[string]interface{}{"city": "Porto", "zip": json.Number("4000")},
+		},
+		{"id": int64(2), "total": nil, "created": nil, "seen_at": nil, "tags": nil, "address": nil},
+	}
+	return datasync.NewSliceRows(columns, rows)
+}
+
+func TestWrite_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, sampleRows()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := "id,total,created,seen_at,tags,address\n" +
+		"1,12.50,2024-01-31,2024-01-31T12:00:00+01:00,\"[\"\"a\"\",\"\"b, c\"\"]\",\"{\"\"city\"\":\"\"Porto\"\",\"\"zip\"\":4000}\"\n" +
+		"2,,,,,\n"
+	if buf.String() != expected {
+		t.Fatalf("Unexpected CSV:\n  got:      %q\n  expected: %q", buf.String(), expected)
+	}
+}
+
+func TestWrite_ArrowKeepsTypes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatArrow, sampleRows()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	reader, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewFileReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	schema := reader.Schema()
+	expectedTypes := []arrow.DataType{
+		arrow.PrimitiveTypes.Int64,
+		&arrow.Decimal128Type{Precision: 10, Scale: 2},
+		arrow.FixedWidthTypes.Date32,
+		&arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"},
+		arrow.ListOf(arrow.BinaryTypes.String),
+	}
+	for i, expected := range expectedTypes {
+		if !arrow.TypeEqual(schema.Field(i).Type, expected) {
+			t.Fatalf("Expected column %s to be %s, got %s", schema.Field(i).Name, expected, schema.Field(i).Type)
+		}
+	}
+
+	batch, err := reader.Record(0)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if batch.NumRows() != 2 {
+		t.Fatalf("Expected 2 rows, got %d", batch.NumRows())
+	}
+	if total := batch.Column(1).(*array.Decimal128).Value(0).ToString(2); total != "12.50" {
+		t.Fatalf("Expected total 12.50, got %s", total)
+	}
+	if !batch.Column(1).IsNull(1) {
+		t.Fatal("Expected a null total in the second row")
+	}
+	address := batch.Column(5).(*array.Struct)
+	if city := address.Field(0).(*array.String).Value(0); city != "Porto" {
+		t.Fatalf("Expected city Porto, got %s", city)
+	}
+}
+
+func TestWrite_Parquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatParquet, sampleRows()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	parquetReader, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewParquetReader failed: %v", err)
+	}
+	reader, err := pqarrow.NewFileReader(parquetReader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("NewFileReader failed: %v", err)
+	}
+
+	table, err := reader.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("ReadTable failed: %v", err)
+	}
+	defer table.Release()
+
+	if table.NumRows() != 2 || table.NumCols() != 6 {
+		t.Fatalf("Expected 2 rows of 6 columns, got %d rows of %d", table.NumRows(), table.NumCols())
+	}
+	if total := table.Schema().Field(1).Type; !arrow.TypeEqual(total, &arrow.Decimal128Type{Precision: 10, Scale: 2}) {
+		t.Fatalf("Expected total to stay decimal(10,2), got %s", total)
+	}
+}
+
+// positionalRows serves a single row whose columns share a name, so it is only
+// readable by position
+type positionalRows struct {
+	columns []datasync.ResultColumn
+	values  []interface{}
+	done    bool
+}
+
+func (r *positionalRows) Columns() []datasync.ResultColumn { return r.columns }
+
+func (r *positionalRows) Next() bool {
+	if r.done {
+		return false
+	}
+	r.done = true
+	return true
+}
+
+func (r *positionalRows) Row() map[string]interface{} {
+	row := make(map[string]interface{}, len(r.columns))
+	for i, col := range r.columns {
+		row[col.Name] = r.values[i]
+	}
+	return row
+}
+
+func (r *positionalRows) Values() []interface{} { return r.values }
+
+func (r *positionalRows) Err() error { return nil }
+
+func (r *positionalRows) Close() error { return nil }
+
+func TestWrite_ReadsValuesByPosition(t *testing.T) {
+	newRows := func() datasync.Rows {
+		return &positionalRows{
+			columns: []datasync.ResultColumn{{Name: "id", Type: "bigint"}, {Name: "id", Type: "bigint"}},
+			values:  []interface{}{int64(1), int64(2)},
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, newRows()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != "id,id\n1,2\n" {
+		t.Fatalf("Expected each column's own value, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatArrow, newRows()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	reader, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewFileReader failed: %v", err)
+	}
+	defer reader.Close()
+	batch, err := reader.Record(0)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	first, second := batch.Column(0).(*array.Int64).Value(0), batch.Column(1).(*array.Int64).Value(0)
+	if first != 1 || second != 2 {
+		t.Fatalf("Expected each column's own value, got %d and %d", first, second)
+	}
+}
+
+func TestWrite_RejectsBadValues(t *testing.T) {
+	rows := datasync.NewSliceRows(
+		[]datasync.ResultColumn{{Name: "day", Type: "date"}},
+		[]map[string]interface{}{{"day": "31/01/2024"}},
+	)
+
+	err := Write(&bytes.Buffer{}, FormatArrow, rows)
+	if err == nil || !strings.Contains(err.Error(), "column day") {
+		t.Fatalf("Expected an error naming the column, got %v", err)
+	}
+}
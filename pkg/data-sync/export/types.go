@@ -0,0 +1,322 @@
+package export
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
+
+// arrowSchema maps result columns to an Arrow schema; every column is nullable
+func arrowSchema(columns []datasync.ResultColumn) *arrow.Schema {
+	fields := make([]arrow.Field, len(columns))
+	for i, col := range columns {
+		fields[i] = arrow.Field{Name: col.Name, Type: arrowType(col.Type), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowType maps a Trino type to the Arrow type it is exported as. Types without an
+// Arrow equivalent, such as json, uuid and intervals, are exported as strings.
+func arrowType(trinoType string) arrow.DataType {
+	base, args := datasync.SplitTypeName(trinoType)
+	switch base {
+	case "boolean":
+		return arrow.FixedWidthTypes.Boolean
+	case "tinyint":
+		return arrow.PrimitiveTypes.Int8
+	case "smallint":
+		return arrow.PrimitiveTypes.Int16
+	case "integer":
+		return arrow.PrimitiveTypes.Int32
+	case "bigint":
+		return arrow.PrimitiveTypes.Int64
+	case "real":
+		return arrow.PrimitiveTypes.Float32
+	case "double":
+		return arrow.PrimitiveTypes.Float64
+	case "decimal":
+		precision, scale := int32(38), int32(0)
+		if len(args) == 2 {
+			if p, err := strconv.Atoi(args[0]); err == nil {
+				precision = int32(p)
+			}
+			if s, err := strconv.Atoi(args[1]); err == nil {
+				scale = int32(s)
+			}
+		}
+		return &arrow.Decimal128Type{Precision: precision, Scale: scale}
+	case "date":
+		return arrow.FixedWidthTypes.Date32
+	case "timestamp":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	case "varbinary":
+		return arrow.BinaryTypes.Binary
+	case "array":
+		if len(args) == 1 {
+			return arrow.ListOf(arrowType(args[0]))
+		}
+	case "map":
+		if len(args) == 2 {
+			return arrow.MapOf(arrowType(args[0]), arrowType(args[1]))
+		}
+	case "row":
+		fields := make([]arrow.Field, len(args))
+		for i, field := range args {
+			name, fieldType := datasync.SplitRowField(field, i)
+			fields[i] = arrow.Field{Name: name, Type: arrowType(fieldType), Nullable: true}
+		}
+		return arrow.StructOf(fields...)
+	}
+
+	if strings.HasPrefix(base, "timestamp") && strings.HasSuffix(base, "with time zone") {
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	}
+	if strings.HasPrefix(base, "timestamp") {
+		// timestamp(6) and other precisions
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	}
+	return arrow.BinaryTypes.String
+}
+
+// appendValue appends a value, as encoded by datasync.EncodeValue or nested in one, to a builder
+func appendValue(builder array.Builder, value interface{}) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+
+	switch b := builder.(type) {
+	case *array.BooleanBuilder:
+		v, ok := value.(bool)
+		if !ok {
+			return unexpected(value, "boolean")
+		}
+		b.Append(v)
+
+	case *array.Int8Builder:
+		n, err := toInt64(value, math.MinInt8, math.MaxInt8)
+		if err != nil {
+			return err
+		}
+		b.Append(int8(n))
+	case *array.Int16Builder:
+		n, err := toInt64(value, math.MinInt16, math.MaxInt16)
+		if err != nil {
+			return err
+		}
+		b.Append(int16(n))
+	case *array.Int32Builder:
+		n, err := toInt64(value, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return err
+		}
+		b.Append(int32(n))
+	case *array.Int64Builder:
+		n, err := toInt64(value, math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		b.Append(n)
+
+	case *array.Float32Builder:
+		f, err := toFloat64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(float32(f))
+	case *array.Float64Builder:
+		f, err := toFloat64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(f)
+
+	case *array.Decimal128Builder:
+		decimalType := b.Type().(*arrow.Decimal128Type)
+		n, err := decimal128.FromString(fmt.Sprint(value), decimalType.Precision, decimalType.Scale)
+		if err != nil {
+			return fmt.Errorf("invalid decimal %v: %w", value, err)
+		}
+		b.Append(n)
+
+	case *array.Date32Builder:
+		text, ok := value.(string)
+		if !ok {
+			return unexpected(value, "date")
+		}
+		date, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			return fmt.Errorf("invalid date '%s'", text)
+		}
+		b.Append(arrow.Date32FromTime(date))
+
+	case *array.TimestampBuilder:
+		text, ok := value.(string)
+		if !ok {
+			return unexpected(value, "timestamp")
+		}
+		ts, err := parseTimestamp(text)
+		if err != nil {
+			return err
+		}
+		micros, err := arrow.TimestampFromTime(ts, arrow.Microsecond)
+		if err != nil {
+			return err
+		}
+		b.Append(micros)
+
+	case *array.BinaryBuilder:
+		text, ok := value.(string)
+		if !ok {
+			return unexpected(value, "varbinary")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return fmt.Errorf("invalid base64 varbinary value")
+		}
+		b.Append(decoded)
+
+	case *array.StringBuilder:
+		switch v := value.(type) {
+		case string:
+			b.Append(v)
+		case []interface{}, map[string]interface{}:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			b.Append(string(encoded))
+		default:
+			b.Append(fmt.Sprint(v))
+		}
+
+	case *array.ListBuilder:
+		elements, ok := value.([]interface{})
+		if !ok {
+			return unexpected(value, "array")
+		}
+		b.Append(true)
+		for _, element := range elements {
+			if err := appendValue(b.ValueBuilder(), element); err != nil {
+				return err
+			}
+		}
+
+	case *array.MapBuilder:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return unexpected(value, "map")
+		}
+		b.Append(true)
+		for key, item := range entries {
+			if err := appendValue(b.KeyBuilder(), key); err != nil {
+				return fmt.Errorf("map key: %w", err)
+			}
+			if err := appendValue(b.ItemBuilder(), item); err != nil {
+				return err
+			}
+		}
+
+	case *array.StructBuilder:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return unexpected(value, "row")
+		}
+		structType := b.Type().(*arrow.StructType)
+		b.Append(true)
+		for i, field := range structType.Fields() {
+			if err := appendValue(b.FieldBuilder(i), fields[field.Name]); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported Arrow type %s", builder.Type())
+	}
+	return nil
+}
+
+func unexpected(value interface{}, trinoType string) error {
+	return fmt.Errorf("unexpected %T value %v for a %s column", value, value, trinoType)
+}
+
+// toInt64 reads an integer sent as a Go integer, a JSON number or a string (map keys)
+func toInt64(value interface{}, min, max int64) (int64, error) {
+	var n int64
+	var err error
+	switch v := value.(type) {
+	case int64:
+		n = v
+	case int:
+		n = int64(v)
+	case json.Number:
+		n, err = v.Int64()
+	case string:
+		n, err = strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, unexpected(value, "integer")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %v", value)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("integer %d out of range", n)
+	}
+	return n, nil
+}
+
+// toFloat64 reads a float sent as a Go float, a JSON number or a string such as "NaN"
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		switch v {
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, unexpected(value, "double")
+	}
+}
+
+// parseTimestamp reads a timestamp in the ISO 8601 form of datasync.EncodeValue, or in the
+// form Trino uses inside nested values: "2024-01-31 12:00:00.000", optionally followed by a zone
+func parseTimestamp(text string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"} {
+		if ts, err := time.Parse(layout, text); err == nil {
+			return ts, nil
+		}
+	}
+
+	if space := strings.LastIndexByte(text, ' '); space > 0 {
+		local, err := time.Parse("2006-01-02 15:04:05.999999999", text[:space])
+		if err == nil {
+			zone := text[space+1:]
+			if offset, err := time.Parse("-07:00", zone); err == nil {
+				return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), offset.Location()), nil
+			}
+			if location, err := time.LoadLocation(zone); err == nil {
+				return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), location), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp '%s'", text)
+}
@@ -0,0 +1,89 @@
+package datasync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidIdentifier is returned for names that cannot be used as SQL identifiers
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// reservedWords are Trino's reserved keywords, which must be quoted to be used as identifiers
+var reservedWords = map[string]bool{
+	"ALTER": true, "AND": true, "AS": true, "BETWEEN": true, "BY": true, "CASE": true, "CAST": true,
+	"CONSTRAINT": true, "CREATE": true, "CROSS": true, "CUBE": true, "CURRENT_CATALOG": true,
+	"CURRENT_DATE": true, "CURRENT_PATH": true, "CURRENT_ROLE": true, "CURRENT_SCHEMA": true,
+	"CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "CURRENT_USER": true, "DEALLOCATE": true,
+	"DELETE": true, "DESCRIBE": true, "DISTINCT": true, "DROP": true, "ELSE": true, "END": true,
+	"ESCAPE": true, "EXCEPT": true, "EXECUTE": true, "EXISTS": true, "EXTRACT": true, "FALSE": true,
+	"FOR": true, "FROM": true, "FULL": true, "GROUP": true, "GROUPING": true, "HAVING": true,
+	"IN": true, "INNER": true, "INSERT": true, "INTERSECT": true, "INTO": true, "IS": true,
+	"JOIN": true, "JSON_ARRAY": true, "JSON_EXISTS": true, "JSON_OBJECT": true, "JSON_QUERY": true,
+	"JSON_TABLE": true, "JSON_VALUE": true, "LEFT": true, "LIKE": true, "LISTAGG": true,
+	"LOCALTIME": true, "LOCALTIMESTAMP": true, "NATURAL": true, "NORMALIZE": true, "NOT": true,
+	"NULL": true, "ON": true, "OR": true, "ORDER": true, "OUTER": true, "PREPARE": true,
+	"RECURSIVE": true, "RIGHT": true, "ROLLUP": true, "SELECT": true, "SKIP": true, "TABLE": true,
+	"THEN": true, "TRIM": true, "TRUE": true, "UESCAPE": true, "UNION": true, "UNNEST": true,
+	"USING": true, "VALUES": true, "WHEN": true, "WHERE": true, "WITH": true,
+}
+
+// ValidateIdentifier checks that a name can be used as an identifier: it must be non-empty
+// UTF-8 without control characters. Any other name, hostile or not, is safe once quoted.
+func ValidateIdentifier(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidIdentifier)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalidIdentifier, name)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: %q contains a control character", ErrInvalidIdentifier, name)
+		}
+	}
+	return nil
+}
+
+// QuoteIdentifier quotes a name as a delimited identifier, doubling any quotes inside it
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// IsPlainIdentifier reports whether a name is letters, digits and underscores not starting
+// with a digit, and so can be written without quotes unless it is a keyword
+func IsPlainIdentifier(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if ch != '_' && (ch < '0' || ch > '9') && (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatIdentifier writes a name as an identifier, quoting it unless it is plain and not reserved
+func FormatIdentifier(name string) string {
+	if IsPlainIdentifier(name) && !reservedWords[strings.ToUpper(name)] {
+		return name
+	}
+	return QuoteIdentifier(name)
+}
+
+// QualifiedName validates each part of a dotted name, e.g. catalog, schema and table, and
+// formats them as identifiers joined with dots
+func QualifiedName(parts ...string) (string, error) {
+	formatted := make([]string, len(parts))
+	for i, part := range parts {
+		if err := ValidateIdentifier(part); err != nil {
+			return "", err
+		}
+		formatted[i] = FormatIdentifier(part)
+	}
+	return strings.Join(formatted, "."), nil
+}
@@ -0,0 +1,48 @@
+package datasync
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"customers", "customers"},
+		{"Customers", "Customers"},
+		{"order", `"order"`},
+		{"my-collection", `"my-collection"`},
+		{"2024_sales", `"2024_sales"`},
+		{"user name", `"user name"`},
+		{`x"; DROP TABLE users; --`, `"x""; DROP TABLE users; --"`},
+	}
+
+	for _, tt := range tests {
+		if got := FormatIdentifier(tt.name); got != tt.expected {
+			t.Errorf("Expected FormatIdentifier(%q) to be %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	name, err := QualifiedName("mongo", "app-db", "Events")
+	if err != nil {
+		t.Fatalf("QualifiedName failed: %v", err)
+	}
+	if name != `mongo."app-db".Events` {
+		t.Errorf("Unexpected qualified name: %s", name)
+	}
+
+	for _, parts := range [][]string{
+		{"pg", ""},
+		{"pg", "public", "users\n; DROP TABLE users"},
+		{"pg", "bad\x00name"},
+		{"pg", string([]byte{0xff, 0xfe})},
+	} {
+		if _, err := QualifiedName(parts...); !errors.Is(err, ErrInvalidIdentifier) {
+			t.Errorf("Expected ErrInvalidIdentifier for %q, got %v", parts, err)
+		}
+	}
+}
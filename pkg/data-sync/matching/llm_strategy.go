@@ -7,31 +7,31 @@ import (
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/chatbot"
 	"github.com/guilherme096/data-sync/pkg/data-sync/models"
-	"google.golang.org/genai"
 )
 
-type GeminiMatchingStrategy struct {
-	client *chatbot.GeminiClient
+// LLMMatchingStrategy asks an LLM provider to suggest relations
+type LLMMatchingStrategy struct {
+	provider chatbot.Provider
 }
 
-func NewGeminiMatchingStrategy(client *chatbot.GeminiClient) *GeminiMatchingStrategy {
-	return &GeminiMatchingStrategy{client: client}
+func NewLLMMatchingStrategy(provider chatbot.Provider) *LLMMatchingStrategy {
+	return &LLMMatchingStrategy{provider: provider}
 }
 
-func (s *GeminiMatchingStrategy) SuggestRelations(ctx MatchingContext) ([]RelationSuggestion, error) {
+func (s *LLMMatchingStrategy) SuggestRelations(ctx context.Context, matchCtx MatchingContext) ([]RelationSuggestion, error) {
 	// Build prompt with metadata
-	prompt := s.buildPrompt(ctx)
+	prompt := s.buildPrompt(matchCtx)
 
-	// Call Gemini with structured output request
-	response, err := s.callGeminiForSuggestions(prompt)
+	// Call the model with structured output request
+	response, err := s.callForSuggestions(ctx, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get suggestions from Gemini: %w", err)
+		return nil, fmt.Errorf("failed to get suggestions from %s: %w", s.provider.Name(), err)
 	}
 
 	return response, nil
 }
 
-func (s *GeminiMatchingStrategy) buildPrompt(ctx MatchingContext) string {
+func (s *LLMMatchingStrategy) buildPrompt(ctx MatchingContext) string {
 	// Serialize metadata to JSON for clarity
 	tablesJSON, _ := json.MarshalIndent(ctx.PhysicalTables, "", "  ")
 	relationsJSON, _ := json.MarshalIndent(ctx.ExistingRelations, "", "  ")
@@ -125,8 +125,8 @@ Generate ONLY the JSON output, no additional text.`,
 		ctx.MaxSuggestions)
 }
 
-// GeminiSuggestionsResponse matches the JSON structure we expect
-type GeminiSuggestionsResponse struct {
+// LLMSuggestionsResponse matches the JSON structure we expect
+type LLMSuggestionsResponse struct {
 	Suggestions []struct {
 		Name       string `json:"name"`
 		LeftTable  struct {
@@ -153,64 +153,56 @@ type GeminiSuggestionsResponse struct {
 	} `json:"suggestions"`
 }
 
-func (s *GeminiMatchingStrategy) callGeminiForSuggestions(prompt string) ([]RelationSuggestion, error) {
-	// Use Gemini's JSON mode for structured output
-	ctx := context.Background()
-
-	systemInstruction := "You are a data architecture expert. Always respond with valid JSON matching the requested schema."
-
-	config := &genai.GenerateContentConfig{
-		ResponseMIMEType: "application/json",
-		SystemInstruction: genai.NewContentFromText(
-			systemInstruction,
-			"system",
-		),
-	}
-
-	res, err := s.client.SendMessageWithConfig(ctx, prompt, config)
+func (s *LLMMatchingStrategy) callForSuggestions(ctx context.Context, prompt string) ([]RelationSuggestion, error) {
+	// Use the provider's JSON mode for structured output
+	res, err := s.provider.Chat(ctx, chatbot.ChatRequest{
+		System:   "You are a data architecture expert. Always respond with valid JSON matching the requested schema.",
+		Messages: []chatbot.Message{{Role: chatbot.RoleUser, Content: prompt}},
+		JSON:     true,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse JSON response
-	var geminiResp GeminiSuggestionsResponse
-	if err := json.Unmarshal([]byte(res), &geminiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse Gemini response: %w", err)
+	var llmResp LLMSuggestionsResponse
+	if err := json.Unmarshal([]byte(res.Content), &llmResp); err != nil {
+		return nil, fmt.Errorf("failed to parse model response: %w", err)
 	}
 
 	// Convert to our domain model
-	suggestions := make([]RelationSuggestion, 0, len(geminiResp.Suggestions))
-	for _, gemSuggestion := range geminiResp.Suggestions {
+	suggestions := make([]RelationSuggestion, 0, len(llmResp.Suggestions))
+	for _, llmSuggestion := range llmResp.Suggestions {
 		suggestion := RelationSuggestion{
-			Name:         gemSuggestion.Name,
-			RelationType: gemSuggestion.RelationType,
-			Description:  gemSuggestion.Description,
-			Confidence:   gemSuggestion.Confidence,
+			Name:         llmSuggestion.Name,
+			RelationType: llmSuggestion.RelationType,
+			Description:  llmSuggestion.Description,
+			Confidence:   llmSuggestion.Confidence,
 		}
 
 		// Convert left table
 		suggestion.LeftTable = models.TableSource{
-			Type:       gemSuggestion.LeftTable.Type,
-			Catalog:    gemSuggestion.LeftTable.Catalog,
-			Schema:     gemSuggestion.LeftTable.Schema,
-			Table:      gemSuggestion.LeftTable.Table,
-			RelationID: gemSuggestion.LeftTable.RelationID,
+			Type:       llmSuggestion.LeftTable.Type,
+			Catalog:    llmSuggestion.LeftTable.Catalog,
+			Schema:     llmSuggestion.LeftTable.Schema,
+			Table:      llmSuggestion.LeftTable.Table,
+			RelationID: llmSuggestion.LeftTable.RelationID,
 		}
 
 		// Convert right table
 		suggestion.RightTable = models.TableSource{
-			Type:       gemSuggestion.RightTable.Type,
-			Catalog:    gemSuggestion.RightTable.Catalog,
-			Schema:     gemSuggestion.RightTable.Schema,
-			Table:      gemSuggestion.RightTable.Table,
-			RelationID: gemSuggestion.RightTable.RelationID,
+			Type:       llmSuggestion.RightTable.Type,
+			Catalog:    llmSuggestion.RightTable.Catalog,
+			Schema:     llmSuggestion.RightTable.Schema,
+			Table:      llmSuggestion.RightTable.Table,
+			RelationID: llmSuggestion.RightTable.RelationID,
 		}
 
 		// Convert join column if present
-		if gemSuggestion.JoinColumn != nil {
+		if llmSuggestion.JoinColumn != nil {
 			suggestion.JoinColumn = &models.JoinColumn{
-				Left:  gemSuggestion.JoinColumn.Left,
-				Right: gemSuggestion.JoinColumn.Right,
+				Left:  llmSuggestion.JoinColumn.Left,
+				Right: llmSuggestion.JoinColumn.Right,
 			}
 		}
 
@@ -1,6 +1,10 @@
 package matching
 
-import "github.com/guilherme096/data-sync/pkg/data-sync/models"
+import (
+	"context"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
 
 // RelationSuggestion represents a single suggested table relation
 type RelationSuggestion struct {
@@ -16,7 +20,7 @@ type RelationSuggestion struct {
 // MatchingStrategy defines the interface for relation matching strategies
 type MatchingStrategy interface {
 	// SuggestRelations analyzes available tables and relations to suggest new relations
-	SuggestRelations(ctx MatchingContext) ([]RelationSuggestion, error)
+	SuggestRelations(ctx context.Context, matchCtx MatchingContext) ([]RelationSuggestion, error)
 }
 
 // MatchingContext provides context for the matching operation
@@ -56,6 +60,6 @@ func NewMatcher(strategy MatchingStrategy) *Matcher {
 }
 
 // SuggestRelations delegates to the strategy
-func (m *Matcher) SuggestRelations(ctx MatchingContext) ([]RelationSuggestion, error) {
-	return m.strategy.SuggestRelations(ctx)
+func (m *Matcher) SuggestRelations(ctx context.Context, matchCtx MatchingContext) ([]RelationSuggestion, error) {
+	return m.strategy.SuggestRelations(ctx, matchCtx)
 }
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ChatSession is a chatbot conversation kept on the server
+type ChatSession struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Summary condenses the earlier turns that were dropped from Messages to fit the context window
+	Summary   string               `json:"summary,omitempty"`
+	Messages  []ChatSessionMessage `json:"messages"`
+	CreatedAt time.Time            `json:"createdAt"`
+	UpdatedAt time.Time            `json:"updatedAt"`
+}
+
+// ChatSessionMessage is one message of a session: user text, an assistant answer with any tool
+// calls it made, or the result of one of those calls
+type ChatSessionMessage struct {
+	Role      string         `json:"role"` // "user", "assistant" or "tool"
+	Content   string         `json:"content,omitempty"`
+	ToolCalls []ChatToolCall `json:"toolCalls,omitempty"`
+
+	// ToolCallID, ToolName and ToolResult are set on tool messages
+	ToolCallID string                 `json:"toolCallId,omitempty"`
+	ToolName   string                 `json:"toolName,omitempty"`
+	ToolResult map[string]interface{} `json:"toolResult,omitempty"`
+}
+
+// ChatToolCall is a request from the model to run a tool
+type ChatToolCall struct {
+	// ID pairs the call with its result; providers that do not use IDs leave it empty
+	ID        string                 `json:"id,omitempty"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
@@ -4,6 +4,17 @@ package models
 type GlobalTable struct {
 	Name        string
 	Description string
+
+	// UnionType combines the rows of several table mappings: "ALL" (default) or "DISTINCT"
+	UnionType string
+
+	// SourceColumn optionally names an extra column holding the physical table
+	// (catalog.schema.table) each row came from
+	SourceColumn string
+
+	// Degraded is set after a sync when a mapping or relation of the table points at physical
+	// metadata that no longer exists or has an incompatible type; GET /sync/impact says why
+	Degraded bool
 }
 
 // GlobalColumn represents a column in a global table
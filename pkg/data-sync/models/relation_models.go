@@ -1,5 +1,7 @@
 package models
 
+import "fmt"
+
 type TableSource struct {
 	Type       string `json:"type"` // "physical" or "relation"
 	Catalog    string `json:"catalog,omitempty"`
@@ -20,5 +22,74 @@ type TableRelation struct {
 	RightTable   TableSource  `json:"rightTable"`
 	RelationType string       `json:"relationType"` // "JOIN" or "UNION"
 	JoinColumn   *JoinColumn  `json:"joinColumn,omitempty"`
+	JoinColumns  []JoinColumn `json:"joinColumns,omitempty"` // Composite join key; used instead of JoinColumn when set
+	JoinType     string       `json:"joinType,omitempty"`    // "INNER" (default), "LEFT", "RIGHT" or "FULL", only for JOIN
+	UnionType    string       `json:"unionType,omitempty"`   // "ALL" (default) or "DISTINCT", only for UNION
 	Description  string       `json:"description,omitempty"`
 }
+
+// Set semantics for combining the rows of UNION relations and of global tables with several mappings
+const (
+	UnionAll      = "ALL"
+	UnionDistinct = "DISTINCT"
+)
+
+// IsUnionDistinct reports whether a union type removes duplicate rows; empty means UNION ALL
+func IsUnionDistinct(unionType string) bool {
+	return unionType == UnionDistinct
+}
+
+// ValidateUnionType checks that a union type is empty, ALL or DISTINCT
+func ValidateUnionType(unionType string) error {
+	if unionType != "" && unionType != UnionAll && unionType != UnionDistinct {
+		return fmt.Errorf("union type must be ALL or DISTINCT")
+	}
+	return nil
+}
+
+// Join types supported by JOIN relations
+const (
+	JoinInner = "INNER"
+	JoinLeft  = "LEFT"
+	JoinRight = "RIGHT"
+	JoinFull  = "FULL"
+)
+
+// ValidateJoinType checks that a join type is empty, INNER, LEFT, RIGHT or FULL
+func ValidateJoinType(joinType string) error {
+	switch joinType {
+	case "", JoinInner, JoinLeft, JoinRight, JoinFull:
+		return nil
+	}
+	return fmt.Errorf("join type must be INNER, LEFT, RIGHT or FULL")
+}
+
+// JoinKeys returns the column pairs a JOIN relation is joined on:
+// JoinColumns when set, otherwise the single JoinColumn
+func (r *TableRelation) JoinKeys() []JoinColumn {
+	if len(r.JoinColumns) > 0 {
+		return r.JoinColumns
+	}
+	if r.JoinColumn != nil {
+		return []JoinColumn{*r.JoinColumn}
+	}
+	return nil
+}
+
+// ValidateJoin checks the join type and join key of a JOIN relation
+func (r *TableRelation) ValidateJoin() error {
+	if err := ValidateJoinType(r.JoinType); err != nil {
+		return err
+	}
+
+	keys := r.JoinKeys()
+	if len(keys) == 0 {
+		return fmt.Errorf("JOIN relation requires join columns")
+	}
+	for _, key := range keys {
+		if key.Left == "" || key.Right == "" {
+			return fmt.Errorf("JOIN relation requires both left and right join columns")
+		}
+	}
+	return nil
+}
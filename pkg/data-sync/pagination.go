@@ -0,0 +1,147 @@
+package datasync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrInvalidPageToken is returned for page tokens that are unknown, expired, already used or
+// belong to another query
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Cursor reads the rows of a running query a page at a time, so a large result is read once,
+// from start to end, however many pages it is served in
+type Cursor struct {
+	rows   Rows
+	cancel context.CancelFunc
+	// peeked is set when the row after the last page has been read, to tell whether more follow
+	peeked bool
+}
+
+// NewCursor pages through rows; cancel, if not nil, is called to stop their query on Close
+func NewCursor(rows Rows, cancel context.CancelFunc) *Cursor {
+	return &Cursor{rows: rows, cancel: cancel}
+}
+
+// Rows returns the rows the cursor reads
+func (c *Cursor) Rows() Rows {
+	return c.rows
+}
+
+// ReadPage reads up to pageSize rows, reporting whether more rows follow.
+// Only the page is held in memory.
+func (c *Cursor) ReadPage(pageSize int) ([]map[string]interface{}, bool, error) {
+	page := make([]map[string]interface{}, 0, min(pageSize, 1024))
+	if c.peeked {
+		page = append(page, c.rows.Row())
+		c.peeked = false
+	}
+	for len(page) < pageSize && c.rows.Next() {
+		page = append(page, c.rows.Row())
+	}
+	if err := c.rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	c.peeked = len(page) == pageSize && c.rows.Next()
+	return page, c.peeked, c.rows.Err()
+}
+
+// Close closes the rows and stops their query
+func (c *Cursor) Close() error {
+	err := c.rows.Close()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return err
+}
+
+// Cursors keeps cursors open between page requests behind single-use page tokens. A cursor
+// left idle for longer than the idle timeout is closed, and when max cursors are open keeping
+// another closes the one kept longest ago.
+type Cursors struct {
+	idleTimeout time.Duration
+	max         int
+
+	mu   sync.Mutex
+	open map[string]*keptCursor
+}
+
+// keptCursor is a cursor waiting for its next page request
+type keptCursor struct {
+	cursor *Cursor
+	key    string
+	keptAt time.Time
+	timer  *time.Timer
+}
+
+// NewCursors creates a store of at most max open cursors, each closed after idleTimeout unused
+func NewCursors(idleTimeout time.Duration, max int) *Cursors {
+	return &Cursors{idleTimeout: idleTimeout, max: max, open: make(map[string]*keptCursor)}
+}
+
+// Keep holds a cursor open for the next page of the query identified by key and returns the
+// page token that continues it
+func (c *Cursors) Keep(key string, cursor *Cursor) string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	token := hex.EncodeToString(id)
+
+	kept := &keptCursor{cursor: cursor, key: key, keptAt: time.Now()}
+
+	c.mu.Lock()
+	var evicted *keptCursor
+	if len(c.open) >= c.max {
+		var oldestToken string
+		for t, k := range c.open {
+			if evicted == nil || k.keptAt.Before(evicted.keptAt) {
+				oldestToken, evicted = t, k
+			}
+		}
+		delete(c.open, oldestToken)
+		evicted.timer.Stop()
+	}
+	kept.timer = time.AfterFunc(c.idleTimeout, func() { c.expire(token, kept) })
+	c.open[token] = kept
+	c.mu.Unlock()
+
+	if evicted != nil {
+		evicted.cursor.Close()
+	}
+	return token
+}
+
+// Take removes and returns the cursor a page token continues; Keep it again to serve another page
+func (c *Cursors) Take(key, token string) (*Cursor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept, ok := c.open[token]
+	if !ok {
+		return nil, fmt.Errorf("%w: it has expired or was already used, request the first page again", ErrInvalidPageToken)
+	}
+	if kept.key != key {
+		return nil, fmt.Errorf("%w: it belongs to a different query", ErrInvalidPageToken)
+	}
+	delete(c.open, token)
+	kept.timer.Stop()
+	return kept.cursor, nil
+}
+
+// expire closes a cursor left idle, unless it has been taken since
+func (c *Cursors) expire(token string, kept *keptCursor) {
+	c.mu.Lock()
+	if c.open[token] != kept {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.open, token)
+	c.mu.Unlock()
+
+	kept.cursor.Close()
+}
@@ -0,0 +1,105 @@
+package datasync
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func numberedRows(n int) Rows {
+	rows := make([]map[string]interface{}, n)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"n": i}
+	}
+	return NewSliceRows([]ResultColumn{{Name: "n", Type: "integer"}}, rows)
+}
+
+func TestCursor_ReadsEachRowOnce(t *testing.T) {
+	cursor := NewCursor(numberedRows(7), nil)
+
+	var seen []int
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected 3 pages, got more")
+		}
+
+		page, hasMore, err := cursor.ReadPage(3)
+		if err != nil {
+			t.Fatalf("ReadPage failed: %v", err)
+		}
+		for _, row := range page {
+			seen = append(seen, row["n"].(int))
+		}
+		if !hasMore {
+			break
+		}
+	}
+
+	if len(seen) != 7 {
+		t.Fatalf("Expected 7 rows across pages, got %v", seen)
+	}
+	for i, n := range seen {
+		if n != i {
+			t.Errorf("Expected row %d at position %d, got %d", i, i, n)
+		}
+	}
+}
+
+func TestCursor_ExactMultipleHasNoExtraPage(t *testing.T) {
+	cursor := NewCursor(numberedRows(6), nil)
+	cursor.ReadPage(3)
+
+	page, hasMore, err := cursor.ReadPage(3)
+	if err != nil {
+		t.Fatalf("ReadPage failed: %v", err)
+	}
+	if len(page) != 3 || hasMore {
+		t.Errorf("Expected final page of 3 rows without more, got %d rows, hasMore=%v", len(page), hasMore)
+	}
+}
+
+func TestCursors_TokensAreSingleUseAndBoundToTheirQuery(t *testing.T) {
+	cursors := NewCursors(time.Minute, 10)
+	cursor := NewCursor(numberedRows(1), nil)
+	token := cursors.Keep("SELECT * FROM a", cursor)
+
+	if _, err := cursors.Take("SELECT * FROM b", token); !errors.Is(err, ErrInvalidPageToken) {
+		t.Fatalf("Expected an error for a token from another query, got %v", err)
+	}
+	if taken, err := cursors.Take("SELECT * FROM a", token); err != nil || taken != cursor {
+		t.Fatalf("Expected the kept cursor, got %v", err)
+	}
+	if _, err := cursors.Take("SELECT * FROM a", token); !errors.Is(err, ErrInvalidPageToken) {
+		t.Fatalf("Expected an error for a used token, got %v", err)
+	}
+	if _, err := cursors.Take("SELECT * FROM a", "not-a-token!"); !errors.Is(err, ErrInvalidPageToken) {
+		t.Fatalf("Expected an error for a malformed token, got %v", err)
+	}
+}
+
+func TestCursors_CloseEvictedAndIdleCursors(t *testing.T) {
+	cursors := NewCursors(50*time.Millisecond, 1)
+	newCursor := func() (*Cursor, chan struct{}) {
+		closed := make(chan struct{})
+		return NewCursor(numberedRows(1), func() { close(closed) }), closed
+	}
+	waitClosed := func(closed chan struct{}, what string) {
+		t.Helper()
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the %s cursor to be closed", what)
+		}
+	}
+
+	first, firstClosed := newCursor()
+	token := cursors.Keep("q", first)
+	second, secondClosed := newCursor()
+	cursors.Keep("q", second)
+
+	waitClosed(firstClosed, "evicted")
+	if _, err := cursors.Take("q", token); !errors.Is(err, ErrInvalidPageToken) {
+		t.Fatalf("Expected the evicted cursor's token to be invalid, got %v", err)
+	}
+	waitClosed(secondClosed, "idle")
+}
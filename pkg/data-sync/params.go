@@ -0,0 +1,286 @@
+package datasync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type hints a parameter can carry; without one the JSON type of the value is used
+const (
+	ParamTypeDate      = "date"      // "2024-01-31"
+	ParamTypeTimestamp = "timestamp" // "2024-01-31 12:00:00" or RFC 3339
+	ParamTypeDecimal   = "decimal"   // "12.50", kept exact
+)
+
+// ErrInvalidParams is wrapped by errors caused by the parameters given rather than by running the query
+var ErrInvalidParams = errors.New("invalid query parameters")
+
+// Param is a value bound to a query placeholder
+type Param struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type,omitempty"`
+}
+
+// Params are the values bound to a query's placeholders: positional values for ? placeholders,
+// in order, or named values for :name placeholders. A nil *Params binds nothing.
+//
+// In JSON, a list is positional and an object is named. Each value is either a plain JSON
+// value or {"value": ..., "type": "date"|"timestamp"|"decimal"}.
+type Params struct {
+	Positional []Param
+	Named      map[string]Param
+}
+
+// UnmarshalJSON decodes a JSON list as positional parameters and an object as named parameters
+func (p *Params) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*p = Params{}
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var values []json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("invalid positional parameters: %w", err)
+		}
+		p.Positional = make([]Param, len(values))
+		for i, raw := range values {
+			param, err := decodeParam(raw)
+			if err != nil {
+				return fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+			p.Positional[i] = param
+		}
+		return nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("params must be a list of positional values or an object of named values")
+	}
+	p.Named = make(map[string]Param, len(values))
+	for name, raw := range values {
+		param, err := decodeParam(raw)
+		if err != nil {
+			return fmt.Errorf("parameter :%s: %w", name, err)
+		}
+		p.Named[name] = param
+	}
+	return nil
+}
+
+// MarshalJSON encodes parameters in the form UnmarshalJSON reads
+func (p Params) MarshalJSON() ([]byte, error) {
+	if p.Named != nil {
+		return json.Marshal(p.Named)
+	}
+	if p.Positional == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p.Positional)
+}
+
+// decodeParam reads a plain value or a {"value", "type"} object. Numbers are kept as
+// json.Number so large integers and decimals are not rounded through float64.
+func decodeParam(raw json.RawMessage) (Param, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return Param{}, err
+	}
+
+	object, isObject := value.(map[string]interface{})
+	if !isObject {
+		return Param{Value: value}, nil
+	}
+
+	typed, hasValue := object["value"]
+	if !hasValue {
+		return Param{}, fmt.Errorf("object parameters need a \"value\" field")
+	}
+	for key := range object {
+		if key != "value" && key != "type" {
+			return Param{}, fmt.Errorf("unknown parameter field '%s'", key)
+		}
+	}
+
+	param := Param{Value: typed}
+	if hint, ok := object["type"]; ok {
+		hintString, isString := hint.(string)
+		if !isString {
+			return Param{}, fmt.Errorf("parameter type must be a string")
+		}
+		param.Type = strings.ToLower(hintString)
+	}
+	if err := validateParamType(param.Type); err != nil {
+		return Param{}, err
+	}
+	return param, nil
+}
+
+// validateParamType checks that a type hint is empty or one of the supported types
+func validateParamType(paramType string) error {
+	switch paramType {
+	case "", ParamTypeDate, ParamTypeTimestamp, ParamTypeDecimal:
+		return nil
+	default:
+		return fmt.Errorf("unsupported parameter type '%s' (expected date, timestamp or decimal)", paramType)
+	}
+}
+
+// Bind returns the query with every placeholder turned into ? and the parameters in
+// placeholder order, so they can be passed to a driver positionally.
+//
+// A query using ? placeholders takes positional parameters, one per placeholder.
+// A query using :name placeholders takes named parameters; a name may repeat, and a
+// numeric name such as :1 refers to a positional parameter. Every parameter given
+// must be used, so a misspelt name is reported rather than silently ignored.
+func (p *Params) Bind(query string) (string, []Param, error) {
+	var positional []Param
+	var named map[string]Param
+	if p != nil {
+		positional, named = p.Positional, p.Named
+	}
+
+	placeholders := scanPlaceholders(query)
+
+	questionMarks := 0
+	for _, ph := range placeholders {
+		if ph.name == "" {
+			questionMarks++
+		}
+	}
+	if questionMarks > 0 && questionMarks != len(placeholders) {
+		return "", nil, paramError("a query cannot mix ? and :name placeholders")
+	}
+
+	if questionMarks > 0 {
+		if len(named) > 0 {
+			return "", nil, paramError("query uses ? placeholders but named parameters were given")
+		}
+		if questionMarks != len(positional) {
+			return "", nil, paramError("query has %d ? placeholders but %d parameters were given", questionMarks, len(positional))
+		}
+		return query, positional, nil
+	}
+
+	var sb strings.Builder
+	args := make([]Param, 0, len(placeholders))
+	usedNamed := make(map[string]bool)
+	usedPositional := make(map[int]bool)
+	last := 0
+	for _, ph := range placeholders {
+		param, found := named[ph.name]
+		if found {
+			usedNamed[ph.name] = true
+		} else if index, err := strconv.Atoi(ph.name); err == nil && index >= 1 && index <= len(positional) {
+			param, found = positional[index-1], true
+			usedPositional[index] = true
+		}
+		if !found {
+			return "", nil, paramError("no value given for parameter :%s", ph.name)
+		}
+
+		sb.WriteString(query[last:ph.start])
+		sb.WriteString("?")
+		last = ph.end
+		args = append(args, param)
+	}
+	sb.WriteString(query[last:])
+
+	var unused []string
+	for name := range named {
+		if !usedNamed[name] {
+			unused = append(unused, ":"+name)
+		}
+	}
+	for i := range positional {
+		if !usedPositional[i+1] {
+			unused = append(unused, "parameter "+strconv.Itoa(i+1))
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", nil, paramError("unused parameters: %s", strings.Join(unused, ", "))
+	}
+
+	return sb.String(), args, nil
+}
+
+// HasPlaceholders reports whether a query has ? or :name placeholders
+func HasPlaceholders(query string) bool {
+	return len(scanPlaceholders(query)) > 0
+}
+
+func paramError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidParams, fmt.Sprintf(format, args...))
+}
+
+// placeholder is a ? or :name placeholder found in a query
+type placeholder struct {
+	start, end int
+	name       string // empty for ?
+}
+
+// scanPlaceholders finds the placeholders in a query, skipping string literals,
+// quoted identifiers and comments, and :: casts
+func scanPlaceholders(query string) []placeholder {
+	var found []placeholder
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			// Skip to the closing quote; doubled quotes are escapes and are skipped in pairs
+			for i++; i < len(query); i++ {
+				if query[i] == ch {
+					if i+1 < len(query) && query[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+
+		case strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return found
+			}
+			i += end + 3
+
+		case ch == '?':
+			found = append(found, placeholder{start: i, end: i + 1})
+
+		case ch == ':':
+			if i+1 < len(query) && query[i+1] == ':' {
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(query) && isParamNameChar(query[end]) {
+				end++
+			}
+			if end > i+1 {
+				found = append(found, placeholder{start: i, end: end, name: query[i+1 : end]})
+				i = end - 1
+			}
+		}
+	}
+	return found
+}
+
+func isParamNameChar(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
@@ -0,0 +1,85 @@
+package datasync
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParams_UnmarshalJSON(t *testing.T) {
+	var positional Params
+	if err := json.Unmarshal([]byte(`["PT", 3, {"value": "2024-01-31", "type": "date"}]`), &positional); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(positional.Positional) != 3 || positional.Named != nil {
+		t.Fatalf("Expected 3 positional parameters, got %+v", positional)
+	}
+	if positional.Positional[1].Value != json.Number("3") {
+		t.Fatalf("Expected numbers to keep their digits, got %#v", positional.Positional[1].Value)
+	}
+	if positional.Positional[2] != (Param{Value: "2024-01-31", Type: ParamTypeDate}) {
+		t.Fatalf("Expected a typed date parameter, got %+v", positional.Positional[2])
+	}
+
+	var named Params
+	if err := json.Unmarshal([]byte(`{"country": "PT", "total": {"value": "12.50", "type": "decimal"}}`), &named); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(named.Named) != 2 || named.Named["total"].Type != ParamTypeDecimal {
+		t.Fatalf("Expected 2 named parameters, got %+v", named)
+	}
+
+	for _, invalid := range []string{`"PT"`, `[{"type": "date"}]`, `[{"value": 1, "type": "uuid"}]`, `{"a": {"value": 1, "unit": "m"}}`} {
+		var params Params
+		if err := json.Unmarshal([]byte(invalid), &params); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestParams_BindPositional(t *testing.T) {
+	params := &Params{Positional: []Param{{Value: "PT"}, {Value: "x"}}}
+
+	query, args, err := params.Bind("SELECT '?' AS q FROM t WHERE country = ? AND name = ? -- any ?")
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if query != "SELECT '?' AS q FROM t WHERE country = ? AND name = ? -- any ?" {
+		t.Fatalf("Expected the query unchanged, got %s", query)
+	}
+	if len(args) != 2 || args[0].Value != "PT" || args[1].Value != "x" {
+		t.Fatalf("Expected arguments in order, got %v", args)
+	}
+
+	if _, _, err := params.Bind("SELECT * FROM t WHERE country = ?"); !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("Expected ErrInvalidParams for a count mismatch, got %v", err)
+	}
+}
+
+func TestParams_BindNamed(t *testing.T) {
+	params := &Params{Named: map[string]Param{"country": {Value: "PT"}, "day": {Value: "2024-01-31", Type: ParamTypeDate}}}
+
+	query, args, err := params.Bind("SELECT CAST(x AS varchar)::text, ':day' FROM t WHERE c = :country OR d = :day OR e = :country")
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	expected := "SELECT CAST(x AS varchar)::text, ':day' FROM t WHERE c = ? OR d = ? OR e = ?"
+	if query != expected {
+		t.Fatalf("Expected %s, got %s", expected, query)
+	}
+	if len(args) != 3 || args[0].Value != "PT" || args[1].Type != ParamTypeDate || args[2].Value != "PT" {
+		t.Fatalf("Expected arguments in placeholder order, got %v", args)
+	}
+
+	if _, _, err := params.Bind("SELECT * FROM t WHERE c = :country AND d = :missing"); !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("Expected ErrInvalidParams for a missing parameter, got %v", err)
+	}
+	if _, _, err := params.Bind("SELECT * FROM t WHERE c = :country"); err == nil || err.Error() != "invalid query parameters: unused parameters: :day" {
+		t.Fatalf("Expected an unused parameter error, got %v", err)
+	}
+
+	var none *Params
+	if _, args, err := none.Bind("SELECT 1"); err != nil || len(args) != 0 {
+		t.Fatalf("Expected nil params to bind nothing, got %v, %v", args, err)
+	}
+}
@@ -0,0 +1,543 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
+
+// Expr is a node in a SQL expression tree
+type Expr interface {
+	exprNode()
+}
+
+// ColumnRef references a column, optionally qualified by a table name or alias
+type ColumnRef struct {
+	Table  string
+	Column string
+}
+
+// StarExpr is * or table.* in a select list
+type StarExpr struct {
+	Table string
+}
+
+// LiteralKind identifies the kind of a literal value
+type LiteralKind int
+
+const (
+	LiteralNumber LiteralKind = iota
+	LiteralString
+	LiteralBoolean
+	LiteralNull
+	LiteralTyped    // DATE '2024-01-01', TIMESTAMP '...', DECIMAL '...'
+	LiteralInterval // INTERVAL '1' DAY
+)
+
+// Literal is a constant value
+type Literal struct {
+	Kind     LiteralKind
+	Value    string // Unquoted value for strings and typed literals
+	TypeName string // Type for typed literals, unit for intervals
+}
+
+// UnaryExpr is a prefix operator: NOT, - or +
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+// BinaryExpr is an infix operator: AND, OR, comparisons, arithmetic and ||
+type BinaryExpr struct {
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// LikeExpr is expr [NOT] LIKE pattern [ESCAPE escape]
+type LikeExpr struct {
+	Expr    Expr
+	Pattern Expr
+	Escape  Expr
+	Not     bool
+}
+
+// InExpr is expr [NOT] IN (list) or expr [NOT] IN (subquery)
+type InExpr struct {
+	Expr     Expr
+	List     []Expr
+	Subquery *SelectStmt
+	Not      bool
+}
+
+// BetweenExpr is expr [NOT] BETWEEN low AND high
+type BetweenExpr struct {
+	Expr Expr
+	Low  Expr
+	High Expr
+	Not  bool
+}
+
+// IsNullExpr is expr IS [NOT] NULL
+type IsNullExpr struct {
+	Expr Expr
+	Not  bool
+}
+
+// FuncCall is a function or aggregate call such as lower(name) or count(DISTINCT id)
+type FuncCall struct {
+	Name     string
+	Distinct bool
+	Star     bool // count(*)
+	Args     []Expr
+	Niladic  bool // written without parentheses, e.g. current_date
+}
+
+// CastExpr is CAST(expr AS type) or TRY_CAST(expr AS type)
+type CastExpr struct {
+	Expr     Expr
+	TypeName string
+	Try      bool
+}
+
+// CaseExpr is a simple (CASE operand WHEN ...) or searched (CASE WHEN ...) case expression
+type CaseExpr struct {
+	Operand Expr
+	Whens   []WhenClause
+	Else    Expr
+}
+
+// WhenClause is a single WHEN ... THEN ... branch of a CaseExpr
+type WhenClause struct {
+	Cond   Expr
+	Result Expr
+}
+
+// ParenExpr preserves explicit parentheses from the original query
+type ParenExpr struct {
+	Expr Expr
+}
+
+// SubqueryExpr is a scalar subquery
+type SubqueryExpr struct {
+	Select *SelectStmt
+}
+
+// ExistsExpr is EXISTS (subquery)
+type ExistsExpr struct {
+	Select *SelectStmt
+}
+
+// ParamExpr is a query parameter placeholder. Named placeholders (:name) keep their name;
+// positional placeholders (?) are numbered from 1 in order of appearance, so they
+// survive translation even when an expression is repeated in several UNION branches.
+type ParamExpr struct {
+	Name string
+}
+
+func (*ColumnRef) exprNode()    {}
+func (*StarExpr) exprNode()     {}
+func (*Literal) exprNode()      {}
+func (*UnaryExpr) exprNode()    {}
+func (*BinaryExpr) exprNode()   {}
+func (*LikeExpr) exprNode()     {}
+func (*InExpr) exprNode()       {}
+func (*BetweenExpr) exprNode()  {}
+func (*IsNullExpr) exprNode()   {}
+func (*FuncCall) exprNode()     {}
+func (*CastExpr) exprNode()     {}
+func (*CaseExpr) exprNode()     {}
+func (*ParenExpr) exprNode()    {}
+func (*SubqueryExpr) exprNode() {}
+func (*ExistsExpr) exprNode()   {}
+func (*ParamExpr) exprNode()    {}
+
+// TableExpr is an item in a FROM clause
+type TableExpr interface {
+	tableNode()
+}
+
+// TableName references a table by (optionally qualified) name
+type TableName struct {
+	Catalog string
+	Schema  string
+	Name    string
+	Alias   string
+}
+
+// SubqueryTable is a derived table: (SELECT ...) alias
+type SubqueryTable struct {
+	Select *SelectStmt
+	Alias  string
+}
+
+// GeneratedTable is a derived table whose query has already been rendered to SQL.
+// The parser never produces it; the generator uses it to place translated sources in FROM.
+type GeneratedTable struct {
+	SQL   string
+	Alias string
+}
+
+// JoinType is the kind of a join
+type JoinType string
+
+const (
+	JoinInner JoinType = "INNER"
+	JoinLeft  JoinType = "LEFT"
+	JoinRight JoinType = "RIGHT"
+	JoinFull  JoinType = "FULL"
+	JoinCross JoinType = "CROSS"
+)
+
+// JoinExpr joins two table expressions. Chains of joins are left-deep.
+type JoinExpr struct {
+	Type  JoinType
+	Left  TableExpr
+	Right TableExpr
+	On    Expr
+	Using []string
+}
+
+func (*TableName) tableNode()      {}
+func (*SubqueryTable) tableNode()  {}
+func (*GeneratedTable) tableNode() {}
+func (*JoinExpr) tableNode()       {}
+
+// SelectItem is one entry of a select list
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+}
+
+// OrderItem is one entry of an ORDER BY clause
+type OrderItem struct {
+	Expr  Expr
+	Desc  bool
+	Nulls string // "FIRST", "LAST" or empty
+}
+
+// SelectStmt is a parsed SELECT statement
+type SelectStmt struct {
+	Distinct bool
+	Items    []SelectItem
+	From     TableExpr
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []OrderItem
+	Limit    *int64
+	Offset   *int64
+}
+
+// IsSelectAll reports whether the select list is a single unqualified *
+func (s *SelectStmt) IsSelectAll() bool {
+	if len(s.Items) != 1 {
+		return false
+	}
+	star, ok := s.Items[0].Expr.(*StarExpr)
+	return ok && star.Table == ""
+}
+
+// String formats the statement back to SQL
+func (s *SelectStmt) String() string {
+	var sb strings.Builder
+	writeSelect(&sb, s)
+	return sb.String()
+}
+
+// FormatExpr formats an expression back to SQL
+func FormatExpr(e Expr) string {
+	var sb strings.Builder
+	writeExpr(&sb, e)
+	return sb.String()
+}
+
+// FormatTableExpr formats a FROM clause item back to SQL
+func FormatTableExpr(t TableExpr) string {
+	var sb strings.Builder
+	writeTableExpr(&sb, t)
+	return sb.String()
+}
+
+// FormatIdent formats an identifier, quoting it when it is not a plain word or
+// collides with a keyword of this parser or of Trino
+func FormatIdent(name string) string {
+	if keywords[strings.ToUpper(name)] {
+		return datasync.QuoteIdentifier(name)
+	}
+	return datasync.FormatIdentifier(name)
+}
+
+func formatString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func writeSelect(sb *strings.Builder, s *SelectStmt) {
+	sb.WriteString("SELECT ")
+	if s.Distinct {
+		sb.WriteString("DISTINCT ")
+	}
+	for i, item := range s.Items {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		writeExpr(sb, item.Expr)
+		if item.Alias != "" {
+			sb.WriteString(" AS ")
+			sb.WriteString(FormatIdent(item.Alias))
+		}
+	}
+
+	if s.From != nil {
+		sb.WriteString(" FROM ")
+		writeTableExpr(sb, s.From)
+	}
+
+	if s.Where != nil {
+		sb.WriteString(" WHERE ")
+		writeExpr(sb, s.Where)
+	}
+
+	if len(s.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY ")
+		writeExprList(sb, s.GroupBy)
+	}
+
+	if s.Having != nil {
+		sb.WriteString(" HAVING ")
+		writeExpr(sb, s.Having)
+	}
+
+	if len(s.OrderBy) > 0 {
+		sb.WriteString(" ORDER BY ")
+		for i, item := range s.OrderBy {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeExpr(sb, item.Expr)
+			if item.Desc {
+				sb.WriteString(" DESC")
+			}
+			if item.Nulls != "" {
+				sb.WriteString(" NULLS ")
+				sb.WriteString(item.Nulls)
+			}
+		}
+	}
+
+	if s.Offset != nil {
+		sb.WriteString(" OFFSET ")
+		sb.WriteString(strconv.FormatInt(*s.Offset, 10))
+	}
+
+	if s.Limit != nil {
+		sb.WriteString(" LIMIT ")
+		sb.WriteString(strconv.FormatInt(*s.Limit, 10))
+	}
+}
+
+func writeTableExpr(sb *strings.Builder, t TableExpr) {
+	switch t := t.(type) {
+	case *TableName:
+		parts := []string{}
+		for _, part := range []string{t.Catalog, t.Schema, t.Name} {
+			if part != "" {
+				parts = append(parts, FormatIdent(part))
+			}
+		}
+		sb.WriteString(strings.Join(parts, "."))
+		if t.Alias != "" {
+			sb.WriteString(" ")
+			sb.WriteString(FormatIdent(t.Alias))
+		}
+
+	case *SubqueryTable:
+		sb.WriteString("(")
+		writeSelect(sb, t.Select)
+		sb.WriteString(")")
+		if t.Alias != "" {
+			sb.WriteString(" ")
+			sb.WriteString(FormatIdent(t.Alias))
+		}
+
+	case *GeneratedTable:
+		sb.WriteString("(" + t.SQL + ") ")
+		sb.WriteString(FormatIdent(t.Alias))
+
+	case *JoinExpr:
+		writeTableExpr(sb, t.Left)
+		if t.Type == JoinInner {
+			sb.WriteString(" JOIN ")
+		} else {
+			sb.WriteString(" " + string(t.Type) + " JOIN ")
+		}
+		writeTableExpr(sb, t.Right)
+		if t.On != nil {
+			sb.WriteString(" ON ")
+			writeExpr(sb, t.On)
+		} else if len(t.Using) > 0 {
+			cols := make([]string, len(t.Using))
+			for i, col := range t.Using {
+				cols[i] = FormatIdent(col)
+			}
+			sb.WriteString(" USING (" + strings.Join(cols, ", ") + ")")
+		}
+	}
+}
+
+func writeExprList(sb *strings.Builder, exprs []Expr) {
+	for i, e := range exprs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		writeExpr(sb, e)
+	}
+}
+
+func writeExpr(sb *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case *ColumnRef:
+		if e.Table != "" {
+			sb.WriteString(FormatIdent(e.Table))
+			sb.WriteString(".")
+		}
+		sb.WriteString(FormatIdent(e.Column))
+
+	case *StarExpr:
+		if e.Table != "" {
+			sb.WriteString(FormatIdent(e.Table))
+			sb.WriteString(".")
+		}
+		sb.WriteString("*")
+
+	case *Literal:
+		switch e.Kind {
+		case LiteralString:
+			sb.WriteString(formatString(e.Value))
+		case LiteralTyped:
+			sb.WriteString(e.TypeName + " " + formatString(e.Value))
+		case LiteralInterval:
+			sb.WriteString("INTERVAL " + formatString(e.Value) + " " + e.TypeName)
+		default:
+			sb.WriteString(e.Value)
+		}
+
+	case *ParamExpr:
+		sb.WriteString(":" + e.Name)
+
+	case *UnaryExpr:
+		sb.WriteString(e.Op)
+		if _, nested := e.Expr.(*UnaryExpr); e.Op == "NOT" || nested {
+			// A space keeps "- -x" from being read back as a comment
+			sb.WriteString(" ")
+		}
+		writeExpr(sb, e.Expr)
+
+	case *BinaryExpr:
+		writeExpr(sb, e.Left)
+		sb.WriteString(" " + e.Op + " ")
+		writeExpr(sb, e.Right)
+
+	case *LikeExpr:
+		writeExpr(sb, e.Expr)
+		if e.Not {
+			sb.WriteString(" NOT")
+		}
+		sb.WriteString(" LIKE ")
+		writeExpr(sb, e.Pattern)
+		if e.Escape != nil {
+			sb.WriteString(" ESCAPE ")
+			writeExpr(sb, e.Escape)
+		}
+
+	case *InExpr:
+		writeExpr(sb, e.Expr)
+		if e.Not {
+			sb.WriteString(" NOT")
+		}
+		sb.WriteString(" IN (")
+		if e.Subquery != nil {
+			writeSelect(sb, e.Subquery)
+		} else {
+			writeExprList(sb, e.List)
+		}
+		sb.WriteString(")")
+
+	case *BetweenExpr:
+		writeExpr(sb, e.Expr)
+		if e.Not {
+			sb.WriteString(" NOT")
+		}
+		sb.WriteString(" BETWEEN ")
+		writeExpr(sb, e.Low)
+		sb.WriteString(" AND ")
+		writeExpr(sb, e.High)
+
+	case *IsNullExpr:
+		writeExpr(sb, e.Expr)
+		if e.Not {
+			sb.WriteString(" IS NOT NULL")
+		} else {
+			sb.WriteString(" IS NULL")
+		}
+
+	case *FuncCall:
+		sb.WriteString(e.Name)
+		if e.Niladic {
+			break
+		}
+		sb.WriteString("(")
+		if e.Star {
+			sb.WriteString("*")
+		} else {
+			if e.Distinct {
+				sb.WriteString("DISTINCT ")
+			}
+			writeExprList(sb, e.Args)
+		}
+		sb.WriteString(")")
+
+	case *CastExpr:
+		if e.Try {
+			sb.WriteString("TRY_CAST(")
+		} else {
+			sb.WriteString("CAST(")
+		}
+		writeExpr(sb, e.Expr)
+		sb.WriteString(" AS " + e.TypeName + ")")
+
+	case *CaseExpr:
+		sb.WriteString("CASE")
+		if e.Operand != nil {
+			sb.WriteString(" ")
+			writeExpr(sb, e.Operand)
+		}
+		for _, when := range e.Whens {
+			sb.WriteString(" WHEN ")
+			writeExpr(sb, when.Cond)
+			sb.WriteString(" THEN ")
+			writeExpr(sb, when.Result)
+		}
+		if e.Else != nil {
+			sb.WriteString(" ELSE ")
+			writeExpr(sb, e.Else)
+		}
+		sb.WriteString(" END")
+
+	case *ParenExpr:
+		sb.WriteString("(")
+		writeExpr(sb, e.Expr)
+		sb.WriteString(")")
+
+	case *SubqueryExpr:
+		sb.WriteString("(")
+		writeSelect(sb, e.Select)
+		sb.WriteString(")")
+
+	case *ExistsExpr:
+		sb.WriteString("EXISTS (")
+		writeSelect(sb, e.Select)
+		sb.WriteString(")")
+	}
+}
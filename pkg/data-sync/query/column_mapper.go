@@ -2,6 +2,7 @@ package query
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/models"
 	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
@@ -61,7 +62,7 @@ func (m *ColumnMapper) mapSingleColumn(globalTableName, globalColumnName string,
 		physicalTable.CatalogName, physicalTable.SchemaName, physicalTable.TableName)
 }
 
-// GetAllColumns retrieves all global columns for a global table
+// GetAllColumns retrieves all global columns for a global table, sorted by name
 func (m *ColumnMapper) GetAllColumns(globalTableName string) ([]string, error) {
 	globalColumns, err := m.storage.ListGlobalColumns(globalTableName)
 	if err != nil {
@@ -73,6 +74,9 @@ func (m *ColumnMapper) GetAllColumns(globalTableName string) ([]string, error) {
 		columnNames[i] = col.Name
 	}
 
+	// Storage does not guarantee an order; sort so generated SQL is deterministic
+	sort.Strings(columnNames)
+
 	return columnNames, nil
 }
 
@@ -97,33 +101,130 @@ func (m *ColumnMapper) MapColumnsForMultipleTables(
 	return columnMaps, nil
 }
 
-// MapColumnsForRelation maps global columns for a resolved relation
-// Returns column maps for both left and right tables
+// RelationColumnMaps holds a global column -> physical column map for every
+// physical table in a relation tree. Maps only hold the columns the table has
+// a mapping for; JOIN sides may each provide part of the columns.
+type RelationColumnMaps map[*RelationNode]map[string]string
+
+// MapColumnsForRelation maps global columns for a resolved relation tree
+// Returns column maps for every physical table, including those in nested relations
 func (m *ColumnMapper) MapColumnsForRelation(
 	globalTableName string,
 	globalColumns []string,
 	relation *ResolvedRelation,
-) ([]map[string]string, error) {
-	// For Phase 2, only support physical tables in relations
-	if relation.LeftNode.Type != NodeTypePhysical || relation.RightNode.Type != NodeTypePhysical {
-		return nil, fmt.Errorf("nested relations not supported in Phase 2")
+) (RelationColumnMaps, error) {
+	// Nested relations joined on a column need that column mapped too
+	columnsToMap := append([]string{}, globalColumns...)
+	columnsToMap = append(columnsToMap, nestedJoinColumns(relation)...)
+
+	leaves := physicalNodes(relation)
+	columnMaps := make(RelationColumnMaps, len(leaves))
+	for _, leaf := range leaves {
+		columnMaps[leaf] = make(map[string]string)
+	}
+
+	for _, globalCol := range columnsToMap {
+		mappings, err := m.storage.ListColumnMappings(globalTableName, globalCol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get mappings for column '%s' in global table '%s': %w", globalCol, globalTableName, err)
+		}
+
+		for _, leaf := range leaves {
+			for _, mapping := range mappings {
+				if mapping.CatalogName == leaf.Catalog &&
+					mapping.SchemaName == leaf.Schema &&
+					mapping.TableName == leaf.Table {
+					columnMaps[leaf][globalCol] = mapping.ColumnName
+					break
+				}
+			}
+		}
 	}
 
-	// Create table mappings from relation nodes
-	leftTable := &models.TableMapping{
-		GlobalTableName: globalTableName,
-		CatalogName:     relation.LeftNode.Catalog,
-		SchemaName:      relation.LeftNode.Schema,
-		TableName:       relation.LeftNode.Table,
+	// Every requested column must be produced by the relation as a whole
+	for _, globalCol := range globalColumns {
+		if err := columnMaps.requireColumn(globalTableName, relation, globalCol); err != nil {
+			return nil, err
+		}
 	}
 
-	rightTable := &models.TableMapping{
-		GlobalTableName: globalTableName,
-		CatalogName:     relation.RightNode.Catalog,
-		SchemaName:      relation.RightNode.Schema,
-		TableName:       relation.RightNode.Table,
+	return columnMaps, nil
+}
+
+// Provides reports whether a relation node can produce a global column:
+// physical tables need a mapping, UNIONs need it on both sides and JOINs on either side
+func (maps RelationColumnMaps) Provides(node *RelationNode, column string) bool {
+	if node.Type == NodeTypePhysical {
+		_, exists := maps[node][column]
+		return exists
+	}
+
+	left := maps.Provides(node.Relation.LeftNode, column)
+	right := maps.Provides(node.Relation.RightNode, column)
+	if node.Relation.RelationType == "UNION" {
+		return left && right
 	}
+	return left || right
+}
 
-	tables := []*models.TableMapping{leftTable, rightTable}
-	return m.MapColumnsForMultipleTables(globalTableName, globalColumns, tables)
+// requireColumn returns an error naming the part of a relation tree that cannot produce a global column
+func (maps RelationColumnMaps) requireColumn(globalTableName string, relation *ResolvedRelation, column string) error {
+	nodes := []*RelationNode{relation.LeftNode, relation.RightNode}
+
+	if relation.RelationType == "JOIN" {
+		if maps.Provides(nodes[0], column) || maps.Provides(nodes[1], column) {
+			return nil
+		}
+		return fmt.Errorf("no column mapping found for '%s.%s' in either side of JOIN relation '%s'",
+			globalTableName, column, relation.Name)
+	}
+
+	for _, node := range nodes {
+		if node.Type == NodeTypeRelation {
+			if err := maps.requireColumn(globalTableName, node.Relation, column); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, exists := maps[node][column]; !exists {
+			return fmt.Errorf("no column mapping found for '%s.%s' in physical table '%s.%s.%s'",
+				globalTableName, column, node.Catalog, node.Schema, node.Table)
+		}
+	}
+	return nil
+}
+
+// physicalNodes returns the physical tables at the leaves of a relation tree
+func physicalNodes(relation *ResolvedRelation) []*RelationNode {
+	var nodes []*RelationNode
+	for _, node := range []*RelationNode{relation.LeftNode, relation.RightNode} {
+		if node.Type == NodeTypePhysical {
+			nodes = append(nodes, node)
+		} else {
+			nodes = append(nodes, physicalNodes(node.Relation)...)
+		}
+	}
+	return nodes
+}
+
+// nestedJoinColumns returns the join columns that refer to nested relations.
+// A nested relation exposes global column names, so these name global columns.
+func nestedJoinColumns(relation *ResolvedRelation) []string {
+	var columns []string
+	if relation.RelationType == "JOIN" {
+		for _, key := range relation.JoinColumns {
+			if relation.LeftNode.Type == NodeTypeRelation {
+				columns = append(columns, key.Left)
+			}
+			if relation.RightNode.Type == NodeTypeRelation {
+				columns = append(columns, key.Right)
+			}
+		}
+	}
+	for _, node := range []*RelationNode{relation.LeftNode, relation.RightNode} {
+		if node.Type == NodeTypeRelation {
+			columns = append(columns, nestedJoinColumns(node.Relation)...)
+		}
+	}
+	return columns
 }
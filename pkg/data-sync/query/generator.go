@@ -4,9 +4,53 @@ import (
 	"fmt"
 	"strings"
 
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
 	"github.com/guilherme096/data-sync/pkg/data-sync/models"
 )
 
+// SelectColumn is a global column in the select list and the name it is returned under
+type SelectColumn struct {
+	Global string
+	Alias  string
+}
+
+// OutputName returns the name the column is returned under
+func (c SelectColumn) OutputName() string {
+	if c.Alias != "" {
+		return c.Alias
+	}
+	return c.Global
+}
+
+// QueryClauses holds the parts of a parsed global query that are applied on top of the resolved tables
+type QueryClauses struct {
+	Distinct bool
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []OrderItem
+	Limit    *int64
+	Offset   *int64
+
+	// SourceColumn names the column tagging each row with the physical table it came from;
+	// it is not mapped but generated as a literal. Empty when the global table has none.
+	SourceColumn string
+}
+
+// sourceTag returns the literal for the source column when column names it, or nil
+func (c *QueryClauses) sourceTag(column, source string) Expr {
+	if c.SourceColumn == "" || column != c.SourceColumn {
+		return nil
+	}
+	return &Literal{Kind: LiteralString, Value: source}
+}
+
+// branchClauses returns the clauses applied inside each source of a UNION or derived table:
+// the WHERE clause and source column, without ordering or paging
+func (c *QueryClauses) branchClauses() *QueryClauses {
+	return &QueryClauses{Where: c.Where, SourceColumn: c.SourceColumn}
+}
+
 // SQLGenerator generates Trino SQL from resolved components
 type SQLGenerator struct{}
 
@@ -16,66 +60,68 @@ func NewSQLGenerator() *SQLGenerator {
 }
 
 // GenerateSQL builds a simple SELECT query for a physical table
-// For Phase 1: Only supports simple SELECT with optional WHERE and LIMIT clauses
+// Supports a list of mapped columns with optional WHERE and LIMIT clauses
 func (g *SQLGenerator) GenerateSQL(
 	physicalTable *models.TableMapping,
 	columnMap map[string]string,
-	globalColumns []string,
-	whereClause string,
-	limitClause string,
-	isSelectAll bool,
+	columns []SelectColumn,
+	clauses *QueryClauses,
 ) (string, error) {
 	// Build fully qualified table name
-	fullTableName := fmt.Sprintf("%s.%s.%s",
-		physicalTable.CatalogName,
-		physicalTable.SchemaName,
-		physicalTable.TableName,
-	)
+	fullTableName, err := physicalTableName(physicalTable.CatalogName, physicalTable.SchemaName, physicalTable.TableName)
+	if err != nil {
+		return "", err
+	}
 
-	// Build SELECT clause
-	var selectClause string
-	if isSelectAll {
-		selectClause = "*"
-	} else {
-		// Map global columns to physical columns
-		physicalCols := make([]string, len(globalColumns))
-		for i, globalCol := range globalColumns {
-			physicalCol, exists := columnMap[globalCol]
-			if !exists {
-				return "", fmt.Errorf("column '%s' not found in column mapping", globalCol)
-			}
-			physicalCols[i] = physicalCol
+	source := fmt.Sprintf("%s.%s.%s", physicalTable.CatalogName, physicalTable.SchemaName, physicalTable.TableName)
+
+	// Map global columns to physical columns
+	selectParts := make([]string, len(columns))
+	for i, col := range columns {
+		if tag := clauses.sourceTag(col.Global, source); tag != nil {
+			selectParts[i] = fmt.Sprintf("%s AS %s", FormatExpr(tag), FormatIdent(col.OutputName()))
+			continue
 		}
-		selectClause = strings.Join(physicalCols, ", ")
+		physicalCol, exists := columnMap[col.Global]
+		if !exists {
+			return "", fmt.Errorf("column '%s' not found in column mapping", col.Global)
+		}
+		selectParts[i] = selectExpr(&ColumnRef{Column: physicalCol}, col.OutputName())
 	}
 
 	// Build the query
-	query := fmt.Sprintf("SELECT %s FROM %s", selectClause, fullTableName)
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selectParts, ", "), fullTableName)
 
-	// Add WHERE clause if present
-	if whereClause != "" {
-		// For Phase 1, pass through WHERE clause as-is
-		// TODO: In later phases, map column names in WHERE clause
-		query += fmt.Sprintf(" WHERE %s", whereClause)
+	// Add WHERE clause if present, with global columns mapped to this table's physical columns
+	whereClause, err := whereSQL(clauses.Where, func(globalCol string) (Expr, error) {
+		if tag := clauses.sourceTag(globalCol, source); tag != nil {
+			return tag, nil
+		}
+		physicalCol, exists := columnMap[globalCol]
+		if !exists {
+			return nil, fmt.Errorf("column '%s' in WHERE clause not found in column mapping", globalCol)
+		}
+		return &ColumnRef{Column: physicalCol}, nil
+	})
+	if err != nil {
+		return "", err
 	}
+	query += whereClause
 
 	// Add LIMIT clause if present
-	if limitClause != "" {
-		query += fmt.Sprintf(" LIMIT %s", limitClause)
-	}
+	query += limitSQL(clauses.Limit)
 
 	return query, nil
 }
 
 // GenerateUnionSQL builds a UNION query from multiple physical tables or a relation
-// For Phase 2: Supports UNION of physical tables
+// For Phase 2: Supports UNION of physical tables; unionType is "ALL" (default) or "DISTINCT"
 func (g *SQLGenerator) GenerateUnionSQL(
 	tables []*models.TableMapping,
 	columnMaps []map[string]string,
-	globalColumns []string,
-	whereClause string,
-	limitClause string,
-	isSelectAll bool,
+	columns []SelectColumn,
+	clauses *QueryClauses,
+	unionType string,
 ) (string, error) {
 	if len(tables) == 0 {
 		return "", fmt.Errorf("no tables provided for UNION")
@@ -85,11 +131,14 @@ func (g *SQLGenerator) GenerateUnionSQL(
 		return "", fmt.Errorf("mismatch between tables and column maps")
 	}
 
+	// Individual queries keep the WHERE clause but not the LIMIT
+	branchClauses := clauses.branchClauses()
+
 	queries := make([]string, len(tables))
 
 	for i, table := range tables {
 		// Generate SELECT for each table (without LIMIT for individual queries)
-		query, err := g.GenerateSQL(table, columnMaps[i], globalColumns, whereClause, "", isSelectAll)
+		query, err := g.GenerateSQL(table, columnMaps[i], columns, branchClauses)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate SQL for table %s.%s.%s: %w",
 				table.CatalogName, table.SchemaName, table.TableName, err)
@@ -98,53 +147,102 @@ func (g *SQLGenerator) GenerateUnionSQL(
 	}
 
 	// Join with UNION
-	unionQuery := strings.Join(queries, " UNION ")
+	unionQuery := strings.Join(queries, unionKeyword(unionType))
 
 	// Add LIMIT clause after UNION
-	if limitClause != "" {
-		unionQuery += fmt.Sprintf(" LIMIT %s", limitClause)
-	}
+	unionQuery += limitSQL(clauses.Limit)
 
 	return unionQuery, nil
 }
 
 // GenerateUnionFromRelation builds a UNION query from a resolved relation
-// For Phase 2: Supports simple UNION relations (no nesting)
+// Phase 4: Branches may be nested relations, which are emitted as parenthesised queries
 func (g *SQLGenerator) GenerateUnionFromRelation(
 	relation *ResolvedRelation,
-	columnMaps []map[string]string,
-	globalColumns []string,
-	whereClause string,
-	limitClause string,
-	isSelectAll bool,
+	columnMaps RelationColumnMaps,
+	columns []SelectColumn,
+	clauses *QueryClauses,
 ) (string, error) {
 	if relation.RelationType != "UNION" {
 		return "", fmt.Errorf("relation type '%s' not supported for UNION generation", relation.RelationType)
 	}
 
-	// Get physical tables from relation
-	tables := []*RelationNode{relation.LeftNode, relation.RightNode}
+	// Individual queries keep the WHERE clause but not the LIMIT
+	unionQuery, err := g.unionSQL(relation, columnMaps, columns, clauses.branchClauses())
+	if err != nil {
+		return "", err
+	}
+
+	// Add LIMIT clause after UNION
+	unionQuery += limitSQL(clauses.Limit)
 
-	if len(columnMaps) != len(tables) {
-		return "", fmt.Errorf("mismatch between relation tables and column maps")
+	return unionQuery, nil
+}
+
+// GenerateJoinFromRelation builds a JOIN query from a resolved relation
+// Phase 4: Sides may be nested relations, which are emitted as subqueries
+func (g *SQLGenerator) GenerateJoinFromRelation(
+	relation *ResolvedRelation,
+	columnMaps RelationColumnMaps,
+	columns []SelectColumn,
+	clauses *QueryClauses,
+) (string, error) {
+	if relation.RelationType != "JOIN" {
+		return "", fmt.Errorf("relation type '%s' not supported for JOIN generation", relation.RelationType)
 	}
 
-	queries := make([]string, len(tables))
+	query, err := g.joinSQL(relation, columnMaps, columns, clauses.branchClauses())
+	if err != nil {
+		return "", err
+	}
 
-	for i, node := range tables {
-		if node.Type != NodeTypePhysical {
-			return "", fmt.Errorf("nested relations not supported in Phase 2")
-		}
+	// Add LIMIT clause if present
+	query += limitSQL(clauses.Limit)
+
+	return query, nil
+}
 
-		// Build table mapping from node
-		tableMapping := &models.TableMapping{
-			CatalogName: node.Catalog,
-			SchemaName:  node.Schema,
-			TableName:   node.Table,
+// relationSQL builds the query for a relation node without a LIMIT.
+// The query returns the requested columns under their output names.
+func (g *SQLGenerator) relationSQL(
+	relation *ResolvedRelation,
+	columnMaps RelationColumnMaps,
+	columns []SelectColumn,
+	clauses *QueryClauses,
+) (string, error) {
+	switch relation.RelationType {
+	case "UNION":
+		return g.unionSQL(relation, columnMaps, columns, clauses)
+	case "JOIN":
+		return g.joinSQL(relation, columnMaps, columns, clauses)
+	default:
+		return "", fmt.Errorf("unsupported relation type: %s", relation.RelationType)
+	}
+}
+
+// unionSQL builds the branches of a UNION relation, each with its own WHERE rewrite
+func (g *SQLGenerator) unionSQL(
+	relation *ResolvedRelation,
+	columnMaps RelationColumnMaps,
+	columns []SelectColumn,
+	clauses *QueryClauses,
+) (string, error) {
+	nodes := []*RelationNode{relation.LeftNode, relation.RightNode}
+	queries := make([]string, len(nodes))
+
+	for i, node := range nodes {
+		if node.Type == NodeTypeRelation {
+			// Nested relation - parenthesise its query
+			query, err := g.relationSQL(node.Relation, columnMaps, columns, clauses)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate SQL for relation '%s': %w", node.Relation.Name, err)
+			}
+			queries[i] = "(" + query + ")"
+			continue
 		}
 
 		// Generate SELECT for this table (without LIMIT for individual queries)
-		query, err := g.GenerateSQL(tableMapping, columnMaps[i], globalColumns, whereClause, "", isSelectAll)
+		query, err := g.GenerateSQL(physicalTableMapping(node), columnMaps[node], columns, clauses)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate SQL for table %s.%s.%s: %w",
 				node.Catalog, node.Schema, node.Table, err)
@@ -153,100 +251,402 @@ func (g *SQLGenerator) GenerateUnionFromRelation(
 	}
 
 	// Join with UNION
-	unionQuery := strings.Join(queries, " UNION ")
-
-	// Add LIMIT clause after UNION
-	if limitClause != "" {
-		unionQuery += fmt.Sprintf(" LIMIT %s", limitClause)
-	}
+	return strings.Join(queries, unionKeyword(relation.UnionType)), nil
+}
 
-	return unionQuery, nil
+// relationJoinSide is one side of a JOIN relation: what to put in the FROM clause
+// and how to reference a global column from it
+type relationJoinSide struct {
+	from        string
+	joinColumns []*ColumnRef
+	column      func(globalCol string) (*ColumnRef, bool)
 }
 
-// GenerateJoinFromRelation builds a JOIN query from a resolved relation
-// For Phase 3: Supports simple JOIN relations (no nesting)
-func (g *SQLGenerator) GenerateJoinFromRelation(
+// joinSQL builds a JOIN relation as a single SELECT over aliases t1 and t2.
+// Global columns provided by both sides are merged with COALESCE, so outer joins
+// return a value from whichever side matched; the source column of a JOIN holds
+// the relation name, as its rows come from both sides.
+func (g *SQLGenerator) joinSQL(
 	relation *ResolvedRelation,
-	columnMaps []map[string]string,
-	globalColumns []string,
-	whereClause string,
-	limitClause string,
-	isSelectAll bool,
+	columnMaps RelationColumnMaps,
+	columns []SelectColumn,
+	clauses *QueryClauses,
 ) (string, error) {
-	if relation.RelationType != "JOIN" {
-		return "", fmt.Errorf("relation type '%s' not supported for JOIN generation", relation.RelationType)
-	}
+	where := clauses.Where
 
-	if relation.JoinColumn == nil {
+	if len(relation.JoinColumns) == 0 {
 		return "", fmt.Errorf("JOIN relation requires join columns")
 	}
 
-	// Verify both nodes are physical
-	if relation.LeftNode.Type != NodeTypePhysical || relation.RightNode.Type != NodeTypePhysical {
-		return "", fmt.Errorf("nested relations not supported in Phase 3")
-	}
-
-	// Build table names with aliases
-	leftTableFull := fmt.Sprintf("%s.%s.%s",
-		relation.LeftNode.Catalog,
-		relation.LeftNode.Schema,
-		relation.LeftNode.Table)
-	rightTableFull := fmt.Sprintf("%s.%s.%s",
-		relation.RightNode.Catalog,
-		relation.RightNode.Schema,
-		relation.RightNode.Table)
-
-	leftAlias := "t1"
-	rightAlias := "t2"
-
-	// Build SELECT clause with qualified column names
-	var selectClause string
-	if isSelectAll {
-		// For SELECT *, we need to qualify columns to avoid ambiguity
-		selectClause = fmt.Sprintf("%s.*, %s.*", leftAlias, rightAlias)
-	} else {
-		// Map each global column to its physical column with table alias
-		if len(columnMaps) != 2 {
-			return "", fmt.Errorf("expected 2 column maps for JOIN, got %d", len(columnMaps))
-		}
-
-		selectParts := make([]string, 0)
-		for _, globalCol := range globalColumns {
-			// Check left table first
-			if physicalCol, exists := columnMaps[0][globalCol]; exists {
-				selectParts = append(selectParts, fmt.Sprintf("%s.%s", leftAlias, physicalCol))
-			} else if physicalCol, exists := columnMaps[1][globalCol]; exists {
-				// Check right table
-				selectParts = append(selectParts, fmt.Sprintf("%s.%s", rightAlias, physicalCol))
-			} else {
-				return "", fmt.Errorf("column '%s' not found in either table", globalCol)
+	// Global columns this join has to produce, for the select list and the WHERE clause
+	needed := globalColumnNames(columns)
+	whereRefs, err := CollectColumnRefs(where)
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range whereRefs {
+		needed = append(needed, ref.Column)
+	}
+
+	// Build both sides with aliases
+	leftKeys := make([]string, len(relation.JoinColumns))
+	rightKeys := make([]string, len(relation.JoinColumns))
+	for i, key := range relation.JoinColumns {
+		leftKeys[i], rightKeys[i] = key.Left, key.Right
+	}
+
+	left, err := g.joinSideSQL(relation.LeftNode, "t1", leftKeys, needed, columnMaps)
+	if err != nil {
+		return "", err
+	}
+	right, err := g.joinSideSQL(relation.RightNode, "t2", rightKeys, needed, columnMaps)
+	if err != nil {
+		return "", err
+	}
+
+	conditions := make([]string, len(relation.JoinColumns))
+	for i := range relation.JoinColumns {
+		conditions[i] = fmt.Sprintf("%s = %s", FormatExpr(left.joinColumns[i]), FormatExpr(right.joinColumns[i]))
+	}
+
+	// Map each global column to the side that provides it. Columns on both sides are
+	// merged, except join keys of an inner join, which are equal on both sides.
+	resolve := func(globalCol string) (Expr, bool) {
+		leftCol, inLeft := left.column(globalCol)
+		rightCol, inRight := right.column(globalCol)
+		switch {
+		case inLeft && inRight:
+			if !isOuterJoin(relation.JoinType) && isJoinKey(left, right, leftCol, rightCol) {
+				return leftCol, true
 			}
+			return &FuncCall{Name: "COALESCE", Args: []Expr{leftCol, rightCol}}, true
+		case inLeft:
+			return leftCol, true
+		case inRight:
+			return rightCol, true
+		}
+		return nil, false
+	}
+
+	selectParts := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if tag := clauses.sourceTag(col.Global, relation.Name); tag != nil {
+			selectParts = append(selectParts, fmt.Sprintf("%s AS %s", FormatExpr(tag), FormatIdent(col.OutputName())))
+			continue
+		}
+		column, exists := resolve(col.Global)
+		if !exists {
+			return "", fmt.Errorf("column '%s' not found in either table", col.Global)
 		}
-		selectClause = strings.Join(selectParts, ", ")
+		selectParts = append(selectParts, selectExpr(column, col.OutputName()))
 	}
 
 	// Build the JOIN query
-	query := fmt.Sprintf("SELECT %s FROM %s %s JOIN %s %s ON %s.%s = %s.%s",
-		selectClause,
-		leftTableFull,
-		leftAlias,
-		rightTableFull,
-		rightAlias,
-		leftAlias,
-		relation.JoinColumn.Left,
-		rightAlias,
-		relation.JoinColumn.Right,
+	query := fmt.Sprintf("SELECT %s FROM %s %s %s ON %s",
+		strings.Join(selectParts, ", "),
+		left.from,
+		joinKeyword(relation.JoinType),
+		right.from,
+		strings.Join(conditions, " AND "),
 	)
 
-	// Add WHERE clause if present
-	if whereClause != "" {
-		query += fmt.Sprintf(" WHERE %s", whereClause)
+	// Add WHERE clause if present, qualifying each column with the alias of the side it maps to
+	whereClause, err := whereSQL(where, func(globalCol string) (Expr, error) {
+		if tag := clauses.sourceTag(globalCol, relation.Name); tag != nil {
+			return tag, nil
+		}
+		column, exists := resolve(globalCol)
+		if !exists {
+			return nil, fmt.Errorf("column '%s' in WHERE clause not found in either table", globalCol)
+		}
+		return column, nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Add LIMIT clause if present
-	if limitClause != "" {
-		query += fmt.Sprintf(" LIMIT %s", limitClause)
+	return query + whereClause, nil
+}
+
+// joinSideSQL builds one side of a JOIN relation. Physical tables are referenced
+// directly and joined on physical columns; nested relations become a subquery
+// exposing the global columns they can provide and are joined on global columns.
+func (g *SQLGenerator) joinSideSQL(
+	node *RelationNode,
+	alias string,
+	joinColumns []string,
+	needed []string,
+	columnMaps RelationColumnMaps,
+) (*relationJoinSide, error) {
+	keys := make([]*ColumnRef, len(joinColumns))
+	for i, col := range joinColumns {
+		keys[i] = &ColumnRef{Table: alias, Column: col}
 	}
 
-	return query, nil
+	if node.Type == NodeTypePhysical {
+		columnMap := columnMaps[node]
+		tableName, err := physicalTableName(node.Catalog, node.Schema, node.Table)
+		if err != nil {
+			return nil, err
+		}
+		return &relationJoinSide{
+			from:        tableName + " " + FormatIdent(alias),
+			joinColumns: keys,
+			column: func(globalCol string) (*ColumnRef, bool) {
+				physicalCol, exists := columnMap[globalCol]
+				if !exists {
+					return nil, false
+				}
+				return &ColumnRef{Table: alias, Column: physicalCol}, true
+			},
+		}, nil
+	}
+
+	// Select the join columns and every needed column the nested relation provides
+	provided := make(map[string]bool)
+	var subColumns []SelectColumn
+	for _, col := range joinColumns {
+		if !columnMaps.Provides(node, col) {
+			return nil, fmt.Errorf("join column '%s' is not provided by nested relation '%s'", col, node.Relation.Name)
+		}
+		if !provided[col] {
+			provided[col] = true
+			subColumns = append(subColumns, SelectColumn{Global: col})
+		}
+	}
+	for _, col := range needed {
+		if !provided[col] && columnMaps.Provides(node, col) {
+			provided[col] = true
+			subColumns = append(subColumns, SelectColumn{Global: col})
+		}
+	}
+
+	query, err := g.relationSQL(node.Relation, columnMaps, subColumns, &QueryClauses{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SQL for relation '%s': %w", node.Relation.Name, err)
+	}
+
+	return &relationJoinSide{
+		from:        fmt.Sprintf("(%s) %s", query, FormatIdent(alias)),
+		joinColumns: keys,
+		column: func(globalCol string) (*ColumnRef, bool) {
+			if !provided[globalCol] {
+				return nil, false
+			}
+			return &ColumnRef{Table: alias, Column: globalCol}, true
+		},
+	}, nil
+}
+
+// isJoinKey reports whether two columns are joined on each other by the same key pair
+func isJoinKey(left, right *relationJoinSide, leftCol, rightCol *ColumnRef) bool {
+	for i := range left.joinColumns {
+		if *left.joinColumns[i] == *leftCol && *right.joinColumns[i] == *rightCol {
+			return true
+		}
+	}
+	return false
+}
+
+// isOuterJoin reports whether a join type keeps rows without a match on one of its sides
+func isOuterJoin(joinType string) bool {
+	return joinType == models.JoinLeft || joinType == models.JoinRight || joinType == models.JoinFull
+}
+
+// joinKeyword returns the SQL keyword for a JOIN relation's join type; INNER is written as JOIN
+func joinKeyword(joinType string) string {
+	switch joinType {
+	case models.JoinLeft:
+		return "LEFT JOIN"
+	case models.JoinRight:
+		return "RIGHT JOIN"
+	case models.JoinFull:
+		return "FULL OUTER JOIN"
+	}
+	return "JOIN"
+}
+
+// physicalTableMapping builds a table mapping for a physical relation node
+func physicalTableMapping(node *RelationNode) *models.TableMapping {
+	return &models.TableMapping{
+		CatalogName: node.Catalog,
+		SchemaName:  node.Schema,
+		TableName:   node.Table,
+	}
+}
+
+// GenerateMappedQuery builds a query over a single physical table with every global
+// column reference in the select list and clauses mapped to its physical column.
+// Used for queries that aggregate, group, sort or compute expressions.
+func (g *SQLGenerator) GenerateMappedQuery(
+	physicalTable *models.TableMapping,
+	columnMap map[string]string,
+	items []SelectItem,
+	clauses *QueryClauses,
+) (string, error) {
+	source := fmt.Sprintf("%s.%s.%s", physicalTable.CatalogName, physicalTable.SchemaName, physicalTable.TableName)
+
+	stmt, err := buildSelect(items, clauses, func(col *ColumnRef) (Expr, error) {
+		if tag := clauses.sourceTag(col.Column, source); tag != nil {
+			return tag, nil
+		}
+		physicalCol, exists := columnMap[col.Column]
+		if !exists {
+			return nil, fmt.Errorf("column '%s' not found in column mapping", col.Column)
+		}
+		return &ColumnRef{Column: physicalCol}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	stmt.From = &TableName{
+		Catalog: physicalTable.CatalogName,
+		Schema:  physicalTable.SchemaName,
+		Name:    physicalTable.TableName,
+	}
+
+	return stmt.String(), nil
+}
+
+// GenerateDerivedQuery wraps a source query in a derived table and applies the
+// select list, grouping, ordering and paging outside it. The source query must
+// return global column names and already apply the WHERE clause; this is how
+// aggregates are computed over UNIONs and relations.
+func (g *SQLGenerator) GenerateDerivedQuery(
+	sourceSQL string,
+	alias string,
+	items []SelectItem,
+	clauses *QueryClauses,
+) (string, error) {
+	outerClauses := *clauses
+	outerClauses.Where = nil
+
+	stmt, err := buildSelect(items, &outerClauses, func(col *ColumnRef) (Expr, error) {
+		return col, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	stmt.From = &GeneratedTable{SQL: sourceSQL, Alias: alias}
+
+	return stmt.String(), nil
+}
+
+// buildSelect builds a statement without a FROM clause from a select list and clauses,
+// with every column reference replaced through resolve. ORDER BY items naming an output
+// column are left as they are. Column references that change name keep their original
+// name as the output name.
+func buildSelect(items []SelectItem, clauses *QueryClauses, resolve ColumnRewriter) (*SelectStmt, error) {
+	stmt := &SelectStmt{
+		Distinct: clauses.Distinct,
+		Items:    make([]SelectItem, len(items)),
+		Limit:    clauses.Limit,
+		Offset:   clauses.Offset,
+	}
+
+	for i, item := range items {
+		expr, err := RewriteColumns(item.Expr, resolve)
+		if err != nil {
+			return nil, err
+		}
+		alias := item.Alias
+		if original, ok := item.Expr.(*ColumnRef); ok && alias == "" {
+			if mapped, ok := expr.(*ColumnRef); !ok || mapped.Column != original.Column {
+				alias = original.Column
+			}
+		}
+		stmt.Items[i] = SelectItem{Expr: expr, Alias: alias}
+	}
+
+	var err error
+	if stmt.Where, err = RewriteColumns(clauses.Where, resolve); err != nil {
+		return nil, err
+	}
+	if stmt.GroupBy, err = rewriteList(clauses.GroupBy, resolve); err != nil {
+		return nil, err
+	}
+	if stmt.Having, err = RewriteColumns(clauses.Having, resolve); err != nil {
+		return nil, err
+	}
+
+	outputs := outputNames(items)
+	for _, item := range clauses.OrderBy {
+		if col, ok := item.Expr.(*ColumnRef); ok && col.Table == "" && outputs[col.Column] {
+			stmt.OrderBy = append(stmt.OrderBy, item)
+			continue
+		}
+		expr, err := RewriteColumns(item.Expr, resolve)
+		if err != nil {
+			return nil, err
+		}
+		stmt.OrderBy = append(stmt.OrderBy, OrderItem{Expr: expr, Desc: item.Desc, Nulls: item.Nulls})
+	}
+
+	return stmt, nil
+}
+
+// outputNames returns the names of the columns a select list returns
+func outputNames(items []SelectItem) map[string]bool {
+	names := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.Alias != "" {
+			names[item.Alias] = true
+		} else if col, ok := item.Expr.(*ColumnRef); ok {
+			names[col.Column] = true
+		}
+	}
+	return names
+}
+
+// selectExpr renders a select list entry, aliasing it unless it is a column with the output name
+func selectExpr(expr Expr, outputName string) string {
+	if column, ok := expr.(*ColumnRef); ok && column.Column == outputName {
+		return FormatExpr(column)
+	}
+	return fmt.Sprintf("%s AS %s", FormatExpr(expr), FormatIdent(outputName))
+}
+
+// physicalTableName validates a physical table's catalog, schema and table names and formats
+// them as a qualified name
+func physicalTableName(catalog, schema, table string) (string, error) {
+	name, err := datasync.QualifiedName(catalog, schema, table)
+	if err != nil {
+		return "", fmt.Errorf("invalid physical table '%s.%s.%s': %w", catalog, schema, table, err)
+	}
+	return name, nil
+}
+
+// whereSQL renders a WHERE clause with every global column reference replaced
+// by the expression returned by resolve, or nothing when there is no predicate
+func whereSQL(where Expr, resolve func(globalCol string) (Expr, error)) (string, error) {
+	if where == nil {
+		return "", nil
+	}
+
+	mapped, err := RewriteColumns(where, func(col *ColumnRef) (Expr, error) {
+		return resolve(col.Column)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return " WHERE " + FormatExpr(mapped), nil
+}
+
+// unionKeyword returns the operator joining UNION branches: UNION ALL unless DISTINCT is requested
+func unionKeyword(unionType string) string {
+	if models.IsUnionDistinct(unionType) {
+		return " UNION "
+	}
+	return " UNION ALL "
+}
+
+// limitSQL renders a LIMIT clause, or nothing when no limit is set
+func limitSQL(limit *int64) string {
+	if limit == nil {
+		return ""
+	}
+	return fmt.Sprintf(" LIMIT %d", *limit)
 }
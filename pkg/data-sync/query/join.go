@@ -0,0 +1,365 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// joinSide is one global table taking part in a join between global tables
+type joinSide struct {
+	// Global table name and the name it is referenced by in the query (alias or table name)
+	TableName string
+	Name      string
+
+	// Columns defined on the global table
+	globalColumns map[string]bool
+
+	// Global columns the outer query reads from this side, in order of first use
+	needed   []string
+	isNeeded map[string]bool
+
+	// All columns are needed (the query selects * or name.*)
+	all bool
+}
+
+// need records that the outer query reads a column from this side
+func (s *joinSide) need(column string) {
+	if !s.isNeeded[column] {
+		s.isNeeded[column] = true
+		s.needed = append(s.needed, column)
+	}
+}
+
+// joinQuery holds the global tables of a join and the state needed to rewrite it
+type joinQuery struct {
+	sides  []*joinSide
+	byName map[string]*joinSide
+
+	// Columns named in USING clauses; unqualified references to them stay unqualified
+	using map[string]bool
+}
+
+// translateJoin converts a query joining several global tables to Trino SQL.
+// Each global table is translated on its own and emitted as a subquery that
+// exposes global column names, so the outer query keeps the user's column
+// references. Joins without an ON or USING clause use the declared column relationships.
+func (t *Translator) translateJoin(stmt *SelectStmt) (string, error) {
+	// 1. Collect the global tables being joined
+	query := &joinQuery{byName: make(map[string]*joinSide), using: make(map[string]bool)}
+	if err := t.collectJoinSides(stmt.From, query); err != nil {
+		return "", fmt.Errorf("analysis error: %w", err)
+	}
+
+	// 2. Fill in missing join conditions from declared relationships
+	if err := t.applyRelationships(stmt.From, query); err != nil {
+		return "", fmt.Errorf("relationship error: %w", err)
+	}
+
+	// 3. Qualify column references and record the columns each side needs
+	if err := query.qualifyJoinConditions(stmt.From); err != nil {
+		return "", fmt.Errorf("analysis error: %w", err)
+	}
+
+	if err := query.markStars(stmt.Items); err != nil {
+		return "", fmt.Errorf("analysis error: %w", err)
+	}
+
+	outer, err := buildSelect(stmt.Items, &QueryClauses{
+		Distinct: stmt.Distinct,
+		Where:    stmt.Where,
+		GroupBy:  stmt.GroupBy,
+		Having:   stmt.Having,
+		OrderBy:  stmt.OrderBy,
+		Limit:    stmt.Limit,
+		Offset:   stmt.Offset,
+	}, query.qualify)
+	if err != nil {
+		return "", fmt.Errorf("analysis error: %w", err)
+	}
+
+	// 4. Translate each global table to a subquery
+	subqueries := make(map[string]string, len(query.sides))
+	for _, side := range query.sides {
+		target := &queryTarget{TableName: side.TableName, Clauses: &QueryClauses{}}
+		if !side.all && len(side.needed) > 0 {
+			target.Columns = make([]SelectColumn, len(side.needed))
+			for i, col := range side.needed {
+				target.Columns[i] = SelectColumn{Global: col}
+			}
+		}
+
+		sql, err := t.translateTarget(target)
+		if err != nil {
+			return "", fmt.Errorf("failed to translate global table '%s': %w", side.TableName, err)
+		}
+		subqueries[side.Name] = sql
+	}
+
+	// 5. Build the outer query over the subqueries
+	outer.From = withSubqueries(stmt.From, subqueries)
+
+	return outer.String(), nil
+}
+
+// collectJoinSides records every global table in a FROM clause
+func (t *Translator) collectJoinSides(from TableExpr, query *joinQuery) error {
+	switch from := from.(type) {
+	case *JoinExpr:
+		if err := t.collectJoinSides(from.Left, query); err != nil {
+			return err
+		}
+		return t.collectJoinSides(from.Right, query)
+
+	case *TableName:
+		if from.Catalog != "" || from.Schema != "" {
+			return fmt.Errorf("'%s' is not a global table name; query global tables without catalog or schema", FormatTableExpr(from))
+		}
+
+		name := referenceName(from)
+		if query.byName[name] != nil {
+			return fmt.Errorf("table name '%s' is used more than once; give each joined table a distinct alias", name)
+		}
+
+		globalTable, err := t.storage.GetGlobalTable(from.Name)
+		if err != nil || globalTable == nil {
+			return fmt.Errorf("global table '%s' not found", from.Name)
+		}
+
+		columns, err := t.storage.ListGlobalColumns(from.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get columns for global table '%s': %w", from.Name, err)
+		}
+
+		side := &joinSide{
+			TableName:     from.Name,
+			Name:          name,
+			globalColumns: make(map[string]bool, len(columns)),
+			isNeeded:      make(map[string]bool),
+		}
+		for _, col := range columns {
+			side.globalColumns[col.Name] = true
+		}
+		if globalTable.SourceColumn != "" {
+			side.globalColumns[globalTable.SourceColumn] = true
+		}
+
+		query.sides = append(query.sides, side)
+		query.byName[name] = side
+		return nil
+
+	default:
+		return fmt.Errorf("subqueries in FROM are not supported")
+	}
+}
+
+// applyRelationships sets the ON clause of every join that has neither ON nor
+// USING to the column relationship declared between its two sides
+func (t *Translator) applyRelationships(from TableExpr, query *joinQuery) error {
+	join, ok := from.(*JoinExpr)
+	if !ok {
+		return nil
+	}
+
+	if err := t.applyRelationships(join.Left, query); err != nil {
+		return err
+	}
+	if err := t.applyRelationships(join.Right, query); err != nil {
+		return err
+	}
+
+	if join.Type == JoinCross || join.On != nil || len(join.Using) > 0 {
+		return nil
+	}
+
+	leftSides := query.sidesOf(join.Left)
+	rightSides := query.sidesOf(join.Right)
+
+	var conditions []Expr
+	for _, left := range leftSides {
+		relationships, err := t.storage.ListColumnRelationships(left.TableName)
+		if err != nil {
+			return fmt.Errorf("failed to get relationships for global table '%s': %w", left.TableName, err)
+		}
+
+		for _, rel := range relationships {
+			for _, right := range rightSides {
+				if cond := relationshipCondition(rel, left, right); cond != nil {
+					conditions = append(conditions, cond)
+				}
+			}
+		}
+	}
+
+	switch len(conditions) {
+	case 0:
+		return fmt.Errorf("no relationship declared for join with '%s'; add an ON clause", FormatTableExpr(join.Right))
+	case 1:
+		join.On = conditions[0]
+		return nil
+	default:
+		return fmt.Errorf("multiple relationships declared for join with '%s'; add an ON clause", FormatTableExpr(join.Right))
+	}
+}
+
+// relationshipCondition returns the join condition a relationship implies
+// between two sides, or nil when it does not link them
+func relationshipCondition(rel *models.ColumnRelationship, left, right *joinSide) Expr {
+	var leftColumn, rightColumn string
+	switch {
+	case rel.SourceGlobalTableName == left.TableName && rel.TargetGlobalTableName == right.TableName:
+		leftColumn, rightColumn = rel.SourceGlobalColumnName, rel.TargetGlobalColumnName
+	case rel.SourceGlobalTableName == right.TableName && rel.TargetGlobalTableName == left.TableName:
+		leftColumn, rightColumn = rel.TargetGlobalColumnName, rel.SourceGlobalColumnName
+	default:
+		return nil
+	}
+
+	return &BinaryExpr{
+		Op:    "=",
+		Left:  &ColumnRef{Table: left.Name, Column: leftColumn},
+		Right: &ColumnRef{Table: right.Name, Column: rightColumn},
+	}
+}
+
+// sidesOf returns the joined tables in a FROM clause subtree
+func (q *joinQuery) sidesOf(from TableExpr) []*joinSide {
+	switch from := from.(type) {
+	case *JoinExpr:
+		return append(q.sidesOf(from.Left), q.sidesOf(from.Right)...)
+	case *TableName:
+		return []*joinSide{q.byName[referenceName(from)]}
+	}
+	return nil
+}
+
+// qualifyJoinConditions qualifies the column references in every ON clause
+// and records the columns named in USING clauses
+func (q *joinQuery) qualifyJoinConditions(from TableExpr) error {
+	join, ok := from.(*JoinExpr)
+	if !ok {
+		return nil
+	}
+
+	if err := q.qualifyJoinConditions(join.Left); err != nil {
+		return err
+	}
+	if err := q.qualifyJoinConditions(join.Right); err != nil {
+		return err
+	}
+
+	if join.On != nil {
+		on, err := RewriteColumns(join.On, q.qualify)
+		if err != nil {
+			return err
+		}
+		join.On = on
+	}
+
+	for _, col := range join.Using {
+		for _, sides := range [][]*joinSide{q.sidesOf(join.Left), q.sidesOf(join.Right)} {
+			found := false
+			for _, side := range sides {
+				if side.globalColumns[col] {
+					side.need(col)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("USING column '%s' not found on both sides of the join", col)
+			}
+		}
+		q.using[col] = true
+	}
+
+	return nil
+}
+
+// markStars records the joined tables whose columns are all selected by * or name.*
+func (q *joinQuery) markStars(items []SelectItem) error {
+	for _, item := range items {
+		star, ok := item.Expr.(*StarExpr)
+		if !ok {
+			continue
+		}
+		if star.Table == "" {
+			for _, side := range q.sides {
+				side.all = true
+			}
+			continue
+		}
+		side := q.byName[star.Table]
+		if side == nil {
+			return fmt.Errorf("unknown table '%s' in %s.*", star.Table, star.Table)
+		}
+		side.all = true
+	}
+	return nil
+}
+
+// qualify resolves a column reference to the joined table it belongs to,
+// records that the column is needed and returns the qualified reference
+func (q *joinQuery) qualify(col *ColumnRef) (Expr, error) {
+	if col.Table != "" {
+		side := q.byName[col.Table]
+		if side == nil {
+			return nil, fmt.Errorf("unknown table '%s' in column reference %s", col.Table, FormatExpr(col))
+		}
+		if !side.globalColumns[col.Column] {
+			return nil, fmt.Errorf("column '%s' not found in global table '%s'", col.Column, side.TableName)
+		}
+		side.need(col.Column)
+		return &ColumnRef{Table: col.Table, Column: col.Column}, nil
+	}
+
+	var matches []*joinSide
+	for _, side := range q.sides {
+		if side.globalColumns[col.Column] {
+			matches = append(matches, side)
+		}
+	}
+
+	// Columns joined with USING are merged into one and may be referenced unqualified
+	if q.using[col.Column] {
+		for _, side := range matches {
+			side.need(col.Column)
+		}
+		return &ColumnRef{Column: col.Column}, nil
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("column '%s' not found in any joined table", col.Column)
+	case 1:
+		matches[0].need(col.Column)
+		return &ColumnRef{Table: matches[0].Name, Column: col.Column}, nil
+	default:
+		return nil, fmt.Errorf("column reference '%s' is ambiguous; qualify it with a table name", col.Column)
+	}
+}
+
+// withSubqueries returns a copy of a FROM clause with every global table replaced by its translated subquery
+func withSubqueries(from TableExpr, subqueries map[string]string) TableExpr {
+	switch from := from.(type) {
+	case *JoinExpr:
+		return &JoinExpr{
+			Type:  from.Type,
+			Left:  withSubqueries(from.Left, subqueries),
+			Right: withSubqueries(from.Right, subqueries),
+			On:    from.On,
+			Using: from.Using,
+		}
+	case *TableName:
+		name := referenceName(from)
+		return &GeneratedTable{SQL: subqueries[name], Alias: name}
+	}
+	return from
+}
+
+// referenceName returns the name a table is referenced by in the query
+func referenceName(table *TableName) string {
+	if table.Alias != "" {
+		return table.Alias
+	}
+	return table.Name
+}
@@ -0,0 +1,267 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenType identifies the kind of a lexical token
+type TokenType int
+
+const (
+	TokenEOF TokenType = iota
+	TokenIdent
+	TokenQuotedIdent
+	TokenKeyword
+	TokenNumber
+	TokenString
+	TokenOperator
+	TokenComma
+	TokenDot
+	TokenLParen
+	TokenRParen
+	TokenSemicolon
+	TokenParam // ? or :name; Value is the name, empty for ?
+)
+
+// Token is a single lexical unit of a SQL query
+type Token struct {
+	Type  TokenType
+	Value string // Keywords are upper-cased, quoted identifiers are unquoted
+	Pos   int    // Byte offset in the original query
+}
+
+// keywords lists the reserved words recognised by the parser.
+// Anything not listed here is treated as a plain identifier; non-reserved
+// words such as NULLS, FIRST or ROWS are matched contextually by the parser.
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "ALL": true, "AS": true, "FROM": true,
+	"WHERE": true, "GROUP": true, "BY": true, "HAVING": true, "ORDER": true,
+	"ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true,
+	"OUTER": true, "CROSS": true, "ON": true, "USING": true,
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true, "NULL": true,
+	"LIKE": true, "BETWEEN": true, "EXISTS": true,
+	"TRUE": true, "FALSE": true, "CASE": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "END": true, "CAST": true, "TRY_CAST": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true,
+}
+
+// Lexer splits a SQL query into tokens
+type Lexer struct {
+	input string
+	pos   int
+}
+
+// NewLexer creates a lexer for the given query
+func NewLexer(input string) *Lexer {
+	return &Lexer{input: input}
+}
+
+// Tokenize returns all tokens in the input, terminated by a TokenEOF token
+func (l *Lexer) Tokenize() ([]Token, error) {
+	var tokens []Token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.Type == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *Lexer) next() (Token, error) {
+	if err := l.skipWhitespaceAndComments(); err != nil {
+		return Token{}, err
+	}
+
+	if l.pos >= len(l.input) {
+		return Token{Type: TokenEOF, Pos: l.pos}, nil
+	}
+
+	start := l.pos
+	ch := l.input[l.pos]
+
+	switch {
+	case ch == '\'':
+		value, err := l.readQuoted('\'')
+		if err != nil {
+			return Token{}, err
+		}
+		return Token{Type: TokenString, Value: value, Pos: start}, nil
+
+	case ch == '"' || ch == '`':
+		value, err := l.readQuoted(ch)
+		if err != nil {
+			return Token{}, err
+		}
+		if value == "" {
+			return Token{}, fmt.Errorf("empty quoted identifier at position %d", start)
+		}
+		return Token{Type: TokenQuotedIdent, Value: value, Pos: start}, nil
+
+	case isDigit(ch) || (ch == '.' && l.pos+1 < len(l.input) && isDigit(l.input[l.pos+1])):
+		return Token{Type: TokenNumber, Value: l.readNumber(), Pos: start}, nil
+
+	case isIdentStart(ch):
+		word := l.readWord()
+		upper := strings.ToUpper(word)
+		if keywords[upper] {
+			return Token{Type: TokenKeyword, Value: upper, Pos: start}, nil
+		}
+		return Token{Type: TokenIdent, Value: word, Pos: start}, nil
+	}
+
+	l.pos++
+	switch ch {
+	case '?':
+		return Token{Type: TokenParam, Pos: start}, nil
+	case ':':
+		if l.pos < len(l.input) && (isIdentStart(l.input[l.pos]) || isDigit(l.input[l.pos])) {
+			return Token{Type: TokenParam, Value: l.readWord(), Pos: start}, nil
+		}
+	case ',':
+		return Token{Type: TokenComma, Value: ",", Pos: start}, nil
+	case '.':
+		return Token{Type: TokenDot, Value: ".", Pos: start}, nil
+	case '(':
+		return Token{Type: TokenLParen, Value: "(", Pos: start}, nil
+	case ')':
+		return Token{Type: TokenRParen, Value: ")", Pos: start}, nil
+	case ';':
+		return Token{Type: TokenSemicolon, Value: ";", Pos: start}, nil
+	case '=', '+', '-', '*', '/', '%':
+		return Token{Type: TokenOperator, Value: string(ch), Pos: start}, nil
+	case '<':
+		if l.peekByte('=') || l.peekByte('>') {
+			op := l.input[start : l.pos+1]
+			l.pos++
+			return Token{Type: TokenOperator, Value: op, Pos: start}, nil
+		}
+		return Token{Type: TokenOperator, Value: "<", Pos: start}, nil
+	case '>':
+		if l.peekByte('=') {
+			l.pos++
+			return Token{Type: TokenOperator, Value: ">=", Pos: start}, nil
+		}
+		return Token{Type: TokenOperator, Value: ">", Pos: start}, nil
+	case '!':
+		if l.peekByte('=') {
+			l.pos++
+			return Token{Type: TokenOperator, Value: "<>", Pos: start}, nil
+		}
+	case '|':
+		if l.peekByte('|') {
+			l.pos++
+			return Token{Type: TokenOperator, Value: "||", Pos: start}, nil
+		}
+	}
+
+	return Token{}, fmt.Errorf("unexpected character '%c' at position %d", ch, start)
+}
+
+func (l *Lexer) skipWhitespaceAndComments() error {
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			l.pos++
+		case strings.HasPrefix(l.input[l.pos:], "--"):
+			end := strings.IndexByte(l.input[l.pos:], '\n')
+			if end < 0 {
+				l.pos = len(l.input)
+			} else {
+				l.pos += end + 1
+			}
+		case strings.HasPrefix(l.input[l.pos:], "/*"):
+			end := strings.Index(l.input[l.pos+2:], "*/")
+			if end < 0 {
+				return fmt.Errorf("unterminated comment at position %d", l.pos)
+			}
+			l.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// readQuoted reads a quoted string or identifier. A doubled quote character
+// inside the value is an escaped quote.
+func (l *Lexer) readQuoted(quote byte) (string, error) {
+	start := l.pos
+	l.pos++ // opening quote
+
+	var sb strings.Builder
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		if ch == quote {
+			if l.pos+1 < len(l.input) && l.input[l.pos+1] == quote {
+				sb.WriteByte(quote)
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			return sb.String(), nil
+		}
+		sb.WriteByte(ch)
+		l.pos++
+	}
+
+	return "", fmt.Errorf("unterminated quoted value starting at position %d", start)
+}
+
+func (l *Lexer) readNumber() string {
+	start := l.pos
+	for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+		l.pos++
+	}
+	if l.pos < len(l.input) && l.input[l.pos] == '.' {
+		l.pos++
+		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+		exp := l.pos + 1
+		if exp < len(l.input) && (l.input[exp] == '+' || l.input[exp] == '-') {
+			exp++
+		}
+		if exp < len(l.input) && isDigit(l.input[exp]) {
+			l.pos = exp
+			for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+				l.pos++
+			}
+		}
+	}
+	return l.input[start:l.pos]
+}
+
+func (l *Lexer) readWord() string {
+	start := l.pos
+	for l.pos < len(l.input) {
+		ch := l.input[l.pos]
+		if !isIdentStart(ch) && !isDigit(ch) {
+			break
+		}
+		l.pos++
+	}
+	return l.input[start:l.pos]
+}
+
+func (l *Lexer) peekByte(b byte) bool {
+	return l.pos < len(l.input) && l.input[l.pos] == b
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// isIdentStart reports whether ch can start an unquoted identifier.
+// Bytes of multi-byte UTF-8 sequences are accepted so non-ASCII names lex as words.
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= 0x80
+}
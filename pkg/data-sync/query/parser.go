@@ -2,18 +2,11 @@ package query
 
 import (
 	"fmt"
-	"regexp"
+	"strconv"
 	"strings"
-)
 
-// ParsedQuery represents a simple parsed SQL query
-type ParsedQuery struct {
-	TableName    string
-	Columns      []string
-	WhereClause  string
-	LimitClause  string
-	IsSelectAll  bool
-}
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+)
 
 // QueryParser handles parsing of SQL queries
 type QueryParser struct{}
@@ -23,66 +16,1027 @@ func NewQueryParser() *QueryParser {
 	return &QueryParser{}
 }
 
-// Parse extracts table name, columns, and WHERE clause from a simple SELECT query
-// For Phase 1, supports only: SELECT col1, col2 FROM table WHERE condition
-func (p *QueryParser) Parse(query string) (*ParsedQuery, error) {
-	query = strings.TrimSpace(query)
+// Parse tokenizes the query and parses it into a SELECT statement AST.
+// Supports select items with expressions and aliases, FROM with joins and
+// derived tables, WHERE, GROUP BY, HAVING, ORDER BY, LIMIT and OFFSET.
+func (p *QueryParser) Parse(query string) (*SelectStmt, error) {
+	tokens, err := NewLexer(query).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &parserState{tokens: tokens}
 
-	// Basic validation
-	if !strings.HasPrefix(strings.ToUpper(query), "SELECT") {
+	if !ps.isKeyword("SELECT") {
 		return nil, fmt.Errorf("only SELECT queries are supported")
 	}
 
-	// Remove trailing semicolon if present
-	query = strings.TrimSuffix(query, ";")
+	stmt, err := ps.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+
+	// Allow a single trailing semicolon
+	if ps.peek().Type == TokenSemicolon {
+		ps.advance()
+	}
+
+	if ps.peek().Type != TokenEOF {
+		return nil, ps.errorf("unexpected %s", describeToken(ps.peek()))
+	}
+
+	return stmt, nil
+}
+
+// parserState is a recursive-descent parser over a token stream
+type parserState struct {
+	tokens []Token
+	pos    int
+
+	// positionalParams counts the ? placeholders seen so far
+	positionalParams int
+	// namedParams records whether a :name placeholder has been seen; the ? placeholders are
+	// numbered, so mixing the two styles must be caught here rather than when binding
+	namedParams bool
+}
+
+func (ps *parserState) peek() Token {
+	return ps.tokens[ps.pos]
+}
+
+func (ps *parserState) peekAt(offset int) Token {
+	if ps.pos+offset >= len(ps.tokens) {
+		return ps.tokens[len(ps.tokens)-1]
+	}
+	return ps.tokens[ps.pos+offset]
+}
+
+func (ps *parserState) advance() Token {
+	tok := ps.tokens[ps.pos]
+	if tok.Type != TokenEOF {
+		ps.pos++
+	}
+	return tok
+}
+
+func (ps *parserState) isKeyword(kw string) bool {
+	tok := ps.peek()
+	return tok.Type == TokenKeyword && tok.Value == kw
+}
+
+// isWord matches a non-reserved word such as NULLS or ROWS
+func (ps *parserState) isWord(word string) bool {
+	tok := ps.peek()
+	return tok.Type == TokenIdent && strings.EqualFold(tok.Value, word)
+}
+
+func (ps *parserState) acceptKeyword(kw string) bool {
+	if ps.isKeyword(kw) {
+		ps.advance()
+		return true
+	}
+	return false
+}
+
+func (ps *parserState) acceptWord(word string) bool {
+	if ps.isWord(word) {
+		ps.advance()
+		return true
+	}
+	return false
+}
+
+func (ps *parserState) expectKeyword(kw string) error {
+	if !ps.acceptKeyword(kw) {
+		return ps.errorf("expected %s but found %s", kw, describeToken(ps.peek()))
+	}
+	return nil
+}
+
+func (ps *parserState) expect(tokenType TokenType, what string) (Token, error) {
+	tok := ps.peek()
+	if tok.Type != tokenType {
+		return Token{}, ps.errorf("expected %s but found %s", what, describeToken(tok))
+	}
+	return ps.advance(), nil
+}
+
+func (ps *parserState) isOperator(op string) bool {
+	tok := ps.peek()
+	return tok.Type == TokenOperator && tok.Value == op
+}
+
+func (ps *parserState) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %d: %s", ps.peek().Pos, fmt.Sprintf(format, args...))
+}
+
+func describeToken(tok Token) string {
+	switch tok.Type {
+	case TokenEOF:
+		return "end of query"
+	case TokenString:
+		return fmt.Sprintf("string '%s'", tok.Value)
+	case TokenParam:
+		if tok.Value == "" {
+			return "parameter ?"
+		}
+		return fmt.Sprintf("parameter :%s", tok.Value)
+	case TokenQuotedIdent:
+		return fmt.Sprintf("identifier \"%s\"", tok.Value)
+	default:
+		return fmt.Sprintf("'%s'", tok.Value)
+	}
+}
+
+// ============================================================================
+// Statements
+// ============================================================================
+
+func (ps *parserState) parseSelect() (*SelectStmt, error) {
+	if err := ps.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	stmt := &SelectStmt{}
+	if ps.acceptKeyword("DISTINCT") {
+		stmt.Distinct = true
+	} else {
+		ps.acceptKeyword("ALL")
+	}
+
+	items, err := ps.parseSelectItems()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Items = items
+
+	if ps.acceptKeyword("FROM") {
+		from, err := ps.parseFrom()
+		if err != nil {
+			return nil, err
+		}
+		stmt.From = from
+	}
+
+	if ps.acceptKeyword("WHERE") {
+		where, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Where = where
+	}
+
+	if ps.acceptKeyword("GROUP") {
+		if err := ps.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		groupBy, err := ps.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		stmt.GroupBy = groupBy
+	}
+
+	if ps.acceptKeyword("HAVING") {
+		having, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Having = having
+	}
+
+	if ps.acceptKeyword("ORDER") {
+		if err := ps.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		orderBy, err := ps.parseOrderBy()
+		if err != nil {
+			return nil, err
+		}
+		stmt.OrderBy = orderBy
+	}
+
+	// Trino puts OFFSET before LIMIT, but accept either order
+	for {
+		switch {
+		case ps.isKeyword("OFFSET") && stmt.Offset == nil:
+			ps.advance()
+			offset, err := ps.parseCount("OFFSET")
+			if err != nil {
+				return nil, err
+			}
+			if !ps.acceptWord("ROWS") {
+				ps.acceptWord("ROW")
+			}
+			stmt.Offset = &offset
+			continue
+
+		case ps.isKeyword("LIMIT") && stmt.Limit == nil:
+			ps.advance()
+			if ps.acceptKeyword("ALL") {
+				continue
+			}
+			limit, err := ps.parseCount("LIMIT")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Limit = &limit
+			continue
+		}
+		break
+	}
+
+	return stmt, nil
+}
 
-	// Extract FROM clause and table name
-	fromRegex := regexp.MustCompile(`(?i)\s+FROM\s+([a-zA-Z0-9_]+)`)
-	fromMatch := fromRegex.FindStringSubmatch(query)
-	if len(fromMatch) < 2 {
-		return nil, fmt.Errorf("invalid query: FROM clause not found")
+func (ps *parserState) parseCount(clause string) (int64, error) {
+	tok, err := ps.expect(TokenNumber, clause+" count")
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(tok.Value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s value '%s'", clause, tok.Value)
 	}
-	tableName := fromMatch[1]
+	return n, nil
+}
+
+func (ps *parserState) parseSelectItems() ([]SelectItem, error) {
+	var items []SelectItem
+	for {
+		item, err := ps.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
 
-	// Extract SELECT columns
-	selectRegex := regexp.MustCompile(`(?i)SELECT\s+(.*?)\s+FROM`)
-	selectMatch := selectRegex.FindStringSubmatch(query)
-	if len(selectMatch) < 2 {
-		return nil, fmt.Errorf("invalid query: SELECT clause not found")
+		if ps.peek().Type != TokenComma {
+			return items, nil
+		}
+		ps.advance()
 	}
+}
 
-	columnsStr := strings.TrimSpace(selectMatch[1])
-	isSelectAll := columnsStr == "*"
+func (ps *parserState) parseSelectItem() (SelectItem, error) {
+	// * or qualifier.*
+	if ps.isOperator("*") {
+		ps.advance()
+		return SelectItem{Expr: &StarExpr{}}, nil
+	}
+	if isIdentToken(ps.peek()) && ps.peekAt(1).Type == TokenDot &&
+		ps.peekAt(2).Type == TokenOperator && ps.peekAt(2).Value == "*" {
+		table := ps.advance().Value
+		ps.advance()
+		ps.advance()
+		return SelectItem{Expr: &StarExpr{Table: table}}, nil
+	}
 
-	var columns []string
-	if !isSelectAll {
-		// Split columns by comma
-		for _, col := range strings.Split(columnsStr, ",") {
-			columns = append(columns, strings.TrimSpace(col))
+	expr, err := ps.parseExpr()
+	if err != nil {
+		return SelectItem{}, err
+	}
+
+	alias, err := ps.parseAlias()
+	if err != nil {
+		return SelectItem{}, err
+	}
+
+	return SelectItem{Expr: expr, Alias: alias}, nil
+}
+
+// parseAlias parses an optional [AS] alias
+func (ps *parserState) parseAlias() (string, error) {
+	if ps.acceptKeyword("AS") {
+		tok := ps.peek()
+		if !isIdentToken(tok) {
+			return "", ps.errorf("expected alias after AS but found %s", describeToken(tok))
 		}
+		return ps.advance().Value, nil
+	}
+	if isIdentToken(ps.peek()) {
+		return ps.advance().Value, nil
 	}
+	return "", nil
+}
+
+func (ps *parserState) parseOrderBy() ([]OrderItem, error) {
+	var items []OrderItem
+	for {
+		expr, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := OrderItem{Expr: expr}
+
+		if ps.acceptKeyword("DESC") {
+			item.Desc = true
+		} else {
+			ps.acceptKeyword("ASC")
+		}
 
-	// Extract LIMIT clause (optional)
-	limitClause := ""
-	limitRegex := regexp.MustCompile(`(?i)\s+LIMIT\s+(\d+)`)
-	limitMatch := limitRegex.FindStringSubmatch(query)
-	if len(limitMatch) >= 2 {
-		limitClause = limitMatch[1]
+		if ps.acceptWord("NULLS") {
+			switch {
+			case ps.acceptWord("FIRST"):
+				item.Nulls = "FIRST"
+			case ps.acceptWord("LAST"):
+				item.Nulls = "LAST"
+			default:
+				return nil, ps.errorf("expected FIRST or LAST after NULLS")
+			}
+		}
+
+		items = append(items, item)
+		if ps.peek().Type != TokenComma {
+			return items, nil
+		}
+		ps.advance()
 	}
+}
 
-	// Extract WHERE clause (optional) - must come before LIMIT
-	whereClause := ""
-	whereRegex := regexp.MustCompile(`(?i)\s+WHERE\s+(.+?)(?:\s+LIMIT\s+\d+)?$`)
-	whereMatch := whereRegex.FindStringSubmatch(query)
-	if len(whereMatch) >= 2 {
-		whereClause = strings.TrimSpace(whereMatch[1])
+// ============================================================================
+// FROM clause
+// ============================================================================
+
+func (ps *parserState) parseFrom() (TableExpr, error) {
+	left, err := ps.parseTablePrimary()
+	if err != nil {
+		return nil, err
 	}
 
-	return &ParsedQuery{
-		TableName:   tableName,
-		Columns:     columns,
-		WhereClause: whereClause,
-		LimitClause: limitClause,
-		IsSelectAll: isSelectAll,
-	}, nil
+	for {
+		if ps.peek().Type == TokenComma {
+			ps.advance()
+			right, err := ps.parseTablePrimary()
+			if err != nil {
+				return nil, err
+			}
+			left = &JoinExpr{Type: JoinCross, Left: left, Right: right}
+			continue
+		}
+
+		joinType, ok, err := ps.parseJoinType()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return left, nil
+		}
+
+		right, err := ps.parseTablePrimary()
+		if err != nil {
+			return nil, err
+		}
+		join := &JoinExpr{Type: joinType, Left: left, Right: right}
+
+		if joinType != JoinCross {
+			switch {
+			case ps.acceptKeyword("ON"):
+				on, err := ps.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				join.On = on
+			case ps.acceptKeyword("USING"):
+				using, err := ps.parseIdentList()
+				if err != nil {
+					return nil, err
+				}
+				join.Using = using
+			}
+		}
+
+		left = join
+	}
+}
+
+// parseJoinType consumes a join keyword sequence if one is present
+func (ps *parserState) parseJoinType() (JoinType, bool, error) {
+	var joinType JoinType
+	switch {
+	case ps.acceptKeyword("JOIN"):
+		return JoinInner, true, nil
+	case ps.acceptKeyword("INNER"):
+		joinType = JoinInner
+	case ps.acceptKeyword("CROSS"):
+		joinType = JoinCross
+	case ps.acceptKeyword("LEFT"):
+		joinType = JoinLeft
+		ps.acceptKeyword("OUTER")
+	case ps.acceptKeyword("RIGHT"):
+		joinType = JoinRight
+		ps.acceptKeyword("OUTER")
+	case ps.acceptKeyword("FULL"):
+		joinType = JoinFull
+		ps.acceptKeyword("OUTER")
+	default:
+		return "", false, nil
+	}
+
+	if err := ps.expectKeyword("JOIN"); err != nil {
+		return "", false, err
+	}
+	return joinType, true, nil
+}
+
+func (ps *parserState) parseTablePrimary() (TableExpr, error) {
+	if ps.peek().Type == TokenLParen {
+		ps.advance()
+		if !ps.isKeyword("SELECT") {
+			return nil, ps.errorf("expected subquery after '('")
+		}
+		sub, err := ps.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		alias, err := ps.parseAlias()
+		if err != nil {
+			return nil, err
+		}
+		return &SubqueryTable{Select: sub, Alias: alias}, nil
+	}
+
+	parts, err := ps.parseQualifiedName()
+	if err != nil {
+		return nil, err
+	}
+
+	table := &TableName{}
+	switch len(parts) {
+	case 1:
+		table.Name = parts[0]
+	case 2:
+		table.Schema, table.Name = parts[0], parts[1]
+	case 3:
+		table.Catalog, table.Schema, table.Name = parts[0], parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("invalid table name '%s'", strings.Join(parts, "."))
+	}
+
+	alias, err := ps.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+	table.Alias = alias
+
+	return table, nil
+}
+
+func (ps *parserState) parseQualifiedName() ([]string, error) {
+	tok := ps.peek()
+	if !isIdentToken(tok) {
+		return nil, ps.errorf("expected table name but found %s", describeToken(tok))
+	}
+	parts := []string{ps.advance().Value}
+
+	for ps.peek().Type == TokenDot {
+		ps.advance()
+		tok := ps.peek()
+		if !isIdentToken(tok) {
+			return nil, ps.errorf("expected identifier after '.' but found %s", describeToken(tok))
+		}
+		parts = append(parts, ps.advance().Value)
+	}
+
+	return parts, nil
+}
+
+func (ps *parserState) parseIdentList() ([]string, error) {
+	if _, err := ps.expect(TokenLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	var idents []string
+	for {
+		tok := ps.peek()
+		if !isIdentToken(tok) {
+			return nil, ps.errorf("expected column name but found %s", describeToken(tok))
+		}
+		idents = append(idents, ps.advance().Value)
+
+		if ps.peek().Type != TokenComma {
+			break
+		}
+		ps.advance()
+	}
+
+	if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return idents, nil
+}
+
+func isIdentToken(tok Token) bool {
+	return tok.Type == TokenIdent || tok.Type == TokenQuotedIdent
+}
+
+// ============================================================================
+// Expressions (lowest to highest precedence)
+// ============================================================================
+
+func (ps *parserState) parseExprList() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if ps.peek().Type != TokenComma {
+			return exprs, nil
+		}
+		ps.advance()
+	}
+}
+
+func (ps *parserState) parseExpr() (Expr, error) {
+	return ps.parseOr()
+}
+
+func (ps *parserState) parseOr() (Expr, error) {
+	left, err := ps.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for ps.acceptKeyword("OR") {
+		right, err := ps.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (ps *parserState) parseAnd() (Expr, error) {
+	left, err := ps.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for ps.acceptKeyword("AND") {
+		right, err := ps.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (ps *parserState) parseNot() (Expr, error) {
+	if ps.acceptKeyword("NOT") {
+		expr, err := ps.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: "NOT", Expr: expr}, nil
+	}
+	return ps.parsePredicate()
+}
+
+// parsePredicate parses comparisons, IS [NOT] NULL, [NOT] IN, [NOT] BETWEEN and [NOT] LIKE
+func (ps *parserState) parsePredicate() (Expr, error) {
+	left, err := ps.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := ps.peek()
+		if tok.Type == TokenOperator && isComparison(tok.Value) {
+			ps.advance()
+			right, err := ps.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			left = &BinaryExpr{Op: tok.Value, Left: left, Right: right}
+			continue
+		}
+
+		if ps.acceptKeyword("IS") {
+			not := ps.acceptKeyword("NOT")
+			if err := ps.expectKeyword("NULL"); err != nil {
+				return nil, err
+			}
+			left = &IsNullExpr{Expr: left, Not: not}
+			continue
+		}
+
+		not := false
+		if ps.isKeyword("NOT") {
+			next := ps.peekAt(1)
+			if next.Type == TokenKeyword && (next.Value == "IN" || next.Value == "BETWEEN" || next.Value == "LIKE") {
+				ps.advance()
+				not = true
+			}
+		}
+
+		switch {
+		case ps.acceptKeyword("IN"):
+			in, err := ps.parseInTail(left, not)
+			if err != nil {
+				return nil, err
+			}
+			left = in
+
+		case ps.acceptKeyword("BETWEEN"):
+			low, err := ps.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			if err := ps.expectKeyword("AND"); err != nil {
+				return nil, err
+			}
+			high, err := ps.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			left = &BetweenExpr{Expr: left, Low: low, High: high, Not: not}
+
+		case ps.acceptKeyword("LIKE"):
+			pattern, err := ps.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			like := &LikeExpr{Expr: left, Pattern: pattern, Not: not}
+			if ps.acceptWord("ESCAPE") {
+				escape, err := ps.parseConcat()
+				if err != nil {
+					return nil, err
+				}
+				like.Escape = escape
+			}
+			left = like
+
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (ps *parserState) parseInTail(left Expr, not bool) (Expr, error) {
+	if _, err := ps.expect(TokenLParen, "'(' after IN"); err != nil {
+		return nil, err
+	}
+
+	in := &InExpr{Expr: left, Not: not}
+	if ps.isKeyword("SELECT") {
+		sub, err := ps.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		in.Subquery = sub
+	} else {
+		list, err := ps.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		in.List = list
+	}
+
+	if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "<>", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func (ps *parserState) parseConcat() (Expr, error) {
+	left, err := ps.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isOperator("||") {
+		ps.advance()
+		right, err := ps.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "||", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (ps *parserState) parseAdditive() (Expr, error) {
+	left, err := ps.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isOperator("+") || ps.isOperator("-") {
+		op := ps.advance().Value
+		right, err := ps.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (ps *parserState) parseMultiplicative() (Expr, error) {
+	left, err := ps.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for ps.isOperator("*") || ps.isOperator("/") || ps.isOperator("%") {
+		op := ps.advance().Value
+		right, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (ps *parserState) parseUnary() (Expr, error) {
+	if ps.isOperator("-") || ps.isOperator("+") {
+		op := ps.advance().Value
+		expr, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: op, Expr: expr}, nil
+	}
+	return ps.parsePrimary()
+}
+
+func (ps *parserState) parsePrimary() (Expr, error) {
+	tok := ps.peek()
+
+	switch tok.Type {
+	case TokenNumber:
+		ps.advance()
+		return &Literal{Kind: LiteralNumber, Value: tok.Value}, nil
+
+	case TokenString:
+		ps.advance()
+		return &Literal{Kind: LiteralString, Value: tok.Value}, nil
+
+	case TokenParam:
+		if (tok.Value == "" && ps.namedParams) || (tok.Value != "" && ps.positionalParams > 0) {
+			return nil, fmt.Errorf("%w: a query cannot mix ? and :name placeholders", datasync.ErrInvalidParams)
+		}
+		ps.advance()
+		if tok.Value == "" {
+			ps.positionalParams++
+			return &ParamExpr{Name: strconv.Itoa(ps.positionalParams)}, nil
+		}
+		ps.namedParams = true
+		return &ParamExpr{Name: tok.Value}, nil
+
+	case TokenLParen:
+		ps.advance()
+		if ps.isKeyword("SELECT") {
+			sub, err := ps.parseSelect()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+				return nil, err
+			}
+			return &SubqueryExpr{Select: sub}, nil
+		}
+		expr, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return &ParenExpr{Expr: expr}, nil
+
+	case TokenKeyword:
+		switch tok.Value {
+		case "NULL":
+			ps.advance()
+			return &Literal{Kind: LiteralNull, Value: "NULL"}, nil
+		case "TRUE", "FALSE":
+			ps.advance()
+			return &Literal{Kind: LiteralBoolean, Value: tok.Value}, nil
+		case "CASE":
+			return ps.parseCase()
+		case "CAST", "TRY_CAST":
+			return ps.parseCast()
+		case "EXISTS":
+			ps.advance()
+			if _, err := ps.expect(TokenLParen, "'(' after EXISTS"); err != nil {
+				return nil, err
+			}
+			sub, err := ps.parseSelect()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+				return nil, err
+			}
+			return &ExistsExpr{Select: sub}, nil
+		case "LEFT", "RIGHT":
+			// left(...) and right(...) are also string functions
+			if ps.peekAt(1).Type == TokenLParen {
+				ps.advance()
+				return ps.parseFuncCall(strings.ToLower(tok.Value))
+			}
+		}
+
+	case TokenIdent, TokenQuotedIdent:
+		return ps.parseIdentExpr()
+	}
+
+	return nil, ps.errorf("unexpected %s", describeToken(tok))
+}
+
+// niladicFunctions are called without parentheses; unquoted, these names are never columns
+var niladicFunctions = map[string]bool{
+	"CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "LOCALTIME": true,
+	"LOCALTIMESTAMP": true, "CURRENT_USER": true, "CURRENT_CATALOG": true, "CURRENT_SCHEMA": true,
+	"CURRENT_PATH": true, "CURRENT_ROLE": true,
+}
+
+// parseIdentExpr parses column references, function calls and typed literals
+func (ps *parserState) parseIdentExpr() (Expr, error) {
+	tok := ps.advance()
+
+	if tok.Type == TokenIdent {
+		upper := strings.ToUpper(tok.Value)
+
+		// Typed literals: DATE '2024-01-01', TIMESTAMP '...', DECIMAL '...'
+		if ps.peek().Type == TokenString {
+			switch upper {
+			case "DATE", "TIME", "TIMESTAMP", "DECIMAL", "REAL", "DOUBLE", "CHAR", "VARBINARY", "JSON":
+				value := ps.advance().Value
+				return &Literal{Kind: LiteralTyped, Value: value, TypeName: upper}, nil
+			case "INTERVAL":
+				value := ps.advance().Value
+				unit, err := ps.expect(TokenIdent, "interval unit")
+				if err != nil {
+					return nil, err
+				}
+				return &Literal{Kind: LiteralInterval, Value: value, TypeName: strings.ToUpper(unit.Value)}, nil
+			}
+		}
+
+		if ps.peek().Type == TokenLParen {
+			return ps.parseFuncCall(tok.Value)
+		}
+		if niladicFunctions[upper] && ps.peek().Type != TokenDot {
+			return &FuncCall{Name: tok.Value, Niladic: true}, nil
+		}
+	}
+
+	if ps.peek().Type == TokenDot {
+		ps.advance()
+		col := ps.peek()
+		if !isIdentToken(col) {
+			return nil, ps.errorf("expected column name after '.' but found %s", describeToken(col))
+		}
+		ps.advance()
+		return &ColumnRef{Table: tok.Value, Column: col.Value}, nil
+	}
+
+	return &ColumnRef{Column: tok.Value}, nil
+}
+
+func (ps *parserState) parseFuncCall(name string) (Expr, error) {
+	if _, err := ps.expect(TokenLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	call := &FuncCall{Name: name}
+
+	if ps.isOperator("*") {
+		ps.advance()
+		call.Star = true
+	} else if ps.peek().Type != TokenRParen {
+		if ps.acceptKeyword("DISTINCT") {
+			call.Distinct = true
+		} else {
+			ps.acceptKeyword("ALL")
+		}
+		args, err := ps.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = args
+	}
+
+	if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+func (ps *parserState) parseCast() (Expr, error) {
+	try := ps.advance().Value == "TRY_CAST"
+	if _, err := ps.expect(TokenLParen, "'(' after CAST"); err != nil {
+		return nil, err
+	}
+
+	expr, err := ps.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+
+	typeName, err := ps.parseTypeName()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ps.expect(TokenRParen, "')'"); err != nil {
+		return nil, err
+	}
+	return &CastExpr{Expr: expr, TypeName: typeName, Try: try}, nil
+}
+
+// parseTypeName reads a type such as varchar(20), decimal(10, 2),
+// timestamp(3) with time zone or array(integer) up to the closing parenthesis of CAST
+func (ps *parserState) parseTypeName() (string, error) {
+	var sb strings.Builder
+	depth := 0
+	for {
+		tok := ps.peek()
+		switch {
+		case tok.Type == TokenEOF:
+			return "", ps.errorf("unterminated type name")
+		case tok.Type == TokenRParen && depth == 0:
+			if sb.Len() == 0 {
+				return "", ps.errorf("expected type name")
+			}
+			return sb.String(), nil
+		case tok.Type == TokenLParen:
+			depth++
+			sb.WriteString("(")
+		case tok.Type == TokenRParen:
+			depth--
+			sb.WriteString(")")
+		case tok.Type == TokenComma:
+			sb.WriteString(", ")
+		case tok.Type == TokenIdent || tok.Type == TokenKeyword || tok.Type == TokenNumber:
+			if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "(") && !strings.HasSuffix(sb.String(), ", ") {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(strings.ToLower(tok.Value))
+		default:
+			return "", ps.errorf("unexpected %s in type name", describeToken(tok))
+		}
+		ps.advance()
+	}
+}
+
+func (ps *parserState) parseCase() (Expr, error) {
+	ps.advance() // CASE
+
+	c := &CaseExpr{}
+	if !ps.isKeyword("WHEN") {
+		operand, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Operand = operand
+	}
+
+	for ps.acceptKeyword("WHEN") {
+		cond, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := ps.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		result, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Whens = append(c.Whens, WhenClause{Cond: cond, Result: result})
+	}
+
+	if len(c.Whens) == 0 {
+		return nil, ps.errorf("CASE requires at least one WHEN clause")
+	}
+
+	if ps.acceptKeyword("ELSE") {
+		elseExpr, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Else = elseExpr
+	}
+
+	if err := ps.expectKeyword("END"); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
@@ -0,0 +1,160 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestParse_SimpleSelect(t *testing.T) {
+	parser := NewQueryParser()
+
+	stmt, err := parser.Parse("SELECT id, name FROM customers WHERE country = 'USA' LIMIT 10;")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(stmt.Items) != 2 {
+		t.Fatalf("Expected 2 select items, got %d", len(stmt.Items))
+	}
+
+	table, ok := stmt.From.(*TableName)
+	if !ok || table.Name != "customers" {
+		t.Fatalf("Expected FROM customers, got %#v", stmt.From)
+	}
+
+	if stmt.Limit == nil || *stmt.Limit != 10 {
+		t.Errorf("Expected LIMIT 10, got %v", stmt.Limit)
+	}
+
+	where, ok := stmt.Where.(*BinaryExpr)
+	if !ok || where.Op != "=" {
+		t.Fatalf("Expected comparison in WHERE, got %#v", stmt.Where)
+	}
+	if lit, ok := where.Right.(*Literal); !ok || lit.Value != "USA" {
+		t.Errorf("Expected literal 'USA', got %#v", where.Right)
+	}
+}
+
+func TestParse_LimitInsideStringLiteral(t *testing.T) {
+	parser := NewQueryParser()
+
+	stmt, err := parser.Parse("SELECT * FROM notes WHERE body = 'no LIMIT 5 here' LIMIT 3")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if stmt.Limit == nil || *stmt.Limit != 3 {
+		t.Errorf("Expected LIMIT 3, got %v", stmt.Limit)
+	}
+
+	expected := "body = 'no LIMIT 5 here'"
+	if got := FormatExpr(stmt.Where); got != expected {
+		t.Errorf("Expected WHERE %q, got %q", expected, got)
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	parser := NewQueryParser()
+
+	queries := []string{
+		"SELECT * FROM customers",
+		"SELECT DISTINCT country FROM customers",
+		"SELECT c.name AS customer_name, upper(c.email) AS email FROM customers c",
+		"SELECT country, count(*) AS total FROM customers GROUP BY country HAVING count(*) > 5 ORDER BY 2 DESC, country NULLS LAST OFFSET 10 LIMIT 20",
+		"SELECT count(DISTINCT customer_id) FROM orders",
+		"SELECT c.name, o.total FROM customers c JOIN orders o ON c.id = o.customer_id",
+		"SELECT * FROM customers c LEFT JOIN orders o ON c.id = o.customer_id AND o.total > 10",
+		"SELECT * FROM a FULL JOIN b USING (id)",
+		"SELECT * FROM postgresql.public.customers",
+		"SELECT * FROM (SELECT id FROM customers) sub",
+		"SELECT \"Order Id\", \"select\" FROM orders",
+		"SELECT * FROM orders WHERE status IN ('open', 'closed') AND total BETWEEN 10 AND 20",
+		"SELECT * FROM orders WHERE status NOT IN (SELECT status FROM archived) OR note IS NOT NULL",
+		"SELECT * FROM customers WHERE name NOT LIKE 'A%' AND NOT (age < 18 OR age > 65)",
+		"SELECT CASE WHEN total > 100 THEN 'big' ELSE 'small' END AS size FROM orders",
+		"SELECT CAST(total AS decimal(10, 2)), TRY_CAST(code AS integer) FROM orders",
+		"SELECT * FROM orders WHERE created_at >= DATE '2024-01-01' AND created_at < current_date - INTERVAL '7' DAY",
+		"SELECT first_name || ' ' || last_name AS full_name FROM customers",
+		"SELECT * FROM orders WHERE EXISTS (SELECT 1 FROM refunds WHERE refunds.order_id = orders.id)",
+		"SELECT -total * (1 + tax) / 2 FROM orders",
+	}
+
+	for _, query := range queries {
+		stmt, err := parser.Parse(query)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", query, err)
+			continue
+		}
+		if got := stmt.String(); got != query {
+			t.Errorf("Round trip mismatch:\n  input:  %s\n  output: %s", query, got)
+		}
+	}
+}
+
+func TestParse_Normalisation(t *testing.T) {
+	parser := NewQueryParser()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"select id from customers limit 5 offset 2", "SELECT id FROM customers OFFSET 2 LIMIT 5"},
+		{"SELECT id FROM customers -- trailing comment", "SELECT id FROM customers"},
+		{"SELECT /* inline */ id FROM customers WHERE a != 1", "SELECT id FROM customers WHERE a <> 1"},
+		{"SELECT id FROM customers c INNER JOIN orders AS o ON c.id = o.cid", "SELECT id FROM customers c JOIN orders o ON c.id = o.cid"},
+		{"SELECT id FROM customers, orders", "SELECT id FROM customers CROSS JOIN orders"},
+		{"SELECT name FROM customers ORDER BY name ASC LIMIT ALL", "SELECT name FROM customers ORDER BY name"},
+		{"SELECT 'it''s' FROM t", "SELECT 'it''s' FROM t"},
+	}
+
+	for _, tt := range tests {
+		stmt, err := parser.Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.query, err)
+			continue
+		}
+		if got := stmt.String(); got != tt.expected {
+			t.Errorf("Parse(%q) = %q, expected %q", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestParse_Precedence(t *testing.T) {
+	parser := NewQueryParser()
+
+	stmt, err := parser.Parse("SELECT * FROM t WHERE a = 1 OR b = 2 AND c = 3")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	or, ok := stmt.Where.(*BinaryExpr)
+	if !ok || or.Op != "OR" {
+		t.Fatalf("Expected OR at the root, got %#v", stmt.Where)
+	}
+	if and, ok := or.Right.(*BinaryExpr); !ok || and.Op != "AND" {
+		t.Errorf("Expected AND on the right of OR, got %#v", or.Right)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	parser := NewQueryParser()
+
+	queries := []string{
+		"",
+		"DELETE FROM customers",
+		"SELECT FROM customers",
+		"SELECT id FROM",
+		"SELECT id FROM customers WHERE",
+		"SELECT id FROM customers WHERE name = 'unterminated",
+		"SELECT id FROM customers LIMIT -1",
+		"SELECT id FROM customers GROUP country",
+		"SELECT id FROM customers; SELECT 1",
+		"SELECT (id FROM customers",
+		"SELECT CASE END FROM customers",
+	}
+
+	for _, query := range queries {
+		if _, err := parser.Parse(query); err == nil {
+			t.Errorf("Expected error for %q, got nil", query)
+		}
+	}
+}
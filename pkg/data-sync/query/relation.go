@@ -27,6 +27,7 @@ type RelationNode struct {
 	// For relations (nested)
 	RelationID   string
 	RelationType string // "JOIN" or "UNION"
+	Relation     *ResolvedRelation
 }
 
 // ResolvedRelation represents a fully resolved relation tree
@@ -36,7 +37,22 @@ type ResolvedRelation struct {
 	RelationType string // "JOIN" or "UNION"
 	LeftNode     *RelationNode
 	RightNode    *RelationNode
-	JoinColumn   *models.JoinColumn // Only for JOIN relations
+	JoinColumns  []models.JoinColumn // Only for JOIN relations: the column pairs joined on
+	JoinType     string              // Only for JOIN relations: "INNER" (default), "LEFT", "RIGHT" or "FULL"
+	UnionType    string              // Only for UNION relations: "ALL" (default) or "DISTINCT"
+}
+
+// HasDistinctUnion reports whether any UNION in the relation tree removes duplicate rows
+func (r *ResolvedRelation) HasDistinctUnion() bool {
+	if r.RelationType == "UNION" && models.IsUnionDistinct(r.UnionType) {
+		return true
+	}
+	for _, node := range []*RelationNode{r.LeftNode, r.RightNode} {
+		if node.Type == NodeTypeRelation && node.Relation.HasDistinctUnion() {
+			return true
+		}
+	}
+	return false
 }
 
 // RelationResolver resolves table relations to their physical tables
@@ -51,59 +67,9 @@ func NewRelationResolver(storage storage.MetadataStorage) *RelationResolver {
 	}
 }
 
-// ResolveRelation resolves a table relation to its components
-// Phase 3: Supports both UNION and JOIN with physical tables (no nesting yet)
+// ResolveRelation resolves a table relation to its full tree, including nested relations
 func (r *RelationResolver) ResolveRelation(relationID string) (*ResolvedRelation, error) {
-	// Fetch the relation from storage
-	relation, err := r.storage.GetTableRelation(relationID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get relation '%s': %w", relationID, err)
-	}
-
-	if relation == nil {
-		return nil, fmt.Errorf("relation '%s' not found", relationID)
-	}
-
-	// Support both UNION and JOIN in Phase 3
-	if relation.RelationType != "UNION" && relation.RelationType != "JOIN" {
-		return nil, fmt.Errorf("relation type '%s' not supported (only UNION and JOIN)", relation.RelationType)
-	}
-
-	// For JOIN, validate that join columns are specified
-	if relation.RelationType == "JOIN" {
-		if relation.JoinColumn == nil {
-			return nil, fmt.Errorf("JOIN relation requires join columns to be specified")
-		}
-		if relation.JoinColumn.Left == "" || relation.JoinColumn.Right == "" {
-			return nil, fmt.Errorf("JOIN relation requires both left and right join columns")
-		}
-	}
-
-	// Resolve left node
-	leftNode, err := r.resolveTableSource(&relation.LeftTable)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve left table: %w", err)
-	}
-
-	// Resolve right node
-	rightNode, err := r.resolveTableSource(&relation.RightTable)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve right table: %w", err)
-	}
-
-	return &ResolvedRelation{
-		ID:           relation.ID,
-		Name:         relation.Name,
-		RelationType: relation.RelationType,
-		LeftNode:     leftNode,
-		RightNode:    rightNode,
-		JoinColumn:   relation.JoinColumn,
-	}, nil
-}
-
-// resolveTableSource resolves a TableSource to a RelationNode
-func (r *RelationResolver) resolveTableSource(source *models.TableSource) (*RelationNode, error) {
-	return r.resolveTableSourceWithVisited(source, make(map[string]bool))
+	return r.ResolveRelationWithVisited(relationID, make(map[string]bool))
 }
 
 // resolveTableSourceWithVisited resolves a TableSource with circular reference detection
@@ -125,25 +91,18 @@ func (r *RelationResolver) resolveTableSourceWithVisited(source *models.TableSou
 			return nil, fmt.Errorf("relation source requires relationId")
 		}
 
-		// Check for circular reference
-		if visited[source.RelationID] {
-			return nil, fmt.Errorf("circular relation detected: relation '%s' references itself", source.RelationID)
-		}
-
-		// Mark as visited
-		visited[source.RelationID] = true
-
 		// Recursively resolve the nested relation
 		nestedRelation, err := r.ResolveRelationWithVisited(source.RelationID, visited)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve nested relation '%s': %w", source.RelationID, err)
 		}
 
-		// Return as a relation node
+		// Return as a relation node holding the nested tree
 		return &RelationNode{
 			Type:         NodeTypeRelation,
 			RelationID:   nestedRelation.ID,
 			RelationType: nestedRelation.RelationType,
+			Relation:     nestedRelation,
 		}, nil
 
 	default:
@@ -151,9 +110,17 @@ func (r *RelationResolver) resolveTableSourceWithVisited(source *models.TableSou
 	}
 }
 
-// ResolveRelationWithVisited resolves a relation with circular reference tracking
-// Phase 4: Supports nested relations recursively
+// ResolveRelationWithVisited resolves a relation with circular reference tracking.
+// visited holds the relations on the path from the root, so a relation may be
+// used more than once in a tree as long as it does not contain itself.
 func (r *RelationResolver) ResolveRelationWithVisited(relationID string, visited map[string]bool) (*ResolvedRelation, error) {
+	// Check for circular reference
+	if visited[relationID] {
+		return nil, fmt.Errorf("circular relation detected: relation '%s' references itself", relationID)
+	}
+	visited[relationID] = true
+	defer delete(visited, relationID)
+
 	// Fetch the relation from storage
 	relation, err := r.storage.GetTableRelation(relationID)
 	if err != nil {
@@ -169,13 +136,10 @@ func (r *RelationResolver) ResolveRelationWithVisited(relationID string, visited
 		return nil, fmt.Errorf("relation type '%s' not supported (only UNION and JOIN)", relation.RelationType)
 	}
 
-	// For JOIN, validate that join columns are specified
+	// For JOIN, validate that join columns and the join type are specified correctly
 	if relation.RelationType == "JOIN" {
-		if relation.JoinColumn == nil {
-			return nil, fmt.Errorf("JOIN relation requires join columns to be specified")
-		}
-		if relation.JoinColumn.Left == "" || relation.JoinColumn.Right == "" {
-			return nil, fmt.Errorf("JOIN relation requires both left and right join columns")
+		if err := relation.ValidateJoin(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -197,20 +161,27 @@ func (r *RelationResolver) ResolveRelationWithVisited(relationID string, visited
 		RelationType: relation.RelationType,
 		LeftNode:     leftNode,
 		RightNode:    rightNode,
-		JoinColumn:   relation.JoinColumn,
+		JoinColumns:  relation.JoinKeys(),
+		JoinType:     relation.JoinType,
+		UnionType:    relation.UnionType,
 	}, nil
 }
 
-// GetPhysicalTables extracts all physical tables from a resolved relation
+// GetPhysicalTables extracts all physical tables from a resolved relation, including nested relations
 func (r *RelationResolver) GetPhysicalTables(resolved *ResolvedRelation) ([]*RelationNode, error) {
 	tables := []*RelationNode{}
 
-	if resolved.LeftNode.Type == NodeTypePhysical {
-		tables = append(tables, resolved.LeftNode)
-	}
-
-	if resolved.RightNode.Type == NodeTypePhysical {
-		tables = append(tables, resolved.RightNode)
+	for _, node := range []*RelationNode{resolved.LeftNode, resolved.RightNode} {
+		switch node.Type {
+		case NodeTypePhysical:
+			tables = append(tables, node)
+		case NodeTypeRelation:
+			nested, err := r.GetPhysicalTables(node.Relation)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, nested...)
+		}
 	}
 
 	return tables, nil
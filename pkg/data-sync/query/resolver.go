@@ -19,6 +19,20 @@ type ResolvedTableSource struct {
 
 	// For explicit relations (Phase 2)
 	Relation *ResolvedRelation
+
+	// Set semantics for multiple mappings: "ALL" (default) or "DISTINCT"
+	UnionType string
+
+	// Optional column tagging each row with the physical table it came from
+	SourceColumn string
+}
+
+// HasDistinctUnion reports whether combining the sources removes duplicate rows
+func (s *ResolvedTableSource) HasDistinctUnion() bool {
+	if s.IsRelation {
+		return s.Relation.HasDistinctUnion()
+	}
+	return s.MultipleMappings != nil && models.IsUnionDistinct(s.UnionType)
 }
 
 // TableResolver resolves global tables to physical tables
@@ -93,8 +107,9 @@ func (r *TableResolver) ResolveGlobalTableAdvanced(name string) (*ResolvedTableS
 				return nil, fmt.Errorf("failed to resolve relation: %w", err)
 			}
 			return &ResolvedTableSource{
-				IsRelation: true,
-				Relation:   resolved,
+				IsRelation:   true,
+				Relation:     resolved,
+				SourceColumn: globalTable.SourceColumn,
 			}, nil
 		}
 	}
@@ -114,6 +129,7 @@ func (r *TableResolver) ResolveGlobalTableAdvanced(name string) (*ResolvedTableS
 		return &ResolvedTableSource{
 			IsRelation:    false,
 			SingleMapping: mappings[0],
+			SourceColumn:  globalTable.SourceColumn,
 		}, nil
 	}
 
@@ -121,5 +137,7 @@ func (r *TableResolver) ResolveGlobalTableAdvanced(name string) (*ResolvedTableS
 	return &ResolvedTableSource{
 		IsRelation:       false,
 		MultipleMappings: mappings,
+		UnionType:        globalTable.UnionType,
+		SourceColumn:     globalTable.SourceColumn,
 	}, nil
 }
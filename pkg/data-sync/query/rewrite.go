@@ -0,0 +1,169 @@
+package query
+
+import "fmt"
+
+// ColumnRewriter returns the expression that replaces a column reference
+type ColumnRewriter func(col *ColumnRef) (Expr, error)
+
+// RewriteColumns returns a copy of expr with every column reference replaced
+// by the result of fn. The input expression is not modified.
+// Subqueries are rejected because their column references belong to other tables.
+func RewriteColumns(e Expr, fn ColumnRewriter) (Expr, error) {
+	if e == nil {
+		return nil, nil
+	}
+
+	switch e := e.(type) {
+	case *ColumnRef:
+		return fn(e)
+
+	case *StarExpr, *Literal, *ParamExpr:
+		return e, nil
+
+	case *UnaryExpr:
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: e.Op, Expr: inner}, nil
+
+	case *BinaryExpr:
+		left, err := RewriteColumns(e.Left, fn)
+		if err != nil {
+			return nil, err
+		}
+		right, err := RewriteColumns(e.Right, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Op: e.Op, Left: left, Right: right}, nil
+
+	case *LikeExpr:
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := RewriteColumns(e.Pattern, fn)
+		if err != nil {
+			return nil, err
+		}
+		escape, err := RewriteColumns(e.Escape, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &LikeExpr{Expr: inner, Pattern: pattern, Escape: escape, Not: e.Not}, nil
+
+	case *InExpr:
+		if e.Subquery != nil {
+			return nil, fmt.Errorf("subqueries are not supported in global queries")
+		}
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		list, err := rewriteList(e.List, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &InExpr{Expr: inner, List: list, Not: e.Not}, nil
+
+	case *BetweenExpr:
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		low, err := RewriteColumns(e.Low, fn)
+		if err != nil {
+			return nil, err
+		}
+		high, err := RewriteColumns(e.High, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &BetweenExpr{Expr: inner, Low: low, High: high, Not: e.Not}, nil
+
+	case *IsNullExpr:
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &IsNullExpr{Expr: inner, Not: e.Not}, nil
+
+	case *FuncCall:
+		args, err := rewriteList(e.Args, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &FuncCall{Name: e.Name, Distinct: e.Distinct, Star: e.Star, Niladic: e.Niladic, Args: args}, nil
+
+	case *CastExpr:
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &CastExpr{Expr: inner, TypeName: e.TypeName, Try: e.Try}, nil
+
+	case *CaseExpr:
+		operand, err := RewriteColumns(e.Operand, fn)
+		if err != nil {
+			return nil, err
+		}
+		whens := make([]WhenClause, len(e.Whens))
+		for i, when := range e.Whens {
+			cond, err := RewriteColumns(when.Cond, fn)
+			if err != nil {
+				return nil, err
+			}
+			result, err := RewriteColumns(when.Result, fn)
+			if err != nil {
+				return nil, err
+			}
+			whens[i] = WhenClause{Cond: cond, Result: result}
+		}
+		elseExpr, err := RewriteColumns(e.Else, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &CaseExpr{Operand: operand, Whens: whens, Else: elseExpr}, nil
+
+	case *ParenExpr:
+		inner, err := RewriteColumns(e.Expr, fn)
+		if err != nil {
+			return nil, err
+		}
+		return &ParenExpr{Expr: inner}, nil
+
+	case *SubqueryExpr, *ExistsExpr:
+		return nil, fmt.Errorf("subqueries are not supported in global queries")
+	}
+
+	return nil, fmt.Errorf("unsupported expression type %T", e)
+}
+
+func rewriteList(exprs []Expr, fn ColumnRewriter) ([]Expr, error) {
+	if exprs == nil {
+		return nil, nil
+	}
+	result := make([]Expr, len(exprs))
+	for i, e := range exprs {
+		rewritten, err := RewriteColumns(e, fn)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = rewritten
+	}
+	return result, nil
+}
+
+// CollectColumnRefs returns every column reference in expr, in order of appearance
+func CollectColumnRefs(e Expr) ([]*ColumnRef, error) {
+	var refs []*ColumnRef
+	_, err := RewriteColumns(e, func(col *ColumnRef) (Expr, error) {
+		refs = append(refs, col)
+		return col, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -12,15 +13,40 @@ import (
 // QueryTranslator translates queries on global tables to Trino SQL
 type QueryTranslator interface {
 	Translate(globalQuery string) (trinoQuery string, error error)
-	TranslateAndExecute(globalQuery string) (*QueryResult, error)
+	TranslateQuery(globalQuery string) (string, error)
+	Validate(ctx context.Context, globalQuery string) (*Validation, error)
+	TranslateAndExecute(ctx context.Context, globalQuery string) (*QueryResult, error)
+	TranslateAndStream(ctx context.Context, globalQuery string, params *datasync.Params) (*QueryStream, error)
 }
 
 // QueryResult contains the results of a translated and executed query
 type QueryResult struct {
 	GeneratedSQL  string                   `json:"generatedSQL"`
+	Columns       []datasync.ResultColumn  `json:"columns"`
 	Rows          []map[string]interface{} `json:"rows"`
 	RowCount      int                      `json:"rowCount"`
 	ExecutionTime string                   `json:"executionTime"`
+	NextPageToken string                   `json:"nextPageToken,omitempty"`
+}
+
+// QueryStream is a translated query whose rows are read from Trino as they arrive.
+// Rows must be closed.
+type QueryStream struct {
+	GeneratedSQL string
+	// TableName is the global table the query reads from, or the first one for a join
+	TableName string
+	Rows      datasync.Rows
+	StartTime time.Time
+}
+
+// Validation is a query that translated and, unless it has placeholders, was planned by Trino
+// without being run
+type Validation struct {
+	GeneratedSQL string
+	// Planned reports whether Trino planned the query; a query with placeholders is only translated
+	Planned bool
+	// Warnings are problems that do not stop the query from running
+	Warnings []string
 }
 
 // Translator implements QueryTranslator
@@ -48,139 +74,258 @@ func NewTranslator(storage storage.MetadataStorage, engine datasync.QueryEngine)
 // Translate converts a query on global tables to executable Trino SQL
 func (t *Translator) Translate(globalQuery string) (string, error) {
 	// 1. Parse the query
-	parsed, err := t.parser.Parse(globalQuery)
+	stmt, err := t.parser.Parse(globalQuery)
 	if err != nil {
 		return "", fmt.Errorf("parse error: %w", err)
 	}
 
+	target, err := t.analyzeQuery(stmt)
+	if err != nil {
+		return "", fmt.Errorf("analysis error: %w", err)
+	}
+
 	// 2. Resolve global table to physical table
-	physicalTable, err := t.resolver.ResolveGlobalTable(parsed.TableName)
+	physicalTable, err := t.resolver.ResolveGlobalTable(target.TableName)
 	if err != nil {
 		return "", fmt.Errorf("resolution error: %w", err)
 	}
 
 	// 3. Get columns to map
-	columnsToMap := parsed.Columns
-	if parsed.IsSelectAll {
-		// Get all global columns for SELECT *
-		allCols, err := t.columnMapper.GetAllColumns(parsed.TableName)
-		if err != nil {
-			return "", fmt.Errorf("column resolution error: %w", err)
-		}
-		columnsToMap = allCols
+	columns, err := t.resolveSelectColumns(target)
+	if err != nil {
+		return "", fmt.Errorf("column resolution error: %w", err)
 	}
 
-	// 4. Map columns
-	columnMap, err := t.columnMapper.MapColumns(parsed.TableName, columnsToMap, physicalTable)
+	// 4. Map columns and generate SQL
+	return t.translateSingleMapping(target, physicalTable, columns)
+}
+
+// TranslateAndExecute translates the query and executes it against Trino, cancelling it when ctx is done
+func (t *Translator) TranslateAndExecute(ctx context.Context, globalQuery string) (*QueryResult, error) {
+	stream, err := t.TranslateAndStream(ctx, globalQuery, nil)
 	if err != nil {
-		return "", fmt.Errorf("column mapping error: %w", err)
+		return nil, err
 	}
 
-	// 5. Generate SQL
-	trinoSQL, err := t.generator.GenerateSQL(
-		physicalTable,
-		columnMap,
-		columnsToMap,
-		parsed.WhereClause,
-		parsed.LimitClause,
-		parsed.IsSelectAll,
-	)
+	result, err := datasync.CollectRows(stream.Rows)
 	if err != nil {
-		return "", fmt.Errorf("SQL generation error: %w", err)
+		return nil, fmt.Errorf("execution error: %w", err)
 	}
 
-	return trinoSQL, nil
+	return stream.Result(result.Rows), nil
 }
 
-// TranslateAndExecute translates the query and executes it against Trino
-func (t *Translator) TranslateAndExecute(globalQuery string) (*QueryResult, error) {
+// TranslateAndStream translates the query and starts it on Trino, returning its rows as they arrive.
+// Placeholders in the global query are carried into the generated SQL and bound to params there.
+func (t *Translator) TranslateAndStream(ctx context.Context, globalQuery string, params *datasync.Params) (*QueryStream, error) {
 	startTime := time.Now()
 
+	trinoSQL, err := t.TranslateQuery(globalQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := t.engine.QueryRowsContext(ctx, trinoSQL, params)
+	if err != nil {
+		return nil, fmt.Errorf("execution error: %w", err)
+	}
+
+	tableName := ""
+	if stmt, err := t.parser.Parse(globalQuery); err == nil {
+		tableName = firstTableName(stmt.From)
+	}
+
+	return &QueryStream{GeneratedSQL: trinoSQL, TableName: tableName, Rows: rows, StartTime: startTime}, nil
+}
+
+// TranslateQuery converts a query on global tables to the Trino SQL TranslateAndStream would run
+func (t *Translator) TranslateQuery(globalQuery string) (string, error) {
 	// Try Phase 2 translation first (supports UNION, etc.)
 	trinoSQL, err := t.TranslateAdvanced(globalQuery)
 	if err != nil {
-		// Fall back to Phase 1 translation
-		trinoSQL, err = t.Translate(globalQuery)
-		if err != nil {
-			return nil, err
+		// Fall back to Phase 1 translation, reporting the advanced error if that fails too
+		var fallbackErr error
+		trinoSQL, fallbackErr = t.Translate(globalQuery)
+		if fallbackErr != nil {
+			return "", err
 		}
 	}
+	return trinoSQL, nil
+}
 
-	// Execute the query
-	result, err := t.engine.ExecuteQuery(trinoSQL, nil)
+// Validate translates a query and has Trino plan the generated SQL with EXPLAIN, without running
+// it. Parse and resolution errors keep the prefixes of TranslateQuery; Trino's are "planning error".
+// A query with placeholders cannot be planned, so it is only translated and Planned is false.
+func (t *Translator) Validate(ctx context.Context, globalQuery string) (*Validation, error) {
+	trinoSQL, err := t.TranslateQuery(globalQuery)
 	if err != nil {
-		return nil, fmt.Errorf("execution error: %w", err)
+		return nil, err
+	}
+	validation := &Validation{GeneratedSQL: trinoSQL}
+
+	if stmt, err := t.parser.Parse(globalQuery); err == nil {
+		for _, name := range tableNames(stmt.From) {
+			if table, err := t.storage.GetGlobalTable(name); err == nil && table.Degraded {
+				validation.Warnings = append(validation.Warnings, fmt.Sprintf("global table '%s' is degraded: a mapping or relation points at physical metadata that changed, see GET /sync/impact", name))
+			}
+		}
+	}
+
+	// Trino cannot plan a query whose placeholders have no values
+	if datasync.HasPlaceholders(trinoSQL) {
+		validation.Warnings = append(validation.Warnings, "the query has parameter placeholders, so Trino did not plan it")
+		return validation, nil
+	}
+
+	if _, err := t.engine.ExecuteQueryContext(ctx, "EXPLAIN "+trinoSQL, nil); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("planning error: %w", err)
 	}
+	validation.Planned = true
+	return validation, nil
+}
+
+// tableNames returns the names of the tables a FROM clause reads, including those of subqueries
+func tableNames(from TableExpr) []string {
+	switch from := from.(type) {
+	case *TableName:
+		return []string{from.Name}
+	case *JoinExpr:
+		return append(tableNames(from.Left), tableNames(from.Right)...)
+	case *SubqueryTable:
+		return tableNames(from.Select.From)
+	}
+	return nil
+}
 
-	executionTime := time.Since(startTime)
+// firstTableName returns the name of the left-most table in a FROM clause, or "" for a subquery
+func firstTableName(from TableExpr) string {
+	switch from := from.(type) {
+	case *TableName:
+		return from.Name
+	case *JoinExpr:
+		return firstTableName(from.Left)
+	}
+	return ""
+}
 
+// Result builds a QueryResult holding rows read from the stream, timed from when the query started
+func (s *QueryStream) Result(rows []map[string]interface{}) *QueryResult {
 	return &QueryResult{
-		GeneratedSQL:  trinoSQL,
-		Rows:          result.Rows,
-		RowCount:      len(result.Rows),
-		ExecutionTime: fmt.Sprintf("%dms", executionTime.Milliseconds()),
-	}, nil
+		GeneratedSQL:  s.GeneratedSQL,
+		Columns:       s.Rows.Columns(),
+		Rows:          rows,
+		RowCount:      len(rows),
+		ExecutionTime: fmt.Sprintf("%dms", time.Since(s.StartTime).Milliseconds()),
+	}
 }
 
 // TranslateAdvanced converts a query on global tables to executable Trino SQL
-// Phase 2: Supports UNION relations and multiple table mappings
+// Phase 2: Supports UNION relations, multiple table mappings and joins between global tables
 func (t *Translator) TranslateAdvanced(globalQuery string) (string, error) {
 	// 1. Parse the query
-	parsed, err := t.parser.Parse(globalQuery)
+	stmt, err := t.parser.Parse(globalQuery)
 	if err != nil {
 		return "", fmt.Errorf("parse error: %w", err)
 	}
 
+	// Joins between global tables resolve each side separately
+	if _, isJoin := stmt.From.(*JoinExpr); isJoin {
+		return t.translateJoin(stmt)
+	}
+
+	target, err := t.analyzeQuery(stmt)
+	if err != nil {
+		return "", fmt.Errorf("analysis error: %w", err)
+	}
+
+	return t.translateTarget(target)
+}
+
+// translateTarget generates SQL for a query on a single global table
+func (t *Translator) translateTarget(target *queryTarget) (string, error) {
 	// 2. Resolve global table using advanced resolver
-	resolved, err := t.resolver.ResolveGlobalTableAdvanced(parsed.TableName)
+	resolved, err := t.resolver.ResolveGlobalTableAdvanced(target.TableName)
 	if err != nil {
 		return "", fmt.Errorf("resolution error: %w", err)
 	}
+	target.Clauses.SourceColumn = resolved.SourceColumn
 
 	// 3. Get columns to map
-	columnsToMap := parsed.Columns
-	if parsed.IsSelectAll {
-		// Get all global columns for SELECT *
-		allCols, err := t.columnMapper.GetAllColumns(parsed.TableName)
-		if err != nil {
-			return "", fmt.Errorf("column resolution error: %w", err)
-		}
-		columnsToMap = allCols
+	columns, err := t.resolveSelectColumns(target)
+	if err != nil {
+		return "", fmt.Errorf("column resolution error: %w", err)
 	}
 
 	// 4. Handle different resolution types
+	if target.Complex && (resolved.IsRelation || resolved.MultipleMappings != nil) {
+		// Aggregate over a derived table of all sources
+		return t.translateDerived(target, resolved)
+	}
+
 	if resolved.IsRelation {
 		// Explicit relation (UNION or JOIN)
-		return t.translateRelation(parsed, resolved.Relation, columnsToMap)
+		return t.translateRelation(target, resolved.Relation, columns)
 	} else if resolved.MultipleMappings != nil {
 		// Multiple mappings - auto-generate UNION
-		return t.translateMultipleMappings(parsed, resolved.MultipleMappings, columnsToMap)
+		return t.translateMultipleMappings(target, resolved.MultipleMappings, columns, resolved.UnionType)
 	} else {
 		// Single mapping - use simple translation
-		columnMap, err := t.columnMapper.MapColumns(parsed.TableName, columnsToMap, resolved.SingleMapping)
-		if err != nil {
-			return "", fmt.Errorf("column mapping error: %w", err)
-		}
+		return t.translateSingleMapping(target, resolved.SingleMapping, columns)
+	}
+}
+
+// translateSingleMapping handles translation for a global table with a single physical table
+func (t *Translator) translateSingleMapping(
+	target *queryTarget,
+	mapping *models.TableMapping,
+	columns []SelectColumn,
+) (string, error) {
+	columnMap, err := t.columnMapper.MapColumns(target.TableName, target.requiredColumns(columns), mapping)
+	if err != nil {
+		return "", fmt.Errorf("column mapping error: %w", err)
+	}
 
-		return t.generator.GenerateSQL(
-			resolved.SingleMapping,
-			columnMap,
-			columnsToMap,
-			parsed.WhereClause,
-			parsed.LimitClause,
-			parsed.IsSelectAll,
-		)
+	if target.Complex {
+		return t.generator.GenerateMappedQuery(mapping, columnMap, expandStar(target.Items, columns), target.Clauses)
 	}
+	return t.generator.GenerateSQL(mapping, columnMap, columns, target.Clauses)
+}
+
+// translateDerived handles complex queries over several physical tables: the sources
+// are translated to a query returning global columns, which is wrapped in a derived
+// table with the aggregation, grouping and ordering applied outside it
+func (t *Translator) translateDerived(target *queryTarget, resolved *ResolvedTableSource) (string, error) {
+	source := &queryTarget{
+		TableName:        target.TableName,
+		PredicateColumns: target.PredicateColumns,
+		Clauses:          target.Clauses.branchClauses(),
+	}
+
+	// A UNION DISTINCT deduplicates whole rows, so it must project every global
+	// column to return the rows the global table holds; UNION ALL needs only the referenced ones
+	if !resolved.HasDistinctUnion() && len(target.Columns) > 0 {
+		source.Columns = target.Columns
+	}
+
+	sourceSQL, err := t.translateTarget(source)
+	if err != nil {
+		return "", err
+	}
+
+	return t.generator.GenerateDerivedQuery(sourceSQL, target.referenceName(), target.Items, target.Clauses)
 }
 
 // translateRelation handles translation for explicit relations
 func (t *Translator) translateRelation(
-	parsed *ParsedQuery,
+	target *queryTarget,
 	relation *ResolvedRelation,
-	columnsToMap []string,
+	columns []SelectColumn,
 ) (string, error) {
 	// Map columns for the relation
-	columnMaps, err := t.columnMapper.MapColumnsForRelation(parsed.TableName, columnsToMap, relation)
+	columnMaps, err := t.columnMapper.MapColumnsForRelation(target.TableName, target.requiredColumns(columns), relation)
 	if err != nil {
 		return "", fmt.Errorf("column mapping error: %w", err)
 	}
@@ -188,23 +333,9 @@ func (t *Translator) translateRelation(
 	// Generate SQL based on relation type
 	switch relation.RelationType {
 	case "UNION":
-		return t.generator.GenerateUnionFromRelation(
-			relation,
-			columnMaps,
-			columnsToMap,
-			parsed.WhereClause,
-			parsed.LimitClause,
-			parsed.IsSelectAll,
-		)
+		return t.generator.GenerateUnionFromRelation(relation, columnMaps, columns, target.Clauses)
 	case "JOIN":
-		return t.generator.GenerateJoinFromRelation(
-			relation,
-			columnMaps,
-			columnsToMap,
-			parsed.WhereClause,
-			parsed.LimitClause,
-			parsed.IsSelectAll,
-		)
+		return t.generator.GenerateJoinFromRelation(relation, columnMaps, columns, target.Clauses)
 	default:
 		return "", fmt.Errorf("unsupported relation type: %s", relation.RelationType)
 	}
@@ -212,23 +343,255 @@ func (t *Translator) translateRelation(
 
 // translateMultipleMappings handles translation for multiple table mappings (auto-UNION)
 func (t *Translator) translateMultipleMappings(
-	parsed *ParsedQuery,
+	target *queryTarget,
 	mappings []*models.TableMapping,
-	columnsToMap []string,
+	columns []SelectColumn,
+	unionType string,
 ) (string, error) {
 	// Map columns for each table
-	columnMaps, err := t.columnMapper.MapColumnsForMultipleTables(parsed.TableName, columnsToMap, mappings)
+	columnMaps, err := t.columnMapper.MapColumnsForMultipleTables(target.TableName, target.requiredColumns(columns), mappings)
 	if err != nil {
 		return "", fmt.Errorf("column mapping error: %w", err)
 	}
 
 	// Generate UNION SQL
-	return t.generator.GenerateUnionSQL(
-		mappings,
-		columnMaps,
-		columnsToMap,
-		parsed.WhereClause,
-		parsed.LimitClause,
-		parsed.IsSelectAll,
-	)
+	return t.generator.GenerateUnionSQL(mappings, columnMaps, columns, target.Clauses, unionType)
+}
+
+// queryTarget is the global table a parsed query reads from and what it asks of it
+type queryTarget struct {
+	TableName string
+	Alias     string
+
+	// Select list as written in the query
+	Items []SelectItem
+
+	// Columns requested in the select list; nil when the query selects *.
+	// For complex queries, every global column referenced outside the WHERE clause.
+	Columns []SelectColumn
+
+	// Global columns referenced by the WHERE clause
+	PredicateColumns []string
+
+	// Complex is set when the query does more than select columns with WHERE and
+	// LIMIT: it aggregates, groups, sorts, deduplicates or computes expressions
+	Complex bool
+
+	Clauses *QueryClauses
+}
+
+// analyzeQuery checks that a parsed statement only uses features the
+// translator supports and extracts the global table and requested columns
+func (t *Translator) analyzeQuery(stmt *SelectStmt) (*queryTarget, error) {
+	if stmt.From == nil {
+		return nil, fmt.Errorf("FROM clause not found")
+	}
+
+	table, ok := stmt.From.(*TableName)
+	if !ok {
+		if _, isJoin := stmt.From.(*JoinExpr); isJoin {
+			return nil, fmt.Errorf("JOINs between global tables are not supported")
+		}
+		return nil, fmt.Errorf("subqueries in FROM are not supported")
+	}
+	if table.Catalog != "" || table.Schema != "" {
+		return nil, fmt.Errorf("'%s' is not a global table name; query global tables without catalog or schema", FormatTableExpr(table))
+	}
+
+	target := &queryTarget{
+		TableName: table.Name,
+		Alias:     table.Alias,
+		Items:     stmt.Items,
+		Complex: stmt.Distinct || len(stmt.GroupBy) > 0 || stmt.Having != nil ||
+			len(stmt.OrderBy) > 0 || stmt.Offset != nil,
+		Clauses: &QueryClauses{
+			Distinct: stmt.Distinct,
+			Where:    stmt.Where,
+			GroupBy:  stmt.GroupBy,
+			Having:   stmt.Having,
+			OrderBy:  stmt.OrderBy,
+			Limit:    stmt.Limit,
+			Offset:   stmt.Offset,
+		},
+	}
+
+	// Validate WHERE column references; they are mapped per physical table during generation
+	whereRefs, err := target.collectRefs(stmt.Where)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range whereRefs {
+		target.PredicateColumns = append(target.PredicateColumns, ref.Column)
+	}
+
+	// Validate the select list
+	selectsAll := false
+	var selected []SelectColumn
+	var referenced []*ColumnRef
+	for _, item := range stmt.Items {
+		switch expr := item.Expr.(type) {
+		case *StarExpr:
+			if !target.refersTo(expr.Table) {
+				return nil, fmt.Errorf("unknown table '%s' in %s.*", expr.Table, expr.Table)
+			}
+			selectsAll = true
+		case *ColumnRef:
+			if !target.refersTo(expr.Table) {
+				return nil, fmt.Errorf("unknown table '%s' in column reference %s", expr.Table, FormatExpr(expr))
+			}
+			selected = append(selected, SelectColumn{Global: expr.Column, Alias: item.Alias})
+			referenced = append(referenced, expr)
+		default:
+			refs, err := target.collectRefs(item.Expr)
+			if err != nil {
+				return nil, err
+			}
+			referenced = append(referenced, refs...)
+			target.Complex = true
+		}
+	}
+	if selectsAll && len(stmt.Items) > 1 {
+		target.Complex = true
+	}
+
+	if !target.Complex {
+		if !selectsAll {
+			target.Columns = selected
+		}
+		return target, nil
+	}
+
+	// Collect the columns used by grouping and ordering; ORDER BY may also name output columns
+	exprs := append([]Expr{stmt.Having}, stmt.GroupBy...)
+	outputs := outputNames(stmt.Items)
+	for _, item := range stmt.OrderBy {
+		if col, ok := item.Expr.(*ColumnRef); ok && col.Table == "" && outputs[col.Column] {
+			continue
+		}
+		exprs = append(exprs, item.Expr)
+	}
+	for _, expr := range exprs {
+		refs, err := target.collectRefs(expr)
+		if err != nil {
+			return nil, err
+		}
+		referenced = append(referenced, refs...)
+	}
+
+	if selectsAll {
+		return target, nil
+	}
+
+	seen := make(map[string]bool)
+	target.Columns = []SelectColumn{}
+	for _, ref := range referenced {
+		if !seen[ref.Column] {
+			seen[ref.Column] = true
+			target.Columns = append(target.Columns, SelectColumn{Global: ref.Column})
+		}
+	}
+
+	return target, nil
+}
+
+// collectRefs returns the column references in an expression, checking that
+// each one refers to the target table
+func (q *queryTarget) collectRefs(e Expr) ([]*ColumnRef, error) {
+	refs, err := CollectColumnRefs(e)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if !q.refersTo(ref.Table) {
+			return nil, fmt.Errorf("unknown table '%s' in column reference %s", ref.Table, FormatExpr(ref))
+		}
+	}
+	return refs, nil
+}
+
+// referenceName returns the name the query refers to the target table by
+func (q *queryTarget) referenceName() string {
+	if q.Alias != "" {
+		return q.Alias
+	}
+	return q.TableName
+}
+
+// refersTo reports whether a column qualifier refers to the target table
+func (q *queryTarget) refersTo(qualifier string) bool {
+	if qualifier == "" {
+		return true
+	}
+	if q.Alias != "" {
+		return qualifier == q.Alias
+	}
+	return qualifier == q.TableName
+}
+
+// requiredColumns returns every global column the query needs mapped:
+// the select columns followed by any columns only used in the WHERE clause.
+// The source column is generated rather than mapped and is left out.
+func (q *queryTarget) requiredColumns(columns []SelectColumn) []string {
+	seen := map[string]bool{q.Clauses.SourceColumn: true}
+	var names []string
+	for _, name := range append(globalColumnNames(columns), q.PredicateColumns...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resolveSelectColumns returns the requested columns, expanding SELECT * to all global columns
+func (t *Translator) resolveSelectColumns(target *queryTarget) ([]SelectColumn, error) {
+	if target.Columns != nil {
+		return target.Columns, nil
+	}
+
+	// Get all global columns for SELECT *
+	allCols, err := t.columnMapper.GetAllColumns(target.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(allCols) == 0 {
+		return nil, fmt.Errorf("global table '%s' has no columns", target.TableName)
+	}
+
+	columns := make([]SelectColumn, 0, len(allCols)+1)
+	sourceListed := false
+	for _, name := range allCols {
+		columns = append(columns, SelectColumn{Global: name})
+		sourceListed = sourceListed || name == target.Clauses.SourceColumn
+	}
+
+	// The source column is part of * even when it is not declared as a global column
+	if target.Clauses.SourceColumn != "" && !sourceListed {
+		columns = append(columns, SelectColumn{Global: target.Clauses.SourceColumn})
+	}
+	return columns, nil
+}
+
+// expandStar replaces * in a select list with references to the given columns
+func expandStar(items []SelectItem, columns []SelectColumn) []SelectItem {
+	expanded := make([]SelectItem, 0, len(items))
+	for _, item := range items {
+		if _, ok := item.Expr.(*StarExpr); !ok {
+			expanded = append(expanded, item)
+			continue
+		}
+		for _, col := range columns {
+			expanded = append(expanded, SelectItem{Expr: &ColumnRef{Column: col.Global}})
+		}
+	}
+	return expanded
+}
+
+// globalColumnNames returns the global column names of the select columns
+func globalColumnNames(columns []SelectColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Global
+	}
+	return names
 }
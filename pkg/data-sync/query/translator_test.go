@@ -0,0 +1,831 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	datasync "github.com/guilherme096/data-sync/pkg/data-sync"
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// newTestStorage creates a storage with a "customers" global table mapped to
+// postgresql.public.customers and mysql.main.clients
+func newTestStorage(t *testing.T, withSecondMapping bool) *storage.MemoryMetadataStorage {
+	t.Helper()
+
+	store := storage.NewMemoryMetadataStorage()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "customers"}))
+	for _, col := range []string{"id", "name", "email", "country"} {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "customers", Name: col}))
+	}
+
+	must(store.CreateTableMapping(&models.TableMapping{
+		GlobalTableName: "customers", CatalogName: "postgresql", SchemaName: "public", TableName: "customers",
+	}))
+	pgColumns := map[string]string{"id": "id", "name": "full_name", "email": "email_address", "country": "country"}
+	for global, physical := range pgColumns {
+		must(store.CreateColumnMapping(&models.ColumnMapping{
+			GlobalTableName: "customers", GlobalColumnName: global,
+			CatalogName: "postgresql", SchemaName: "public", TableName: "customers", ColumnName: physical,
+		}))
+	}
+
+	if withSecondMapping {
+		must(store.CreateTableMapping(&models.TableMapping{
+			GlobalTableName: "customers", CatalogName: "mysql", SchemaName: "main", TableName: "clients",
+		}))
+		myColumns := map[string]string{"id": "client_id", "name": "name", "email": "mail", "country": "country_code"}
+		for global, physical := range myColumns {
+			must(store.CreateColumnMapping(&models.ColumnMapping{
+				GlobalTableName: "customers", GlobalColumnName: global,
+				CatalogName: "mysql", SchemaName: "main", TableName: "clients", ColumnName: physical,
+			}))
+		}
+	}
+
+	return store
+}
+
+func TestTranslate_SingleMapping(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, false), nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT id, name AS customer_name, email FROM customers LIMIT 5")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT id, full_name AS customer_name, email_address AS email FROM postgresql.public.customers LIMIT 5"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_AutoUnion(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, true), nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT c.id FROM customers c LIMIT 10")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT id FROM postgresql.public.customers UNION ALL SELECT client_id AS id FROM mysql.main.clients LIMIT 10"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_UnionDistinctWithSourceColumn(t *testing.T) {
+	store := newTestStorage(t, true)
+	globalTable, err := store.GetGlobalTable("customers")
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	globalTable.UnionType = models.UnionDistinct
+	globalTable.SourceColumn = "source"
+	if err := store.UpdateGlobalTable(globalTable); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT id, source FROM customers WHERE source <> 'mysql.main.clients'")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT id, 'postgresql.public.customers' AS source FROM postgresql.public.customers" +
+		" WHERE 'postgresql.public.customers' <> 'mysql.main.clients'" +
+		" UNION SELECT client_id AS id, 'mysql.main.clients' AS source FROM mysql.main.clients" +
+		" WHERE 'mysql.main.clients' <> 'mysql.main.clients'"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+
+	// A complex query over a UNION DISTINCT keeps every column in the derived table
+	sql, err = translator.TranslateAdvanced("SELECT source, count(*) FROM customers GROUP BY source")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected = "SELECT source, count(*) FROM" +
+		" (SELECT country, email_address AS email, id, full_name AS name, 'postgresql.public.customers' AS source FROM postgresql.public.customers" +
+		" UNION SELECT country_code AS country, mail AS email, client_id AS id, name, 'mysql.main.clients' AS source FROM mysql.main.clients) customers" +
+		" GROUP BY source"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_Errors(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, false), nil)
+
+	queries := []string{
+		"SELECT id FROM unknown_table",
+		"SELECT missing FROM customers",
+		"SELECT x.id FROM customers c",
+		"SELECT id FROM postgresql.public.customers",
+		"SELECT id FROM customers WHERE",
+	}
+
+	for _, query := range queries {
+		if _, err := translator.TranslateAdvanced(query); err == nil {
+			t.Errorf("Expected error for %q, got nil", query)
+		}
+	}
+}
+
+// addJoinRelation adds a "profiles" JOIN relation over postgresql.public.users
+// and mysql.main.user_details, exposed as a global table of the same name
+func addJoinRelation(t *testing.T, store *storage.MemoryMetadataStorage) {
+	t.Helper()
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID:           "rel_profiles",
+		Name:         "profiles",
+		LeftTable:    models.TableSource{Type: "physical", Catalog: "postgresql", Schema: "public", Table: "users"},
+		RightTable:   models.TableSource{Type: "physical", Catalog: "mysql", Schema: "main", Table: "user_details"},
+		RelationType: "JOIN",
+		JoinColumn:   &models.JoinColumn{Left: "id", Right: "user_id"},
+	}))
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "profiles"}))
+
+	columns := []struct {
+		global, left, right string
+	}{
+		{"id", "id", "user_id"},
+		{"username", "username", ""},
+		{"bio", "", "biography"},
+	}
+	for _, col := range columns {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "profiles", Name: col.global}))
+		if col.left != "" {
+			must(store.CreateColumnMapping(&models.ColumnMapping{
+				GlobalTableName: "profiles", GlobalColumnName: col.global,
+				CatalogName: "postgresql", SchemaName: "public", TableName: "users", ColumnName: col.left,
+			}))
+		}
+		if col.right != "" {
+			must(store.CreateColumnMapping(&models.ColumnMapping{
+				GlobalTableName: "profiles", GlobalColumnName: col.global,
+				CatalogName: "mysql", SchemaName: "main", TableName: "user_details", ColumnName: col.right,
+			}))
+		}
+	}
+}
+
+func TestTranslate_WhereMappedToPhysicalColumns(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, false), nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT id FROM customers c WHERE c.email = 'x@example.com' AND (name LIKE 'A%' OR country IN ('PT', 'ES'))")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT id FROM postgresql.public.customers WHERE email_address = 'x@example.com' AND (full_name LIKE 'A%' OR country IN ('PT', 'ES'))"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_NiladicFunctions(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, false), nil)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "SELECT id FROM customers WHERE email < current_date",
+			expected: "SELECT id FROM postgresql.public.customers WHERE email_address < current_date",
+		},
+		{
+			query:    "SELECT coalesce(name, current_timestamp) AS seen, localtime FROM customers",
+			expected: "SELECT coalesce(full_name, current_timestamp) AS seen, localtime FROM postgresql.public.customers",
+		},
+		{
+			query:    "SELECT id FROM customers ORDER BY coalesce(email, localtime), current_date",
+			expected: "SELECT id FROM postgresql.public.customers ORDER BY coalesce(email_address, localtime), current_date",
+		},
+	}
+
+	for _, tt := range tests {
+		sql, err := translator.TranslateAdvanced(tt.query)
+		if err != nil {
+			t.Fatalf("TranslateAdvanced(%q) failed: %v", tt.query, err)
+		}
+		if sql != tt.expected {
+			t.Errorf("Unexpected SQL for %q:\n  got:      %s\n  expected: %s", tt.query, sql, tt.expected)
+		}
+	}
+}
+
+func TestTranslate_WhereRewrittenPerUnionBranch(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, true), nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT name FROM customers WHERE email = 'x@example.com'")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT full_name AS name FROM postgresql.public.customers WHERE email_address = 'x@example.com'" +
+		" UNION ALL SELECT name FROM mysql.main.clients WHERE mail = 'x@example.com'"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_ParamsSurviveTranslation(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, true), nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT name FROM customers WHERE email = ? AND name LIKE ?")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	// ? placeholders are numbered so each UNION branch binds the same value
+	expected := "SELECT full_name AS name FROM postgresql.public.customers WHERE email_address = :1 AND full_name LIKE :2" +
+		" UNION ALL SELECT name FROM mysql.main.clients WHERE mail = :1 AND name LIKE :2"
+	if sql != expected {
+		t.Fatalf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+
+	params := &datasync.Params{Positional: []datasync.Param{{Value: "x@example.com"}, {Value: "A%"}}}
+	_, args, err := params.Bind(sql)
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if len(args) != 4 || args[0].Value != "x@example.com" || args[1].Value != "A%" || args[2].Value != "x@example.com" || args[3].Value != "A%" {
+		t.Fatalf("Expected arguments bound in placeholder order, got %v", args)
+	}
+
+	sql, err = translator.TranslateAdvanced("SELECT name FROM customers WHERE name LIKE :pattern")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+	expected = "SELECT full_name AS name FROM postgresql.public.customers WHERE full_name LIKE :pattern" +
+		" UNION ALL SELECT name FROM mysql.main.clients WHERE name LIKE :pattern"
+	if sql != expected {
+		t.Fatalf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_RejectsMixedPlaceholders(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, true), nil)
+
+	for _, globalQuery := range []string{
+		"SELECT name FROM customers WHERE email = ? AND name LIKE :pattern",
+		"SELECT name FROM customers WHERE name LIKE :pattern AND email = ?",
+	} {
+		_, err := translator.TranslateQuery(globalQuery)
+		if !errors.Is(err, datasync.ErrInvalidParams) || !strings.Contains(err.Error(), "cannot mix ? and :name placeholders") {
+			t.Fatalf("Expected mixed placeholders to be rejected for %q, got %v", globalQuery, err)
+		}
+	}
+}
+
+func TestTranslate_WhereQualifiedForJoin(t *testing.T) {
+	store := newTestStorage(t, false)
+	addJoinRelation(t, store)
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT username FROM profiles WHERE bio IS NOT NULL AND id > 10")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT t1.username FROM postgresql.public.users t1 JOIN mysql.main.user_details t2 ON t1.id = t2.user_id" +
+		" WHERE t2.biography IS NOT NULL AND t1.id > 10"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_WhereUnknownColumn(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, false), nil)
+
+	if _, err := translator.TranslateAdvanced("SELECT id FROM customers WHERE missing = 1"); err == nil {
+		t.Error("Expected error for unmapped WHERE column, got nil")
+	}
+}
+
+// addOrdersTable adds an "orders" global table mapped to postgresql.public.orders,
+// related to customers through orders.customer_id -> customers.id
+func addOrdersTable(t *testing.T, store *storage.MemoryMetadataStorage) {
+	t.Helper()
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "orders"}))
+	must(store.CreateTableMapping(&models.TableMapping{
+		GlobalTableName: "orders", CatalogName: "postgresql", SchemaName: "public", TableName: "orders",
+	}))
+	columns := map[string]string{"id": "order_id", "customer_id": "cust_id", "total": "total"}
+	for global, physical := range columns {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "orders", Name: global}))
+		must(store.CreateColumnMapping(&models.ColumnMapping{
+			GlobalTableName: "orders", GlobalColumnName: global,
+			CatalogName: "postgresql", SchemaName: "public", TableName: "orders", ColumnName: physical,
+		}))
+	}
+
+	must(store.CreateColumnRelationship(&models.ColumnRelationship{
+		SourceGlobalTableName: "orders", SourceGlobalColumnName: "customer_id",
+		TargetGlobalTableName: "customers", TargetGlobalColumnName: "id",
+	}))
+}
+
+func TestTranslate_JoinGlobalTables(t *testing.T) {
+	store := newTestStorage(t, false)
+	addOrdersTable(t, store)
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT c.name, o.total FROM customers c JOIN orders o ON c.id = o.customer_id WHERE total > 100 LIMIT 5")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT c.name, o.total FROM" +
+		" (SELECT id, full_name AS name FROM postgresql.public.customers) c" +
+		" JOIN (SELECT cust_id AS customer_id, total FROM postgresql.public.orders) o ON c.id = o.customer_id" +
+		" WHERE o.total > 100 LIMIT 5"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_JoinUsesDeclaredRelationship(t *testing.T) {
+	store := newTestStorage(t, false)
+	addOrdersTable(t, store)
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT o.id, c.email FROM orders o LEFT JOIN customers c")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT o.id, c.email FROM" +
+		" (SELECT cust_id AS customer_id, order_id AS id FROM postgresql.public.orders) o" +
+		" LEFT JOIN (SELECT id, email_address AS email FROM postgresql.public.customers) c ON o.customer_id = c.id"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_JoinErrors(t *testing.T) {
+	store := newTestStorage(t, false)
+	addOrdersTable(t, store)
+	addJoinRelation(t, store)
+	translator := NewTranslator(store, nil)
+
+	queries := []string{
+		"SELECT id FROM customers c JOIN orders o ON c.id = o.customer_id",
+		"SELECT c.missing FROM customers c JOIN orders o ON c.id = o.customer_id",
+		"SELECT c.id FROM customers c JOIN profiles p",
+		"SELECT c.id FROM customers c JOIN customers c ON c.id = c.id",
+		"SELECT c.id FROM customers c JOIN unknown u ON c.id = u.id",
+	}
+
+	for _, query := range queries {
+		if _, err := translator.TranslateAdvanced(query); err == nil {
+			t.Errorf("Expected error for %q, got nil", query)
+		}
+	}
+}
+
+// addNestedRelations adds an "all_profiles" UNION relation over two JOIN relations
+// and an "order_regions" JOIN relation over a nested UNION relation
+func addNestedRelations(t *testing.T, store *storage.MemoryMetadataStorage) {
+	t.Helper()
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	physical := func(catalog, schema, table string) models.TableSource {
+		return models.TableSource{Type: "physical", Catalog: catalog, Schema: schema, Table: table}
+	}
+	nested := func(id string) models.TableSource {
+		return models.TableSource{Type: "relation", RelationID: id}
+	}
+	mapColumn := func(globalTable, globalColumn, catalog, schema, table, column string) {
+		must(store.CreateColumnMapping(&models.ColumnMapping{
+			GlobalTableName: globalTable, GlobalColumnName: globalColumn,
+			CatalogName: catalog, SchemaName: schema, TableName: table, ColumnName: column,
+		}))
+	}
+
+	// all_profiles = (users JOIN user_details) UNION (legacy_users JOIN legacy_details)
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_current", Name: "current_profiles", RelationType: "JOIN",
+		LeftTable: physical("postgresql", "public", "users"), RightTable: physical("mysql", "main", "user_details"),
+		JoinColumn: &models.JoinColumn{Left: "id", Right: "user_id"},
+	}))
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_legacy", Name: "legacy_profiles", RelationType: "JOIN",
+		LeftTable: physical("postgresql", "public", "legacy_users"), RightTable: physical("mysql", "main", "legacy_details"),
+		JoinColumn: &models.JoinColumn{Left: "uid", Right: "user_id"},
+	}))
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_all", Name: "all_profiles", RelationType: "UNION",
+		LeftTable: nested("rel_current"), RightTable: nested("rel_legacy"),
+	}))
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "all_profiles"}))
+	for _, col := range []string{"id", "username", "bio"} {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "all_profiles", Name: col}))
+	}
+	mapColumn("all_profiles", "id", "postgresql", "public", "users", "id")
+	mapColumn("all_profiles", "id", "mysql", "main", "user_details", "user_id")
+	mapColumn("all_profiles", "id", "postgresql", "public", "legacy_users", "uid")
+	mapColumn("all_profiles", "id", "mysql", "main", "legacy_details", "user_id")
+	mapColumn("all_profiles", "username", "postgresql", "public", "users", "username")
+	mapColumn("all_profiles", "username", "postgresql", "public", "legacy_users", "login")
+	mapColumn("all_profiles", "bio", "mysql", "main", "user_details", "biography")
+	mapColumn("all_profiles", "bio", "mysql", "main", "legacy_details", "about")
+
+	// order_regions = (eu_customers UNION us_customers) JOIN orders, joined on the global id column
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_regions", Name: "regional_customers", RelationType: "UNION",
+		LeftTable: physical("postgresql", "eu", "customers"), RightTable: physical("postgresql", "us", "customers"),
+	}))
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_order_regions", Name: "order_regions", RelationType: "JOIN",
+		LeftTable: nested("rel_regions"), RightTable: physical("postgresql", "public", "orders"),
+		JoinColumn: &models.JoinColumn{Left: "customer_id", Right: "cust_id"},
+	}))
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "order_regions"}))
+	for _, col := range []string{"customer_id", "region", "total"} {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "order_regions", Name: col}))
+	}
+	mapColumn("order_regions", "customer_id", "postgresql", "eu", "customers", "id")
+	mapColumn("order_regions", "customer_id", "postgresql", "us", "customers", "customer_id")
+	mapColumn("order_regions", "region", "postgresql", "eu", "customers", "country")
+	mapColumn("order_regions", "region", "postgresql", "us", "customers", "state")
+	mapColumn("order_regions", "total", "postgresql", "public", "orders", "total")
+}
+
+func TestTranslate_NestedUnionOfJoins(t *testing.T) {
+	store := newTestStorage(t, false)
+	addNestedRelations(t, store)
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT id, username, bio FROM all_profiles WHERE username LIKE 'a%' LIMIT 20")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "(SELECT t1.id, t1.username, t2.biography AS bio FROM postgresql.public.users t1" +
+		" JOIN mysql.main.user_details t2 ON t1.id = t2.user_id WHERE t1.username LIKE 'a%')" +
+		" UNION ALL (SELECT t1.uid AS id, t1.login AS username, t2.about AS bio FROM postgresql.public.legacy_users t1" +
+		" JOIN mysql.main.legacy_details t2 ON t1.uid = t2.user_id WHERE t1.login LIKE 'a%')" +
+		" LIMIT 20"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_JoinOverNestedUnion(t *testing.T) {
+	store := newTestStorage(t, false)
+	addNestedRelations(t, store)
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT region, total FROM order_regions WHERE total > 50")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT t1.region, t2.total FROM" +
+		" (SELECT id AS customer_id, country AS region FROM postgresql.eu.customers" +
+		" UNION ALL SELECT customer_id, state AS region FROM postgresql.us.customers) t1" +
+		" JOIN postgresql.public.orders t2 ON t1.customer_id = t2.cust_id WHERE t2.total > 50"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestTranslate_OuterJoinRelation(t *testing.T) {
+	store := storage.NewMemoryMetadataStorage()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	// merged_customers = postgresql customers FULL OUTER JOIN mysql clients on (id, country)
+	must(store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_merged", Name: "merged_customers", RelationType: "JOIN", JoinType: models.JoinFull,
+		LeftTable:   models.TableSource{Type: "physical", Catalog: "postgresql", Schema: "public", Table: "customers"},
+		RightTable:  models.TableSource{Type: "physical", Catalog: "mysql", Schema: "main", Table: "clients"},
+		JoinColumns: []models.JoinColumn{{Left: "id", Right: "client_id"}, {Left: "country", Right: "country_code"}},
+	}))
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "merged_customers"}))
+
+	columns := []struct {
+		global, left, right string
+	}{
+		{"id", "id", "client_id"},
+		{"country", "country", "country_code"},
+		{"email", "email_address", "mail"},
+		{"name", "full_name", ""},
+	}
+	for _, col := range columns {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "merged_customers", Name: col.global}))
+		must(store.CreateColumnMapping(&models.ColumnMapping{
+			GlobalTableName: "merged_customers", GlobalColumnName: col.global,
+			CatalogName: "postgresql", SchemaName: "public", TableName: "customers", ColumnName: col.left,
+		}))
+		if col.right != "" {
+			must(store.CreateColumnMapping(&models.ColumnMapping{
+				GlobalTableName: "merged_customers", GlobalColumnName: col.global,
+				CatalogName: "mysql", SchemaName: "main", TableName: "clients", ColumnName: col.right,
+			}))
+		}
+	}
+
+	translator := NewTranslator(store, nil)
+
+	sql, err := translator.TranslateAdvanced("SELECT * FROM merged_customers WHERE email LIKE '%@example.com'")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := "SELECT COALESCE(t1.country, t2.country_code) AS country, COALESCE(t1.email_address, t2.mail) AS email," +
+		" COALESCE(t1.id, t2.client_id) AS id, t1.full_name AS name" +
+		" FROM postgresql.public.customers t1 FULL OUTER JOIN mysql.main.clients t2" +
+		" ON t1.id = t2.client_id AND t1.country = t2.country_code" +
+		" WHERE COALESCE(t1.email_address, t2.mail) LIKE '%@example.com'"
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+}
+
+func TestResolveRelation_Nesting(t *testing.T) {
+	store := newTestStorage(t, false)
+	addNestedRelations(t, store)
+	resolver := NewRelationResolver(store)
+
+	// The same relation may appear twice in a tree without being a cycle
+	if err := store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_twice", Name: "twice", RelationType: "UNION",
+		LeftTable:  models.TableSource{Type: "relation", RelationID: "rel_current"},
+		RightTable: models.TableSource{Type: "relation", RelationID: "rel_current"},
+	}); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	resolved, err := resolver.ResolveRelation("rel_twice")
+	if err != nil {
+		t.Fatalf("ResolveRelation failed: %v", err)
+	}
+	tables, err := resolver.GetPhysicalTables(resolved)
+	if err != nil {
+		t.Fatalf("GetPhysicalTables failed: %v", err)
+	}
+	if len(tables) != 4 {
+		t.Errorf("Expected 4 physical tables, got %d", len(tables))
+	}
+
+	// A relation that contains itself is rejected
+	if err := store.CreateTableRelation(&models.TableRelation{
+		ID: "rel_cycle", Name: "cycle", RelationType: "UNION",
+		LeftTable:  models.TableSource{Type: "relation", RelationID: "rel_cycle"},
+		RightTable: models.TableSource{Type: "physical", Catalog: "postgresql", Schema: "public", Table: "users"},
+	}); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := resolver.ResolveRelation("rel_cycle"); err == nil {
+		t.Error("Expected error for circular relation, got nil")
+	}
+}
+
+func TestTranslate_Aggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T) *storage.MemoryMetadataStorage
+		query    string
+		expected string
+	}{
+		{
+			name:  "single mapping",
+			setup: func(t *testing.T) *storage.MemoryMetadataStorage { return newTestStorage(t, false) },
+			query: "SELECT name, count(*) FROM customers WHERE email IS NOT NULL GROUP BY name HAVING count(DISTINCT country) > 1 ORDER BY name LIMIT 3",
+			expected: "SELECT full_name AS name, count(*) FROM postgresql.public.customers WHERE email_address IS NOT NULL" +
+				" GROUP BY full_name HAVING count(DISTINCT country) > 1 ORDER BY name LIMIT 3",
+		},
+		{
+			name:  "auto union",
+			setup: func(t *testing.T) *storage.MemoryMetadataStorage { return newTestStorage(t, true) },
+			query: "SELECT country, count(*) FROM customers GROUP BY country ORDER BY 2 DESC",
+			expected: "SELECT country, count(*) FROM" +
+				" (SELECT country FROM postgresql.public.customers" +
+				" UNION ALL SELECT country_code AS country FROM mysql.main.clients) customers" +
+				" GROUP BY country ORDER BY 2 DESC",
+		},
+		{
+			name: "join relation",
+			setup: func(t *testing.T) *storage.MemoryMetadataStorage {
+				store := newTestStorage(t, false)
+				addJoinRelation(t, store)
+				return store
+			},
+			query: "SELECT DISTINCT upper(p.username) AS username FROM profiles p WHERE bio IS NOT NULL ORDER BY username OFFSET 5 LIMIT 10",
+			expected: "SELECT DISTINCT upper(p.username) AS username FROM" +
+				" (SELECT t1.username FROM postgresql.public.users t1" +
+				" JOIN mysql.main.user_details t2 ON t1.id = t2.user_id WHERE t2.biography IS NOT NULL) p" +
+				" ORDER BY username OFFSET 5 LIMIT 10",
+		},
+		{
+			name: "join between global tables",
+			setup: func(t *testing.T) *storage.MemoryMetadataStorage {
+				store := newTestStorage(t, false)
+				addOrdersTable(t, store)
+				return store
+			},
+			query: "SELECT c.country, sum(o.total) AS revenue FROM customers c JOIN orders o GROUP BY c.country ORDER BY revenue DESC",
+			expected: "SELECT c.country, sum(o.total) AS revenue FROM" +
+				" (SELECT id, country FROM postgresql.public.customers) c" +
+				" JOIN (SELECT cust_id AS customer_id, total FROM postgresql.public.orders) o ON c.id = o.customer_id" +
+				" GROUP BY c.country ORDER BY revenue DESC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator(tt.setup(t), nil)
+
+			sql, err := translator.TranslateAdvanced(tt.query)
+			if err != nil {
+				t.Fatalf("TranslateAdvanced failed: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, tt.expected)
+			}
+		})
+	}
+}
+
+// blockingEngine is a QueryEngine whose queries run until their context is done
+type blockingEngine struct{}
+
+func (blockingEngine) ExecuteQuery(query string, params *datasync.Params) (datasync.QueryResult, error) {
+	return blockingEngine{}.ExecuteQueryContext(context.Background(), query, params)
+}
+
+func (blockingEngine) ExecuteQueryContext(ctx context.Context, query string, params *datasync.Params) (datasync.QueryResult, error) {
+	<-ctx.Done()
+	return datasync.QueryResult{}, ctx.Err()
+}
+
+func (blockingEngine) QueryRowsContext(ctx context.Context, query string, params *datasync.Params) (datasync.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTranslateAndExecute_CancelledByContext(t *testing.T) {
+	translator := NewTranslator(newTestStorage(t, false), blockingEngine{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := translator.TranslateAndExecute(ctx, "SELECT id FROM customers")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTranslate_QuotesHostilePhysicalNames(t *testing.T) {
+	store := storage.NewMemoryMetadataStorage()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	must(store.CreateGlobalTable(&models.GlobalTable{Name: "events"}))
+	for _, col := range []string{"id", "kind"} {
+		must(store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "events", Name: col}))
+	}
+	must(store.CreateTableMapping(&models.TableMapping{
+		GlobalTableName: "events", CatalogName: "mongo", SchemaName: "app-db", TableName: `Events"; DROP TABLE users; --`,
+	}))
+	for global, physical := range map[string]string{"id": "_id", "kind": "order"} {
+		must(store.CreateColumnMapping(&models.ColumnMapping{
+			GlobalTableName: "events", GlobalColumnName: global,
+			CatalogName: "mongo", SchemaName: "app-db", TableName: `Events"; DROP TABLE users; --`, ColumnName: physical,
+		}))
+	}
+
+	translator := NewTranslator(store, nil)
+	sql, err := translator.TranslateAdvanced("SELECT id, kind FROM events WHERE kind = 'click'")
+	if err != nil {
+		t.Fatalf("TranslateAdvanced failed: %v", err)
+	}
+
+	expected := `SELECT _id AS id, "order" AS kind FROM mongo."app-db"."Events""; DROP TABLE users; --" WHERE "order" = 'click'`
+	if sql != expected {
+		t.Errorf("Unexpected SQL:\n  got:      %s\n  expected: %s", sql, expected)
+	}
+
+	// Names that cannot be identifiers at all are rejected rather than sent to Trino
+	must(store.DeleteTableMapping("events", "mongo", "app-db", `Events"; DROP TABLE users; --`))
+	must(store.CreateTableMapping(&models.TableMapping{
+		GlobalTableName: "events", CatalogName: "mongo", SchemaName: "app\ndb", TableName: "events",
+	}))
+	must(store.CreateColumnMapping(&models.ColumnMapping{
+		GlobalTableName: "events", GlobalColumnName: "id",
+		CatalogName: "mongo", SchemaName: "app\ndb", TableName: "events", ColumnName: "_id",
+	}))
+	if _, err := translator.TranslateAdvanced("SELECT id FROM events"); !errors.Is(err, datasync.ErrInvalidIdentifier) {
+		t.Fatalf("Expected ErrInvalidIdentifier, got %v", err)
+	}
+}
+
+// explainEngine records the queries it runs and fails them with err
+type explainEngine struct {
+	queries []string
+	err     error
+}
+
+func (e *explainEngine) ExecuteQuery(query string, params *datasync.Params) (datasync.QueryResult, error) {
+	return e.ExecuteQueryContext(context.Background(), query, params)
+}
+
+func (e *explainEngine) ExecuteQueryContext(ctx context.Context, query string, params *datasync.Params) (datasync.QueryResult, error) {
+	e.queries = append(e.queries, query)
+	return datasync.QueryResult{}, e.err
+}
+
+func (e *explainEngine) QueryRowsContext(ctx context.Context, query string, params *datasync.Params) (datasync.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestValidate(t *testing.T) {
+	store := newTestStorage(t, false)
+	engine := &explainEngine{}
+	translator := NewTranslator(store, engine)
+
+	validation, err := translator.Validate(context.Background(), "SELECT name FROM customers")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	expected := "SELECT full_name AS name FROM postgresql.public.customers"
+	if validation.GeneratedSQL != expected || !validation.Planned || len(validation.Warnings) != 0 {
+		t.Fatalf("Expected %q planned without warnings, got %+v", expected, validation)
+	}
+	if len(engine.queries) != 1 || engine.queries[0] != "EXPLAIN "+expected {
+		t.Fatalf("Expected the query to be explained, got %q", engine.queries)
+	}
+
+	// Translation errors are reported without asking Trino
+	if _, err := translator.Validate(context.Background(), "SELECT nickname FROM customers"); err == nil || len(engine.queries) != 1 {
+		t.Fatalf("Expected a translation error, got %v after %d queries", err, len(engine.queries))
+	}
+
+	engine.err = errors.New("Column 'full_name' cannot be resolved")
+	if _, err := translator.Validate(context.Background(), "SELECT name FROM customers"); err == nil || err.Error() != "planning error: Column 'full_name' cannot be resolved" {
+		t.Fatalf("Expected a planning error, got %v", err)
+	}
+}
+
+func TestValidate_Warnings(t *testing.T) {
+	store := newTestStorage(t, false)
+	if err := store.SetDegradedGlobalTables([]string{"customers"}); err != nil {
+		t.Fatalf("SetDegradedGlobalTables failed: %v", err)
+	}
+	engine := &explainEngine{}
+	translator := NewTranslator(store, engine)
+
+	validation, err := translator.Validate(context.Background(), "SELECT name FROM customers WHERE id = ?")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(validation.Warnings) != 2 || len(engine.queries) != 0 {
+		t.Fatalf("Expected degraded and placeholder warnings without asking Trino, got %q after %d queries", validation.Warnings, len(engine.queries))
+	}
+	if validation.Planned {
+		t.Fatal("Expected a query with placeholders not to be reported as planned")
+	}
+}
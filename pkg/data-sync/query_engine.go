@@ -1,14 +1,21 @@
 package datasync
 
+import "context"
+
 type QueryEngine interface {
-	ExecuteQuery(query string, params map[string]interface{}) (QueryResult, error)
+	ExecuteQuery(query string, params *Params) (QueryResult, error)
+	// ExecuteQueryContext runs a query that is cancelled on the engine when ctx is done
+	ExecuteQueryContext(ctx context.Context, query string, params *Params) (QueryResult, error)
+	// QueryRowsContext runs a query and returns its rows as they arrive instead of buffering them
+	QueryRowsContext(ctx context.Context, query string, params *Params) (Rows, error)
 }
 
 type QueryResult struct {
-	Rows []map[string]interface{}
+	Columns []ResultColumn
+	Rows    []map[string]interface{}
 }
 
 type QueryRequest struct {
-	Query  string                 `json:"query"`
-	Params map[string]interface{} `json:"params"`
+	Query  string  `json:"query"`
+	Params *Params `json:"params"`
 }
@@ -0,0 +1,68 @@
+package datasync
+
+// Rows iterates over the rows of a query as the engine returns them, so large
+// results never have to be held in memory at once. Close must always be called;
+// closing before the last row stops the query on the engine.
+type Rows interface {
+	// Columns lists the result's columns in SELECT order
+	Columns() []ResultColumn
+	// Next advances to the next row, returning false when there are no more rows or an error occurred
+	Next() bool
+	// Row returns the current row keyed by column name, with values encoded by EncodeValue
+	Row() map[string]interface{}
+	// Values returns the current row in Columns order, with values encoded by EncodeValue
+	Values() []interface{}
+	Err() error
+	Close() error
+}
+
+// CollectRows reads every remaining row into a QueryResult and closes rows
+func CollectRows(rows Rows) (QueryResult, error) {
+	defer rows.Close()
+
+	var results []map[string]interface{}
+	for rows.Next() {
+		results = append(results, rows.Row())
+	}
+	if err := rows.Err(); err != nil {
+		return QueryResult{}, err
+	}
+	return QueryResult{Columns: rows.Columns(), Rows: results}, nil
+}
+
+// sliceRows serves rows that are already in memory
+type sliceRows struct {
+	columns []ResultColumn
+	rows    []map[string]interface{}
+	pos     int
+}
+
+// NewSliceRows returns Rows over an in-memory result
+func NewSliceRows(columns []ResultColumn, rows []map[string]interface{}) Rows {
+	return &sliceRows{columns: columns, rows: rows, pos: -1}
+}
+
+func (r *sliceRows) Columns() []ResultColumn { return r.columns }
+
+func (r *sliceRows) Next() bool {
+	if r.pos+1 >= len(r.rows) {
+		r.pos = len(r.rows)
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *sliceRows) Row() map[string]interface{} { return r.rows[r.pos] }
+
+func (r *sliceRows) Values() []interface{} {
+	values := make([]interface{}, len(r.columns))
+	for i, col := range r.columns {
+		values[i] = r.rows[r.pos][col.Name]
+	}
+	return values
+}
+
+func (r *sliceRows) Err() error { return nil }
+
+func (r *sliceRows) Close() error { return nil }
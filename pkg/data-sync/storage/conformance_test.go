@@ -0,0 +1,693 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// conformanceTests are run against every MetadataStorage implementation
+var conformanceTests = []struct {
+	name string
+	run  func(t *testing.T, storage MetadataStorage)
+}{
+	{"CreateAndGetCatalog", testCreateAndGetCatalog},
+	{"CreateCatalog_EmptyName", testCreateCatalog_EmptyName},
+	{"CreateCatalog_Duplicate", testCreateCatalog_Duplicate},
+	{"GetCatalog_NotFound", testGetCatalog_NotFound},
+	{"ListCatalogs", testListCatalogs},
+	{"CreateAndGetSchema", testCreateAndGetSchema},
+	{"CreateSchema_EmptyName", testCreateSchema_EmptyName},
+	{"CreateSchema_CatalogNotFound", testCreateSchema_CatalogNotFound},
+	{"CreateSchema_Duplicate", testCreateSchema_Duplicate},
+	{"ListSchemas", testListSchemas},
+	{"ListSchemas_EmptyCatalog", testListSchemas_EmptyCatalog},
+	{"ListSchemas_NonexistentCatalog", testListSchemas_NonexistentCatalog},
+	{"DeleteLocalMetadata_Cascades", testDeleteLocalMetadataCascades},
+	{"GlobalTableRoundTrip", testGlobalTableRoundTrip},
+	{"DeleteGlobalTable_Cascades", testDeleteGlobalTableCascades},
+	{"SetDegradedGlobalTables", testSetDegradedGlobalTables},
+	{"DeleteGlobalColumn_Cascades", testDeleteGlobalColumnCascades},
+	{"TableRelationRoundTrip", testTableRelationRoundTrip},
+	{"CreateTableRelation_Invalid", testCreateTableRelationInvalid},
+	{"ChatSessionRoundTrip", testChatSessionRoundTrip},
+	{"WithTx", testWithTx},
+}
+
+// runConformanceTests runs the conformance suite, giving every test a fresh, empty storage
+func runConformanceTests(t *testing.T, newStorage func(t *testing.T) MetadataStorage) {
+	for _, tt := range conformanceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStorage(t))
+		})
+	}
+}
+
+func testCreateAndGetCatalog(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{
+		Name:     "postgresql",
+		Metadata: map[string]string{"type": "relational"},
+	}
+
+	err := storage.CreateCatalog(catalog)
+	if err != nil {
+		t.Fatalf("CreateCatalog failed: %v", err)
+	}
+
+	retrieved, err := storage.GetCatalog("postgresql")
+	if err != nil {
+		t.Fatalf("GetCatalog failed: %v", err)
+	}
+
+	if retrieved.Name != "postgresql" {
+		t.Errorf("Expected catalog name 'postgresql', got '%s'", retrieved.Name)
+	}
+}
+
+func testCreateCatalog_EmptyName(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{
+		Name:     "",
+		Metadata: map[string]string{},
+	}
+
+	err := storage.CreateCatalog(catalog)
+	if err == nil {
+		t.Fatal("Expected error for empty catalog name, got nil")
+	}
+}
+
+func testCreateCatalog_Duplicate(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{
+		Name:     "mysql",
+		Metadata: map[string]string{},
+	}
+
+	err := storage.CreateCatalog(catalog)
+	if err != nil {
+		t.Fatalf("First CreateCatalog failed: %v", err)
+	}
+
+	err = storage.CreateCatalog(catalog)
+	if err == nil {
+		t.Fatal("Expected error for duplicate catalog, got nil")
+	}
+}
+
+func testGetCatalog_NotFound(t *testing.T, storage MetadataStorage) {
+	_, err := storage.GetCatalog("nonexistent")
+	if err == nil {
+		t.Fatal("Expected error for nonexistent catalog, got nil")
+	}
+}
+
+func testListCatalogs(t *testing.T, storage MetadataStorage) {
+	catalogs := []*models.Catalog{
+		{Name: "postgresql", Metadata: map[string]string{}},
+		{Name: "mysql", Metadata: map[string]string{}},
+		{Name: "mongodb", Metadata: map[string]string{}},
+	}
+
+	for _, catalog := range catalogs {
+		err := storage.CreateCatalog(catalog)
+		if err != nil {
+			t.Fatalf("CreateCatalog failed: %v", err)
+		}
+	}
+
+	retrieved, err := storage.ListCatalogs()
+	if err != nil {
+		t.Fatalf("ListCatalogs failed: %v", err)
+	}
+
+	if len(retrieved) != 3 {
+		t.Errorf("Expected 3 catalogs, got %d", len(retrieved))
+	}
+}
+
+func testCreateAndGetSchema(t *testing.T, storage MetadataStorage) {
+	// Create catalog first
+	catalog := &models.Catalog{
+		Name:     "postgresql",
+		Metadata: map[string]string{},
+	}
+	err := storage.CreateCatalog(catalog)
+	if err != nil {
+		t.Fatalf("CreateCatalog failed: %v", err)
+	}
+
+	// Create schema
+	schema := &models.Schema{
+		Name:        "public",
+		CatalogName: "postgresql",
+		Metadata:    map[string]string{"owner": "postgres"},
+	}
+
+	err = storage.CreateSchema(schema)
+	if err != nil {
+		t.Fatalf("CreateSchema failed: %v", err)
+	}
+
+	retrieved, err := storage.GetSchema("postgresql", "public")
+	if err != nil {
+		t.Fatalf("GetSchema failed: %v", err)
+	}
+
+	if retrieved.Name != "public" || retrieved.CatalogName != "postgresql" {
+		t.Errorf("Expected schema 'public' in catalog 'postgresql', got '%s' in '%s'",
+			retrieved.Name, retrieved.CatalogName)
+	}
+}
+
+func testCreateSchema_EmptyName(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{Name: "postgresql", Metadata: map[string]string{}}
+	storage.CreateCatalog(catalog)
+
+	schema := &models.Schema{
+		Name:        "",
+		CatalogName: "postgresql",
+		Metadata:    map[string]string{},
+	}
+
+	err := storage.CreateSchema(schema)
+	if err == nil {
+		t.Fatal("Expected error for empty schema name, got nil")
+	}
+}
+
+func testCreateSchema_CatalogNotFound(t *testing.T, storage MetadataStorage) {
+	schema := &models.Schema{
+		Name:        "public",
+		CatalogName: "nonexistent",
+		Metadata:    map[string]string{},
+	}
+
+	err := storage.CreateSchema(schema)
+	if err == nil {
+		t.Fatal("Expected error for nonexistent catalog, got nil")
+	}
+}
+
+func testCreateSchema_Duplicate(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{Name: "postgresql", Metadata: map[string]string{}}
+	storage.CreateCatalog(catalog)
+
+	schema := &models.Schema{
+		Name:        "public",
+		CatalogName: "postgresql",
+		Metadata:    map[string]string{},
+	}
+
+	err := storage.CreateSchema(schema)
+	if err != nil {
+		t.Fatalf("First CreateSchema failed: %v", err)
+	}
+
+	err = storage.CreateSchema(schema)
+	if err == nil {
+		t.Fatal("Expected error for duplicate schema, got nil")
+	}
+}
+
+func testListSchemas(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{Name: "postgresql", Metadata: map[string]string{}}
+	storage.CreateCatalog(catalog)
+
+	schemas := []*models.Schema{
+		{Name: "public", CatalogName: "postgresql", Metadata: map[string]string{}},
+		{Name: "information_schema", CatalogName: "postgresql", Metadata: map[string]string{}},
+		{Name: "pg_catalog", CatalogName: "postgresql", Metadata: map[string]string{}},
+	}
+
+	for _, schema := range schemas {
+		err := storage.CreateSchema(schema)
+		if err != nil {
+			t.Fatalf("CreateSchema failed: %v", err)
+		}
+	}
+
+	retrieved, err := storage.ListSchemas("postgresql")
+	if err != nil {
+		t.Fatalf("ListSchemas failed: %v", err)
+	}
+
+	if len(retrieved) != 3 {
+		t.Errorf("Expected 3 schemas, got %d", len(retrieved))
+	}
+}
+
+func testListSchemas_EmptyCatalog(t *testing.T, storage MetadataStorage) {
+	catalog := &models.Catalog{Name: "postgresql", Metadata: map[string]string{}}
+	storage.CreateCatalog(catalog)
+
+	schemas, err := storage.ListSchemas("postgresql")
+	if err != nil {
+		t.Fatalf("ListSchemas failed: %v", err)
+	}
+
+	if len(schemas) != 0 {
+		t.Errorf("Expected 0 schemas for empty catalog, got %d", len(schemas))
+	}
+}
+
+func testListSchemas_NonexistentCatalog(t *testing.T, storage MetadataStorage) {
+	schemas, err := storage.ListSchemas("nonexistent")
+	if err != nil {
+		t.Fatalf("ListSchemas failed: %v", err)
+	}
+
+	if len(schemas) != 0 {
+		t.Errorf("Expected 0 schemas for nonexistent catalog, got %d", len(schemas))
+	}
+}
+
+func testDeleteLocalMetadataCascades(t *testing.T, storage MetadataStorage) {
+	storage.CreateCatalog(&models.Catalog{Name: "pg"})
+	for _, schema := range []string{"public", "sales"} {
+		storage.CreateSchema(&models.Schema{CatalogName: "pg", Name: schema})
+		storage.CreateTable(&models.Table{CatalogName: "pg", SchemaName: schema, Name: "orders"})
+		for _, column := range []string{"id", "total"} {
+			if err := storage.CreateColumn(&models.Column{CatalogName: "pg", SchemaName: schema, TableName: "orders", Name: column, DataType: "bigint"}); err != nil {
+				t.Fatalf("CreateColumn failed: %v", err)
+			}
+		}
+	}
+
+	if err := storage.DeleteColumn("pg", "public", "orders", "total"); err != nil {
+		t.Fatalf("DeleteColumn failed: %v", err)
+	}
+	columns, _ := storage.ListColumns("pg", "public", "orders")
+	if len(columns) != 1 || columns[0].Name != "id" {
+		t.Fatalf("Expected only column 'id' to remain, got %v", columns)
+	}
+
+	if err := storage.DeleteTable("pg", "public", "orders"); err != nil {
+		t.Fatalf("DeleteTable failed: %v", err)
+	}
+	if columns, _ := storage.ListColumns("pg", "public", "orders"); len(columns) != 0 {
+		t.Fatalf("Expected the table's columns to be deleted, got %d", len(columns))
+	}
+
+	if err := storage.DeleteSchema("pg", "sales"); err != nil {
+		t.Fatalf("DeleteSchema failed: %v", err)
+	}
+	if tables, _ := storage.ListTables("pg", "sales"); len(tables) != 0 {
+		t.Fatalf("Expected the schema's tables to be deleted, got %d", len(tables))
+	}
+
+	if err := storage.DeleteCatalog("pg"); err != nil {
+		t.Fatalf("DeleteCatalog failed: %v", err)
+	}
+	if schemas, _ := storage.ListSchemas("pg"); len(schemas) != 0 {
+		t.Fatalf("Expected the catalog's schemas to be deleted, got %d", len(schemas))
+	}
+
+	if err := storage.DeleteTable("pg", "public", "orders"); err == nil {
+		t.Fatal("Expected an error deleting a missing table")
+	}
+}
+
+func testGlobalTableRoundTrip(t *testing.T, storage MetadataStorage) {
+	table := &models.GlobalTable{Name: "orders", Description: "All orders", UnionType: models.UnionDistinct, SourceColumn: "source"}
+	if err := storage.CreateGlobalTable(table); err != nil {
+		t.Fatalf("CreateGlobalTable failed: %v", err)
+	}
+
+	if err := storage.CreateGlobalTable(table); err == nil {
+		t.Fatal("Expected error for duplicate global table, got nil")
+	}
+
+	retrieved, err := storage.GetGlobalTable("orders")
+	if err != nil {
+		t.Fatalf("GetGlobalTable failed: %v", err)
+	}
+	if *retrieved != *table {
+		t.Errorf("Expected global table %+v, got %+v", *table, *retrieved)
+	}
+
+	if err := storage.CreateGlobalTable(&models.GlobalTable{Name: "bad", UnionType: "SOME"}); err == nil {
+		t.Fatal("Expected error for invalid union type, got nil")
+	}
+
+	updated := &models.GlobalTable{Name: "orders", Description: "Orders from every shop"}
+	if err := storage.UpdateGlobalTable(updated); err != nil {
+		t.Fatalf("UpdateGlobalTable failed: %v", err)
+	}
+	if retrieved, _ := storage.GetGlobalTable("orders"); retrieved == nil || *retrieved != *updated {
+		t.Errorf("Expected updated global table %+v, got %+v", *updated, retrieved)
+	}
+
+	if err := storage.UpdateGlobalTable(&models.GlobalTable{Name: "missing"}); err == nil {
+		t.Fatal("Expected error updating a missing global table, got nil")
+	}
+}
+
+func testDeleteGlobalTableCascades(t *testing.T, storage MetadataStorage) {
+	mustCreateGlobalTable(t, storage, "customers", "id", "name")
+	mustCreateGlobalTable(t, storage, "orders", "id", "customer_id")
+
+	if err := storage.CreateTableMapping(&models.TableMapping{
+		GlobalTableName: "customers", CatalogName: "postgresql", SchemaName: "public", TableName: "customers",
+	}); err != nil {
+		t.Fatalf("CreateTableMapping failed: %v", err)
+	}
+	if err := storage.CreateColumnMapping(&models.ColumnMapping{
+		GlobalTableName: "customers", GlobalColumnName: "id",
+		CatalogName: "postgresql", SchemaName: "public", TableName: "customers", ColumnName: "id",
+	}); err != nil {
+		t.Fatalf("CreateColumnMapping failed: %v", err)
+	}
+	if err := storage.CreateColumnRelationship(&models.ColumnRelationship{
+		SourceGlobalTableName: "orders", SourceGlobalColumnName: "customer_id",
+		TargetGlobalTableName: "customers", TargetGlobalColumnName: "id",
+	}); err != nil {
+		t.Fatalf("CreateColumnRelationship failed: %v", err)
+	}
+
+	if err := storage.DeleteGlobalTable("customers"); err != nil {
+		t.Fatalf("DeleteGlobalTable failed: %v", err)
+	}
+	if err := storage.DeleteGlobalTable("customers"); err == nil {
+		t.Fatal("Expected error deleting a missing global table, got nil")
+	}
+
+	if _, err := storage.GetGlobalTable("customers"); err == nil {
+		t.Error("Expected deleted global table to be gone")
+	}
+	if columns, _ := storage.ListGlobalColumns("customers"); len(columns) != 0 {
+		t.Errorf("Expected 0 global columns after delete, got %d", len(columns))
+	}
+	if mappings, _ := storage.ListTableMappings("customers"); len(mappings) != 0 {
+		t.Errorf("Expected 0 table mappings after delete, got %d", len(mappings))
+	}
+	if mappings, _ := storage.ListColumnMappings("customers", "id"); len(mappings) != 0 {
+		t.Errorf("Expected 0 column mappings after delete, got %d", len(mappings))
+	}
+	if relationships, _ := storage.ListColumnRelationships("orders"); len(relationships) != 0 {
+		t.Errorf("Expected relationships of the deleted table to be removed, got %d", len(relationships))
+	}
+}
+
+func testSetDegradedGlobalTables(t *testing.T, storage MetadataStorage) {
+	mustCreateGlobalTable(t, storage, "customers")
+	mustCreateGlobalTable(t, storage, "orders")
+
+	if err := storage.SetDegradedGlobalTables([]string{"customers"}); err != nil {
+		t.Fatalf("SetDegradedGlobalTables failed: %v", err)
+	}
+
+	// Updating a table's definition keeps its degraded mark
+	if err := storage.UpdateGlobalTable(&models.GlobalTable{Name: "customers", Description: "All customers"}); err != nil {
+		t.Fatalf("UpdateGlobalTable failed: %v", err)
+	}
+	customers, _ := storage.GetGlobalTable("customers")
+	orders, _ := storage.GetGlobalTable("orders")
+	if !customers.Degraded || orders.Degraded {
+		t.Fatalf("Expected only customers to be degraded, got customers=%v orders=%v", customers.Degraded, orders.Degraded)
+	}
+
+	if err := storage.SetDegradedGlobalTables(nil); err != nil {
+		t.Fatalf("SetDegradedGlobalTables failed: %v", err)
+	}
+	if customers, _ := storage.GetGlobalTable("customers"); customers.Degraded {
+		t.Fatal("Expected the degraded mark to be cleared")
+	}
+
+	// Tables already fetched are not changed by later writes
+	if !customers.Degraded {
+		t.Fatal("Expected a fetched table to keep the degraded mark it was read with")
+	}
+	update := &models.GlobalTable{Name: "orders", Description: "All orders"}
+	if err := storage.SetDegradedGlobalTables([]string{"orders"}); err != nil {
+		t.Fatalf("SetDegradedGlobalTables failed: %v", err)
+	}
+	if err := storage.UpdateGlobalTable(update); err != nil {
+		t.Fatalf("UpdateGlobalTable failed: %v", err)
+	}
+	if update.Degraded || orders.Degraded {
+		t.Fatal("Expected the caller's tables to be left unchanged")
+	}
+}
+
+func testDeleteGlobalColumnCascades(t *testing.T, storage MetadataStorage) {
+	mustCreateGlobalTable(t, storage, "customers", "id", "name")
+
+	for _, table := range []string{"customers", "clients"} {
+		if err := storage.CreateColumnMapping(&models.ColumnMapping{
+			GlobalTableName: "customers", GlobalColumnName: "name",
+			CatalogName: "postgresql", SchemaName: "public", TableName: table, ColumnName: "full_name",
+		}); err != nil {
+			t.Fatalf("CreateColumnMapping failed: %v", err)
+		}
+	}
+
+	mappings, err := storage.ListColumnMappings("customers", "name")
+	if err != nil {
+		t.Fatalf("ListColumnMappings failed: %v", err)
+	}
+	if len(mappings) != 2 || mappings[0].TableName != "customers" || mappings[1].TableName != "clients" {
+		t.Fatalf("Expected column mappings in creation order, got %+v", mappings)
+	}
+
+	if err := storage.DeleteGlobalColumn("customers", "name"); err != nil {
+		t.Fatalf("DeleteGlobalColumn failed: %v", err)
+	}
+
+	if err := storage.UpdateGlobalColumn(&models.GlobalColumn{GlobalTableName: "customers", Name: "id", DataType: "bigint"}); err != nil {
+		t.Fatalf("UpdateGlobalColumn failed: %v", err)
+	}
+
+	columns, _ := storage.ListGlobalColumns("customers")
+	if len(columns) != 1 || columns[0].Name != "id" || columns[0].DataType != "bigint" {
+		t.Errorf("Expected only column 'id' with type bigint to remain, got %+v", columns)
+	}
+	if mappings, _ := storage.ListColumnMappings("customers", "name"); len(mappings) != 0 {
+		t.Errorf("Expected 0 column mappings after delete, got %d", len(mappings))
+	}
+}
+
+func testTableRelationRoundTrip(t *testing.T, storage MetadataStorage) {
+	relation := &models.TableRelation{
+		ID:           "rel_1",
+		Name:         "merged_customers",
+		LeftTable:    models.TableSource{Type: "physical", Catalog: "postgresql", Schema: "public", Table: "customers"},
+		RightTable:   models.TableSource{Type: "relation", RelationID: "rel_0"},
+		RelationType: "JOIN",
+		JoinType:     models.JoinLeft,
+		JoinColumns:  []models.JoinColumn{{Left: "id", Right: "id"}, {Left: "country", Right: "country"}},
+	}
+	if err := storage.CreateTableRelation(relation); err != nil {
+		t.Fatalf("CreateTableRelation failed: %v", err)
+	}
+
+	retrieved, err := storage.GetTableRelation("rel_1")
+	if err != nil {
+		t.Fatalf("GetTableRelation failed: %v", err)
+	}
+	if retrieved.Name != relation.Name || retrieved.JoinType != models.JoinLeft ||
+		retrieved.RightTable != relation.RightTable || len(retrieved.JoinColumns) != 2 ||
+		retrieved.JoinColumns[1] != relation.JoinColumns[1] {
+		t.Errorf("Expected relation %+v, got %+v", *relation, *retrieved)
+	}
+
+	// Stored relations are not changed through the caller's or a fetched struct
+	relation.JoinColumns[0].Left = "customer_id"
+	retrieved.JoinColumns[1].Right = "country_code"
+	retrieved.Name = "renamed"
+	if stored, _ := storage.GetTableRelation("rel_1"); stored.Name != "merged_customers" ||
+		stored.JoinColumns[0].Left != "id" || stored.JoinColumns[1].Right != "country" {
+		t.Errorf("Expected the stored relation to be unchanged, got %+v", *stored)
+	}
+	relations, _ := storage.ListTableRelations()
+	relations[0].JoinColumns[0].Right = "client_id"
+	if stored, _ := storage.GetTableRelation("rel_1"); stored.JoinColumns[0].Right != "id" {
+		t.Errorf("Expected the stored relation to be unchanged by a listed one, got %+v", *stored)
+	}
+
+	duplicateName := *relation
+	duplicateName.ID = "rel_2"
+	if err := storage.CreateTableRelation(&duplicateName); err == nil {
+		t.Fatal("Expected error for duplicate relation name, got nil")
+	}
+
+	if err := storage.DeleteTableRelation("rel_1"); err != nil {
+		t.Fatalf("DeleteTableRelation failed: %v", err)
+	}
+	if relations, _ := storage.ListTableRelations(); len(relations) != 0 {
+		t.Errorf("Expected 0 relations after delete, got %d", len(relations))
+	}
+}
+
+func testCreateTableRelationInvalid(t *testing.T, storage MetadataStorage) {
+	source := models.TableSource{Type: "physical", Catalog: "postgresql", Schema: "public", Table: "customers"}
+
+	relations := []*models.TableRelation{
+		{ID: "r1", Name: "no_type", LeftTable: source, RightTable: source},
+		{ID: "r2", Name: "no_keys", LeftTable: source, RightTable: source, RelationType: "JOIN"},
+		{ID: "r3", Name: "bad_join", LeftTable: source, RightTable: source, RelationType: "JOIN",
+			JoinType: "CROSS", JoinColumn: &models.JoinColumn{Left: "id", Right: "id"}},
+		{ID: "r4", Name: "bad_union", LeftTable: source, RightTable: source, RelationType: "UNION", UnionType: "SOME"},
+	}
+
+	for _, relation := range relations {
+		if err := storage.CreateTableRelation(relation); err == nil {
+			t.Errorf("Expected error for relation '%s', got nil", relation.Name)
+		}
+	}
+}
+
+func testWithTx(t *testing.T, storage MetadataStorage) {
+	mustCreateGlobalTable(t, storage, "customers", "id")
+	for _, table := range []string{"customers", "clients"} {
+		if err := storage.CreateTableMapping(&models.TableMapping{
+			GlobalTableName: "customers", CatalogName: "postgresql", SchemaName: "public", TableName: table,
+		}); err != nil {
+			t.Fatalf("CreateTableMapping failed: %v", err)
+		}
+	}
+
+	// A failing transaction leaves everything as it was, nested transactions included
+	failed := errors.New("failed")
+	err := storage.WithTx(func(tx MetadataStorage) error {
+		if err := tx.DeleteTableMapping("customers", "postgresql", "public", "customers"); err != nil {
+			return err
+		}
+		if err := tx.UpdateGlobalTable(&models.GlobalTable{Name: "customers", Description: "All customers"}); err != nil {
+			return err
+		}
+		if err := tx.WithTx(func(tx MetadataStorage) error {
+			return tx.CreateGlobalTable(&models.GlobalTable{Name: "orders"})
+		}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+
+	if customers, _ := storage.GetGlobalTable("customers"); customers.Description != "" {
+		t.Errorf("Expected the update to be rolled back, got description %q", customers.Description)
+	}
+	if _, err := storage.GetGlobalTable("orders"); err == nil {
+		t.Error("Expected the table created in a nested transaction to be rolled back")
+	}
+	mappings, _ := storage.ListTableMappings("customers")
+	if len(mappings) != 2 || mappings[0].TableName != "customers" || mappings[1].TableName != "clients" {
+		t.Errorf("Expected both table mappings to be restored, got %+v", mappings)
+	}
+
+	// Writes made outside a failing transaction while it runs are kept
+	started := make(chan struct{})
+	written := make(chan error)
+	err = storage.WithTx(func(tx MetadataStorage) error {
+		go func() {
+			close(started)
+			written <- storage.CreateGlobalTable(&models.GlobalTable{Name: "invoices"})
+		}()
+		<-started
+		if err := tx.CreateGlobalTable(&models.GlobalTable{Name: "refunds"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("CreateGlobalTable failed: %v", err)
+	}
+	if _, err := storage.GetGlobalTable("invoices"); err != nil {
+		t.Errorf("Expected the table written outside the transaction to be kept, got %v", err)
+	}
+	if _, err := storage.GetGlobalTable("refunds"); err == nil {
+		t.Error("Expected the table written in the transaction to be rolled back")
+	}
+
+	// A successful transaction keeps its writes
+	if err := storage.WithTx(func(tx MetadataStorage) error {
+		return tx.CreateGlobalTable(&models.GlobalTable{Name: "orders"})
+	}); err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+	if _, err := storage.GetGlobalTable("orders"); err != nil {
+		t.Errorf("Expected the committed table, got %v", err)
+	}
+}
+
+// mustCreateGlobalTable creates a global table with the given columns
+func mustCreateGlobalTable(t *testing.T, storage MetadataStorage, name string, columns ...string) {
+	t.Helper()
+
+	if err := storage.CreateGlobalTable(&models.GlobalTable{Name: name}); err != nil {
+		t.Fatalf("CreateGlobalTable failed: %v", err)
+	}
+	for _, column := range columns {
+		if err := storage.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: name, Name: column}); err != nil {
+			t.Fatalf("CreateGlobalColumn failed: %v", err)
+		}
+	}
+}
+
+func testChatSessionRoundTrip(t *testing.T, storage MetadataStorage) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	older := &models.ChatSession{ID: "chat_1", Title: "Orders", CreatedAt: start, UpdatedAt: start}
+	newer := &models.ChatSession{
+		ID:        "chat_2",
+		Title:     "Clients",
+		Summary:   "The user asked about clients.",
+		CreatedAt: start,
+		UpdatedAt: start.Add(time.Minute),
+		Messages: []models.ChatSessionMessage{
+			{Role: "user", Content: "How many clients?"},
+			{Role: "assistant", ToolCalls: []models.ChatToolCall{{ID: "call_1", Name: "executeGlobalQuery", Arguments: map[string]interface{}{"query": "SELECT COUNT(*) FROM clients"}}}},
+			{Role: "tool", ToolCallID: "call_1", ToolName: "executeGlobalQuery", ToolResult: map[string]interface{}{"count": "3"}},
+			{Role: "assistant", Content: "There are 3 clients."},
+		},
+	}
+	for _, session := range []*models.ChatSession{older, newer} {
+		if err := storage.SaveChatSession(session); err != nil {
+			t.Fatalf("SaveChatSession failed: %v", err)
+		}
+	}
+
+	retrieved, err := storage.GetChatSession("chat_2")
+	if err != nil {
+		t.Fatalf("GetChatSession failed: %v", err)
+	}
+	if retrieved.Summary != newer.Summary || len(retrieved.Messages) != 4 || !retrieved.UpdatedAt.Equal(newer.UpdatedAt) {
+		t.Fatalf("Unexpected session: %+v", retrieved)
+	}
+	call := retrieved.Messages[1].ToolCalls[0]
+	if call.ID != "call_1" || call.Arguments["query"] != "SELECT COUNT(*) FROM clients" {
+		t.Errorf("Expected the tool call to round-trip, got %+v", call)
+	}
+	if result := retrieved.Messages[2]; result.ToolCallID != "call_1" || result.ToolResult["count"] != "3" {
+		t.Errorf("Expected the tool result to round-trip, got %+v", result)
+	}
+
+	// Saving again replaces the session, and moves it to the top of the list
+	older.Title = "Renamed"
+	older.UpdatedAt = start.Add(time.Hour)
+	if err := storage.SaveChatSession(older); err != nil {
+		t.Fatalf("SaveChatSession failed: %v", err)
+	}
+	sessions, err := storage.ListChatSessions()
+	if err != nil {
+		t.Fatalf("ListChatSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "chat_1" || sessions[0].Title != "Renamed" {
+		t.Fatalf("Expected the renamed session first, got %+v", sessions)
+	}
+
+	if err := storage.DeleteChatSession("chat_1"); err != nil {
+		t.Fatalf("DeleteChatSession failed: %v", err)
+	}
+	if _, err := storage.GetChatSession("chat_1"); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Errorf("Expected ErrChatSessionNotFound after delete, got %v", err)
+	}
+	if err := storage.DeleteChatSession("chat_1"); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Errorf("Expected ErrChatSessionNotFound deleting twice, got %v", err)
+	}
+}
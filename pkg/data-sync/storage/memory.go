@@ -2,6 +2,9 @@ package storage
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
 	"sync"
 
 	"github.com/guilherme096/data-sync/pkg/data-sync/models"
@@ -23,6 +26,8 @@ type MemoryMetadataStorage struct {
 	columnMappings map[string]map[string][]*models.ColumnMapping     // globalTable -> columnName -> mappings
 	columnRelationships map[string][]*models.ColumnRelationship      // globalTable -> relationships
 	tableRelations map[string]*models.TableRelation                  // relationID -> relation
+
+	chatSessions map[string]*models.ChatSession // sessionID -> session
 }
 
 func NewMemoryMetadataStorage() *MemoryMetadataStorage {
@@ -39,6 +44,8 @@ func NewMemoryMetadataStorage() *MemoryMetadataStorage {
 		columnMappings: make(map[string]map[string][]*models.ColumnMapping),
 		columnRelationships: make(map[string][]*models.ColumnRelationship),
 		tableRelations: make(map[string]*models.TableRelation),
+
+		chatSessions: make(map[string]*models.ChatSession),
 	}
 }
 
@@ -213,6 +220,35 @@ func (m *MemoryMetadataStorage) UpsertSchema(schema *models.Schema) error {
 	return nil
 }
 
+func (m *MemoryMetadataStorage) DeleteCatalog(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.catalogs[name]; !exists {
+		return fmt.Errorf("catalog '%s' not found", name)
+	}
+
+	delete(m.catalogs, name)
+	delete(m.schemas, name)
+	delete(m.tables, name)
+	delete(m.columns, name)
+	return nil
+}
+
+func (m *MemoryMetadataStorage) DeleteSchema(catalogName, schemaName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.schemas[catalogName][schemaName]; !exists {
+		return fmt.Errorf("schema '%s' not found in catalog '%s'", schemaName, catalogName)
+	}
+
+	delete(m.schemas[catalogName], schemaName)
+	delete(m.tables[catalogName], schemaName)
+	delete(m.columns[catalogName], schemaName)
+	return nil
+}
+
 // ============================================================================
 // Local Table Operations (from data source discovery)
 // ============================================================================
@@ -348,6 +384,19 @@ func (m *MemoryMetadataStorage) UpsertTable(table *models.Table) error {
 	return nil
 }
 
+func (m *MemoryMetadataStorage) DeleteTable(catalogName, schemaName, tableName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.tables[catalogName][schemaName][tableName]; !exists {
+		return fmt.Errorf("table '%s' not found in schema '%s.%s'", tableName, catalogName, schemaName)
+	}
+
+	delete(m.tables[catalogName][schemaName], tableName)
+	delete(m.columns[catalogName][schemaName], tableName)
+	return nil
+}
+
 // ============================================================================
 // Local Column Operations (from data source discovery)
 // ============================================================================
@@ -510,6 +559,18 @@ func (m *MemoryMetadataStorage) UpsertColumn(column *models.Column) error {
 	return nil
 }
 
+func (m *MemoryMetadataStorage) DeleteColumn(catalogName, schemaName, tableName, columnName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.columns[catalogName][schemaName][tableName][columnName]; !exists {
+		return fmt.Errorf("column '%s' not found in table '%s.%s.%s'", columnName, catalogName, schemaName, tableName)
+	}
+
+	delete(m.columns[catalogName][schemaName][tableName], columnName)
+	return nil
+}
+
 // ============================================================================
 // Global Table Operations
 // ============================================================================
@@ -522,11 +583,59 @@ func (m *MemoryMetadataStorage) CreateGlobalTable(table *models.GlobalTable) err
 		return fmt.Errorf("global table name cannot be empty")
 	}
 
+	if err := models.ValidateUnionType(table.UnionType); err != nil {
+		return err
+	}
+
 	if _, exists := m.globalTables[table.Name]; exists {
 		return fmt.Errorf("global table '%s' already exists", table.Name)
 	}
 
-	m.globalTables[table.Name] = table
+	stored := *table
+	m.globalTables[table.Name] = &stored
+	return nil
+}
+
+func (m *MemoryMetadataStorage) UpdateGlobalTable(table *models.GlobalTable) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if table.Name == "" {
+		return fmt.Errorf("global table name cannot be empty")
+	}
+
+	if err := models.ValidateUnionType(table.UnionType); err != nil {
+		return err
+	}
+
+	existing, exists := m.globalTables[table.Name]
+	if !exists {
+		return fmt.Errorf("global table '%s' not found", table.Name)
+	}
+
+	// The degraded mark is only changed by SetDegradedGlobalTables
+	stored := *table
+	stored.Degraded = existing.Degraded
+	m.globalTables[table.Name] = &stored
+	return nil
+}
+
+func (m *MemoryMetadataStorage) SetDegradedGlobalTables(names []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	degraded := make(map[string]bool, len(names))
+	for _, name := range names {
+		degraded[name] = true
+	}
+	// Replace the entries rather than changing them, since readers may hold the old ones
+	for name, table := range m.globalTables {
+		if table.Degraded != degraded[name] {
+			stored := *table
+			stored.Degraded = degraded[name]
+			m.globalTables[name] = &stored
+		}
+	}
 	return nil
 }
 
@@ -539,7 +648,8 @@ func (m *MemoryMetadataStorage) GetGlobalTable(name string) (*models.GlobalTable
 		return nil, fmt.Errorf("global table '%s' not found", name)
 	}
 
-	return table, nil
+	copied := *table
+	return &copied, nil
 }
 
 func (m *MemoryMetadataStorage) ListGlobalTables() ([]*models.GlobalTable, error) {
@@ -548,7 +658,8 @@ func (m *MemoryMetadataStorage) ListGlobalTables() ([]*models.GlobalTable, error
 
 	tables := make([]*models.GlobalTable, 0, len(m.globalTables))
 	for _, table := range m.globalTables {
-		tables = append(tables, table)
+		copied := *table
+		tables = append(tables, &copied)
 	}
 
 	return tables, nil
@@ -615,6 +726,23 @@ func (m *MemoryMetadataStorage) CreateGlobalColumn(column *models.GlobalColumn)
 	return nil
 }
 
+func (m *MemoryMetadataStorage) UpdateGlobalColumn(column *models.GlobalColumn) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if column.GlobalTableName == "" || column.Name == "" {
+		return fmt.Errorf("global table name and column name cannot be empty")
+	}
+
+	columns, exists := m.globalColumns[column.GlobalTableName]
+	if !exists || columns[column.Name] == nil {
+		return fmt.Errorf("global column '%s' not found in table '%s'", column.Name, column.GlobalTableName)
+	}
+
+	columns[column.Name] = column
+	return nil
+}
+
 func (m *MemoryMetadataStorage) ListGlobalColumns(globalTableName string) ([]*models.GlobalColumn, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -977,12 +1105,18 @@ func (m *MemoryMetadataStorage) CreateTableRelation(relation *models.TableRelati
 		}
 	}
 
-	// Validate JOIN requires join columns
-	if relation.RelationType == "JOIN" && relation.JoinColumn == nil {
-		return fmt.Errorf("JOIN relation requires join columns")
+	// Validate JOIN requires join columns and a supported join type
+	if relation.RelationType == "JOIN" {
+		if err := relation.ValidateJoin(); err != nil {
+			return err
+		}
 	}
 
-	m.tableRelations[relation.ID] = relation
+	if err := models.ValidateUnionType(relation.UnionType); err != nil {
+		return err
+	}
+
+	m.tableRelations[relation.ID] = copyTableRelation(relation)
 	return nil
 }
 
@@ -995,7 +1129,7 @@ func (m *MemoryMetadataStorage) GetTableRelation(id string) (*models.TableRelati
 		return nil, fmt.Errorf("relation with ID '%s' not found", id)
 	}
 
-	return relation, nil
+	return copyTableRelation(relation), nil
 }
 
 func (m *MemoryMetadataStorage) ListTableRelations() ([]*models.TableRelation, error) {
@@ -1004,7 +1138,7 @@ func (m *MemoryMetadataStorage) ListTableRelations() ([]*models.TableRelation, e
 
 	relations := make([]*models.TableRelation, 0, len(m.tableRelations))
 	for _, relation := range m.tableRelations {
-		relations = append(relations, relation)
+		relations = append(relations, copyTableRelation(relation))
 	}
 
 	return relations, nil
@@ -1021,3 +1155,203 @@ func (m *MemoryMetadataStorage) DeleteTableRelation(id string) error {
 	delete(m.tableRelations, id)
 	return nil
 }
+
+func (m *MemoryMetadataStorage) SaveChatSession(session *models.ChatSession) error {
+	if session.ID == "" {
+		return fmt.Errorf("chat session ID cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.chatSessions[session.ID] = copyChatSession(session)
+	return nil
+}
+
+func (m *MemoryMetadataStorage) GetChatSession(id string) (*models.ChatSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.chatSessions[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrChatSessionNotFound, id)
+	}
+	return copyChatSession(session), nil
+}
+
+func (m *MemoryMetadataStorage) ListChatSessions() ([]*models.ChatSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*models.ChatSession, 0, len(m.chatSessions))
+	for _, session := range m.chatSessions {
+		sessions = append(sessions, copyChatSession(session))
+	}
+	sortChatSessions(sessions)
+	return sessions, nil
+}
+
+func (m *MemoryMetadataStorage) DeleteChatSession(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.chatSessions[id]; !exists {
+		return fmt.Errorf("%w: '%s'", ErrChatSessionNotFound, id)
+	}
+	delete(m.chatSessions, id)
+	return nil
+}
+
+// copyChatSession copies a session so callers can append to its messages without racing
+func copyChatSession(session *models.ChatSession) *models.ChatSession {
+	copied := *session
+	copied.Messages = append([]models.ChatSessionMessage{}, session.Messages...)
+	return &copied
+}
+
+// copyTableRelation copies a relation with its join columns, so callers cannot change a stored one
+func copyTableRelation(relation *models.TableRelation) *models.TableRelation {
+	copied := *relation
+	if relation.JoinColumn != nil {
+		joinColumn := *relation.JoinColumn
+		copied.JoinColumn = &joinColumn
+	}
+	copied.JoinColumns = slices.Clone(relation.JoinColumns)
+	return &copied
+}
+
+// sortChatSessions orders sessions most recently updated first
+func sortChatSessions(sessions []*models.ChatSession) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].UpdatedAt.Equal(sessions[j].UpdatedAt) {
+			return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+}
+
+// WithTx runs fn against a private copy of the storage and swaps the copy in if fn succeeds.
+// Other reads and writes wait until the transaction ends, so none are lost when it is
+// discarded; fn must only use the storage it is given.
+func (m *MemoryMetadataStorage) WithTx(fn func(tx MetadataStorage) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	staged := &MemoryMetadataStorage{}
+	staged.setState(m.state().clone())
+	if err := fn(memoryTx{staged}); err != nil {
+		return err
+	}
+
+	// staged is not used again, so its maps can be taken without copying
+	m.setState(staged.state())
+	return nil
+}
+
+// memoryTx is the storage handed to a WithTx callback; nested transactions join the open one
+type memoryTx struct {
+	*MemoryMetadataStorage
+}
+
+func (t memoryTx) WithTx(fn func(tx MetadataStorage) error) error {
+	return fn(t)
+}
+
+// memoryState holds the maps of a MemoryMetadataStorage
+type memoryState struct {
+	catalogs            map[string]*models.Catalog
+	schemas             map[string]map[string]*models.Schema
+	tables              map[string]map[string]map[string]*models.Table
+	columns             map[string]map[string]map[string]map[string]*models.Column
+	globalTables        map[string]*models.GlobalTable
+	globalColumns       map[string]map[string]*models.GlobalColumn
+	tableMappings       map[string][]*models.TableMapping
+	columnMappings      map[string]map[string][]*models.ColumnMapping
+	columnRelationships map[string][]*models.ColumnRelationship
+	tableRelations      map[string]*models.TableRelation
+	chatSessions        map[string]*models.ChatSession
+}
+
+// state returns the storage's maps; the caller holds the lock
+func (m *MemoryMetadataStorage) state() memoryState {
+	return memoryState{
+		catalogs:            m.catalogs,
+		schemas:             m.schemas,
+		tables:              m.tables,
+		columns:             m.columns,
+		globalTables:        m.globalTables,
+		globalColumns:       m.globalColumns,
+		tableMappings:       m.tableMappings,
+		columnMappings:      m.columnMappings,
+		columnRelationships: m.columnRelationships,
+		tableRelations:      m.tableRelations,
+		chatSessions:        m.chatSessions,
+	}
+}
+
+// setState replaces the storage's maps; the caller holds the lock
+func (m *MemoryMetadataStorage) setState(state memoryState) {
+	m.catalogs = state.catalogs
+	m.schemas = state.schemas
+	m.tables = state.tables
+	m.columns = state.columns
+	m.globalTables = state.globalTables
+	m.globalColumns = state.globalColumns
+	m.tableMappings = state.tableMappings
+	m.columnMappings = state.columnMappings
+	m.columnRelationships = state.columnRelationships
+	m.tableRelations = state.tableRelations
+	m.chatSessions = state.chatSessions
+}
+
+// clone copies the maps and the slices in them. Stored values are replaced rather than
+// changed in place, so the copy can be written to without affecting the original.
+func (s memoryState) clone() memoryState {
+	tables := make(map[string]map[string]map[string]*models.Table, len(s.tables))
+	for catalog, schemas := range s.tables {
+		tables[catalog] = cloneNested(schemas)
+	}
+	columns := make(map[string]map[string]map[string]map[string]*models.Column, len(s.columns))
+	for catalog, schemas := range s.columns {
+		columns[catalog] = make(map[string]map[string]map[string]*models.Column, len(schemas))
+		for schema, tables := range schemas {
+			columns[catalog][schema] = cloneNested(tables)
+		}
+	}
+	columnMappings := make(map[string]map[string][]*models.ColumnMapping, len(s.columnMappings))
+	for globalTable, mappings := range s.columnMappings {
+		columnMappings[globalTable] = cloneSlices(mappings)
+	}
+
+	return memoryState{
+		catalogs:            maps.Clone(s.catalogs),
+		schemas:             cloneNested(s.schemas),
+		tables:              tables,
+		columns:             columns,
+		globalTables:        maps.Clone(s.globalTables),
+		globalColumns:       cloneNested(s.globalColumns),
+		tableMappings:       cloneSlices(s.tableMappings),
+		columnMappings:      columnMappings,
+		columnRelationships: cloneSlices(s.columnRelationships),
+		tableRelations:      maps.Clone(s.tableRelations),
+		chatSessions:        maps.Clone(s.chatSessions),
+	}
+}
+
+// cloneNested copies a map of maps
+func cloneNested[V any](m map[string]map[string]V) map[string]map[string]V {
+	cloned := make(map[string]map[string]V, len(m))
+	for key, inner := range m {
+		cloned[key] = maps.Clone(inner)
+	}
+	return cloned
+}
+
+// cloneSlices copies a map of slices, so appends and in-place removals do not reach the copy
+func cloneSlices[V any](m map[string][]V) map[string][]V {
+	cloned := make(map[string][]V, len(m))
+	for key, values := range m {
+		cloned[key] = slices.Clone(values)
+	}
+	return cloned
+}
@@ -1,6 +1,13 @@
 package storage
 
-import "github.com/guilherme096/data-sync/pkg/data-sync/models"
+import (
+	"errors"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// ErrChatSessionNotFound is returned for unknown chat session IDs
+var ErrChatSessionNotFound = errors.New("chat session not found")
 
 type MetadataStorage interface {
 	// Catalog operations
@@ -9,6 +16,8 @@ type MetadataStorage interface {
 	UpsertCatalog(catalog *models.Catalog) error
 	GetCatalog(name string) (*models.Catalog, error)
 	ListCatalogs() ([]*models.Catalog, error)
+	// DeleteCatalog deletes a catalog with its schemas, tables and columns
+	DeleteCatalog(name string) error
 
 	// Schema operations
 	CreateSchema(schema *models.Schema) error
@@ -16,6 +25,8 @@ type MetadataStorage interface {
 	UpsertSchema(schema *models.Schema) error
 	GetSchema(catalogName, schemaName string) (*models.Schema, error)
 	ListSchemas(catalogName string) ([]*models.Schema, error)
+	// DeleteSchema deletes a schema with its tables and columns
+	DeleteSchema(catalogName, schemaName string) error
 
 	// Local table operations (for discovered tables from data sources)
 	CreateTable(table *models.Table) error
@@ -23,6 +34,8 @@ type MetadataStorage interface {
 	UpsertTable(table *models.Table) error
 	GetTable(catalogName, schemaName, tableName string) (*models.Table, error)
 	ListTables(catalogName, schemaName string) ([]*models.Table, error)
+	// DeleteTable deletes a table with its columns
+	DeleteTable(catalogName, schemaName, tableName string) error
 
 	// Local column operations (for discovered columns from data sources)
 	CreateColumn(column *models.Column) error
@@ -30,15 +43,20 @@ type MetadataStorage interface {
 	UpsertColumn(column *models.Column) error
 	GetColumn(catalogName, schemaName, tableName, columnName string) (*models.Column, error)
 	ListColumns(catalogName, schemaName, tableName string) ([]*models.Column, error)
+	DeleteColumn(catalogName, schemaName, tableName, columnName string) error
 
 	// Global table operations
 	CreateGlobalTable(table *models.GlobalTable) error
+	UpdateGlobalTable(table *models.GlobalTable) error
 	GetGlobalTable(name string) (*models.GlobalTable, error)
 	ListGlobalTables() ([]*models.GlobalTable, error)
 	DeleteGlobalTable(name string) error
+	// SetDegradedGlobalTables marks the named global tables as degraded and clears the mark on all others
+	SetDegradedGlobalTables(names []string) error
 
 	// Global column operations
 	CreateGlobalColumn(column *models.GlobalColumn) error
+	UpdateGlobalColumn(column *models.GlobalColumn) error
 	ListGlobalColumns(globalTableName string) ([]*models.GlobalColumn, error)
 	DeleteGlobalColumn(globalTableName, columnName string) error
 
@@ -62,4 +80,16 @@ type MetadataStorage interface {
 	GetTableRelation(id string) (*models.TableRelation, error)
 	ListTableRelations() ([]*models.TableRelation, error)
 	DeleteTableRelation(id string) error
+
+	// Chat session operations
+	// SaveChatSession creates a session or replaces the stored one with the same ID
+	SaveChatSession(session *models.ChatSession) error
+	GetChatSession(id string) (*models.ChatSession, error)
+	// ListChatSessions returns every session, most recently updated first
+	ListChatSessions() ([]*models.ChatSession, error)
+	DeleteChatSession(id string) error
+
+	// WithTx runs fn against a storage whose writes are kept only if fn returns nil,
+	// so a failing fn leaves the stored metadata as it was
+	WithTx(fn func(tx MetadataStorage) error) error
 }
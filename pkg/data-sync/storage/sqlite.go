@@ -0,0 +1,1319 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteMetadataStorage persists metadata in an embedded SQLite database
+type SQLiteMetadataStorage struct {
+	db *sql.DB
+	// tx is set on the storage handed to a WithTx callback, which runs every query in it
+	tx *sql.Tx
+}
+
+// NewSQLiteMetadataStorage opens (or creates) the SQLite database at path and
+// migrates it to the current schema. Use ":memory:" for a throwaway database.
+func NewSQLiteMetadataStorage(path string) (*SQLiteMetadataStorage, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database '%s': %w", path, err)
+	}
+
+	// A single connection serializes writes and keeps ":memory:" databases alive
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite database '%s': %w", path, err)
+	}
+
+	return &SQLiteMetadataStorage{db: db}, nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteMetadataStorage) Close() error {
+	return s.db.Close()
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// conn returns the open transaction of a WithTx callback's storage, or the database
+func (s *SQLiteMetadataStorage) conn() queryer {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// withTx runs fn in a transaction, committing when it returns nil and rolling back otherwise.
+// Inside WithTx it joins the open transaction instead.
+func (s *SQLiteMetadataStorage) withTx(fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// WithTx runs fn in a single SQLite transaction, committing when it returns nil and rolling back otherwise
+func (s *SQLiteMetadataStorage) WithTx(fn func(tx MetadataStorage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	return s.withTx(func(tx *sql.Tx) error {
+		return fn(&SQLiteMetadataStorage{db: s.db, tx: tx})
+	})
+}
+
+// rowExists reports whether a query returns at least one row
+func rowExists(q queryer, query string, args ...any) (bool, error) {
+	var one int
+	err := q.QueryRow("SELECT 1 WHERE EXISTS ("+query+")", args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func encodeMetadata(metadata map[string]string) (string, error) {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeMetadata(data string) (map[string]string, error) {
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// ============================================================================
+// Catalog Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateCatalog(catalog *models.Catalog) error {
+	if catalog.Name == "" {
+		return fmt.Errorf("catalog name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(catalog.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		exists, err := rowExists(tx, `SELECT 1 FROM catalogs WHERE name = ?`, catalog.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("catalog '%s' already exists", catalog.Name)
+		}
+
+		_, err = tx.Exec(`INSERT INTO catalogs (name, metadata) VALUES (?, ?)`, catalog.Name, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) UpdateCatalog(catalog *models.Catalog) error {
+	if catalog.Name == "" {
+		return fmt.Errorf("catalog name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(catalog.Metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec(`UPDATE catalogs SET metadata = ? WHERE name = ?`, metadata, catalog.Name)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("catalog '%s' not found", catalog.Name)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) UpsertCatalog(catalog *models.Catalog) error {
+	if catalog.Name == "" {
+		return fmt.Errorf("catalog name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(catalog.Metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn().Exec(`INSERT INTO catalogs (name, metadata) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET metadata = excluded.metadata`, catalog.Name, metadata)
+	return err
+}
+
+func (s *SQLiteMetadataStorage) GetCatalog(name string) (*models.Catalog, error) {
+	var metadata string
+	err := s.conn().QueryRow(`SELECT metadata FROM catalogs WHERE name = ?`, name).Scan(&metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("catalog '%s' not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &models.Catalog{Name: name}
+	if catalog.Metadata, err = decodeMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+func (s *SQLiteMetadataStorage) ListCatalogs() ([]*models.Catalog, error) {
+	rows, err := s.conn().Query(`SELECT name, metadata FROM catalogs ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalogs := []*models.Catalog{}
+	for rows.Next() {
+		var catalog models.Catalog
+		var metadata string
+		if err := rows.Scan(&catalog.Name, &metadata); err != nil {
+			return nil, err
+		}
+		if catalog.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
+		catalogs = append(catalogs, &catalog)
+	}
+	return catalogs, rows.Err()
+}
+
+// DeleteCatalog deletes a catalog with its schemas, tables and columns in one transaction
+func (s *SQLiteMetadataStorage) DeleteCatalog(name string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM catalogs WHERE name = ?`, name)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return fmt.Errorf("catalog '%s' not found", name)
+		}
+
+		statements := []string{
+			`DELETE FROM schemas WHERE catalog_name = ?`,
+			`DELETE FROM local_tables WHERE catalog_name = ?`,
+			`DELETE FROM local_columns WHERE catalog_name = ?`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, name); err != nil {
+				return fmt.Errorf("failed to delete data of catalog '%s': %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ============================================================================
+// Schema Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateSchema(schema *models.Schema) error {
+	if schema.CatalogName == "" || schema.Name == "" {
+		return fmt.Errorf("catalog name and schema name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(schema.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := requireCatalog(tx, schema.CatalogName); err != nil {
+			return err
+		}
+
+		exists, err := rowExists(tx, `SELECT 1 FROM schemas WHERE catalog_name = ? AND name = ?`, schema.CatalogName, schema.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("schema '%s' already exists in catalog '%s'", schema.Name, schema.CatalogName)
+		}
+
+		_, err = tx.Exec(`INSERT INTO schemas (catalog_name, name, metadata) VALUES (?, ?, ?)`,
+			schema.CatalogName, schema.Name, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) UpdateSchema(schema *models.Schema) error {
+	if schema.CatalogName == "" || schema.Name == "" {
+		return fmt.Errorf("catalog name and schema name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(schema.Metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec(`UPDATE schemas SET metadata = ? WHERE catalog_name = ? AND name = ?`,
+		metadata, schema.CatalogName, schema.Name)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("schema '%s' not found in catalog '%s'", schema.Name, schema.CatalogName)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) UpsertSchema(schema *models.Schema) error {
+	if schema.CatalogName == "" || schema.Name == "" {
+		return fmt.Errorf("catalog name and schema name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(schema.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := requireCatalog(tx, schema.CatalogName); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO schemas (catalog_name, name, metadata) VALUES (?, ?, ?)
+			ON CONFLICT (catalog_name, name) DO UPDATE SET metadata = excluded.metadata`,
+			schema.CatalogName, schema.Name, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) GetSchema(catalogName, schemaName string) (*models.Schema, error) {
+	var metadata string
+	err := s.conn().QueryRow(`SELECT metadata FROM schemas WHERE catalog_name = ? AND name = ?`,
+		catalogName, schemaName).Scan(&metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("schema '%s' not found in catalog '%s'", schemaName, catalogName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &models.Schema{Name: schemaName, CatalogName: catalogName}
+	if schema.Metadata, err = decodeMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (s *SQLiteMetadataStorage) ListSchemas(catalogName string) ([]*models.Schema, error) {
+	rows, err := s.conn().Query(`SELECT name, metadata FROM schemas WHERE catalog_name = ? ORDER BY name`, catalogName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schemas := []*models.Schema{}
+	for rows.Next() {
+		schema := models.Schema{CatalogName: catalogName}
+		var metadata string
+		if err := rows.Scan(&schema.Name, &metadata); err != nil {
+			return nil, err
+		}
+		if schema.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, &schema)
+	}
+	return schemas, rows.Err()
+}
+
+func requireCatalog(q queryer, catalogName string) error {
+	exists, err := rowExists(q, `SELECT 1 FROM catalogs WHERE name = ?`, catalogName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("catalog '%s' not found", catalogName)
+	}
+	return nil
+}
+
+// DeleteSchema deletes a schema with its tables and columns in one transaction
+func (s *SQLiteMetadataStorage) DeleteSchema(catalogName, schemaName string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM schemas WHERE catalog_name = ? AND name = ?`, catalogName, schemaName)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return fmt.Errorf("schema '%s' not found in catalog '%s'", schemaName, catalogName)
+		}
+
+		statements := []string{
+			`DELETE FROM local_tables WHERE catalog_name = ? AND schema_name = ?`,
+			`DELETE FROM local_columns WHERE catalog_name = ? AND schema_name = ?`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, catalogName, schemaName); err != nil {
+				return fmt.Errorf("failed to delete data of schema '%s.%s': %w", catalogName, schemaName, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ============================================================================
+// Local Table Operations (from data source discovery)
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateTable(table *models.Table) error {
+	if table.CatalogName == "" || table.SchemaName == "" || table.Name == "" {
+		return fmt.Errorf("catalog name, schema name, and table name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(table.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := requireSchema(tx, table.CatalogName, table.SchemaName); err != nil {
+			return err
+		}
+
+		exists, err := rowExists(tx, `SELECT 1 FROM local_tables WHERE catalog_name = ? AND schema_name = ? AND name = ?`,
+			table.CatalogName, table.SchemaName, table.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("table '%s' already exists in schema '%s.%s'", table.Name, table.CatalogName, table.SchemaName)
+		}
+
+		_, err = tx.Exec(`INSERT INTO local_tables (catalog_name, schema_name, name, metadata) VALUES (?, ?, ?, ?)`,
+			table.CatalogName, table.SchemaName, table.Name, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) UpdateTable(table *models.Table) error {
+	if table.CatalogName == "" || table.SchemaName == "" || table.Name == "" {
+		return fmt.Errorf("catalog name, schema name, and table name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(table.Metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec(`UPDATE local_tables SET metadata = ? WHERE catalog_name = ? AND schema_name = ? AND name = ?`,
+		metadata, table.CatalogName, table.SchemaName, table.Name)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("table '%s' not found in schema '%s.%s'", table.Name, table.CatalogName, table.SchemaName)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) UpsertTable(table *models.Table) error {
+	if table.CatalogName == "" || table.SchemaName == "" || table.Name == "" {
+		return fmt.Errorf("catalog name, schema name, and table name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(table.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := requireSchema(tx, table.CatalogName, table.SchemaName); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO local_tables (catalog_name, schema_name, name, metadata) VALUES (?, ?, ?, ?)
+			ON CONFLICT (catalog_name, schema_name, name) DO UPDATE SET metadata = excluded.metadata`,
+			table.CatalogName, table.SchemaName, table.Name, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) GetTable(catalogName, schemaName, tableName string) (*models.Table, error) {
+	var metadata string
+	err := s.conn().QueryRow(`SELECT metadata FROM local_tables WHERE catalog_name = ? AND schema_name = ? AND name = ?`,
+		catalogName, schemaName, tableName).Scan(&metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("table '%s' not found in schema '%s.%s'", tableName, catalogName, schemaName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table := &models.Table{Name: tableName, SchemaName: schemaName, CatalogName: catalogName}
+	if table.Metadata, err = decodeMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (s *SQLiteMetadataStorage) ListTables(catalogName, schemaName string) ([]*models.Table, error) {
+	rows, err := s.conn().Query(`SELECT name, metadata FROM local_tables WHERE catalog_name = ? AND schema_name = ? ORDER BY name`,
+		catalogName, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []*models.Table{}
+	for rows.Next() {
+		table := models.Table{SchemaName: schemaName, CatalogName: catalogName}
+		var metadata string
+		if err := rows.Scan(&table.Name, &metadata); err != nil {
+			return nil, err
+		}
+		if table.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &table)
+	}
+	return tables, rows.Err()
+}
+
+func requireSchema(q queryer, catalogName, schemaName string) error {
+	if err := requireCatalog(q, catalogName); err != nil {
+		return err
+	}
+	exists, err := rowExists(q, `SELECT 1 FROM schemas WHERE catalog_name = ? AND name = ?`, catalogName, schemaName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("schema '%s' not found in catalog '%s'", schemaName, catalogName)
+	}
+	return nil
+}
+
+// DeleteTable deletes a table with its columns in one transaction
+func (s *SQLiteMetadataStorage) DeleteTable(catalogName, schemaName, tableName string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM local_tables WHERE catalog_name = ? AND schema_name = ? AND name = ?`,
+			catalogName, schemaName, tableName)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return fmt.Errorf("table '%s' not found in schema '%s.%s'", tableName, catalogName, schemaName)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM local_columns WHERE catalog_name = ? AND schema_name = ? AND table_name = ?`,
+			catalogName, schemaName, tableName); err != nil {
+			return fmt.Errorf("failed to delete columns of table '%s.%s.%s': %w", catalogName, schemaName, tableName, err)
+		}
+		return nil
+	})
+}
+
+// ============================================================================
+// Local Column Operations (from data source discovery)
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateColumn(column *models.Column) error {
+	if column.CatalogName == "" || column.SchemaName == "" || column.TableName == "" || column.Name == "" {
+		return fmt.Errorf("catalog name, schema name, table name, and column name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(column.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := requireTable(tx, column.CatalogName, column.SchemaName, column.TableName); err != nil {
+			return err
+		}
+
+		exists, err := rowExists(tx, `SELECT 1 FROM local_columns
+			WHERE catalog_name = ? AND schema_name = ? AND table_name = ? AND name = ?`,
+			column.CatalogName, column.SchemaName, column.TableName, column.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("column '%s' already exists in table '%s.%s.%s'", column.Name, column.CatalogName, column.SchemaName, column.TableName)
+		}
+
+		_, err = tx.Exec(`INSERT INTO local_columns (catalog_name, schema_name, table_name, name, data_type, metadata)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			column.CatalogName, column.SchemaName, column.TableName, column.Name, column.DataType, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) UpdateColumn(column *models.Column) error {
+	if column.CatalogName == "" || column.SchemaName == "" || column.TableName == "" || column.Name == "" {
+		return fmt.Errorf("catalog name, schema name, table name, and column name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(column.Metadata)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec(`UPDATE local_columns SET data_type = ?, metadata = ?
+		WHERE catalog_name = ? AND schema_name = ? AND table_name = ? AND name = ?`,
+		column.DataType, metadata, column.CatalogName, column.SchemaName, column.TableName, column.Name)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("column '%s' not found in table '%s.%s.%s'", column.Name, column.CatalogName, column.SchemaName, column.TableName)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) UpsertColumn(column *models.Column) error {
+	if column.CatalogName == "" || column.SchemaName == "" || column.TableName == "" || column.Name == "" {
+		return fmt.Errorf("catalog name, schema name, table name, and column name cannot be empty")
+	}
+
+	metadata, err := encodeMetadata(column.Metadata)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := requireTable(tx, column.CatalogName, column.SchemaName, column.TableName); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`INSERT INTO local_columns (catalog_name, schema_name, table_name, name, data_type, metadata)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (catalog_name, schema_name, table_name, name)
+			DO UPDATE SET data_type = excluded.data_type, metadata = excluded.metadata`,
+			column.CatalogName, column.SchemaName, column.TableName, column.Name, column.DataType, metadata)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) GetColumn(catalogName, schemaName, tableName, columnName string) (*models.Column, error) {
+	column := &models.Column{Name: columnName, TableName: tableName, SchemaName: schemaName, CatalogName: catalogName}
+	var metadata string
+	err := s.conn().QueryRow(`SELECT data_type, metadata FROM local_columns
+		WHERE catalog_name = ? AND schema_name = ? AND table_name = ? AND name = ?`,
+		catalogName, schemaName, tableName, columnName).Scan(&column.DataType, &metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("column '%s' not found in table '%s.%s.%s'", columnName, catalogName, schemaName, tableName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if column.Metadata, err = decodeMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return column, nil
+}
+
+func (s *SQLiteMetadataStorage) ListColumns(catalogName, schemaName, tableName string) ([]*models.Column, error) {
+	rows, err := s.conn().Query(`SELECT name, data_type, metadata FROM local_columns
+		WHERE catalog_name = ? AND schema_name = ? AND table_name = ? ORDER BY name`,
+		catalogName, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []*models.Column{}
+	for rows.Next() {
+		column := models.Column{TableName: tableName, SchemaName: schemaName, CatalogName: catalogName}
+		var metadata string
+		if err := rows.Scan(&column.Name, &column.DataType, &metadata); err != nil {
+			return nil, err
+		}
+		if column.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
+		columns = append(columns, &column)
+	}
+	return columns, rows.Err()
+}
+
+func requireTable(q queryer, catalogName, schemaName, tableName string) error {
+	if err := requireSchema(q, catalogName, schemaName); err != nil {
+		return err
+	}
+	exists, err := rowExists(q, `SELECT 1 FROM local_tables WHERE catalog_name = ? AND schema_name = ? AND name = ?`,
+		catalogName, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("table '%s' not found in schema '%s.%s'", tableName, catalogName, schemaName)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) DeleteColumn(catalogName, schemaName, tableName, columnName string) error {
+	result, err := s.conn().Exec(`DELETE FROM local_columns WHERE catalog_name = ? AND schema_name = ? AND table_name = ? AND name = ?`,
+		catalogName, schemaName, tableName, columnName)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("column '%s' not found in table '%s.%s.%s'", columnName, catalogName, schemaName, tableName)
+	}
+	return nil
+}
+
+// ============================================================================
+// Global Table Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateGlobalTable(table *models.GlobalTable) error {
+	if table.Name == "" {
+		return fmt.Errorf("global table name cannot be empty")
+	}
+
+	if err := models.ValidateUnionType(table.UnionType); err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		exists, err := rowExists(tx, `SELECT 1 FROM global_tables WHERE name = ?`, table.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("global table '%s' already exists", table.Name)
+		}
+
+		_, err = tx.Exec(`INSERT INTO global_tables (name, description, union_type, source_column, degraded) VALUES (?, ?, ?, ?, ?)`,
+			table.Name, table.Description, table.UnionType, table.SourceColumn, table.Degraded)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) UpdateGlobalTable(table *models.GlobalTable) error {
+	if table.Name == "" {
+		return fmt.Errorf("global table name cannot be empty")
+	}
+
+	if err := models.ValidateUnionType(table.UnionType); err != nil {
+		return err
+	}
+
+	result, err := s.conn().Exec(`UPDATE global_tables SET description = ?, union_type = ?, source_column = ? WHERE name = ?`,
+		table.Description, table.UnionType, table.SourceColumn, table.Name)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("global table '%s' not found", table.Name)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) GetGlobalTable(name string) (*models.GlobalTable, error) {
+	table := &models.GlobalTable{Name: name}
+	err := s.conn().QueryRow(`SELECT description, union_type, source_column, degraded FROM global_tables WHERE name = ?`, name).
+		Scan(&table.Description, &table.UnionType, &table.SourceColumn, &table.Degraded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("global table '%s' not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (s *SQLiteMetadataStorage) ListGlobalTables() ([]*models.GlobalTable, error) {
+	rows, err := s.conn().Query(`SELECT name, description, union_type, source_column, degraded FROM global_tables ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []*models.GlobalTable{}
+	for rows.Next() {
+		var table models.GlobalTable
+		if err := rows.Scan(&table.Name, &table.Description, &table.UnionType, &table.SourceColumn, &table.Degraded); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &table)
+	}
+	return tables, rows.Err()
+}
+
+// SetDegradedGlobalTables marks the named global tables as degraded and clears the mark on all others, in one transaction
+func (s *SQLiteMetadataStorage) SetDegradedGlobalTables(names []string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`UPDATE global_tables SET degraded = 0`); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if _, err := tx.Exec(`UPDATE global_tables SET degraded = 1 WHERE name = ?`, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteGlobalTable deletes a global table with its columns, mappings and relationships in one transaction
+func (s *SQLiteMetadataStorage) DeleteGlobalTable(name string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM global_tables WHERE name = ?`, name)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return fmt.Errorf("global table '%s' not found", name)
+		}
+
+		statements := []string{
+			`DELETE FROM global_columns WHERE global_table_name = ?`,
+			`DELETE FROM table_mappings WHERE global_table_name = ?`,
+			`DELETE FROM column_mappings WHERE global_table_name = ?`,
+			`DELETE FROM column_relationships WHERE source_table_name = ?1 OR target_table_name = ?1`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement, name); err != nil {
+				return fmt.Errorf("failed to delete data of global table '%s': %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ============================================================================
+// Global Column Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateGlobalColumn(column *models.GlobalColumn) error {
+	if column.GlobalTableName == "" || column.Name == "" {
+		return fmt.Errorf("global table name and column name cannot be empty")
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		exists, err := rowExists(tx, `SELECT 1 FROM global_tables WHERE name = ?`, column.GlobalTableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("global table '%s' not found", column.GlobalTableName)
+		}
+
+		exists, err = rowExists(tx, `SELECT 1 FROM global_columns WHERE global_table_name = ? AND name = ?`,
+			column.GlobalTableName, column.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("global column '%s' already exists in table '%s'", column.Name, column.GlobalTableName)
+		}
+
+		_, err = tx.Exec(`INSERT INTO global_columns (global_table_name, name, data_type, description) VALUES (?, ?, ?, ?)`,
+			column.GlobalTableName, column.Name, column.DataType, column.Description)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) UpdateGlobalColumn(column *models.GlobalColumn) error {
+	if column.GlobalTableName == "" || column.Name == "" {
+		return fmt.Errorf("global table name and column name cannot be empty")
+	}
+
+	result, err := s.conn().Exec(`UPDATE global_columns SET data_type = ?, description = ? WHERE global_table_name = ? AND name = ?`,
+		column.DataType, column.Description, column.GlobalTableName, column.Name)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("global column '%s' not found in table '%s'", column.Name, column.GlobalTableName)
+	}
+	return nil
+}
+
+func (s *SQLiteMetadataStorage) ListGlobalColumns(globalTableName string) ([]*models.GlobalColumn, error) {
+	rows, err := s.conn().Query(`SELECT name, data_type, description FROM global_columns
+		WHERE global_table_name = ? ORDER BY name`, globalTableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []*models.GlobalColumn{}
+	for rows.Next() {
+		column := models.GlobalColumn{GlobalTableName: globalTableName}
+		if err := rows.Scan(&column.Name, &column.DataType, &column.Description); err != nil {
+			return nil, err
+		}
+		columns = append(columns, &column)
+	}
+	return columns, rows.Err()
+}
+
+// DeleteGlobalColumn deletes a global column with its mappings and relationships in one transaction
+func (s *SQLiteMetadataStorage) DeleteGlobalColumn(globalTableName, columnName string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM global_columns WHERE global_table_name = ? AND name = ?`, globalTableName, columnName)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return fmt.Errorf("global column '%s' not found in table '%s'", columnName, globalTableName)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM column_mappings WHERE global_table_name = ? AND global_column_name = ?`,
+			globalTableName, columnName); err != nil {
+			return fmt.Errorf("failed to delete mappings of global column '%s.%s': %w", globalTableName, columnName, err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM column_relationships
+			WHERE (source_table_name = ?1 AND source_column_name = ?2) OR (target_table_name = ?1 AND target_column_name = ?2)`,
+			globalTableName, columnName); err != nil {
+			return fmt.Errorf("failed to delete relationships of global column '%s.%s': %w", globalTableName, columnName, err)
+		}
+		return nil
+	})
+}
+
+// ============================================================================
+// Table Mapping Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateTableMapping(mapping *models.TableMapping) error {
+	if mapping.GlobalTableName == "" || mapping.CatalogName == "" || mapping.SchemaName == "" || mapping.TableName == "" {
+		return fmt.Errorf("all fields in table mapping must be non-empty")
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		exists, err := rowExists(tx, `SELECT 1 FROM global_tables WHERE name = ?`, mapping.GlobalTableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("global table '%s' not found", mapping.GlobalTableName)
+		}
+
+		exists, err = rowExists(tx, `SELECT 1 FROM table_mappings
+			WHERE global_table_name = ? AND catalog_name = ? AND schema_name = ? AND table_name = ?`,
+			mapping.GlobalTableName, mapping.CatalogName, mapping.SchemaName, mapping.TableName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("table mapping already exists")
+		}
+
+		_, err = tx.Exec(`INSERT INTO table_mappings (global_table_name, catalog_name, schema_name, table_name) VALUES (?, ?, ?, ?)`,
+			mapping.GlobalTableName, mapping.CatalogName, mapping.SchemaName, mapping.TableName)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) ListTableMappings(globalTableName string) ([]*models.TableMapping, error) {
+	rows, err := s.conn().Query(`SELECT catalog_name, schema_name, table_name FROM table_mappings
+		WHERE global_table_name = ? ORDER BY id`, globalTableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := []*models.TableMapping{}
+	for rows.Next() {
+		mapping := models.TableMapping{GlobalTableName: globalTableName}
+		if err := rows.Scan(&mapping.CatalogName, &mapping.SchemaName, &mapping.TableName); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, &mapping)
+	}
+	return mappings, rows.Err()
+}
+
+func (s *SQLiteMetadataStorage) DeleteTableMapping(globalTableName, catalog, schema, table string) error {
+	result, err := s.conn().Exec(`DELETE FROM table_mappings
+		WHERE global_table_name = ? AND catalog_name = ? AND schema_name = ? AND table_name = ?`,
+		globalTableName, catalog, schema, table)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("table mapping not found")
+	}
+	return nil
+}
+
+// ============================================================================
+// Column Mapping Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateColumnMapping(mapping *models.ColumnMapping) error {
+	if mapping.GlobalTableName == "" || mapping.GlobalColumnName == "" ||
+		mapping.CatalogName == "" || mapping.SchemaName == "" ||
+		mapping.TableName == "" || mapping.ColumnName == "" {
+		return fmt.Errorf("all fields in column mapping must be non-empty")
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		exists, err := rowExists(tx, `SELECT 1 FROM global_columns WHERE global_table_name = ? AND name = ?`,
+			mapping.GlobalTableName, mapping.GlobalColumnName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("global column '%s.%s' not found", mapping.GlobalTableName, mapping.GlobalColumnName)
+		}
+
+		exists, err = rowExists(tx, `SELECT 1 FROM column_mappings
+			WHERE global_table_name = ? AND global_column_name = ?
+			AND catalog_name = ? AND schema_name = ? AND table_name = ? AND column_name = ?`,
+			mapping.GlobalTableName, mapping.GlobalColumnName,
+			mapping.CatalogName, mapping.SchemaName, mapping.TableName, mapping.ColumnName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("column mapping already exists")
+		}
+
+		_, err = tx.Exec(`INSERT INTO column_mappings
+			(global_table_name, global_column_name, catalog_name, schema_name, table_name, column_name)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			mapping.GlobalTableName, mapping.GlobalColumnName,
+			mapping.CatalogName, mapping.SchemaName, mapping.TableName, mapping.ColumnName)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) ListColumnMappings(globalTableName, globalColumnName string) ([]*models.ColumnMapping, error) {
+	rows, err := s.conn().Query(`SELECT catalog_name, schema_name, table_name, column_name FROM column_mappings
+		WHERE global_table_name = ? AND global_column_name = ? ORDER BY id`, globalTableName, globalColumnName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := []*models.ColumnMapping{}
+	for rows.Next() {
+		mapping := models.ColumnMapping{GlobalTableName: globalTableName, GlobalColumnName: globalColumnName}
+		if err := rows.Scan(&mapping.CatalogName, &mapping.SchemaName, &mapping.TableName, &mapping.ColumnName); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, &mapping)
+	}
+	return mappings, rows.Err()
+}
+
+func (s *SQLiteMetadataStorage) DeleteColumnMapping(globalTableName, globalColumnName, catalog, schema, table, column string) error {
+	result, err := s.conn().Exec(`DELETE FROM column_mappings
+		WHERE global_table_name = ? AND global_column_name = ?
+		AND catalog_name = ? AND schema_name = ? AND table_name = ? AND column_name = ?`,
+		globalTableName, globalColumnName, catalog, schema, table, column)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("column mapping not found")
+	}
+	return nil
+}
+
+// ============================================================================
+// Column Relationship Operations
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateColumnRelationship(relationship *models.ColumnRelationship) error {
+	// Validate all fields are non-empty
+	if relationship.SourceGlobalTableName == "" || relationship.SourceGlobalColumnName == "" ||
+		relationship.TargetGlobalTableName == "" || relationship.TargetGlobalColumnName == "" {
+		return fmt.Errorf("all relationship fields (source table, source column, target table, target column) must be non-empty")
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		endpoints := []struct {
+			role, table, column string
+		}{
+			{"source", relationship.SourceGlobalTableName, relationship.SourceGlobalColumnName},
+			{"target", relationship.TargetGlobalTableName, relationship.TargetGlobalColumnName},
+		}
+		for _, endpoint := range endpoints {
+			exists, err := rowExists(tx, `SELECT 1 FROM global_tables WHERE name = ?`, endpoint.table)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%s global table '%s' not found", endpoint.role, endpoint.table)
+			}
+
+			exists, err = rowExists(tx, `SELECT 1 FROM global_columns WHERE global_table_name = ? AND name = ?`,
+				endpoint.table, endpoint.column)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%s global column '%s.%s' not found", endpoint.role, endpoint.table, endpoint.column)
+			}
+		}
+
+		exists, err := rowExists(tx, `SELECT 1 FROM column_relationships
+			WHERE source_table_name = ? AND source_column_name = ? AND target_table_name = ? AND target_column_name = ?`,
+			relationship.SourceGlobalTableName, relationship.SourceGlobalColumnName,
+			relationship.TargetGlobalTableName, relationship.TargetGlobalColumnName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("relationship already exists between %s.%s and %s.%s",
+				relationship.SourceGlobalTableName, relationship.SourceGlobalColumnName,
+				relationship.TargetGlobalTableName, relationship.TargetGlobalColumnName)
+		}
+
+		_, err = tx.Exec(`INSERT INTO column_relationships
+			(source_table_name, source_column_name, target_table_name, target_column_name, relationship_name, description)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			relationship.SourceGlobalTableName, relationship.SourceGlobalColumnName,
+			relationship.TargetGlobalTableName, relationship.TargetGlobalColumnName,
+			relationship.RelationshipName, relationship.Description)
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) ListColumnRelationships(globalTableName string) ([]*models.ColumnRelationship, error) {
+	rows, err := s.conn().Query(`SELECT source_table_name, source_column_name, target_table_name, target_column_name,
+		relationship_name, description FROM column_relationships
+		WHERE source_table_name = ?1 OR target_table_name = ?1 ORDER BY id`, globalTableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relationships := []*models.ColumnRelationship{}
+	for rows.Next() {
+		var rel models.ColumnRelationship
+		if err := rows.Scan(&rel.SourceGlobalTableName, &rel.SourceGlobalColumnName,
+			&rel.TargetGlobalTableName, &rel.TargetGlobalColumnName,
+			&rel.RelationshipName, &rel.Description); err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, &rel)
+	}
+	return relationships, rows.Err()
+}
+
+func (s *SQLiteMetadataStorage) DeleteColumnRelationship(sourceTable, sourceColumn, targetTable, targetColumn string) error {
+	_, err := s.conn().Exec(`DELETE FROM column_relationships
+		WHERE source_table_name = ? AND source_column_name = ? AND target_table_name = ? AND target_column_name = ?`,
+		sourceTable, sourceColumn, targetTable, targetColumn)
+	return err
+}
+
+// ============================================================================
+// Table Relation Operations (JOIN/UNION)
+// ============================================================================
+
+func (s *SQLiteMetadataStorage) CreateTableRelation(relation *models.TableRelation) error {
+	if relation.ID == "" || relation.Name == "" {
+		return fmt.Errorf("relation ID and name cannot be empty")
+	}
+
+	if relation.RelationType != "JOIN" && relation.RelationType != "UNION" {
+		return fmt.Errorf("relation type must be JOIN or UNION")
+	}
+
+	// Validate JOIN requires join columns and a supported join type
+	if relation.RelationType == "JOIN" {
+		if err := relation.ValidateJoin(); err != nil {
+			return err
+		}
+	}
+
+	if err := models.ValidateUnionType(relation.UnionType); err != nil {
+		return err
+	}
+
+	definition, err := json.Marshal(relation)
+	if err != nil {
+		return fmt.Errorf("failed to encode relation '%s': %w", relation.ID, err)
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		exists, err := rowExists(tx, `SELECT 1 FROM table_relations WHERE id = ?`, relation.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("relation with ID '%s' already exists", relation.ID)
+		}
+
+		exists, err = rowExists(tx, `SELECT 1 FROM table_relations WHERE name = ?`, relation.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("relation with name '%s' already exists", relation.Name)
+		}
+
+		_, err = tx.Exec(`INSERT INTO table_relations (id, name, relation_type, definition) VALUES (?, ?, ?, ?)`,
+			relation.ID, relation.Name, relation.RelationType, string(definition))
+		return err
+	})
+}
+
+func (s *SQLiteMetadataStorage) GetTableRelation(id string) (*models.TableRelation, error) {
+	var definition string
+	err := s.conn().QueryRow(`SELECT definition FROM table_relations WHERE id = ?`, id).Scan(&definition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("relation with ID '%s' not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeRelation(definition)
+}
+
+func (s *SQLiteMetadataStorage) ListTableRelations() ([]*models.TableRelation, error) {
+	rows, err := s.conn().Query(`SELECT definition FROM table_relations ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relations := []*models.TableRelation{}
+	for rows.Next() {
+		var definition string
+		if err := rows.Scan(&definition); err != nil {
+			return nil, err
+		}
+		relation, err := decodeRelation(definition)
+		if err != nil {
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+func (s *SQLiteMetadataStorage) DeleteTableRelation(id string) error {
+	result, err := s.conn().Exec(`DELETE FROM table_relations WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("relation with ID '%s' not found", id)
+	}
+	return nil
+}
+
+func decodeRelation(definition string) (*models.TableRelation, error) {
+	var relation models.TableRelation
+	if err := json.Unmarshal([]byte(definition), &relation); err != nil {
+		return nil, fmt.Errorf("failed to decode relation: %w", err)
+	}
+	return &relation, nil
+}
+
+func (s *SQLiteMetadataStorage) SaveChatSession(session *models.ChatSession) error {
+	if session.ID == "" {
+		return fmt.Errorf("chat session ID cannot be empty")
+	}
+
+	encoded, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode chat session '%s': %w", session.ID, err)
+	}
+
+	_, err = s.conn().Exec(`INSERT INTO chat_sessions (id, session) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET session = excluded.session`, session.ID, string(encoded))
+	return err
+}
+
+func (s *SQLiteMetadataStorage) GetChatSession(id string) (*models.ChatSession, error) {
+	var encoded string
+	err := s.conn().QueryRow(`SELECT session FROM chat_sessions WHERE id = ?`, id).Scan(&encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: '%s'", ErrChatSessionNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeChatSession(encoded)
+}
+
+func (s *SQLiteMetadataStorage) ListChatSessions() ([]*models.ChatSession, error) {
+	rows, err := s.conn().Query(`SELECT session FROM chat_sessions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.ChatSession{}
+	for rows.Next() {
+		var encoded string
+		if err := rows.Scan(&encoded); err != nil {
+			return nil, err
+		}
+		session, err := decodeChatSession(encoded)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortChatSessions(sessions)
+	return sessions, nil
+}
+
+func (s *SQLiteMetadataStorage) DeleteChatSession(id string) error {
+	result, err := s.conn().Exec(`DELETE FROM chat_sessions WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: '%s'", ErrChatSessionNotFound, id)
+	}
+	return nil
+}
+
+func decodeChatSession(encoded string) (*models.ChatSession, error) {
+	var session models.ChatSession
+	if err := json.Unmarshal([]byte(encoded), &session); err != nil {
+		return nil, fmt.Errorf("failed to decode chat session: %w", err)
+	}
+	return &session, nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteMigrations are applied in order to bring a database to the current schema.
+// The schema version is the number of applied migrations; append new migrations, never edit applied ones.
+var sqliteMigrations = []string{
+	// 1: local metadata discovered from data sources
+	`CREATE TABLE catalogs (
+		name     TEXT PRIMARY KEY,
+		metadata TEXT NOT NULL
+	);
+	CREATE TABLE schemas (
+		catalog_name TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		metadata     TEXT NOT NULL,
+		PRIMARY KEY (catalog_name, name)
+	);
+	CREATE TABLE local_tables (
+		catalog_name TEXT NOT NULL,
+		schema_name  TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		metadata     TEXT NOT NULL,
+		PRIMARY KEY (catalog_name, schema_name, name)
+	);
+	CREATE TABLE local_columns (
+		catalog_name TEXT NOT NULL,
+		schema_name  TEXT NOT NULL,
+		table_name   TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		data_type    TEXT NOT NULL,
+		metadata     TEXT NOT NULL,
+		PRIMARY KEY (catalog_name, schema_name, table_name, name)
+	);`,
+
+	// 2: global tables, their columns and mappings to local tables
+	`CREATE TABLE global_tables (
+		name          TEXT PRIMARY KEY,
+		description   TEXT NOT NULL,
+		union_type    TEXT NOT NULL,
+		source_column TEXT NOT NULL
+	);
+	CREATE TABLE global_columns (
+		global_table_name TEXT NOT NULL,
+		name              TEXT NOT NULL,
+		data_type         TEXT NOT NULL,
+		description       TEXT NOT NULL,
+		PRIMARY KEY (global_table_name, name)
+	);
+	CREATE TABLE table_mappings (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		global_table_name TEXT NOT NULL,
+		catalog_name      TEXT NOT NULL,
+		schema_name       TEXT NOT NULL,
+		table_name        TEXT NOT NULL,
+		UNIQUE (global_table_name, catalog_name, schema_name, table_name)
+	);
+	CREATE TABLE column_mappings (
+		id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+		global_table_name  TEXT NOT NULL,
+		global_column_name TEXT NOT NULL,
+		catalog_name       TEXT NOT NULL,
+		schema_name        TEXT NOT NULL,
+		table_name         TEXT NOT NULL,
+		column_name        TEXT NOT NULL,
+		UNIQUE (global_table_name, global_column_name, catalog_name, schema_name, table_name, column_name)
+	);
+	CREATE TABLE column_relationships (
+		id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_table_name   TEXT NOT NULL,
+		source_column_name  TEXT NOT NULL,
+		target_table_name   TEXT NOT NULL,
+		target_column_name  TEXT NOT NULL,
+		relationship_name   TEXT NOT NULL,
+		description         TEXT NOT NULL,
+		UNIQUE (source_table_name, source_column_name, target_table_name, target_column_name)
+	);`,
+
+	// 3: table relations (JOIN/UNION), stored as their JSON definition
+	`CREATE TABLE table_relations (
+		id            TEXT PRIMARY KEY,
+		name          TEXT NOT NULL UNIQUE,
+		relation_type TEXT NOT NULL,
+		definition    TEXT NOT NULL
+	);`,
+
+	// 4: degraded mark set on global tables by the drift impact check after a sync
+	`ALTER TABLE global_tables ADD COLUMN degraded INTEGER NOT NULL DEFAULT 0;`,
+
+	// 5: chatbot sessions, stored as their JSON encoding
+	`CREATE TABLE chat_sessions (
+		id      TEXT PRIMARY KEY,
+		session TEXT NOT NULL
+	);`,
+}
+
+// migrateSQLite applies every migration the database has not seen yet, each in its own transaction
+func migrateSQLite(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
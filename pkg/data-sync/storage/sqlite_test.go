@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+)
+
+// newTestSQLiteStorage opens a fresh SQLite database in a temporary directory
+func newTestSQLiteStorage(t *testing.T, path string) *SQLiteMetadataStorage {
+	t.Helper()
+
+	storage, err := NewSQLiteMetadataStorage(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteMetadataStorage failed: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestSQLiteMetadataStorage(t *testing.T) {
+	runConformanceTests(t, func(t *testing.T) MetadataStorage {
+		return newTestSQLiteStorage(t, filepath.Join(t.TempDir(), "metadata.db"))
+	})
+}
+
+func TestSQLiteMetadataStorage_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.db")
+
+	storage := newTestSQLiteStorage(t, path)
+	if err := storage.CreateGlobalTable(&models.GlobalTable{Name: "customers", Description: "All customers"}); err != nil {
+		t.Fatalf("CreateGlobalTable failed: %v", err)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Reopening runs the migrations again, which must leave existing data untouched
+	reopened := newTestSQLiteStorage(t, path)
+	table, err := reopened.GetGlobalTable("customers")
+	if err != nil {
+		t.Fatalf("GetGlobalTable after reopen failed: %v", err)
+	}
+	if table.Description != "All customers" {
+		t.Errorf("Expected description 'All customers', got '%s'", table.Description)
+	}
+}
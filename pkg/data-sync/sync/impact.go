@@ -0,0 +1,329 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// Problems found by the impact analysis
+const (
+	ProblemMissingTable    = "missing_table"
+	ProblemMissingColumn   = "missing_column"
+	ProblemMissingRelation = "missing_relation"
+	ProblemTypeMismatch    = "type_mismatch"
+)
+
+// Kinds of global metadata an impact issue is found in
+const (
+	KindTableMapping  = "tableMapping"
+	KindColumnMapping = "columnMapping"
+	KindRelation      = "relation"
+)
+
+// ImpactIssue is a mapping or relation that points at physical metadata that no longer
+// exists, or whose type no longer fits, so queries on its global tables would fail
+type ImpactIssue struct {
+	Problem string `json:"problem"`
+	Kind    string `json:"kind"`
+	// Reference names the mapping or relation, e.g. "customers.email" or the relation name
+	Reference string `json:"reference"`
+	Message   string `json:"message"`
+	// GlobalTables lists the global tables whose queries are affected
+	GlobalTables []string `json:"globalTables"`
+}
+
+// ImpactReport lists every broken mapping and relation and the global tables they degrade
+type ImpactReport struct {
+	Issues         []ImpactIssue `json:"issues"`
+	DegradedTables []string      `json:"degradedTables"`
+}
+
+// AnalyzeImpact checks every table mapping, column mapping and table relation against the
+// stored physical metadata
+func AnalyzeImpact(store storage.MetadataStorage) (*ImpactReport, error) {
+	a := &impactAnalyzer{
+		store:   store,
+		report:  &ImpactReport{Issues: []ImpactIssue{}, DegradedTables: []string{}},
+		columns: make(map[string]map[string]*models.Column),
+	}
+
+	tables, err := store.ListGlobalTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list global tables: %w", err)
+	}
+	for _, table := range tables {
+		if err := a.checkMappings(table.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.checkRelations(); err != nil {
+		return nil, err
+	}
+
+	degraded := make(map[string]bool)
+	for _, issue := range a.report.Issues {
+		for _, name := range issue.GlobalTables {
+			degraded[name] = true
+		}
+	}
+	for name := range degraded {
+		a.report.DegradedTables = append(a.report.DegradedTables, name)
+	}
+	sort.Strings(a.report.DegradedTables)
+	return a.report, nil
+}
+
+type impactAnalyzer struct {
+	store  storage.MetadataStorage
+	report *ImpactReport
+
+	// columns caches the physical columns of each table by "catalog.schema.table";
+	// nil means the table does not exist
+	columns map[string]map[string]*models.Column
+}
+
+func (a *impactAnalyzer) add(problem, kind, reference, message string, globalTables ...string) {
+	a.report.Issues = append(a.report.Issues, ImpactIssue{
+		Problem:      problem,
+		Kind:         kind,
+		Reference:    reference,
+		Message:      message,
+		GlobalTables: globalTables,
+	})
+}
+
+// physicalColumns returns the stored columns of a physical table, or nil when the table does not exist
+func (a *impactAnalyzer) physicalColumns(catalog, schema, table string) (map[string]*models.Column, error) {
+	path := joinPath(catalog, schema, table)
+	if columns, cached := a.columns[path]; cached {
+		return columns, nil
+	}
+
+	var columns map[string]*models.Column
+	if _, err := a.store.GetTable(catalog, schema, table); err == nil {
+		list, err := a.store.ListColumns(catalog, schema, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list columns of '%s': %w", path, err)
+		}
+		columns = make(map[string]*models.Column, len(list))
+		for _, column := range list {
+			columns[column.Name] = column
+		}
+	}
+	a.columns[path] = columns
+	return columns, nil
+}
+
+// checkMappings checks a global table's table mappings and the column mappings of its columns
+func (a *impactAnalyzer) checkMappings(globalTable string) error {
+	tableMappings, err := a.store.ListTableMappings(globalTable)
+	if err != nil {
+		return fmt.Errorf("failed to list table mappings of '%s': %w", globalTable, err)
+	}
+	for _, mapping := range tableMappings {
+		columns, err := a.physicalColumns(mapping.CatalogName, mapping.SchemaName, mapping.TableName)
+		if err != nil {
+			return err
+		}
+		if columns == nil {
+			path := joinPath(mapping.CatalogName, mapping.SchemaName, mapping.TableName)
+			a.add(ProblemMissingTable, KindTableMapping, globalTable+" -> "+path,
+				fmt.Sprintf("global table '%s' is mapped to table '%s', which no longer exists", globalTable, path), globalTable)
+		}
+	}
+
+	globalColumns, err := a.store.ListGlobalColumns(globalTable)
+	if err != nil {
+		return fmt.Errorf("failed to list columns of '%s': %w", globalTable, err)
+	}
+	for _, globalColumn := range globalColumns {
+		columnMappings, err := a.store.ListColumnMappings(globalTable, globalColumn.Name)
+		if err != nil {
+			return fmt.Errorf("failed to list column mappings of '%s.%s': %w", globalTable, globalColumn.Name, err)
+		}
+		for _, mapping := range columnMappings {
+			if err := a.checkColumnMapping(globalColumn, mapping); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *impactAnalyzer) checkColumnMapping(globalColumn *models.GlobalColumn, mapping *models.ColumnMapping) error {
+	globalTable := globalColumn.GlobalTableName
+	path := joinPath(mapping.CatalogName, mapping.SchemaName, mapping.TableName, mapping.ColumnName)
+	reference := joinPath(globalTable, globalColumn.Name) + " -> " + path
+
+	columns, err := a.physicalColumns(mapping.CatalogName, mapping.SchemaName, mapping.TableName)
+	if err != nil {
+		return err
+	}
+	if columns == nil {
+		a.add(ProblemMissingTable, KindColumnMapping, reference,
+			fmt.Sprintf("column '%s.%s' is mapped to '%s', whose table no longer exists", globalTable, globalColumn.Name, path), globalTable)
+		return nil
+	}
+
+	column, exists := columns[mapping.ColumnName]
+	if !exists {
+		a.add(ProblemMissingColumn, KindColumnMapping, reference,
+			fmt.Sprintf("column '%s.%s' is mapped to '%s', which no longer exists", globalTable, globalColumn.Name, path), globalTable)
+		return nil
+	}
+
+	if !compatibleTypes(globalColumn.DataType, column.DataType) {
+		a.add(ProblemTypeMismatch, KindColumnMapping, reference,
+			fmt.Sprintf("column '%s.%s' is declared as %s but '%s' is %s", globalTable, globalColumn.Name, globalColumn.DataType, path, column.DataType), globalTable)
+	}
+	return nil
+}
+
+// checkRelations checks the sources and join columns of every table relation. An issue in a
+// relation affects the global table named after it and after every relation that nests it.
+func (a *impactAnalyzer) checkRelations() error {
+	relations, err := a.store.ListTableRelations()
+	if err != nil {
+		return fmt.Errorf("failed to list table relations: %w", err)
+	}
+
+	byID := make(map[string]*models.TableRelation, len(relations))
+	nestedIn := make(map[string][]string) // relation ID -> IDs of relations using it as a source
+	for _, relation := range relations {
+		byID[relation.ID] = relation
+		for _, source := range []models.TableSource{relation.LeftTable, relation.RightTable} {
+			if source.Type == "relation" {
+				nestedIn[source.RelationID] = append(nestedIn[source.RelationID], relation.ID)
+			}
+		}
+	}
+
+	affected := func(id string) []string {
+		var names []string
+		seen := map[string]bool{}
+		queue := []string{id}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if seen[current] {
+				continue
+			}
+			seen[current] = true
+			if relation, ok := byID[current]; ok {
+				if _, err := a.store.GetGlobalTable(relation.Name); err == nil {
+					names = append(names, relation.Name)
+				}
+			}
+			queue = append(queue, nestedIn[current]...)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	for _, relation := range relations {
+		if err := a.checkRelation(relation, byID, affected(relation.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *impactAnalyzer) checkRelation(relation *models.TableRelation, byID map[string]*models.TableRelation, globalTables []string) error {
+	sides := []struct {
+		name   string
+		source models.TableSource
+	}{{"left", relation.LeftTable}, {"right", relation.RightTable}}
+
+	// Columns of physical sides, nil when the side is a relation or is missing
+	sideColumns := make([]map[string]*models.Column, len(sides))
+	for i, side := range sides {
+		if side.source.Type == "relation" {
+			if _, exists := byID[side.source.RelationID]; !exists {
+				a.add(ProblemMissingRelation, KindRelation, relation.Name,
+					fmt.Sprintf("relation '%s' uses relation '%s' as its %s source, which no longer exists", relation.Name, side.source.RelationID, side.name), globalTables...)
+			}
+			continue
+		}
+
+		columns, err := a.physicalColumns(side.source.Catalog, side.source.Schema, side.source.Table)
+		if err != nil {
+			return err
+		}
+		if columns == nil {
+			path := joinPath(side.source.Catalog, side.source.Schema, side.source.Table)
+			a.add(ProblemMissingTable, KindRelation, relation.Name,
+				fmt.Sprintf("relation '%s' uses table '%s' as its %s source, which no longer exists", relation.Name, path, side.name), globalTables...)
+			continue
+		}
+		sideColumns[i] = columns
+	}
+
+	if relation.RelationType != "JOIN" {
+		return nil
+	}
+
+	for _, key := range relation.JoinKeys() {
+		names := []string{key.Left, key.Right}
+		keyColumns := make([]*models.Column, len(sides))
+		for i, side := range sides {
+			if sideColumns[i] == nil {
+				continue
+			}
+			column, exists := sideColumns[i][names[i]]
+			if !exists {
+				path := joinPath(side.source.Catalog, side.source.Schema, side.source.Table, names[i])
+				a.add(ProblemMissingColumn, KindRelation, relation.Name,
+					fmt.Sprintf("relation '%s' joins on '%s', which no longer exists", relation.Name, path), globalTables...)
+				continue
+			}
+			keyColumns[i] = column
+		}
+
+		if keyColumns[0] != nil && keyColumns[1] != nil && !compatibleTypes(keyColumns[0].DataType, keyColumns[1].DataType) {
+			a.add(ProblemTypeMismatch, KindRelation, relation.Name,
+				fmt.Sprintf("relation '%s' joins %s (%s) with %s (%s)", relation.Name,
+					key.Left, keyColumns[0].DataType, key.Right, keyColumns[1].DataType), globalTables...)
+		}
+	}
+	return nil
+}
+
+// typeFamily groups a type name with the types it can be compared and unioned with.
+// Unknown types return "", which is compatible with anything.
+func typeFamily(dataType string) string {
+	base := strings.ToLower(strings.TrimSpace(dataType))
+	if open := strings.IndexAny(base, "( "); open >= 0 {
+		base = base[:open]
+	}
+
+	switch base {
+	case "tinyint", "smallint", "integer", "int", "bigint", "real", "double", "float", "decimal", "numeric", "number":
+		return "number"
+	case "varchar", "char", "string", "text", "json", "uuid":
+		return "string"
+	case "boolean", "bool":
+		return "boolean"
+	case "date":
+		return "date"
+	case "timestamp", "datetime":
+		return "timestamp"
+	case "time":
+		return "time"
+	case "varbinary", "binary":
+		return "binary"
+	case "array", "map", "row":
+		return base
+	}
+	return ""
+}
+
+// compatibleTypes reports whether two types can hold the same values; an empty or unknown type is compatible with anything
+func compatibleTypes(a, b string) bool {
+	familyA, familyB := typeFamily(a), typeFamily(b)
+	return familyA == "" || familyB == "" || familyA == familyB
+}
@@ -0,0 +1,227 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	gosync "sync"
+	"time"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
+	"github.com/robfig/cron/v3"
+)
+
+// Sync job states
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// What started a sync job
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+	TriggerStartup  = "startup"
+)
+
+var (
+	// ErrSyncRunning is returned when a sync is started while another one is running
+	ErrSyncRunning = errors.New("a sync is already running")
+	// ErrJobNotFound is returned for unknown, or no longer kept, job IDs
+	ErrJobNotFound = errors.New("sync job not found")
+	// ErrJobFinished is returned when cancelling a job that is no longer running
+	ErrJobFinished = errors.New("sync job has already finished")
+)
+
+// maxJobHistory is how many jobs are kept for GET /sync/jobs; older ones are forgotten
+const maxJobHistory = 20
+
+// SyncJob is a full sync running in the background
+type SyncJob struct {
+	ID      string `json:"id"`
+	Trigger string `json:"trigger"`
+	Status  string `json:"status"`
+	// Progress counts the catalogs, tables and columns discovered so far
+	Progress   discovery.WalkProgress `json:"progress"`
+	StartedAt  time.Time              `json:"startedAt"`
+	FinishedAt *time.Time             `json:"finishedAt,omitempty"`
+	// Error says why the job failed or was cancelled
+	Error string `json:"error,omitempty"`
+	// Errors lists the parts of the metadata that could not be synced
+	Errors []string `json:"errors"`
+	// Report lists the changes made, once the job has succeeded
+	Report *SyncReport `json:"report,omitempty"`
+}
+
+// JobManager runs full syncs as background jobs, one at a time, and keeps their recent history
+type JobManager struct {
+	sync MetadataSync
+
+	mu      gosync.Mutex
+	jobs    []*SyncJob // oldest first
+	running *SyncJob
+	cancel  context.CancelFunc // cancels the running job
+}
+
+func NewJobManager(sync MetadataSync) *JobManager {
+	return &JobManager{
+		sync: sync,
+	}
+}
+
+// Start starts a full sync in the background. If a sync is already running, it returns that
+// job with ErrSyncRunning, so two syncs never run at once.
+func (m *JobManager) Start(trigger string) (SyncJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running != nil {
+		return m.snapshot(m.running), ErrSyncRunning
+	}
+
+	job := &SyncJob{
+		ID:        fmt.Sprintf("sync_%d", time.Now().UnixNano()),
+		Trigger:   trigger,
+		Status:    JobRunning,
+		StartedAt: time.Now().UTC(),
+		Errors:    []string{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.running = job
+	m.cancel = cancel
+	m.jobs = append(m.jobs, job)
+	if len(m.jobs) > maxJobHistory {
+		m.jobs = m.jobs[len(m.jobs)-maxJobHistory:]
+	}
+
+	log.Printf("Sync job %s started (%s)", job.ID, trigger)
+	go m.run(ctx, job)
+	return m.snapshot(job), nil
+}
+
+func (m *JobManager) run(ctx context.Context, job *SyncJob) {
+	report, err := m.sync.SyncAllWithProgress(ctx, func(progress discovery.WalkProgress) {
+		m.mu.Lock()
+		job.Progress = progress
+		m.mu.Unlock()
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	switch {
+	case ctx.Err() != nil:
+		job.Status = JobCancelled
+		job.Error = "sync was cancelled"
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobSucceeded
+		job.Report = report
+		job.Errors = append(job.Errors, report.Errors...)
+	}
+
+	m.cancel()
+	m.running = nil
+	m.cancel = nil
+
+	if job.Status == JobSucceeded {
+		log.Printf("Sync job %s succeeded: %s", job.ID, report.Describe())
+	} else {
+		log.Printf("Sync job %s %s: %s", job.ID, job.Status, job.Error)
+	}
+}
+
+// Get returns a job by ID
+func (m *JobManager) Get(id string) (SyncJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return m.snapshot(job), nil
+		}
+	}
+	return SyncJob{}, ErrJobNotFound
+}
+
+// List returns the kept jobs, newest first
+func (m *JobManager) List() []SyncJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]SyncJob, 0, len(m.jobs))
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, m.snapshot(m.jobs[i]))
+	}
+	return jobs
+}
+
+// Cancel cancels a running job. The job stops at the next catalog, schema or table and is
+// marked cancelled; storage keeps whatever was synced before that.
+func (m *JobManager) Cancel(id string) (SyncJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running != nil && m.running.ID == id {
+		m.cancel()
+		return m.snapshot(m.running), nil
+	}
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return m.snapshot(job), ErrJobFinished
+		}
+	}
+	return SyncJob{}, ErrJobNotFound
+}
+
+// snapshot copies a job so it can be read without holding the lock; m.mu must be held
+func (m *JobManager) snapshot(job *SyncJob) SyncJob {
+	copied := *job
+	copied.Errors = append([]string{}, job.Errors...)
+	return copied
+}
+
+// Schedule tells when a scheduled sync runs next
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// ParseSchedule parses a standard five-field cron expression, e.g. "*/30 * * * *", or a
+// descriptor such as "@hourly" or "@every 15m"
+func ParseSchedule(spec string) (Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync schedule '%s': %w", spec, err)
+	}
+	return schedule, nil
+}
+
+// RunSchedule starts a sync each time the schedule fires until ctx is done. A run that fires
+// while a sync is still going is skipped.
+func (m *JobManager) RunSchedule(ctx context.Context, schedule Schedule) {
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if job, err := m.Start(TriggerSchedule); errors.Is(err, ErrSyncRunning) {
+			log.Printf("Skipping scheduled sync: job %s is still running", job.ID)
+		}
+	}
+}
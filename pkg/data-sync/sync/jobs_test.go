@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// blockingDiscovery holds schema discovery until release is closed or the sync is cancelled
+type blockingDiscovery struct {
+	*fakeDiscovery
+	release chan struct{}
+}
+
+func (d *blockingDiscovery) DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error) {
+	select {
+	case <-d.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return d.fakeDiscovery.DiscoverSchemas(ctx, catalogName)
+}
+
+// waitForJob polls a job until it is no longer running
+func waitForJob(t *testing.T, jobs *JobManager, id string) SyncJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := jobs.Get(id)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if job.Status != JobRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not finish", id)
+	return SyncJob{}
+}
+
+func TestJobManager_RunsOneSyncAtATime(t *testing.T) {
+	discovery := &blockingDiscovery{
+		fakeDiscovery: &fakeDiscovery{tables: map[string]map[string]string{
+			"pg.public.customers": {"id": "bigint"},
+		}},
+		release: make(chan struct{}),
+	}
+	store := storage.NewMemoryMetadataStorage()
+	jobs := NewJobManager(NewMetadataSync(discovery, store, 0))
+
+	job, err := jobs.Start(TriggerManual)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if job.Status != JobRunning || job.Trigger != TriggerManual {
+		t.Fatalf("Expected a running manual job, got %+v", job)
+	}
+
+	running, err := jobs.Start(TriggerSchedule)
+	if !errors.Is(err, ErrSyncRunning) || running.ID != job.ID {
+		t.Fatalf("Expected ErrSyncRunning with job %s, got %v, %s", job.ID, err, running.ID)
+	}
+
+	close(discovery.release)
+	finished := waitForJob(t, jobs, job.ID)
+	if finished.Status != JobSucceeded || finished.FinishedAt == nil || finished.Report == nil {
+		t.Fatalf("Expected a succeeded job with a report, got %+v", finished)
+	}
+	if finished.Progress.CatalogsDone != 1 || finished.Progress.Tables != 1 || finished.Progress.Columns != 1 {
+		t.Fatalf("Unexpected progress: %+v", finished.Progress)
+	}
+	if _, err := store.GetTable("pg", "public", "customers"); err != nil {
+		t.Fatalf("Expected the job to sync pg.public.customers, got %v", err)
+	}
+
+	if _, err := jobs.Cancel(job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("Expected ErrJobFinished, got %v", err)
+	}
+
+	next, err := jobs.Start(TriggerManual)
+	if err != nil {
+		t.Fatalf("Expected a new job to start once the first finished, got %v", err)
+	}
+	waitForJob(t, jobs, next.ID)
+	if list := jobs.List(); len(list) != 2 || list[0].ID != next.ID {
+		t.Fatalf("Expected 2 jobs, newest first, got %+v", list)
+	}
+}
+
+func TestJobManager_Cancel(t *testing.T) {
+	discovery := &blockingDiscovery{
+		fakeDiscovery: &fakeDiscovery{tables: map[string]map[string]string{
+			"pg.public.customers": {"id": "bigint"},
+		}},
+		release: make(chan struct{}),
+	}
+	jobs := NewJobManager(NewMetadataSync(discovery, storage.NewMemoryMetadataStorage(), 0))
+
+	job, err := jobs.Start(TriggerManual)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := jobs.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+
+	cancelled := waitForJob(t, jobs, job.ID)
+	if cancelled.Status != JobCancelled || cancelled.Error == "" {
+		t.Fatalf("Expected a cancelled job, got %+v", cancelled)
+	}
+	if _, err := jobs.Get("sync_unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("*/30 * * * *")
+	if err != nil {
+		t.Fatalf("ParseSchedule failed: %v", err)
+	}
+	from := time.Date(2024, 1, 31, 12, 10, 0, 0, time.UTC)
+	if next := schedule.Next(from); !next.Equal(time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the next run at 12:30, got %v", next)
+	}
+
+	if _, err := ParseSchedule("@every 15m"); err != nil {
+		t.Fatalf("Expected @every to parse, got %v", err)
+	}
+	if _, err := ParseSchedule("every hour"); err == nil {
+		t.Fatal("Expected an error for an invalid schedule")
+	}
+}
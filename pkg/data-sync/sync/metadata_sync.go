@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -8,199 +9,188 @@ import (
 	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
 )
 
+// MetadataSync keeps stored metadata in line with what Trino reports. Each sync compares the
+// discovered metadata with storage, adding, updating and deleting entries so storage matches,
+// and reports the changes it made.
 type MetadataSync interface {
-	SyncCatalogs() error
-	SyncSchemas(catalogName string) error
-	SyncTables(catalogName, schemaName string) error
-	SyncColumns(catalogName, schemaName, tableName string) error
-	SyncAll() error
-	CheckSyncStatus() (SyncStatus, error)
+	SyncCatalogs(ctx context.Context) (*SyncReport, error)
+	SyncSchemas(ctx context.Context, catalogName string) (*SyncReport, error)
+	SyncTables(ctx context.Context, catalogName, schemaName string) (*SyncReport, error)
+	SyncColumns(ctx context.Context, catalogName, schemaName, tableName string) (*SyncReport, error)
+	SyncAll(ctx context.Context) (*SyncReport, error)
+	// SyncAllWithProgress is SyncAll, sending discovery progress to progress as each catalog is walked
+	SyncAllWithProgress(ctx context.Context, progress func(discovery.WalkProgress)) (*SyncReport, error)
+	// CheckSyncStatus reports what SyncAll would change, without changing anything
+	CheckSyncStatus(ctx context.Context) (SyncStatus, error)
+	// Impact reports the global mappings and relations broken by the stored metadata
+	Impact() (*ImpactReport, error)
 }
 
 type SyncStatus struct {
-	NeedsSync        bool   `json:"needsSync"`
-	DiscoveredCount  int    `json:"discoveredCount"`
-	StoredCount      int    `json:"storedCount"`
-	Message          string `json:"message"`
+	NeedsSync       bool   `json:"needsSync"`
+	DiscoveredCount int    `json:"discoveredCount"`
+	StoredCount     int    `json:"storedCount"`
+	Message         string `json:"message"`
+
+	// Changes lists every difference between Trino and storage, at every level
+	Changes []Change      `json:"changes"`
+	Summary ChangeSummary `json:"summary"`
+	Errors  []string      `json:"errors,omitempty"`
 }
 
 type metadataSync struct {
 	discovery discovery.MetadataDiscovery
 	storage   storage.MetadataStorage
+
+	// parallelism bounds the discovery queries a full sync runs at once
+	parallelism int
 }
 
-func NewMetadataSync(discovery discovery.MetadataDiscovery, storage storage.MetadataStorage) MetadataSync {
+// NewMetadataSync creates a sync service whose full syncs run up to parallelism discovery
+// queries at once; 0 or less uses discovery.DefaultParallelism
+func NewMetadataSync(discovery discovery.MetadataDiscovery, storage storage.MetadataStorage, parallelism int) MetadataSync {
 	return &metadataSync{
-		discovery: discovery,
-		storage:   storage,
+		discovery:   discovery,
+		storage:     storage,
+		parallelism: parallelism,
 	}
 }
 
-func (s *metadataSync) SyncCatalogs() error {
-	catalogs, err := s.discovery.DiscoverCatalogs()
-	if err != nil {
-		return fmt.Errorf("failed to discover catalogs: %w", err)
-	}
+func (s *metadataSync) newReconciler(apply bool) *reconciler {
+	return &reconciler{discovery: s.discovery, storage: s.storage, apply: apply, report: newSyncReport()}
+}
 
-	for _, catalog := range catalogs {
-		if err := s.storage.UpsertCatalog(catalog); err != nil {
-			log.Printf("Warning: failed to upsert catalog '%s': %v", catalog.Name, err)
-			continue
+// walk discovers the whole metadata tree concurrently and returns a reconciler reading from it.
+// progress, if not nil, is sent each walk update after it is logged.
+func (s *metadataSync) walk(ctx context.Context, apply bool, progress func(discovery.WalkProgress)) (*reconciler, error) {
+	snapshot, err := discovery.NewWalker(s.discovery, s.parallelism).Walk(ctx, func(update discovery.WalkProgress) {
+		log.Printf("Discovery: catalog '%s' done (%d/%d catalogs, %d tables, %d columns, %d errors)",
+			update.Catalog, update.CatalogsDone, update.CatalogsTotal, update.Tables, update.Columns, update.Errors)
+		if progress != nil {
+			progress(update)
 		}
-		log.Printf("Synced catalog: %s", catalog.Name)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, cancelled(ctx)
+		}
+		return nil, fmt.Errorf("failed to discover catalogs: %w", err)
 	}
 
-	return nil
+	r := s.newReconciler(apply)
+	r.discovery = snapshot
+	return r, nil
 }
 
-func (s *metadataSync) SyncSchemas(catalogName string) error {
-	schemas, err := s.discovery.DiscoverSchemas(catalogName)
-	if err != nil {
-		return fmt.Errorf("failed to discover schemas for catalog '%s': %w", catalogName, err)
+// SyncCatalogs syncs the list of catalogs, deleting catalogs that no longer exist
+func (s *metadataSync) SyncCatalogs(ctx context.Context) (*SyncReport, error) {
+	r := s.newReconciler(true)
+	if err := r.catalogs(ctx, false); err != nil {
+		return nil, err
 	}
+	return s.finish(r.report)
+}
 
-	for _, schema := range schemas {
-		if err := s.storage.UpsertSchema(schema); err != nil {
-			log.Printf("Warning: failed to upsert schema '%s.%s': %v", catalogName, schema.Name, err)
-			continue
-		}
-		log.Printf("Synced schema: %s.%s", catalogName, schema.Name)
+// SyncSchemas syncs the schemas of a catalog, deleting schemas that no longer exist
+func (s *metadataSync) SyncSchemas(ctx context.Context, catalogName string) (*SyncReport, error) {
+	r := s.newReconciler(true)
+	if err := r.schemas(ctx, catalogName, false); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return s.finish(r.report)
 }
 
-func (s *metadataSync) SyncTables(catalogName, schemaName string) error {
-	tables, err := s.discovery.DiscoverTables(catalogName, schemaName)
-	if err != nil {
-		return fmt.Errorf("failed to discover tables for schema '%s.%s': %w", catalogName, schemaName, err)
+// SyncTables syncs the tables of a schema, deleting tables that no longer exist
+func (s *metadataSync) SyncTables(ctx context.Context, catalogName, schemaName string) (*SyncReport, error) {
+	r := s.newReconciler(true)
+	if err := r.tables(ctx, catalogName, schemaName, false); err != nil {
+		return nil, err
 	}
+	return s.finish(r.report)
+}
 
-	for _, table := range tables {
-		if err := s.storage.UpsertTable(table); err != nil {
-			log.Printf("Warning: failed to upsert table '%s.%s.%s': %v", catalogName, schemaName, table.Name, err)
-			continue
-		}
-		log.Printf("Synced table: %s.%s.%s", catalogName, schemaName, table.Name)
+// SyncColumns syncs the columns of a table, deleting columns that no longer exist
+func (s *metadataSync) SyncColumns(ctx context.Context, catalogName, schemaName, tableName string) (*SyncReport, error) {
+	r := s.newReconciler(true)
+	if err := r.columns(ctx, catalogName, schemaName, tableName); err != nil {
+		return nil, err
 	}
+	return s.finish(r.report)
+}
 
-	return nil
+// SyncAll syncs every catalog, schema, table and column, stopping as soon as ctx is done.
+// The metadata is discovered concurrently before storage is updated. Parts that fail to be
+// discovered are listed in the report's errors and left untouched.
+func (s *metadataSync) SyncAll(ctx context.Context) (*SyncReport, error) {
+	return s.SyncAllWithProgress(ctx, nil)
 }
 
-func (s *metadataSync) SyncColumns(catalogName, schemaName, tableName string) error {
-	columns, err := s.discovery.DiscoverColumns(catalogName, schemaName, tableName)
+func (s *metadataSync) SyncAllWithProgress(ctx context.Context, progress func(discovery.WalkProgress)) (*SyncReport, error) {
+	r, err := s.walk(ctx, true, progress)
 	if err != nil {
-		return fmt.Errorf("failed to discover columns for table '%s.%s.%s': %w", catalogName, schemaName, tableName, err)
+		return nil, err
 	}
-
-	for _, column := range columns {
-		if err := s.storage.UpsertColumn(column); err != nil {
-			log.Printf("Warning: failed to upsert column '%s.%s.%s.%s': %v", catalogName, schemaName, tableName, column.Name, err)
-			continue
-		}
-		log.Printf("Synced column: %s.%s.%s.%s", catalogName, schemaName, tableName, column.Name)
+	if err := r.catalogs(ctx, true); err != nil {
+		return nil, err
 	}
 
-	return nil
+	log.Printf("Full sync completed: %s", r.report.Describe())
+	return s.finish(r.report)
 }
 
-func (s *metadataSync) SyncAll() error {
-	// Sync catalogs
-	if err := s.SyncCatalogs(); err != nil {
-		return fmt.Errorf("failed to sync catalogs: %w", err)
-	}
-
-	catalogs, err := s.storage.ListCatalogs()
+// finish checks the global mappings against the synced metadata, marks the global tables they
+// break as degraded and attaches the impact to the report
+func (s *metadataSync) finish(report *SyncReport) (*SyncReport, error) {
+	impact, err := s.Impact()
 	if err != nil {
-		return fmt.Errorf("failed to list catalogs: %w", err)
+		return nil, err
 	}
-
-	totalSchemas := 0
-	totalTables := 0
-	totalColumns := 0
-
-	// Sync schemas for each catalog
-	for _, catalog := range catalogs {
-		if err := s.SyncSchemas(catalog.Name); err != nil {
-			log.Printf("Warning: failed to sync schemas for catalog '%s': %v", catalog.Name, err)
-			continue
-		}
-
-		schemas, err := s.storage.ListSchemas(catalog.Name)
-		if err != nil {
-			log.Printf("Warning: failed to list schemas for catalog '%s': %v", catalog.Name, err)
-			continue
-		}
-		totalSchemas += len(schemas)
-
-		// Sync tables for each schema
-		for _, schema := range schemas {
-			if err := s.SyncTables(catalog.Name, schema.Name); err != nil {
-				log.Printf("Warning: failed to sync tables for schema '%s.%s': %v", catalog.Name, schema.Name, err)
-				continue
-			}
-
-			tables, err := s.storage.ListTables(catalog.Name, schema.Name)
-			if err != nil {
-				log.Printf("Warning: failed to list tables for schema '%s.%s': %v", catalog.Name, schema.Name, err)
-				continue
-			}
-			totalTables += len(tables)
-
-			// Sync columns for each table
-			for _, table := range tables {
-				if err := s.SyncColumns(catalog.Name, schema.Name, table.Name); err != nil {
-					log.Printf("Warning: failed to sync columns for table '%s.%s.%s': %v", catalog.Name, schema.Name, table.Name, err)
-					continue
-				}
-
-				columns, err := s.storage.ListColumns(catalog.Name, schema.Name, table.Name)
-				if err != nil {
-					log.Printf("Warning: failed to list columns for table '%s.%s.%s': %v", catalog.Name, schema.Name, table.Name, err)
-					continue
-				}
-				totalColumns += len(columns)
-			}
-		}
+	if err := s.storage.SetDegradedGlobalTables(impact.DegradedTables); err != nil {
+		return nil, fmt.Errorf("failed to mark degraded global tables: %w", err)
+	}
+	if len(impact.Issues) > 0 {
+		log.Printf("Sync left %d global mapping issue(s), degrading %v", len(impact.Issues), impact.DegradedTables)
 	}
 
-	log.Printf("Full sync completed: %d catalogs, %d schemas, %d tables, %d columns",
-		len(catalogs), totalSchemas, totalTables, totalColumns)
-	return nil
+	report.Impact = impact
+	return report, nil
 }
 
-func (s *metadataSync) CheckSyncStatus() (SyncStatus, error) {
-	// Get stored catalogs count
+// Impact analyses the global mappings and relations against the stored physical metadata
+func (s *metadataSync) Impact() (*ImpactReport, error) {
+	return AnalyzeImpact(s.storage)
+}
+
+func (s *metadataSync) CheckSyncStatus(ctx context.Context) (SyncStatus, error) {
 	storedCatalogs, err := s.storage.ListCatalogs()
 	if err != nil {
 		return SyncStatus{}, fmt.Errorf("failed to list stored catalogs: %w", err)
 	}
 
-	// Get discovered catalogs count
-	discoveredCatalogs, err := s.discovery.DiscoverCatalogs()
+	r, err := s.walk(ctx, false, nil)
 	if err != nil {
-		return SyncStatus{}, fmt.Errorf("failed to discover catalogs: %w", err)
+		return SyncStatus{}, err
 	}
-
-	storedCount := len(storedCatalogs)
-	discoveredCount := len(discoveredCatalogs)
-
-	// Check if sync is needed
-	needsSync := false
-	message := "Metadata is up to date"
-
-	if storedCount == 0 {
-		needsSync = true
-		message = "No metadata found. Initial sync required"
-	} else if discoveredCount != storedCount {
-		needsSync = true
-		message = fmt.Sprintf("Schema changes detected: %d catalogs in database, %d in storage", discoveredCount, storedCount)
+	if err := r.catalogs(ctx, true); err != nil {
+		return SyncStatus{}, err
 	}
+	report := r.report
+
+	discoveredCount := len(storedCatalogs) + report.countCatalogs(ChangeAdded) - report.countCatalogs(ChangeRemoved)
 
-	return SyncStatus{
-		NeedsSync:       needsSync,
+	status := SyncStatus{
+		NeedsSync:       len(report.Changes) > 0,
 		DiscoveredCount: discoveredCount,
-		StoredCount:     storedCount,
-		Message:         message,
-	}, nil
+		StoredCount:     len(storedCatalogs),
+		Message:         "Metadata is up to date",
+		Changes:         report.Changes,
+		Summary:         report.Summary,
+		Errors:          report.Errors,
+	}
+	if len(storedCatalogs) == 0 && discoveredCount > 0 {
+		status.Message = "No metadata found. Initial sync required"
+	} else if status.NeedsSync {
+		status.Message = "Metadata changes detected: " + report.Describe()
+	}
+	return status, nil
 }
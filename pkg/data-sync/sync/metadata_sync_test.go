@@ -0,0 +1,279 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// fakeDiscovery serves a metadata tree of "catalog.schema.table" -> column -> data type.
+// Paths listed in failing return an error when their children are discovered.
+type fakeDiscovery struct {
+	tables  map[string]map[string]string
+	failing map[string]bool
+}
+
+func (d *fakeDiscovery) children(prefix string, depth int) ([]string, error) {
+	if d.failing[prefix] {
+		return nil, fmt.Errorf("discovery failed for %s", prefix)
+	}
+	seen := map[string]bool{}
+	var names []string
+	for path := range d.tables {
+		parts := strings.Split(path, ".")
+		if prefix != "" && strings.Join(parts[:depth], ".") != prefix {
+			continue
+		}
+		if name := parts[depth]; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *fakeDiscovery) DiscoverCatalogs(ctx context.Context) ([]*models.Catalog, error) {
+	names, err := d.children("", 0)
+	var catalogs []*models.Catalog
+	for _, name := range names {
+		catalogs = append(catalogs, &models.Catalog{Name: name})
+	}
+	return catalogs, err
+}
+
+func (d *fakeDiscovery) DiscoverSchemas(ctx context.Context, catalogName string) ([]*models.Schema, error) {
+	names, err := d.children(catalogName, 1)
+	var schemas []*models.Schema
+	for _, name := range names {
+		schemas = append(schemas, &models.Schema{CatalogName: catalogName, Name: name})
+	}
+	return schemas, err
+}
+
+func (d *fakeDiscovery) DiscoverTables(ctx context.Context, catalogName, schemaName string) ([]*models.Table, error) {
+	names, err := d.children(catalogName+"."+schemaName, 2)
+	var tables []*models.Table
+	for _, name := range names {
+		tables = append(tables, &models.Table{CatalogName: catalogName, SchemaName: schemaName, Name: name})
+	}
+	return tables, err
+}
+
+func (d *fakeDiscovery) DiscoverColumns(ctx context.Context, catalogName, schemaName, tableName string) ([]*models.Column, error) {
+	path := catalogName + "." + schemaName + "." + tableName
+	if d.failing[path] {
+		return nil, fmt.Errorf("discovery failed for %s", path)
+	}
+	var columns []*models.Column
+	for name, dataType := range d.tables[path] {
+		columns = append(columns, &models.Column{CatalogName: catalogName, SchemaName: schemaName, TableName: tableName, Name: name, DataType: dataType})
+	}
+	return columns, nil
+}
+
+func changeList(changes []Change) string {
+	lines := make([]string, len(changes))
+	for i, change := range changes {
+		lines[i] = change.String()
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func TestSyncAll_AppliesDiff(t *testing.T) {
+	discovery := &fakeDiscovery{tables: map[string]map[string]string{
+		"pg.public.customers": {"id": "bigint", "email": "varchar"},
+		"pg.public.orders":    {"id": "bigint"},
+		"mysql.shop.items":    {"sku": "varchar"},
+	}}
+	store := storage.NewMemoryMetadataStorage()
+	sync := NewMetadataSync(discovery, store, 0)
+
+	report, err := sync.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if report.Summary.Added != 11 || report.Summary.Removed != 0 {
+		t.Fatalf("Expected 11 additions on the first sync, got %+v", report.Summary)
+	}
+
+	// The source drops a table, a column and a catalog, adds a column and changes a type
+	discovery.tables = map[string]map[string]string{
+		"pg.public.customers": {"id": "varchar", "name": "varchar"},
+	}
+
+	status, err := sync.CheckSyncStatus(context.Background())
+	if err != nil {
+		t.Fatalf("CheckSyncStatus failed: %v", err)
+	}
+	expected := strings.Join([]string{
+		"added column pg.public.customers.name",
+		"changed column pg.public.customers.id: bigint -> varchar",
+		"removed catalog mysql",
+		"removed column pg.public.customers.email",
+		"removed table pg.public.orders",
+	}, "\n")
+	if !status.NeedsSync || changeList(status.Changes) != expected {
+		t.Fatalf("Unexpected status changes:\n  got:      %s\n  expected: %s", changeList(status.Changes), expected)
+	}
+	if _, err := store.GetTable("pg", "public", "orders"); err != nil {
+		t.Fatalf("Expected CheckSyncStatus to leave storage untouched, got %v", err)
+	}
+
+	report, err = sync.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if changeList(report.Changes) != expected {
+		t.Fatalf("Unexpected sync changes:\n  got:      %s\n  expected: %s", changeList(report.Changes), expected)
+	}
+
+	if _, err := store.GetCatalog("mysql"); err == nil {
+		t.Fatal("Expected catalog mysql to be pruned")
+	}
+	if _, err := store.GetTable("pg", "public", "orders"); err == nil {
+		t.Fatal("Expected table pg.public.orders to be pruned")
+	}
+	column, err := store.GetColumn("pg", "public", "customers", "id")
+	if err != nil || column.DataType != "varchar" {
+		t.Fatalf("Expected column id to be updated to varchar, got %v, %v", column, err)
+	}
+
+	status, err = sync.CheckSyncStatus(context.Background())
+	if err != nil {
+		t.Fatalf("CheckSyncStatus failed: %v", err)
+	}
+	if status.NeedsSync || len(status.Changes) != 0 {
+		t.Fatalf("Expected no changes after syncing, got %v", status.Changes)
+	}
+}
+
+func TestSyncAll_KeepsMetadataThatFailsDiscovery(t *testing.T) {
+	discovery := &fakeDiscovery{tables: map[string]map[string]string{
+		"pg.public.customers": {"id": "bigint"},
+		"pg.sales.orders":     {"id": "bigint"},
+	}}
+	store := storage.NewMemoryMetadataStorage()
+	sync := NewMetadataSync(discovery, store, 0)
+
+	if _, err := sync.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	// Listing pg.sales fails, so its tables must not be treated as dropped
+	discovery.failing = map[string]bool{"pg.sales": true}
+	report, err := sync.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if len(report.Errors) != 1 || len(report.Changes) != 0 {
+		t.Fatalf("Expected one error and no changes, got %v and %v", report.Errors, report.Changes)
+	}
+	if _, err := store.GetTable("pg", "sales", "orders"); err != nil {
+		t.Fatalf("Expected table pg.sales.orders to be kept, got %v", err)
+	}
+}
+
+func TestSyncAll_ReportsImpactOnGlobalMappings(t *testing.T) {
+	discovery := &fakeDiscovery{tables: map[string]map[string]string{
+		"pg.public.customers": {"id": "bigint", "email": "varchar"},
+		"pg.public.orders":    {"id": "bigint", "customer_id": "bigint"},
+		"mysql.shop.clients":  {"id": "integer"},
+	}}
+	store := storage.NewMemoryMetadataStorage()
+	sync := NewMetadataSync(discovery, store, 0)
+
+	if _, err := sync.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+
+	setup := []error{
+		store.CreateGlobalTable(&models.GlobalTable{Name: "customers"}),
+		store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "customers", Name: "id", DataType: "bigint"}),
+		store.CreateGlobalColumn(&models.GlobalColumn{GlobalTableName: "customers", Name: "email", DataType: "varchar"}),
+		store.CreateTableMapping(&models.TableMapping{GlobalTableName: "customers", CatalogName: "pg", SchemaName: "public", TableName: "customers"}),
+		store.CreateTableMapping(&models.TableMapping{GlobalTableName: "customers", CatalogName: "mysql", SchemaName: "shop", TableName: "clients"}),
+		store.CreateColumnMapping(&models.ColumnMapping{GlobalTableName: "customers", GlobalColumnName: "id", CatalogName: "pg", SchemaName: "public", TableName: "customers", ColumnName: "id"}),
+		store.CreateColumnMapping(&models.ColumnMapping{GlobalTableName: "customers", GlobalColumnName: "id", CatalogName: "mysql", SchemaName: "shop", TableName: "clients", ColumnName: "id"}),
+		store.CreateColumnMapping(&models.ColumnMapping{GlobalTableName: "customers", GlobalColumnName: "email", CatalogName: "pg", SchemaName: "public", TableName: "customers", ColumnName: "email"}),
+		store.CreateGlobalTable(&models.GlobalTable{Name: "customer_orders"}),
+		store.CreateTableRelation(&models.TableRelation{
+			ID:           "rel-1",
+			Name:         "customer_orders",
+			LeftTable:    models.TableSource{Type: "physical", Catalog: "pg", Schema: "public", Table: "customers"},
+			RightTable:   models.TableSource{Type: "physical", Catalog: "pg", Schema: "public", Table: "orders"},
+			RelationType: "JOIN",
+			JoinColumn:   &models.JoinColumn{Left: "id", Right: "customer_id"},
+		}),
+	}
+	for _, err := range setup {
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	impact, err := sync.Impact()
+	if err != nil {
+		t.Fatalf("Impact failed: %v", err)
+	}
+	if len(impact.Issues) != 0 || len(impact.DegradedTables) != 0 {
+		t.Fatalf("Expected no impact before drift, got %+v", impact)
+	}
+
+	// The source renames email, turns customers.id into a varchar and drops the mysql table
+	discovery.tables = map[string]map[string]string{
+		"pg.public.customers": {"id": "varchar", "email_address": "varchar"},
+		"pg.public.orders":    {"id": "bigint", "customer_id": "bigint"},
+	}
+	report, err := sync.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if report.Impact == nil {
+		t.Fatal("Expected the sync report to include the impact")
+	}
+
+	var problems []string
+	for _, issue := range report.Impact.Issues {
+		problems = append(problems, issue.Problem+" "+issue.Kind+" "+issue.Reference)
+	}
+	sort.Strings(problems)
+	expected := strings.Join([]string{
+		"missing_column columnMapping customers.email -> pg.public.customers.email",
+		"missing_table columnMapping customers.id -> mysql.shop.clients.id",
+		"missing_table tableMapping customers -> mysql.shop.clients",
+		"type_mismatch columnMapping customers.id -> pg.public.customers.id",
+		"type_mismatch relation customer_orders",
+	}, "\n")
+	if strings.Join(problems, "\n") != expected {
+		t.Fatalf("Unexpected impact issues:\n  got:      %s\n  expected: %s", strings.Join(problems, "\n"), expected)
+	}
+	if strings.Join(report.Impact.DegradedTables, ",") != "customer_orders,customers" {
+		t.Fatalf("Expected customer_orders and customers to be degraded, got %v", report.Impact.DegradedTables)
+	}
+
+	table, err := store.GetGlobalTable("customers")
+	if err != nil || !table.Degraded {
+		t.Fatalf("Expected global table customers to be marked degraded, got %+v, %v", table, err)
+	}
+
+	// Restoring the source clears the degraded flag on the next sync
+	discovery.tables = map[string]map[string]string{
+		"pg.public.customers": {"id": "bigint", "email": "varchar"},
+		"pg.public.orders":    {"id": "bigint", "customer_id": "bigint"},
+		"mysql.shop.clients":  {"id": "integer"},
+	}
+	if _, err := sync.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	table, err = store.GetGlobalTable("customers")
+	if err != nil || table.Degraded {
+		t.Fatalf("Expected global table customers to be restored, got %+v, %v", table, err)
+	}
+}
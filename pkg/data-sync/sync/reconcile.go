@@ -0,0 +1,278 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+
+	"github.com/guilherme096/data-sync/pkg/data-sync/discovery"
+	"github.com/guilherme096/data-sync/pkg/data-sync/models"
+	"github.com/guilherme096/data-sync/pkg/data-sync/storage"
+)
+
+// reconciler compares discovered metadata with stored metadata level by level, recording
+// every difference in a report and, when apply is set, updating storage to match
+type reconciler struct {
+	discovery discovery.MetadataDiscovery
+	storage   storage.MetadataStorage
+	apply     bool
+	report    *SyncReport
+}
+
+// fail records a part of the metadata that could not be synced and is left as it is
+func (r *reconciler) fail(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("Warning: %s", message)
+	r.report.Errors = append(r.report.Errors, message)
+}
+
+func (r *reconciler) record(change Change) {
+	if r.apply {
+		log.Printf("Sync: %s", change)
+	}
+	r.report.add(change)
+}
+
+func cancelled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("sync cancelled: %w", err)
+	}
+	return nil
+}
+
+// catalogs reconciles the catalogs, and everything below them when recurse is set.
+// Only a failure to discover catalogs at all, or cancellation, is returned as an error.
+func (r *reconciler) catalogs(ctx context.Context, recurse bool) error {
+	discovered, err := r.discovery.DiscoverCatalogs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to discover catalogs: %w", err)
+	}
+	stored, err := r.storage.ListCatalogs()
+	if err != nil {
+		return fmt.Errorf("failed to list catalogs: %w", err)
+	}
+
+	storedByName := make(map[string]*models.Catalog, len(stored))
+	for _, catalog := range stored {
+		storedByName[catalog.Name] = catalog
+	}
+	discoveredNames := make(map[string]bool, len(discovered))
+
+	for _, catalog := range discovered {
+		discoveredNames[catalog.Name] = true
+		if existing, ok := storedByName[catalog.Name]; !ok {
+			r.record(Change{Type: ChangeAdded, Kind: KindCatalog, Path: catalog.Name})
+		} else if !maps.Equal(existing.Metadata, catalog.Metadata) {
+			r.record(metadataChange(KindCatalog, catalog.Name, existing.Metadata, catalog.Metadata))
+		}
+		if r.apply {
+			if err := r.storage.UpsertCatalog(catalog); err != nil {
+				r.fail("failed to store catalog '%s': %v", catalog.Name, err)
+				continue
+			}
+		}
+
+		if recurse {
+			if err := cancelled(ctx); err != nil {
+				return err
+			}
+			if err := r.schemas(ctx, catalog.Name, true); err != nil {
+				if ctx.Err() != nil {
+					return cancelled(ctx)
+				}
+				r.fail("%v", err)
+			}
+		}
+	}
+
+	for _, catalog := range stored {
+		if discoveredNames[catalog.Name] {
+			continue
+		}
+		r.record(Change{Type: ChangeRemoved, Kind: KindCatalog, Path: catalog.Name})
+		if r.apply {
+			if err := r.storage.DeleteCatalog(catalog.Name); err != nil {
+				r.fail("failed to delete catalog '%s': %v", catalog.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// schemas reconciles the schemas of a catalog, and everything below them when recurse is set
+func (r *reconciler) schemas(ctx context.Context, catalogName string, recurse bool) error {
+	discovered, err := r.discovery.DiscoverSchemas(ctx, catalogName)
+	if err != nil {
+		return fmt.Errorf("failed to discover schemas for catalog '%s': %w", catalogName, err)
+	}
+	stored, err := r.storage.ListSchemas(catalogName)
+	if err != nil {
+		return fmt.Errorf("failed to list schemas for catalog '%s': %w", catalogName, err)
+	}
+
+	storedByName := make(map[string]*models.Schema, len(stored))
+	for _, schema := range stored {
+		storedByName[schema.Name] = schema
+	}
+	discoveredNames := make(map[string]bool, len(discovered))
+
+	for _, schema := range discovered {
+		discoveredNames[schema.Name] = true
+		path := joinPath(catalogName, schema.Name)
+		if existing, ok := storedByName[schema.Name]; !ok {
+			r.record(Change{Type: ChangeAdded, Kind: KindSchema, Path: path})
+		} else if !maps.Equal(existing.Metadata, schema.Metadata) {
+			r.record(metadataChange(KindSchema, path, existing.Metadata, schema.Metadata))
+		}
+		if r.apply {
+			if err := r.storage.UpsertSchema(schema); err != nil {
+				r.fail("failed to store schema '%s': %v", path, err)
+				continue
+			}
+		}
+
+		if recurse {
+			if err := cancelled(ctx); err != nil {
+				return err
+			}
+			if err := r.tables(ctx, catalogName, schema.Name, true); err != nil {
+				if ctx.Err() != nil {
+					return cancelled(ctx)
+				}
+				r.fail("%v", err)
+			}
+		}
+	}
+
+	for _, schema := range stored {
+		if discoveredNames[schema.Name] {
+			continue
+		}
+		path := joinPath(catalogName, schema.Name)
+		r.record(Change{Type: ChangeRemoved, Kind: KindSchema, Path: path})
+		if r.apply {
+			if err := r.storage.DeleteSchema(catalogName, schema.Name); err != nil {
+				r.fail("failed to delete schema '%s': %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// tables reconciles the tables of a schema, and their columns when recurse is set
+func (r *reconciler) tables(ctx context.Context, catalogName, schemaName string, recurse bool) error {
+	discovered, err := r.discovery.DiscoverTables(ctx, catalogName, schemaName)
+	if err != nil {
+		return fmt.Errorf("failed to discover tables for schema '%s.%s': %w", catalogName, schemaName, err)
+	}
+	stored, err := r.storage.ListTables(catalogName, schemaName)
+	if err != nil {
+		return fmt.Errorf("failed to list tables for schema '%s.%s': %w", catalogName, schemaName, err)
+	}
+
+	storedByName := make(map[string]*models.Table, len(stored))
+	for _, table := range stored {
+		storedByName[table.Name] = table
+	}
+	discoveredNames := make(map[string]bool, len(discovered))
+
+	for _, table := range discovered {
+		discoveredNames[table.Name] = true
+		path := joinPath(catalogName, schemaName, table.Name)
+		if existing, ok := storedByName[table.Name]; !ok {
+			r.record(Change{Type: ChangeAdded, Kind: KindTable, Path: path})
+		} else if !maps.Equal(existing.Metadata, table.Metadata) {
+			r.record(metadataChange(KindTable, path, existing.Metadata, table.Metadata))
+		}
+		if r.apply {
+			if err := r.storage.UpsertTable(table); err != nil {
+				r.fail("failed to store table '%s': %v", path, err)
+				continue
+			}
+		}
+
+		if recurse {
+			if err := cancelled(ctx); err != nil {
+				return err
+			}
+			if err := r.columns(ctx, catalogName, schemaName, table.Name); err != nil {
+				if ctx.Err() != nil {
+					return cancelled(ctx)
+				}
+				r.fail("%v", err)
+			}
+		}
+	}
+
+	for _, table := range stored {
+		if discoveredNames[table.Name] {
+			continue
+		}
+		path := joinPath(catalogName, schemaName, table.Name)
+		r.record(Change{Type: ChangeRemoved, Kind: KindTable, Path: path})
+		if r.apply {
+			if err := r.storage.DeleteTable(catalogName, schemaName, table.Name); err != nil {
+				r.fail("failed to delete table '%s': %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// columns reconciles the columns of a table, reporting data type changes
+func (r *reconciler) columns(ctx context.Context, catalogName, schemaName, tableName string) error {
+	discovered, err := r.discovery.DiscoverColumns(ctx, catalogName, schemaName, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to discover columns for table '%s.%s.%s': %w", catalogName, schemaName, tableName, err)
+	}
+	stored, err := r.storage.ListColumns(catalogName, schemaName, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to list columns for table '%s.%s.%s': %w", catalogName, schemaName, tableName, err)
+	}
+
+	storedByName := make(map[string]*models.Column, len(stored))
+	for _, column := range stored {
+		storedByName[column.Name] = column
+	}
+	discoveredNames := make(map[string]bool, len(discovered))
+
+	for _, column := range discovered {
+		discoveredNames[column.Name] = true
+		path := joinPath(catalogName, schemaName, tableName, column.Name)
+		existing, ok := storedByName[column.Name]
+		switch {
+		case !ok:
+			r.record(Change{Type: ChangeAdded, Kind: KindColumn, Path: path, After: column.DataType})
+		case existing.DataType != column.DataType:
+			r.record(Change{Type: ChangeChanged, Kind: KindColumn, Path: path, Before: existing.DataType, After: column.DataType})
+		case !maps.Equal(existing.Metadata, column.Metadata):
+			r.record(metadataChange(KindColumn, path, existing.Metadata, column.Metadata))
+		default:
+			continue
+		}
+		if r.apply {
+			if err := r.storage.UpsertColumn(column); err != nil {
+				r.fail("failed to store column '%s': %v", path, err)
+			}
+		}
+	}
+
+	for _, column := range stored {
+		if discoveredNames[column.Name] {
+			continue
+		}
+		path := joinPath(catalogName, schemaName, tableName, column.Name)
+		r.record(Change{Type: ChangeRemoved, Kind: KindColumn, Path: path, Before: column.DataType})
+		if r.apply {
+			if err := r.storage.DeleteColumn(catalogName, schemaName, tableName, column.Name); err != nil {
+				r.fail("failed to delete column '%s': %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func metadataChange(kind, path string, before, after map[string]string) Change {
+	return Change{Type: ChangeChanged, Kind: kind, Path: path, Before: fmt.Sprint(before), After: fmt.Sprint(after)}
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change types
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Kinds of metadata a change applies to
+const (
+	KindCatalog = "catalog"
+	KindSchema  = "schema"
+	KindTable   = "table"
+	KindColumn  = "column"
+)
+
+// Change is a difference between the metadata discovered from Trino and the stored metadata.
+// A removed catalog, schema or table implies the removal of everything below it.
+type Change struct {
+	Type string `json:"type"`
+	Kind string `json:"kind"`
+	// Path is the dotted name, e.g. "catalog.schema.table.column"
+	Path string `json:"path"`
+	// Before and After describe what changed, e.g. a column's data type
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+func (c Change) String() string {
+	if c.Type == ChangeChanged {
+		return fmt.Sprintf("%s %s %s: %s -> %s", c.Type, c.Kind, c.Path, c.Before, c.After)
+	}
+	return fmt.Sprintf("%s %s %s", c.Type, c.Kind, c.Path)
+}
+
+// ChangeSummary counts changes by type
+type ChangeSummary struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Changed int `json:"changed"`
+}
+
+// SyncReport lists the changes found, and applied unless it is a dry run, by a sync
+type SyncReport struct {
+	Changes []Change      `json:"changes"`
+	Summary ChangeSummary `json:"summary"`
+	// Errors lists the parts of the metadata that could not be discovered; they are left
+	// untouched rather than pruned
+	Errors []string `json:"errors,omitempty"`
+	// Impact lists the global mappings and relations the synced metadata breaks
+	Impact *ImpactReport `json:"impact,omitempty"`
+}
+
+func newSyncReport() *SyncReport {
+	return &SyncReport{Changes: []Change{}}
+}
+
+func (r *SyncReport) add(change Change) {
+	r.Changes = append(r.Changes, change)
+	switch change.Type {
+	case ChangeAdded:
+		r.Summary.Added++
+	case ChangeRemoved:
+		r.Summary.Removed++
+	case ChangeChanged:
+		r.Summary.Changed++
+	}
+}
+
+// Describe summarises the report in one sentence
+func (r *SyncReport) Describe() string {
+	if len(r.Changes) == 0 {
+		return "no changes"
+	}
+
+	var parts []string
+	if r.Summary.Added > 0 {
+		parts = append(parts, fmt.Sprintf("%d added", r.Summary.Added))
+	}
+	if r.Summary.Removed > 0 {
+		parts = append(parts, fmt.Sprintf("%d removed", r.Summary.Removed))
+	}
+	if r.Summary.Changed > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed", r.Summary.Changed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func joinPath(parts ...string) string {
+	return strings.Join(parts, ".")
+}
+
+// countCatalogs counts the catalog changes of a type
+func (r *SyncReport) countCatalogs(changeType string) int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Kind == KindCatalog && change.Type == changeType {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,201 @@
+package datasync
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var precisionPattern = regexp.MustCompile(`\(\d+\)`)
+
+// ResultColumn is a column of a query result, in SELECT order, with its Trino type name
+// such as "bigint", "decimal(10,2)" or "array(varchar)"
+type ResultColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// UniqueColumnNames renames columns whose name was already used, as in
+// "SELECT c.id, o.id", to name_2, name_3 and so on, skipping names that are taken,
+// so rows keyed by column name keep every column
+func UniqueColumnNames(columns []ResultColumn) []ResultColumn {
+	taken := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		taken[col.Name] = true
+	}
+
+	unique := make([]ResultColumn, len(columns))
+	seen := make(map[string]bool, len(columns))
+	for i, col := range columns {
+		unique[i] = col
+		if !seen[col.Name] {
+			seen[col.Name] = true
+			continue
+		}
+		for n := 2; ; n++ {
+			name := col.Name + "_" + strconv.Itoa(n)
+			if !taken[name] {
+				taken[name] = true
+				seen[name] = true
+				unique[i].Name = name
+				break
+			}
+		}
+	}
+	return unique
+}
+
+// EncodeValue converts a value read from the engine into its JSON form for a column of
+// the given Trino type, so every response encodes values the same way:
+//   - decimals as strings, keeping every digit
+//   - dates as "2006-01-02", times as "15:04:05.999999999", timestamps as ISO 8601
+//     (with an offset only for TIMESTAMP WITH TIME ZONE)
+//   - varbinary as base64 and non-finite doubles as "NaN", "Infinity" or "-Infinity"
+//   - arrays as JSON arrays, maps as JSON objects and rows as objects keyed by field name
+//
+// Scalars nested in arrays, maps and rows are kept as Trino sends them.
+func EncodeValue(value interface{}, trinoType string) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	base, args := SplitTypeName(trinoType)
+	switch v := value.(type) {
+	case time.Time:
+		// Precision does not change the format, so "timestamp(3) with time zone" reads as "timestamp with time zone"
+		switch precisionPattern.ReplaceAllString(strings.ToLower(trinoType), "") {
+		case "date":
+			return v.Format("2006-01-02")
+		case "time", "time with time zone":
+			return v.Format("15:04:05.999999999")
+		case "timestamp with time zone":
+			return v.Format(time.RFC3339Nano)
+		default:
+			return v.Format("2006-01-02T15:04:05.999999999")
+		}
+
+	case float64:
+		return encodeFloat(v)
+	case float32:
+		return encodeFloat(float64(v))
+
+	case json.Number:
+		if base == "decimal" {
+			return v.String()
+		}
+		return v
+
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+
+	case []interface{}:
+		switch base {
+		case "array":
+			elementType := ""
+			if len(args) == 1 {
+				elementType = args[0]
+			}
+			encoded := make([]interface{}, len(v))
+			for i, element := range v {
+				encoded[i] = EncodeValue(element, elementType)
+			}
+			return encoded
+
+		case "row":
+			if len(args) != len(v) {
+				return v
+			}
+			encoded := make(map[string]interface{}, len(v))
+			for i, field := range args {
+				name, fieldType := SplitRowField(field, i)
+				encoded[name] = EncodeValue(v[i], fieldType)
+			}
+			return encoded
+		}
+		return v
+
+	case map[string]interface{}:
+		valueType := ""
+		if base == "map" && len(args) == 2 {
+			valueType = args[1]
+		}
+		encoded := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			encoded[key] = EncodeValue(element, valueType)
+		}
+		return encoded
+	}
+
+	return value
+}
+
+func encodeFloat(v float64) interface{} {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return v
+}
+
+// SplitTypeName splits a type such as "map(varchar, array(integer))" into its lowercase
+// base name and its top-level arguments, here "map" and ["varchar", "array(integer)"]
+func SplitTypeName(trinoType string) (string, []string) {
+	trinoType = strings.TrimSpace(trinoType)
+	open := strings.IndexByte(trinoType, '(')
+	if open < 0 || !strings.HasSuffix(trinoType, ")") {
+		return strings.ToLower(trinoType), nil
+	}
+
+	base := strings.ToLower(strings.TrimSpace(trinoType[:open]))
+	inner := trinoType[open+1 : len(trinoType)-1]
+
+	var args []string
+	depth, start, inQuotes := 0, 0, false
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case '(':
+			if !inQuotes {
+				depth++
+			}
+		case ')':
+			if !inQuotes {
+				depth--
+			}
+		case ',':
+			if !inQuotes && depth == 0 {
+				args = append(args, strings.TrimSpace(inner[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return base, append(args, strings.TrimSpace(inner[start:]))
+}
+
+// SplitRowField splits a row field such as `"name" varchar` into its name and type;
+// anonymous fields are named field0, field1, ...
+func SplitRowField(field string, index int) (string, string) {
+	if strings.HasPrefix(field, `"`) {
+		if end := strings.Index(field[1:], `"`); end >= 0 {
+			return field[1 : end+1], strings.TrimSpace(field[end+2:])
+		}
+	}
+	if space := strings.IndexByte(field, ' '); space > 0 && !strings.ContainsAny(field[:space], "(") {
+		switch strings.ToLower(field[:space]) {
+		case "time", "timestamp", "interval":
+			// The start of a type such as "timestamp with time zone", not a field name
+		default:
+			return field[:space], strings.TrimSpace(field[space+1:])
+		}
+	}
+	return "field" + strconv.Itoa(index), field
+}
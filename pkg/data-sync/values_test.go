@@ -0,0 +1,77 @@
+package datasync
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeValue_Scalars(t *testing.T) {
+	moment := time.Date(2024, 1, 31, 12, 30, 5, 250000000, time.FixedZone("", 3600))
+
+	cases := []struct {
+		value     interface{}
+		trinoType string
+		expected  interface{}
+	}{
+		{"12345678901234567890.12", "decimal(22,2)", "12345678901234567890.12"},
+		{moment, "date", "2024-01-31"},
+		{moment, "time(3)", "12:30:05.25"},
+		{moment, "timestamp(3)", "2024-01-31T12:30:05.25"},
+		{moment, "timestamp(3) with time zone", "2024-01-31T12:30:05.25+01:00"},
+		{math.NaN(), "double", "NaN"},
+		{math.Inf(-1), "double", "-Infinity"},
+		{[]byte("hi"), "varbinary", "aGk="},
+		{int64(42), "bigint", int64(42)},
+		{nil, "varchar", nil},
+	}
+
+	for _, c := range cases {
+		if got := EncodeValue(c.value, c.trinoType); got != c.expected {
+			t.Fatalf("Expected %v (%T) for %s, got %v (%T)", c.expected, c.expected, c.trinoType, got, got)
+		}
+	}
+}
+
+func TestEncodeValue_NestedTypes(t *testing.T) {
+	value := []interface{}{
+		json.Number("7"),
+		[]interface{}{json.Number("1.50"), nil},
+		map[string]interface{}{"a": []interface{}{"x", json.Number("2")}},
+	}
+	trinoType := `row(id bigint, "Prices" array(decimal(5,2)), tags map(varchar, row(varchar, integer)))`
+
+	expected := map[string]interface{}{
+		"id":     json.Number("7"),
+		"Prices": []interface{}{"1.50", nil},
+		"tags": map[string]interface{}{
+			"a": map[string]interface{}{"field0": "x", "field1": json.Number("2")},
+		},
+	}
+
+	got := EncodeValue(value, trinoType)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestUniqueColumnNames(t *testing.T) {
+	columns := UniqueColumnNames([]ResultColumn{
+		{Name: "id", Type: "bigint"},
+		{Name: "id", Type: "integer"},
+		{Name: "id_2", Type: "varchar"},
+		{Name: "id", Type: "date"},
+	})
+
+	expected := []ResultColumn{
+		{Name: "id", Type: "bigint"},
+		{Name: "id_3", Type: "integer"},
+		{Name: "id_2", Type: "varchar"},
+		{Name: "id_4", Type: "date"},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("Expected %v, got %v", expected, columns)
+	}
+}